@@ -0,0 +1,261 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/adaptor"
+	"go.chromium.org/chromiumos/dbusbindings/generate/doc"
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/generate/methodnames"
+	"go.chromium.org/chromiumos/dbusbindings/generate/namemap"
+	"go.chromium.org/chromiumos/dbusbindings/generate/proxy"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+)
+
+// command is a subcommand of the generator. run parses the arguments
+// following the name of the subcommand, and returns the failures of the
+// interfaces left out of the outputs with -keep-going.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) []string
+}
+
+var commands = []command{
+	{"adaptor", "generate the DBus adaptor classes", runAdaptor},
+	{"proxy", "generate the DBus proxy classes", runProxy},
+	{"mock", "generate the gmock classes of the DBus proxies", runMock},
+	{"methodnames", "generate string constants for each method name", runMethodNames},
+	{"validate", "check the interfaces, optionally writing their model and name map", runValidate},
+	{"diff", "print the incompatible changes since the -baseline model", runDiff},
+	{"doc", "generate a Markdown reference of the interfaces", runDoc},
+}
+
+// findCommand returns the subcommand named name, or nil if there is none.
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// parseCommand parses args with the shared flags and those added by register,
+// then loads the introspection XML files left in args.
+func parseCommand(name string, args []string, register func(fs *flag.FlagSet)) *session {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] <introspection XML files>\n", os.Args[0], name)
+		fs.PrintDefaults()
+	}
+	opts := &options{}
+	opts.register(fs)
+	if register != nil {
+		register(fs)
+	}
+	fs.Parse(args)
+	return load(name, opts, fs.Args())
+}
+
+// requireOutput fails if the -o flag of the subcommand name is missing.
+func requireOutput(name, output string) {
+	if output == "" {
+		log.Fatalf("%s: -o is required", name)
+	}
+}
+
+// moduleName returns the name of the C++ module of the given kind, named
+// after the service name of the service config.
+func (s *session) moduleName(kind string) string {
+	m, err := genutil.MakeModuleName(s.sc.ServiceName, kind)
+	if err != nil {
+		log.Fatalf("Failed to name C++ modules: %v", err)
+	}
+	return m
+}
+
+func runAdaptor(args []string) []string {
+	var output string
+	var cxxModule bool
+	s := parseCommand("adaptor", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&output, "o", "", "the output header file name containing the DBus adaptor class")
+		fs.BoolVar(&cxxModule, "cxx-module", false, "experimental: write a C++20 module interface unit instead of a header, named after the service name in the service config")
+	})
+	requireOutput("adaptor", output)
+	var module string
+	if cxxModule {
+		module = s.moduleName("adaptor")
+	}
+	s.checkBaseline()
+
+	s.write("adaptor", output, true, func(introspects []introspect.Introspection, w io.Writer) error {
+		if module != "" {
+			return adaptor.GenerateModule(introspects, w, module, s.overrides)
+		}
+		return adaptor.Generate(introspects, w, output, s.overrides)
+	})
+	return s.finish()
+}
+
+func runProxy(args []string) []string {
+	var output string
+	var cxxModule bool
+	s := parseCommand("proxy", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&output, "o", "", "the output header file name containing the DBus proxy class")
+		fs.BoolVar(&cxxModule, "cxx-module", false, "experimental: write a C++20 module interface unit instead of a header, named after the service name in the service config")
+	})
+	requireOutput("proxy", output)
+	var module string
+	if cxxModule {
+		module = s.moduleName("proxy")
+	}
+	s.checkBaseline()
+
+	s.write("proxy", output, true, func(introspects []introspect.Introspection, w io.Writer) error {
+		if module != "" {
+			return proxy.GenerateModule(introspects, w, module, s.sc, s.overrides)
+		}
+		return proxy.Generate(introspects, w, output, s.sc, s.overrides)
+	})
+	return s.finish()
+}
+
+// runMock writes the gmock classes of the proxies to the -o header, which
+// includes the proxy header at -proxy-path, or at the path of the -proxy
+// header relative to it.
+func runMock(args []string) []string {
+	var output, proxyPath, proxyOutput string
+	s := parseCommand("mock", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&output, "o", "", "the output header file name containing the DBus gmock proxy class")
+		fs.StringVar(&proxyPath, "proxy-path", "", "the path to the header file for proxy interface, relative to the mock output path")
+		fs.StringVar(&proxyOutput, "proxy", "", "the header file name containing the DBus proxy class, which -proxy-path is derived from if omitted")
+	})
+	requireOutput("mock", output)
+	if proxyPath == "" && proxyOutput != "" {
+		var err error
+		proxyPath, err = filepath.Rel(filepath.Dir(output), proxyOutput)
+		if err != nil {
+			log.Fatal("Failed to compute the relpath from mock to proxy: ", err)
+		}
+	}
+	s.checkBaseline()
+
+	s.write("mock", output, true, func(introspects []introspect.Introspection, w io.Writer) error {
+		return proxy.GenerateMock(introspects, w, output, proxyPath, s.sc, s.overrides)
+	})
+	return s.finish()
+}
+
+// runMethodNames writes the method names of all the interfaces to the -o
+// header, or those of each interface to a header of its own in -output-dir.
+func runMethodNames(args []string) []string {
+	var output, outputDir string
+	s := parseCommand("methodnames", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&output, "o", "", "the output header file with string constants for each method name")
+		fs.StringVar(&outputDir, "output-dir", "", "the directory to write a header per interface to, named <interface name>-method-names.h, instead of -o")
+	})
+	if outputDir == "" {
+		requireOutput("methodnames", output)
+	} else if output != "" {
+		log.Fatal("methodnames: -o and -output-dir can't both be set")
+	}
+	s.checkBaseline()
+
+	if outputDir == "" {
+		s.write("method-names", output, true, func(introspects []introspect.Introspection, w io.Writer) error {
+			return methodnames.Generate(introspects, w, output, s.sc)
+		})
+		return s.finish()
+	}
+
+	single := methodnames.SplitInterfaces(s.introspections)
+	var outputs []genutil.Output
+	for _, ii := range single {
+		outputs = append(outputs, genutil.Output{
+			Name:           ii.Interfaces[0].Name,
+			Path:           filepath.Join(outputDir, methodnames.InterfaceFileName(ii.Interfaces[0].Name)),
+			HasHeaderGuard: true,
+		})
+	}
+	if err := genutil.CheckOutputCollisions(outputs); err != nil {
+		log.Fatalf("Conflicting outputs: %v", err)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		log.Fatalf("Failed to create directory %s: %v", outputDir, err)
+	}
+	for i, ii := range single {
+		path := outputs[i].Path
+		s.writeInterfaces(path, path, []introspect.Introspection{ii}, true, func(introspects []introspect.Introspection, w io.Writer) error {
+			return methodnames.Generate(introspects, w, path, s.sc)
+		})
+	}
+	return s.finish()
+}
+
+// runValidate only checks the interfaces according to the shared flags, e.g.
+// -lint and -baseline, and writes the outputs which don't generate code.
+func runValidate(args []string) []string {
+	var dumpModelPath, nameMapPath string
+	s := parseCommand("validate", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&dumpModelPath, "dump-model", "", "the output JSON file containing the resolved introspection model")
+		fs.StringVar(&nameMapPath, "name-map", "", "the output JSON file mapping D-Bus names to the generated C++ identifiers")
+	})
+	if err := genutil.CheckOutputCollisions([]genutil.Output{
+		{Name: "dump-model", Path: dumpModelPath},
+		{Name: "name-map", Path: nameMapPath},
+	}); err != nil {
+		log.Fatalf("Conflicting outputs: %v", err)
+	}
+	s.checkBaseline()
+
+	if dumpModelPath != "" {
+		s.write("dump-model", dumpModelPath, false, introspect.DumpModel)
+	}
+	if nameMapPath != "" {
+		s.write("name-map", nameMapPath, false, func(introspects []introspect.Introspection, w io.Writer) error {
+			return namemap.Generate(introspects, s.sc, w)
+		})
+	}
+	return s.finish()
+}
+
+// runDiff prints the incompatible changes since the -baseline model, one per
+// line, and exits with an error if there are any unless -allow-breaking is
+// set.
+func runDiff(args []string) []string {
+	s := parseCommand("diff", args, nil)
+	if s.opts.baselinePath == "" {
+		log.Fatal("diff: -baseline is required")
+	}
+
+	changes := s.breakingChanges()
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if len(changes) > 0 && !s.opts.allowBreaking {
+		os.Exit(1)
+	}
+	return s.finish()
+}
+
+func runDoc(args []string) []string {
+	var output string
+	s := parseCommand("doc", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&output, "o", "", "the output Markdown file")
+	})
+	requireOutput("doc", output)
+	s.checkBaseline()
+
+	s.write("doc", output, false, doc.Generate)
+	return s.finish()
+}
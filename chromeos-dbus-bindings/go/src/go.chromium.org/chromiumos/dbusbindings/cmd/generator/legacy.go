@@ -0,0 +1,116 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"path/filepath"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/adaptor"
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/generate/methodnames"
+	"go.chromium.org/chromiumos/dbusbindings/generate/namemap"
+	"go.chromium.org/chromiumos/dbusbindings/generate/proxy"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+)
+
+// runLegacy generates every output requested by the flags of the command line
+// predating the subcommands. It is deprecated and will be removed in the next
+// release.
+func runLegacy() []string {
+	var opts options
+	opts.register(flag.CommandLine)
+	methodNamesPath := flag.String("method-names", "", "the output header file with string constants for each method name")
+	adaptorPath := flag.String("adaptor", "", "the output header file name containing the DBus adaptor class")
+	proxyPath := flag.String("proxy", "", "the output header file name containing the DBus proxy class")
+	mockPath := flag.String("mock", "", "the output header file name containing the DBus gmock proxy class")
+	proxyPathForMocks := flag.String("proxy-path-for-mocks", "", "the path to the header file for proxy interface, relative to the mock output path")
+	dumpModelPath := flag.String("dump-model", "", "the output JSON file containing the resolved introspection model")
+	nameMapPath := flag.String("name-map", "", "the output JSON file mapping D-Bus names to the generated C++ identifiers")
+	cxxModules := flag.Bool("cxx-modules", false, "experimental: write the adaptor and proxy as C++20 module interface units instead of headers, named after the service name in the service config")
+	flag.Parse()
+
+	log.Print("Generating without a subcommand is deprecated and will be removed in the next release; run the adaptor, proxy, mock, methodnames or validate subcommands instead")
+
+	if *cxxModules && *mockPath != "" {
+		log.Fatal("-mock is not supported with -cxx-modules")
+	}
+
+	if err := genutil.CheckOutputCollisions([]genutil.Output{
+		{Name: "dump-model", Path: *dumpModelPath},
+		{Name: "name-map", Path: *nameMapPath},
+		{Name: "method-names", Path: *methodNamesPath},
+		{Name: "adaptor", Path: *adaptorPath, HasHeaderGuard: true},
+		{Name: "proxy", Path: *proxyPath, HasHeaderGuard: true},
+		{Name: "mock", Path: *mockPath, HasHeaderGuard: true},
+	}); err != nil {
+		log.Fatalf("Conflicting outputs: %v", err)
+	}
+
+	s := load("legacy", &opts, flag.Args())
+
+	var adaptorModule, proxyModule string
+	if *cxxModules {
+		adaptorModule = s.moduleName("adaptor")
+		proxyModule = s.moduleName("proxy")
+	}
+
+	s.checkBaseline()
+
+	if *dumpModelPath != "" {
+		s.write("dump-model", *dumpModelPath, false, introspect.DumpModel)
+	}
+
+	if *nameMapPath != "" {
+		s.write("name-map", *nameMapPath, false, func(introspects []introspect.Introspection, w io.Writer) error {
+			return namemap.Generate(introspects, s.sc, w)
+		})
+	}
+
+	if *methodNamesPath != "" {
+		s.write("method-names", *methodNamesPath, true, func(introspects []introspect.Introspection, w io.Writer) error {
+			return methodnames.Generate(introspects, w, *methodNamesPath, s.sc)
+		})
+	}
+
+	if *adaptorPath != "" {
+		s.write("adaptor", *adaptorPath, true, func(introspects []introspect.Introspection, w io.Writer) error {
+			if adaptorModule != "" {
+				return adaptor.GenerateModule(introspects, w, adaptorModule, s.overrides)
+			}
+			return adaptor.Generate(introspects, w, *adaptorPath, s.overrides)
+		})
+	}
+
+	if *proxyPath != "" {
+		s.write("proxy", *proxyPath, true, func(introspects []introspect.Introspection, w io.Writer) error {
+			if proxyModule != "" {
+				return proxy.GenerateModule(introspects, w, proxyModule, s.sc, s.overrides)
+			}
+			return proxy.Generate(introspects, w, *proxyPath, s.sc, s.overrides)
+		})
+	}
+
+	if *mockPath != "" {
+		p := *proxyPathForMocks
+		if p == "" && *proxyPath != "" {
+			// -proxy-path-for-mock is not specified. Derive it from proxyPath.
+			d := filepath.Dir(*mockPath)
+			var err error
+			p, err = filepath.Rel(d, *proxyPath)
+			if err != nil {
+				log.Fatal("Failed to compute the relpath from mock to proxy: ", err)
+			}
+		}
+
+		s.write("mock", *mockPath, true, func(introspects []introspect.Introspection, w io.Writer) error {
+			return proxy.GenerateMock(introspects, w, *mockPath, p, s.sc, s.overrides)
+		})
+	}
+
+	return s.finish()
+}
@@ -0,0 +1,258 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/generate/metadata"
+	"go.chromium.org/chromiumos/dbusbindings/generate/stats"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
+)
+
+// options are the flags shared by every subcommand and the legacy command
+// line, controlling how the inputs are read and checked.
+type options struct {
+	serviceConfigPath string
+	templateDir       string
+	interfaces        string
+	embedMetadata     bool
+	strictKinds       bool
+	lint              bool
+	keepGoing         bool
+	baselinePath      string
+	allowBreaking     bool
+	statsPath         string
+}
+
+// register adds the shared flags to fs.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.serviceConfigPath, "service-config", "", "the DBus service configuration file for the generator.")
+	fs.StringVar(&o.templateDir, "template-dir", "", "the directory containing <template name>.tmpl files overriding the built-in templates")
+	fs.StringVar(&o.interfaces, "interfaces", "", "comma-separated names of the interfaces to generate, leaving out the others in the input files; all of them if empty")
+	fs.BoolVar(&o.embedMetadata, "embed-metadata", false, "append the generator version and hashes of the inputs to each generated header")
+	fs.BoolVar(&o.strictKinds, "strict-kinds", false, "require every method to specify its kind and every method argument to specify its direction")
+	fs.BoolVar(&o.lint, "lint", false, "check that annotations fit the elements they annotate, reporting every violation with its position")
+	fs.BoolVar(&o.keepGoing, "keep-going", false, "leave out the interfaces which fail to parse or generate, reporting them all and exiting with an error after writing the outputs")
+	fs.StringVar(&o.baselinePath, "baseline", "", "a model previously written by -dump-model; fail if methods, signals or properties were removed or changed incompatibly since")
+	fs.BoolVar(&o.allowBreaking, "allow-breaking", false, "only warn about the incompatible changes since the -baseline model")
+	fs.StringVar(&o.statsPath, "stats", "", "log the number of interfaces, methods, signals and properties, and the lines and generation time of each output, and append them as a JSON line to this file")
+}
+
+// session holds the inputs read according to the shared options, the
+// failures of the interfaces left out of the outputs with -keep-going, and the
+// stats of the outputs written by the command.
+type session struct {
+	command   string
+	opts      *options
+	sc        serviceconfig.Config
+	overrides genutil.TemplateOverrides
+	// all holds every interface of the input files, and introspections
+	// those selected by -interfaces.
+	all            []introspect.Introspection
+	introspections []introspect.Introspection
+	banner         string
+	trailer        string
+	failures       []string
+	outputs        []stats.Output
+}
+
+// load reads the service config, template overrides and introspection XML
+// files at paths. Failures are fatal, except those of the interfaces left out
+// with -keep-going.
+func load(command string, opts *options, paths []string) *session {
+	s := &session{command: command, opts: opts}
+
+	var rawServiceConfig []byte
+	if opts.serviceConfigPath != "" {
+		c, err := serviceconfig.Load(opts.serviceConfigPath)
+		if err != nil {
+			log.Fatalf("Failed to read config file %s: %v", opts.serviceConfigPath, err)
+		}
+		s.sc = *c
+
+		rawServiceConfig, err = ioutil.ReadFile(opts.serviceConfigPath)
+		if err != nil {
+			log.Fatalf("Failed to read config file %s: %v", opts.serviceConfigPath, err)
+		}
+	}
+
+	if opts.templateDir != "" {
+		o, err := genutil.LoadTemplateOverrides(opts.templateDir)
+		if err != nil {
+			log.Fatalf("Failed to read template overrides in %s: %v", opts.templateDir, err)
+		}
+		s.overrides = o
+	}
+
+	var inputs []metadata.Input
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read file %s: %v\n", path, err)
+		}
+		inputs = append(inputs, metadata.Input{Path: path, Content: b})
+
+		if opts.lint {
+			if err := introspect.Lint(b); err != nil {
+				log.Fatalf("Invalid annotations in interface file %s:\n%v\n", path, err)
+			}
+		}
+
+		var introspection introspect.Introspection
+		if opts.keepGoing {
+			var itfErrs []error
+			introspection, itfErrs, err = introspect.ParseInterfaces(b)
+			for _, itfErr := range itfErrs {
+				s.failures = append(s.failures, fmt.Sprintf("%s: %v", path, itfErr))
+			}
+		} else {
+			introspection, err = introspect.Parse(b)
+		}
+		if err != nil {
+			log.Fatalf("Failed to parse interface file %s: %v\n", path, err)
+		}
+
+		if opts.strictKinds {
+			if err := introspect.VerifyStrictKinds(&introspection); err != nil {
+				log.Fatalf("Failed strict verification of interface file %s: %v\n", path, err)
+			}
+		}
+
+		s.all = append(s.all, introspection)
+	}
+
+	s.introspections = s.all
+	if opts.interfaces != "" {
+		selected, err := genutil.SelectInterfaces(s.all, strings.Split(opts.interfaces, ","))
+		if err != nil {
+			log.Fatalf("Invalid -interfaces: %v\n", err)
+		}
+		s.introspections = selected
+	}
+
+	s.banner = genutil.FormatBanner(s.sc.Banner)
+	if opts.embedMetadata {
+		s.trailer = metadata.New(inputs, rawServiceConfig).Trailer()
+	}
+	return s
+}
+
+// breakingChanges returns the incompatible changes of all the input
+// interfaces since the -baseline model.
+func (s *session) breakingChanges() []string {
+	f, err := os.Open(s.opts.baselinePath)
+	if err != nil {
+		log.Fatalf("Failed to open baseline model %s: %v\n", s.opts.baselinePath, err)
+	}
+	baseline, err := introspect.LoadModel(f)
+	f.Close()
+	if err != nil {
+		log.Fatalf("Failed to load baseline model %s: %v\n", s.opts.baselinePath, err)
+	}
+	return introspect.BreakingChanges(baseline, introspect.NewModel(s.all))
+}
+
+// checkBaseline fails if the input interfaces changed incompatibly since the
+// -baseline model, if any, unless -allow-breaking is set.
+func (s *session) checkBaseline() {
+	if s.opts.baselinePath == "" {
+		return
+	}
+	if changes := s.breakingChanges(); len(changes) > 0 {
+		msg := fmt.Sprintf("Incompatible changes since baseline model %s:\n  %s\n", s.opts.baselinePath, strings.Join(changes, "\n  "))
+		if !s.opts.allowBreaking {
+			log.Fatal(msg)
+		}
+		log.Print(msg)
+	}
+}
+
+// generateAll generates introspects to f. With -keep-going, the interfaces
+// which fail to generate are left out and reported as failures of output.
+func (s *session) generateAll(output string, introspects []introspect.Introspection, f io.Writer, generate func([]introspect.Introspection, io.Writer) error) error {
+	if s.opts.keepGoing {
+		var itfErrs []error
+		introspects, itfErrs = genutil.DropFailingInterfaces(introspects, generate)
+		for _, itfErr := range itfErrs {
+			s.failures = append(s.failures, fmt.Sprintf("%s: %v", output, itfErr))
+		}
+	}
+	return generate(introspects, f)
+}
+
+// write generates output from the selected interfaces to the file at path.
+// Headers get the banner of the service config and the -embed-metadata
+// trailer.
+func (s *session) write(output, path string, header bool, generate func([]introspect.Introspection, io.Writer) error) {
+	s.writeInterfaces(output, path, s.introspections, header, generate)
+}
+
+// writeInterfaces is like write, but generates output from introspects, e.g.
+// a single one of the selected interfaces.
+func (s *session) writeInterfaces(output, path string, introspects []introspect.Introspection, header bool, generate func([]introspect.Introspection, io.Writer) error) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatalf("Failed to create file %s: %v\n", path, err)
+	}
+	w := &stats.Counter{W: f}
+	if header && s.banner != "" {
+		if _, err := io.WriteString(w, s.banner); err != nil {
+			log.Fatalf("Failed to write banner to %s: %v\n", path, err)
+		}
+	}
+
+	start := time.Now()
+	if err := s.generateAll(output, introspects, w, generate); err != nil {
+		log.Fatalf("Failed to generate %s: %v\n", output, err)
+	}
+	elapsed := time.Since(start)
+
+	if header && s.trailer != "" {
+		if _, err := io.WriteString(w, s.trailer); err != nil {
+			log.Fatalf("Failed to write metadata to %s: %v\n", path, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Failed to close file %s: %v\n", path, err)
+	}
+
+	s.outputs = append(s.outputs, stats.Output{
+		Name:             output,
+		Path:             path,
+		Lines:            w.Lines,
+		Bytes:            w.Bytes,
+		GenerationMillis: float64(elapsed.Microseconds()) / 1000,
+	})
+}
+
+// finish reports the stats of the command with -stats, and returns the
+// failures of the interfaces left out of the outputs with -keep-going.
+func (s *session) finish() []string {
+	if s.opts.statsPath == "" {
+		return s.failures
+	}
+
+	r := stats.Report{
+		Time:    time.Now().UTC(),
+		Command: s.command,
+		Surface: stats.CountSurface(s.introspections),
+		Outputs: s.outputs,
+	}
+	log.Print(r)
+	if err := stats.Append(s.opts.statsPath, r); err != nil {
+		log.Fatalf("Failed to write stats to %s: %v\n", s.opts.statsPath, err)
+	}
+	return s.failures
+}
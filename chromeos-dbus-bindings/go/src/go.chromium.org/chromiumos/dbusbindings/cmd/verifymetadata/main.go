@@ -0,0 +1,52 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package main implements a tool verifying that a file generated with
+// -embed-metadata was generated from the given introspection XML files and
+// service config.
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/metadata"
+)
+
+func main() {
+	serviceConfigPath := flag.String("service-config", "", "the DBus service configuration file the header is expected to be generated with.")
+	generatedPath := flag.String("generated", "", "the generated file to verify")
+	flag.Parse()
+
+	if *generatedPath == "" {
+		log.Fatal("-generated is required")
+	}
+
+	generated, err := ioutil.ReadFile(*generatedPath)
+	if err != nil {
+		log.Fatalf("Failed to read generated file %s: %v", *generatedPath, err)
+	}
+
+	var serviceConfig []byte
+	if *serviceConfigPath != "" {
+		serviceConfig, err = ioutil.ReadFile(*serviceConfigPath)
+		if err != nil {
+			log.Fatalf("Failed to read config file %s: %v", *serviceConfigPath, err)
+		}
+	}
+
+	var inputs []metadata.Input
+	for _, path := range flag.Args() {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read file %s: %v\n", path, err)
+		}
+		inputs = append(inputs, metadata.Input{Path: path, Content: b})
+	}
+
+	if err := metadata.Verify(generated, inputs, serviceConfig); err != nil {
+		log.Fatalf("%s does not match the given inputs: %v", *generatedPath, err)
+	}
+}
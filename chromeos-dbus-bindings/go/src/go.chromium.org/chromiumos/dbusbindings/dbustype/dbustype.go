@@ -111,6 +111,20 @@ func (d *dbusType) scalar() bool {
 	return false
 }
 
+// MoveOnly tells whether the C++ type of d can't be copied, i.e. whether it
+// holds a file descriptor.
+func (d *dbusType) MoveOnly() bool {
+	if d.kind == dbusKindFileDescriptor {
+		return true
+	}
+	for _, arg := range d.args {
+		if arg.MoveOnly() {
+			return true
+		}
+	}
+	return false
+}
+
 // InArgType returns the C++ type corresponding to the D-Bus type for an in argument.
 func (d *dbusType) InArgType() string {
 	baseType := d.BaseType()
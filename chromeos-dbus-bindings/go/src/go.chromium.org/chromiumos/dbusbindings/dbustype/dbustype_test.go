@@ -208,4 +208,32 @@ func TestOutArgTypes(t *testing.T) {
 	}
 }
 
+// Types holding file descriptors, at any depth, are move-only.
+func TestMoveOnly(t *testing.T) {
+	cases := []struct {
+		input string
+		want  bool
+	}{
+		{"h", true},
+		{"ah", true},
+		{"a{ih}", true},
+		{"(ih)", true},
+		{"a(sa{sh})", true},
+		{"i", false},
+		{"s", false},
+		{"a{sv}", false},
+		{"(ib)", false},
+	}
+
+	for _, tc := range cases {
+		typ, err := dbustype.Parse(tc.input)
+		if err != nil {
+			t.Fatalf("Parse(%q) got error, want nil: %v", tc.input, err)
+		}
+		if got := typ.MoveOnly(); got != tc.want {
+			t.Errorf("MoveOnly(%q) = %t, want %t", tc.input, got, tc.want)
+		}
+	}
+}
+
 // TODO(chromium:983008): Add tests for PropertyType.
@@ -0,0 +1,209 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+//go:build dbus_e2e
+// +build dbus_e2e
+
+// Package e2e_test generates the adaptor and proxy for a test interface,
+// compiles a server and a client against them and checks that method calls,
+// signals and properties round trip through a private dbus-daemon.
+//
+// It needs dbus-daemon, a C++ compiler and the libbrillo and libchrome
+// pkg-config packages, and is only built with the dbus_e2e build tag:
+//
+//	go test -tags dbus_e2e ./e2e/
+//
+// The compiler defaults to c++, and can be overridden with $CXX.
+package e2e_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/adaptor"
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/generate/proxy"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// requireTools skips the test if the tools needed to build and run the
+// example are missing, and returns the C++ compiler to use.
+func requireTools(t *testing.T) string {
+	t.Helper()
+	cxx := os.Getenv("CXX")
+	if cxx == "" {
+		cxx = "c++"
+	}
+	for _, tool := range []string{"dbus-daemon", "pkg-config", cxx} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s not found: %v", tool, err)
+		}
+	}
+	if err := exec.Command("pkg-config", "--exists", "libbrillo", "libchrome").Run(); err != nil {
+		t.Skipf("libbrillo or libchrome not found by pkg-config: %v", err)
+	}
+	return cxx
+}
+
+// generateBindings writes the adaptor and proxy for testdata/frobber.xml as
+// e2e/dbus_adaptor.h and e2e/dbus_proxy.h under includeDir.
+func generateBindings(t *testing.T, includeDir string) {
+	t.Helper()
+	b, err := ioutil.ReadFile("testdata/frobber.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	is, err := introspect.Parse(b)
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+	introspects := []introspect.Introspection{is}
+	config := serviceconfig.Config{ServiceName: "org.chromium.E2E"}
+
+	dir := filepath.Join(includeDir, "e2e")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	generate := func(name string, gen func(f *os.File, path string) error) {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := gen(f, path); err != nil {
+			t.Fatalf("Generating %s got error, want nil: %v", name, err)
+		}
+	}
+	generate("dbus_adaptor.h", func(f *os.File, path string) error {
+		return adaptor.Generate(introspects, f, path, genutil.TemplateOverrides{})
+	})
+	generate("dbus_proxy.h", func(f *os.File, path string) error {
+		return proxy.Generate(introspects, f, path, config, genutil.TemplateOverrides{})
+	})
+}
+
+// compile builds testdata/<name>.cc into dir/<name>.
+func compile(t *testing.T, cxx, includeDir, dir, name string) string {
+	t.Helper()
+	flags, err := exec.Command("pkg-config", "--cflags", "--libs", "libbrillo", "libchrome").Output()
+	if err != nil {
+		t.Fatalf("pkg-config failed: %v", err)
+	}
+	out := filepath.Join(dir, name)
+	args := []string{"-std=c++17", "-I" + includeDir, "-o", out, filepath.Join("testdata", name+".cc")}
+	args = append(args, strings.Fields(string(flags))...)
+	if b, err := exec.Command(cxx, args...).CombinedOutput(); err != nil {
+		t.Fatalf("Compiling %s failed: %v\n%s", name, err, b)
+	}
+	return out
+}
+
+// startBus runs a private dbus-daemon until the test ends and returns its
+// address.
+func startBus(t *testing.T, dir string) string {
+	t.Helper()
+	configPath := filepath.Join(dir, "bus.conf")
+	if err := ioutil.WriteFile(configPath, []byte(fmt.Sprintf(busConfig, dir)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("dbus-daemon", "--config-file="+configPath, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Starting dbus-daemon failed: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Reading dbus-daemon address failed: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+func TestRoundTrip(t *testing.T) {
+	cxx := requireTools(t)
+	dir, err := ioutil.TempDir("", "e2e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	includeDir := filepath.Join(dir, "include")
+	generateBindings(t, includeDir)
+	server := compile(t, cxx, includeDir, dir, "server")
+	client := compile(t, cxx, includeDir, dir, "client")
+
+	env := append(os.Environ(), "DBUS_SESSION_BUS_ADDRESS="+startBus(t, dir))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	serverCmd := exec.CommandContext(ctx, server)
+	serverCmd.Env = env
+	serverCmd.Stderr = os.Stderr
+	stdout, err := serverCmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := serverCmd.Start(); err != nil {
+		t.Fatalf("Starting server failed: %v", err)
+	}
+	defer serverCmd.Process.Kill()
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); err != nil || line != "ready\n" {
+		t.Fatalf("Server did not get ready: got %q, %v", line, err)
+	}
+
+	clientCmd := exec.CommandContext(ctx, client)
+	clientCmd.Env = env
+	clientCmd.Stderr = os.Stderr
+	out, err := clientCmd.Output()
+	if err != nil {
+		t.Fatalf("Client failed: %v\n%s", err, out)
+	}
+	want := `Add 5
+Fail e2e/Failed
+Echo hi!
+EchoShort hi!
+OpenPipe piped
+Frobbed 7
+Count 1
+`
+	if diff := cmp.Diff(string(out), want); diff != "" {
+		t.Errorf("Client output mismatch (-got +want):\n%s", diff)
+	}
+
+	// The client calls Quit, so the server exits on its own.
+	if err := serverCmd.Wait(); err != nil {
+		t.Errorf("Server failed: %v", err)
+	}
+}
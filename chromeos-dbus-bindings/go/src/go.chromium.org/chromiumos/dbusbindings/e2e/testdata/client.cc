@@ -0,0 +1,158 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Exercises org.chromium.E2E.Frobber through the generated proxy and prints
+// one line per round trip, for e2e_test.go to compare. Asks the server to
+// quit when done.
+
+#include <cstdio>
+#include <memory>
+#include <string>
+
+#include <base/at_exit.h>
+#include <base/files/file_util.h>
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/run_loop.h>
+#include <base/task/single_thread_task_executor.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/errors/error.h>
+#include <dbus/bus.h>
+
+#include "e2e/dbus_proxy.h"
+
+namespace {
+
+using org::chromium::E2E::FrobberProxy;
+using org::chromium::E2E::FrobberProxyInterface;
+
+// Tracks the Frobbed signal and the Count property update triggered by Emit.
+struct EmitState {
+  uint32_t signal_value = 0;
+  bool got_signal = false;
+  bool got_count = false;
+  base::OnceClosure quit_closure;
+
+  void MaybeQuit() {
+    if (got_signal && got_count && quit_closure)
+      std::move(quit_closure).Run();
+  }
+};
+
+void OnFrobbed(EmitState* state, uint32_t value) {
+  state->signal_value = value;
+  state->got_signal = true;
+  state->MaybeQuit();
+}
+
+void OnPropertyChanged(EmitState* state,
+                       FrobberProxyInterface* proxy,
+                       const std::string& name) {
+  if (name != FrobberProxyInterface::CountName() || !proxy->is_count_valid() ||
+      proxy->count() != 1) {
+    return;
+  }
+  state->got_count = true;
+  state->MaybeQuit();
+}
+
+void OnSignalConnected(base::OnceClosure quit_closure,
+                       const std::string& interface_name,
+                       const std::string& signal_name,
+                       bool success) {
+  CHECK(success) << "failed to connect to " << signal_name;
+  std::move(quit_closure).Run();
+}
+
+}  // namespace
+
+int main() {
+  base::AtExitManager at_exit;
+  base::SingleThreadTaskExecutor executor(base::MessagePumpType::IO);
+
+  dbus::Bus::Options options;
+  options.bus_type = dbus::Bus::SESSION;
+  scoped_refptr<dbus::Bus> bus = new dbus::Bus(options);
+  CHECK(bus->Connect());
+  FrobberProxy proxy(bus);
+
+  // Method with input and output arguments.
+  int32_t sum = 0;
+  brillo::ErrorPtr error;
+  CHECK(proxy.Add(2, 3, &sum, &error));
+  printf("Add %d\n", sum);
+
+  // Errors are propagated with their domain and code.
+  error.reset();
+  CHECK(!proxy.Fail(&error));
+  printf("Fail %s/%s\n", error->GetDomain().c_str(), error->GetCode().c_str());
+
+  // Optional last input argument, filled in by the proxy.
+  std::string echoed;
+  error.reset();
+  CHECK(proxy.Echo("hi", &echoed, &error));
+  printf("Echo %s\n", echoed.c_str());
+
+  // Optional last input argument, omitted on the wire and filled in by the
+  // adaptor.
+  error.reset();
+  std::unique_ptr<dbus::Response> response =
+      brillo::dbus_utils::CallMethodAndBlock(
+          proxy.GetObjectProxy(), FrobberProxyInterface::DBusInterfaceName(),
+          "Echo", &error, std::string{"hi"});
+  echoed.clear();
+  CHECK(response && brillo::dbus_utils::ExtractMethodCallResults(
+                        response.get(), &error, &echoed));
+  printf("EchoShort %s\n", echoed.c_str());
+
+  // Asynchronous call handing the received file descriptor to the callback.
+  {
+    base::RunLoop run_loop;
+    base::ScopedFD fd;
+    proxy.OpenPipeAsync(
+        "piped",
+        base::BindOnce(
+            [](base::ScopedFD* fd, base::OnceClosure quit_closure,
+               FrobberProxyInterface::OpenPipeResponse response) {
+              *fd = std::move(response.out_fd);
+              std::move(quit_closure).Run();
+            },
+            &fd, run_loop.QuitClosure()),
+        base::BindOnce([](brillo::Error* error) {
+          LOG(FATAL) << "OpenPipe failed: " << error->GetMessage();
+        }));
+    run_loop.Run();
+    std::string piped(5, '\0');
+    CHECK(base::ReadFromFD(fd.get(), piped.data(), piped.size()));
+    printf("OpenPipe %s\n", piped.c_str());
+  }
+
+  // Signal and property change notification.
+  EmitState state;
+  proxy.InitializeProperties(base::BindRepeating(&OnPropertyChanged, &state));
+  {
+    base::RunLoop run_loop;
+    proxy.RegisterFrobbedSignalHandler(
+        base::BindRepeating(&OnFrobbed, &state),
+        base::BindOnce(&OnSignalConnected, run_loop.QuitClosure()));
+    run_loop.Run();
+  }
+  {
+    base::RunLoop run_loop;
+    state.quit_closure = run_loop.QuitClosure();
+    error.reset();
+    CHECK(proxy.Emit(7, &error));
+    run_loop.Run();
+  }
+  printf("Frobbed %u\n", state.signal_value);
+  printf("Count %u\n", proxy.count());
+
+  error.reset();
+  CHECK(proxy.Quit(&error));
+
+  bus->ShutdownAndBlock();
+  return 0;
+}
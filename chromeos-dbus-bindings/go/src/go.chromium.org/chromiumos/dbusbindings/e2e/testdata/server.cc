@@ -0,0 +1,102 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Exports org.chromium.E2E.Frobber on the session bus through the generated
+// adaptor. Prints "ready" once the service name is owned, and exits when Quit
+// is called.
+
+#include <cstdio>
+#include <string>
+
+#include <base/at_exit.h>
+#include <base/files/file_util.h>
+#include <base/files/scoped_file.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/run_loop.h>
+#include <base/task/single_thread_task_executor.h>
+#include <brillo/dbus/dbus_object.h>
+#include <brillo/errors/error.h>
+#include <dbus/bus.h>
+
+#include "e2e/dbus_adaptor.h"
+
+namespace {
+
+class Frobber : public org::chromium::E2E::FrobberInterface {
+ public:
+  explicit Frobber(base::OnceClosure quit_closure)
+      : adaptor_(this), quit_closure_(std::move(quit_closure)) {}
+
+  org::chromium::E2E::FrobberAdaptor* adaptor() { return &adaptor_; }
+
+  bool Add(brillo::ErrorPtr* error,
+           int32_t in_a,
+           int32_t in_b,
+           int32_t* out_sum) override {
+    *out_sum = in_a + in_b;
+    return true;
+  }
+
+  bool Fail(brillo::ErrorPtr* error) override {
+    brillo::Error::AddTo(error, FROM_HERE, "e2e", "Failed", "requested");
+    return false;
+  }
+
+  std::string Echo(const std::string& in_text,
+                   const std::string& in_suffix) override {
+    return in_text + in_suffix;
+  }
+
+  void Emit(uint32_t in_value) override {
+    adaptor_.SetCount(adaptor_.GetCount() + 1);
+    adaptor_.SendFrobbedSignal(in_value);
+  }
+
+  bool OpenPipe(brillo::ErrorPtr* error,
+                const std::string& in_text,
+                base::ScopedFD* out_fd) override {
+    base::ScopedFD write_fd;
+    CHECK(base::CreatePipe(out_fd, &write_fd));
+    CHECK(base::WriteFileDescriptor(write_fd.get(), in_text));
+    return true;
+  }
+
+  void Quit() override {
+    if (quit_closure_)
+      std::move(quit_closure_).Run();
+  }
+
+ private:
+  org::chromium::E2E::FrobberAdaptor adaptor_;
+  base::OnceClosure quit_closure_;
+};
+
+}  // namespace
+
+int main() {
+  base::AtExitManager at_exit;
+  base::SingleThreadTaskExecutor executor(base::MessagePumpType::IO);
+  base::RunLoop run_loop;
+
+  dbus::Bus::Options options;
+  options.bus_type = dbus::Bus::SESSION;
+  scoped_refptr<dbus::Bus> bus = new dbus::Bus(options);
+  CHECK(bus->Connect());
+
+  Frobber frobber(run_loop.QuitWhenIdleClosure());
+  brillo::dbus_utils::DBusObject object(
+      nullptr, bus, org::chromium::E2E::FrobberAdaptor::GetObjectPath());
+  frobber.adaptor()->RegisterWithDBusObject(&object);
+  object.RegisterAndBlock();
+  CHECK(bus->RequestOwnershipAndBlock("org.chromium.E2E",
+                                      dbus::Bus::REQUIRE_PRIMARY));
+
+  printf("ready\n");
+  fflush(stdout);
+  run_loop.Run();
+
+  bus->ShutdownAndBlock();
+  return 0;
+}
@@ -7,6 +7,7 @@ package adaptor
 
 import (
 	"io"
+	"reflect"
 	"text/template"
 
 	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
@@ -14,34 +15,41 @@ import (
 )
 
 type templateArgs struct {
-	Introspects []introspect.Introspection
-	HeaderGuard string
+	Introspects []introspectionView
+	// Includes are the headers declaring the custom types of introspects.
+	Includes             []string
+	HasSerializedMethods bool
+	HeaderGuard          string
+	// ModuleName is set when generating a C++20 module interface unit.
+	ModuleName string
 }
 
 var funcMap = template.FuncMap{
-	"makeInterfaceName":       genutil.MakeInterfaceName,
-	"makeAdaptorName":         genutil.MakeAdaptorName,
-	"makeFullItfName":         genutil.MakeFullItfName,
-	"extractNameSpaces":       genutil.ExtractNameSpaces,
-	"formatComment":           genutil.FormatComment,
-	"makeMethodRetType":       makeMethodRetType,
-	"makeMethodParams":        makeMethodParams,
-	"makeAddHandlerName":      makeAddHandlerName,
-	"makePropertyWriteAccess": makePropertyWriteAccess,
-	"makeVariableName":        genutil.MakeVariableName,
-	"makeSignalParams":        makeSignalParams,
-	"makeSignalArgNames":      makeSignalArgNames,
-	"makePropertyVariableName": func(p *introspect.Property) string {
-		return p.VariableName()
-	},
-	"makePropertyBaseTypeExtract": func(p *introspect.Property) (string, error) {
-		return p.BaseType()
-	},
-	"makePropertyInArgTypeAdaptor": func(p *introspect.Property) (string, error) {
-		return p.InArgType()
-	},
-	"makeDBusSignalParams": makeDBusSignalParams,
-	"reverse":              genutil.Reverse,
+	"makeInterfaceName": genutil.MakeInterfaceName,
+	"makeAdaptorName":   genutil.MakeAdaptorName,
+	"makeFullItfName":   genutil.MakeFullItfName,
+	"extractNameSpaces": genutil.ExtractNameSpaces,
+	"formatComment":     genutil.FormatComment,
+	"makeVariableName":  genutil.MakeVariableName,
+	"reverse":           genutil.Reverse,
+}
+
+// dotTypes are the types of the data the templates of the adaptor are
+// executed on, which their overrides are checked against.
+var dotTypes = genutil.DotTypes{
+	"fileStartTmpl":                       reflect.TypeOf(templateArgs{}),
+	"fileBodyStartTmpl":                   reflect.TypeOf(templateArgs{}),
+	"fileEndTmpl":                         reflect.TypeOf(templateArgs{}),
+	"interfaceMethodsTmpl":                reflect.TypeOf(interfaceView{}),
+	"registerWithDBusObjectTmpl":          reflect.TypeOf(interfaceView{}),
+	"sendSignalMethodsTmpl":               reflect.TypeOf(interfaceView{}),
+	"propertyMethodImplementationTmpl":    reflect.TypeOf(interfaceView{}),
+	"notifyPropertiesChangedTmpl":         reflect.TypeOf(interfaceView{}),
+	"quotedIntrospectionForInterfaceTmpl": reflect.TypeOf(interfaceView{}),
+	"optionalArgHandlersTmpl":             reflect.TypeOf(interfaceView{}),
+	"signalDataMembersTmpl":               reflect.TypeOf(interfaceView{}),
+	"propertyDataMembersTmpl":             reflect.TypeOf(interfaceView{}),
+	"propertyBatchMembersTmpl":            reflect.TypeOf(interfaceView{}),
 }
 
 const (
@@ -49,19 +57,31 @@ const (
 {{range .Introspects}}{{range .Interfaces -}}
 //  - {{.Name}}
 {{end}}{{end -}}
-#ifndef {{.HeaderGuard}}
-#define {{.HeaderGuard}}
-#include <memory>
+{{template "fileStartTmpl" .}}#include <memory>
+{{- if .HasSerializedMethods}}
+#include <queue>
+{{- end}}
 #include <string>
 #include <tuple>
 #include <vector>
 
 #include <base/files/scoped_file.h>
+{{- if .HasSerializedMethods}}
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/location.h>
+#include <base/memory/weak_ptr.h>
+#include <base/task/sequenced_task_runner.h>
+{{- end}}
 #include <dbus/object_path.h>
+#include <dbus/property.h>
 #include <brillo/any.h>
 #include <brillo/dbus/dbus_object.h>
 #include <brillo/dbus/exported_object_manager.h>
 #include <brillo/variant_dictionary.h>
+{{- range .Includes}}
+#include <{{.}}>
+{{- end}}{{template "fileBodyStartTmpl" .}}
 {{range $introspect := .Introspects}}{{range .Interfaces -}}
 {{$itfName := makeInterfaceName .Name -}}
 {{$className := makeAdaptorName .Name -}}
@@ -87,10 +107,22 @@ class {{$className}} {
 {{- end}}
   {{$className}}(const {{$className}}&) = delete;
   {{$className}}& operator=(const {{$className}}&) = delete;
+{{- if .HasSerializedMethods}}
+
+  ~{{$className}}() {
+    // Drop the queued calls, which can't be dispatched anymore, before the
+    // members they use.
+    weak_ptr_factory_.InvalidateWeakPtrs();
+{{- range .Methods}}{{if .Serialized}}
+    {{makeVariableName .Name}}_pending_calls_ = {};
+{{- end}}{{end}}
+  }
+{{- end}}
 
 {{template "registerWithDBusObjectTmpl" . -}}
 {{template "sendSignalMethodsTmpl" . -}}
 {{template "propertyMethodImplementationTmpl" . -}}
+{{template "notifyPropertiesChangedTmpl" . -}}
 {{if $introspect.Name}}
   static dbus::ObjectPath GetObjectPath() {
     return dbus::ObjectPath{"{{$introspect.Name}}"};
@@ -98,25 +130,29 @@ class {{$className}} {
 {{end}}
 {{template "quotedIntrospectionForInterfaceTmpl" . -}}
 {{"\n "}}private:
+{{template "optionalArgHandlersTmpl" . -}}
 {{template "signalDataMembersTmpl" . -}}
 {{template "propertyDataMembersTmpl" . -}}
+{{template "propertyBatchMembersTmpl" . -}}
 {{if .Methods -}}
 {{"  "}}{{$itfName}}* interface_;  // Owned by container of this adapter.
 {{end -}}
+{{if .HasSerializedMethods -}}
+{{"  "}}base::WeakPtrFactory<{{$className}}> weak_ptr_factory_{this};
+{{end -}}
 };
 
 {{range extractNameSpaces .Name | reverse -}}
 }  // namespace {{.}}
 {{end -}}
 {{end}}{{end -}}
-#endif  // {{.HeaderGuard}}
-`
+{{template "fileEndTmpl" .}}`
 	interfaceMethodsTmpl = `{{define "interfaceMethodsTmpl" -}}
 {{if .Methods}}{{"\n"}}{{end -}}
 {{range .Methods -}}
 {{formatComment .DocString 2 -}}
-{{"  "}}virtual {{makeMethodRetType .}} {{.Name}}(
-{{- range $i, $arg := makeMethodParams .}}{{if ne $i 0}},{{end}}
+{{"  "}}virtual {{.RetType}} {{.Name}}(
+{{- range $i, $arg := .Params}}{{if ne $i 0}},{{end}}
       {{$arg -}}
 {{end -}}
 ) {{if .Const}}const {{end}}= 0;
@@ -129,23 +165,30 @@ class {{$className}} {
         object->AddOrGetInterface("{{.Name}}");
 {{if .Methods}}{{"\n"}}{{end -}}
 {{$itfName := makeInterfaceName .Name -}}
+{{$adaptorName := makeAdaptorName .Name -}}
 {{range .Methods -}}
-{{"    "}}itf->{{makeAddHandlerName .}}(
+{{if .Handler -}}
+{{"    "}}itf->AddRawMethodHandler(
+        "{{.Name}}",
+        base::Unretained(this),
+        &{{$adaptorName}}::Handle{{.Name}});
+{{else -}}
+{{"    "}}itf->{{.AddHandlerName}}(
         "{{.Name}}",
         base::Unretained(interface_),
         &{{$itfName}}::{{.Name}});
 {{end -}}
+{{end -}}
 
 {{if .Signals}}{{"\n"}}{{end -}}
 {{range .Signals -}}
 {{"    "}}signal_{{.Name}}_ = itf->RegisterSignalOfType<Signal{{.Name}}Type>("{{.Name}}");
 {{end -}}
 
-{{$adaptorName := makeAdaptorName .Name -}}
 {{if .Properties}}{{"\n"}}{{end -}}
 {{range .Properties -}}
-{{$writeAccess := makePropertyWriteAccess . -}}
-{{$variableName := makePropertyVariableName . | makeVariableName -}}
+{{$writeAccess := .WriteAccess -}}
+{{$variableName := .VarName -}}
 {{if $writeAccess -}} {{/* Register exported properties. */ -}}
 {{"    "}}{{$variableName}}_.SetAccessMode(
         brillo::dbus_utils::ExportedPropertyBase::Access::{{$writeAccess}});
@@ -155,6 +198,10 @@ class {{$className}} {
 {{end -}}
 {{"    "}}itf->AddProperty({{.Name}}Name(), &{{$variableName}}_);
 {{end -}}
+{{if .Properties -}}
+{{"    "}}dbus_object_ = object;
+    dbus_interface_ = itf;
+{{end -}}
 
 {{"  " -}} }
 {{end}}`
@@ -164,20 +211,20 @@ class {{$className}} {
 {{range .Signals -}}
 {{formatComment .DocString 2 -}}
 {{"  "}}void Send{{.Name}}Signal(
-{{- range $i, $arg := makeSignalParams .}}{{if ne $i 0}},{{end}}
+{{- range $i, $arg := .Params}}{{if ne $i 0}},{{end}}
       {{$arg -}}
 {{end}}) {
     auto signal = signal_{{.Name}}_.lock();
     if (signal)
-      signal->Send({{makeSignalArgNames .}});
+      signal->Send({{.ArgNames}});
   }
 {{end -}}
 {{end}}`
 
 	propertyMethodImplementationTmpl = `{{define "propertyMethodImplementationTmpl" -}}
 {{range .Properties}}{{"\n" -}}
-{{$baseType := makePropertyBaseTypeExtract . -}}
-{{$variableName := makePropertyVariableName . | makeVariableName -}}
+{{$baseType := .ValueType -}}
+{{$variableName := .VarName -}}
 
 {{/* Property name accessor. */ -}}
 {{formatComment .DocString 2 -}}
@@ -189,7 +236,7 @@ class {{$className}} {
   }
 
 {{- /* Setter method. */}}
-  void Set{{.Name}}({{makePropertyInArgTypeAdaptor .}} {{$variableName}}) {
+  void Set{{.Name}}({{.ArgType}} {{$variableName}}) {
     {{$variableName}}_.SetValue({{$variableName}});
   }
 
@@ -203,6 +250,45 @@ class {{$className}} {
   }
 {{end -}}
 {{end -}}
+{{end}}`
+
+	notifyPropertiesChangedTmpl = `{{define "notifyPropertiesChangedTmpl" -}}
+{{if .Properties -}}
+{{"\n"}}  // Sets the values of several properties at once. Unlike calling the
+  // individual setters, this emits a single
+  // org.freedesktop.DBus.Properties.PropertiesChanged signal carrying all the
+  // properties whose value changed. |properties| maps property names to their
+  // new values.
+  void NotifyPropertiesChanged(const brillo::VariantDictionary& properties) {
+    brillo::VariantDictionary changed_properties;
+    for (const auto& [name, value] : properties) {
+{{- range .Properties}}
+{{- $baseType := .ValueType}}
+{{- $variableName := .VarName}}
+      if (name == {{.Name}}Name()) {
+        if (value.IsTypeCompatible<{{$baseType}}>() &&
+            SetPropertyValueSilently(name, &{{$variableName}}_,
+                                     value.Get<{{$baseType}}>())) {
+          changed_properties[name] = value;
+        }
+        continue;
+      }
+{{- end}}
+    }
+    if (changed_properties.empty() || !dbus_object_)
+      return;
+
+    dbus::Signal signal(dbus::kPropertiesInterface, dbus::kPropertiesChanged);
+    dbus::MessageWriter writer(&signal);
+    brillo::dbus_utils::AppendValueToWriter(&writer,
+                                            std::string{"{{.Name}}"});
+    brillo::dbus_utils::AppendValueToWriter(&writer, changed_properties);
+    // No invalidated properties.
+    brillo::dbus_utils::AppendValueToWriter(&writer,
+                                            std::vector<std::string>{});
+    dbus_object_->SendSignal(&signal);
+  }
+{{end -}}
 {{end}}`
 
 	quotedIntrospectionForInterfaceTmpl = `{{define "quotedIntrospectionForInterfaceTmpl" -}}
@@ -230,10 +316,143 @@ class {{$className}} {
   }
 {{end}}`
 
+	optionalArgHandlersTmpl = `{{define "optionalArgHandlersTmpl" -}}
+{{$adaptorName := makeAdaptorName .Name -}}
+{{range .Methods}}{{if .Handler -}}
+{{$h := .Handler -}}
+{{$varName := makeVariableName .Name -}}
+{{if $h.Serialized -}}
+{{"  "}}// Handles {{.Name}} one call at a time: calls arriving before the previous
+  // one has replied are queued, and dispatched in order.
+  void Handle{{.Name}}(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    {{$varName}}_pending_calls_.push(base::BindOnce(
+        &{{$adaptorName}}::Dispatch{{.Name}}, weak_ptr_factory_.GetWeakPtr(),
+        method_call, std::move(sender)));
+    if (!{{$varName}}_running_)
+      DispatchNext{{.Name}}();
+  }
+
+  void DispatchNext{{.Name}}() {
+    if ({{$varName}}_pending_calls_.empty()) {
+      {{$varName}}_running_ = false;
+      return;
+    }
+    {{$varName}}_running_ = true;
+    base::OnceClosure call = std::move({{$varName}}_pending_calls_.front());
+    {{$varName}}_pending_calls_.pop();
+    std::move(call).Run();
+  }
+
+{{end -}}
+{{if $h.Default -}}
+{{"  "}}// Handles {{.Name}}, using {{$h.Default}} for |{{$h.Optional.Name}}| if the caller omitted it.
+{{- else if $h.Checks -}}
+{{"  "}}// Handles {{.Name}}, rejecting unexpected argument values.
+{{- else -}}
+{{"  "}}// Dispatches a queued call of {{.Name}}.
+{{- end}}
+  void {{if $h.Serialized}}Dispatch{{else}}Handle{{end}}{{.Name}}(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+{{- if $h.Serialized}}
+    // Dispatch the next queued call once this one has replied, unless the
+    // adaptor is gone by then. The dispatch is posted, so that calls replying
+    // synchronously don't recurse through the whole queue.
+    sender = base::BindOnce(
+        [](base::WeakPtr<{{$adaptorName}}> self,
+           brillo::dbus_utils::ResponseSender sender,
+           std::unique_ptr<dbus::Response> response) {
+          std::move(sender).Run(std::move(response));
+          if (!self)
+            return;
+          base::SequencedTaskRunner::GetCurrentDefault()->PostTask(
+              FROM_HERE,
+              base::BindOnce(&{{$adaptorName}}::DispatchNext{{.Name}}, self));
+        },
+        weak_ptr_factory_.GetWeakPtr(), std::move(sender));
+{{- end}}
+    auto response = std::make_unique<{{$h.ResponseType}}>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+{{- range $h.Required}}
+    {{.Type}} {{.Name}};
+{{- end}}
+{{- if $h.Default}}
+    {{$h.Optional.Type}} {{$h.Optional.Name}} = {{$h.Default}};
+{{- end}}
+{{- if and (not $h.Default) $h.Required}}
+    if (
+{{- range $i, $arg := $h.Required}}{{if ne $i 0}} ||
+        {{end}}!brillo::dbus_utils::DBusType<{{.Type}}>::Read(&reader, &{{.Name}})
+{{- end}} ||
+        reader.HasMoreData()) {
+{{- else if not $h.Default}}
+    if (reader.HasMoreData()) {
+{{- else if $h.Required}}
+    if (
+{{- range $i, $arg := $h.Required}}{{if ne $i 0}} ||
+        {{end}}!brillo::dbus_utils::DBusType<{{.Type}}>::Read(&reader, &{{.Name}})
+{{- end}} ||
+        (reader.HasMoreData() &&
+         !brillo::dbus_utils::ReadDBusArgs(&reader, &{{$h.Optional.Name}}))) {
+{{- else}}
+    if (reader.HasMoreData() &&
+        !brillo::dbus_utils::ReadDBusArgs(&reader, &{{$h.Optional.Name}})) {
+{{- end}}
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+{{- range $h.Checks}}
+{{- $name := .Name}}
+    if ({{range $i, $v := .Values}}{{if ne $i 0}} &&
+        {{end}}{{$name}} != "{{$v}}"{{end}}) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+{{- if $h.Redact}}
+                               "unexpected value of {{.ArgName}}");
+{{- else}}
+                               "unexpected value of {{.ArgName}}: " + {{$name}});
+{{- end}}
+      return;
+    }
+{{- end}}
+{{- if eq $h.Kind "async"}}
+    {{$h.Call}};
+{{- else if $h.ReturnsValue}}
+    response->Return({{$h.Call}});
+{{- else}}
+{{- range $h.Outputs}}
+    {{.Type}} {{.Name}};
+{{- end}}
+{{- if eq $h.Kind "normal"}}
+    brillo::ErrorPtr error;
+    if (!{{$h.Call}}) {
+      response->ReplyWithError(error.get());
+      return;
+    }
+{{- else}}
+    {{$h.Call}};
+{{- end}}
+    response->Return({{range $i, $out := $h.Outputs}}{{if ne $i 0}}, {{end}}{{$out.Name}}{{end}});
+{{- end}}
+  }
+
+{{if $h.Serialized -}}
+{{"  "}}bool {{$varName}}_running_ = false;
+  std::queue<base::OnceClosure> {{$varName}}_pending_calls_;
+
+{{end -}}
+{{end}}{{end -}}
+{{end}}`
+
 	signalDataMembersTmpl = `{{define "signalDataMembersTmpl" -}}
 {{range .Signals -}}
 {{"  "}}using Signal{{.Name}}Type = brillo::dbus_utils::DBusSignal<
-{{- range $i, $arg := makeDBusSignalParams .}}{{if ne $i 0}},{{end}}
+{{- range $i, $arg := .DBusParams}}{{if ne $i 0}},{{end}}
       {{$arg -}}
 {{end}}>;
   std::weak_ptr<Signal{{.Name}}Type> signal_{{.Name}}_;
@@ -243,20 +462,79 @@ class {{$className}} {
 
 	propertyDataMembersTmpl = `{{define "propertyDataMembersTmpl" -}}
 {{range .Properties -}}
-{{$variableName := makePropertyVariableName . | makeVariableName -}}
-{{"  "}}brillo::dbus_utils::ExportedProperty<{{makePropertyBaseTypeExtract . }}> {{$variableName}}_;
+{{$variableName := .VarName -}}
+{{"  "}}brillo::dbus_utils::ExportedProperty<{{.ValueType}}> {{$variableName}}_;
 {{end -}}
 {{if .Properties}}{{"\n"}}{{end -}}
+{{end}}`
+
+	propertyBatchMembersTmpl = `{{define "propertyBatchMembersTmpl" -}}
+{{if .Properties -}}
+{{"  "}}// Updates |property| without emitting PropertiesChanged for it. Returns
+  // true if the value changed.
+  template <typename T>
+  bool SetPropertyValueSilently(
+      const std::string& name,
+      brillo::dbus_utils::ExportedProperty<T>* property,
+      const T& value) {
+    if (property->value() == value)
+      return false;
+    if (!dbus_interface_) {
+      // Not registered yet, so SetValue() doesn't notify anyway.
+      property->SetValue(value);
+      return true;
+    }
+    // Unregistering the property drops its update callback, so that
+    // SetValue() doesn't emit PropertiesChanged on its own.
+    dbus_interface_->RemoveProperty(name);
+    property->SetValue(value);
+    dbus_interface_->AddProperty(name, property);
+    return true;
+  }
+
+  brillo::dbus_utils::DBusObject* dbus_object_ = nullptr;
+  brillo::dbus_utils::DBusInterface* dbus_interface_ = nullptr;
+
+{{end -}}
 {{end}}`
 )
 
 // Generate prints an interface definition and an interface adaptor for each interface in introspects.
-func Generate(introspects []introspect.Introspection, f io.Writer, outputFilePath string) error {
+// Templates are replaced by their overrides, if any.
+func Generate(introspects []introspect.Introspection, f io.Writer, outputFilePath string, overrides genutil.TemplateOverrides) error {
+	return generate(introspects, f, templateArgs{
+		HeaderGuard: genutil.GenerateHeaderGuard(outputFilePath),
+	}, overrides)
+}
+
+// GenerateModule is like Generate, but prints an experimental C++20 module
+// interface unit named moduleName instead of a header.
+func GenerateModule(introspects []introspect.Introspection, f io.Writer, moduleName string, overrides genutil.TemplateOverrides) error {
+	return generate(introspects, f, templateArgs{
+		ModuleName: moduleName,
+	}, overrides)
+}
+
+// generate completes args with the views of introspects and executes the
+// templates on them.
+func generate(introspects []introspect.Introspection, f io.Writer, args templateArgs, overrides genutil.TemplateOverrides) error {
+	views, err := makeIntrospectionViews(introspects)
+	if err != nil {
+		return err
+	}
+	args.Introspects = views
+	args.Includes = genutil.CustomTypeIncludes(introspects)
+	args.HasSerializedMethods = hasSerializedMethods(views)
+
 	tmpl, err := template.New("adaptor").Funcs(funcMap).Parse(templateText)
 	if err != nil {
 		return err
 	}
 
+	if _, err = tmpl.Parse(genutil.FileBoundaryTemplates); err != nil {
+		return err
+	}
+
 	if _, err = tmpl.Parse(interfaceMethodsTmpl); err != nil {
 		return err
 	}
@@ -272,13 +550,25 @@ func Generate(introspects []introspect.Introspection, f io.Writer, outputFilePat
 	if _, err = tmpl.Parse(quotedIntrospectionForInterfaceTmpl); err != nil {
 		return err
 	}
+	if _, err = tmpl.Parse(optionalArgHandlersTmpl); err != nil {
+		return err
+	}
 	if _, err = tmpl.Parse(signalDataMembersTmpl); err != nil {
 		return err
 	}
 	if _, err = tmpl.Parse(propertyDataMembersTmpl); err != nil {
 		return err
 	}
+	if _, err = tmpl.Parse(notifyPropertiesChangedTmpl); err != nil {
+		return err
+	}
+	if _, err = tmpl.Parse(propertyBatchMembersTmpl); err != nil {
+		return err
+	}
+
+	if err = overrides.Apply(tmpl, dotTypes); err != nil {
+		return err
+	}
 
-	var headerGuard = genutil.GenerateHeaderGuard(outputFilePath)
-	return tmpl.Execute(f, templateArgs{introspects, headerGuard})
+	return tmpl.Execute(f, args)
 }
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"text/template"
 
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
 	"go.chromium.org/chromiumos/dbusbindings/introspect"
 
 	"github.com/google/go-cmp/cmp"
@@ -27,6 +28,7 @@ const (
 
 #include <base/files/scoped_file.h>
 #include <dbus/object_path.h>
+#include <dbus/property.h>
 #include <brillo/any.h>
 #include <brillo/dbus/dbus_object.h>
 #include <brillo/dbus/exported_object_manager.h>
@@ -75,6 +77,8 @@ class InterfaceAdaptor {
 
     itf->AddProperty(CapabilitiesName(), &capabilities_);
     itf->AddProperty(ClassName(), &bluetooth_class_);
+    dbus_object_ = object;
+    dbus_interface_ = itf;
   }
 
   // signal doc
@@ -104,6 +108,45 @@ class InterfaceAdaptor {
     bluetooth_class_.SetValue(bluetooth_class);
   }
 
+  // Sets the values of several properties at once. Unlike calling the
+  // individual setters, this emits a single
+  // org.freedesktop.DBus.Properties.PropertiesChanged signal carrying all the
+  // properties whose value changed. |properties| maps property names to their
+  // new values.
+  void NotifyPropertiesChanged(const brillo::VariantDictionary& properties) {
+    brillo::VariantDictionary changed_properties;
+    for (const auto& [name, value] : properties) {
+      if (name == CapabilitiesName()) {
+        if (value.IsTypeCompatible<brillo::VariantDictionary>() &&
+            SetPropertyValueSilently(name, &capabilities_,
+                                     value.Get<brillo::VariantDictionary>())) {
+          changed_properties[name] = value;
+        }
+        continue;
+      }
+      if (name == ClassName()) {
+        if (value.IsTypeCompatible<uint32_t>() &&
+            SetPropertyValueSilently(name, &bluetooth_class_,
+                                     value.Get<uint32_t>())) {
+          changed_properties[name] = value;
+        }
+        continue;
+      }
+    }
+    if (changed_properties.empty() || !dbus_object_)
+      return;
+
+    dbus::Signal signal(dbus::kPropertiesInterface, dbus::kPropertiesChanged);
+    dbus::MessageWriter writer(&signal);
+    brillo::dbus_utils::AppendValueToWriter(&writer,
+                                            std::string{"fi.w1.wpa_supplicant1.Interface"});
+    brillo::dbus_utils::AppendValueToWriter(&writer, changed_properties);
+    // No invalidated properties.
+    brillo::dbus_utils::AppendValueToWriter(&writer,
+                                            std::vector<std::string>{});
+    dbus_object_->SendSignal(&signal);
+  }
+
   static dbus::ObjectPath GetObjectPath() {
     return dbus::ObjectPath{"/org/chromium/Test"};
   }
@@ -133,6 +176,31 @@ class InterfaceAdaptor {
   brillo::dbus_utils::ExportedProperty<brillo::VariantDictionary> capabilities_;
   brillo::dbus_utils::ExportedProperty<uint32_t> bluetooth_class_;
 
+  // Updates |property| without emitting PropertiesChanged for it. Returns
+  // true if the value changed.
+  template <typename T>
+  bool SetPropertyValueSilently(
+      const std::string& name,
+      brillo::dbus_utils::ExportedProperty<T>* property,
+      const T& value) {
+    if (property->value() == value)
+      return false;
+    if (!dbus_interface_) {
+      // Not registered yet, so SetValue() doesn't notify anyway.
+      property->SetValue(value);
+      return true;
+    }
+    // Unregistering the property drops its update callback, so that
+    // SetValue() doesn't emit PropertiesChanged on its own.
+    dbus_interface_->RemoveProperty(name);
+    property->SetValue(value);
+    dbus_interface_->AddProperty(name, property);
+    return true;
+  }
+
+  brillo::dbus_utils::DBusObject* dbus_object_ = nullptr;
+  brillo::dbus_utils::DBusInterface* dbus_interface_ = nullptr;
+
   InterfaceInterface* interface_;  // Owned by container of this adapter.
 };
 
@@ -259,7 +327,7 @@ func TestGenerateAdaptors(t *testing.T) {
 	}
 
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/adaptor.h"); err != nil {
+	if err := Generate(introspections, out, "/tmp/adaptor.h", nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -268,6 +336,270 @@ func TestGenerateAdaptors(t *testing.T) {
 	}
 }
 
+func TestGenerateAdaptorsModule(t *testing.T) {
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{{Name: "test.EmptyInterface"}},
+	}}
+
+	out := new(bytes.Buffer)
+	if err := GenerateModule(introspections, out, "test.adaptor", nil); err != nil {
+		t.Fatalf("GenerateModule got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.EmptyInterface
+module;
+#include <memory>
+#include <string>
+#include <tuple>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <dbus/object_path.h>
+#include <dbus/property.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_object.h>
+#include <brillo/dbus/exported_object_manager.h>
+#include <brillo/variant_dictionary.h>
+
+export module test.adaptor;
+
+export {
+
+namespace test {
+
+// Interface definition for test::EmptyInterface.
+class EmptyInterfaceInterface {
+ public:
+  virtual ~EmptyInterfaceInterface() = default;
+};
+
+// Interface adaptor for test::EmptyInterface.
+class EmptyInterfaceAdaptor {
+ public:
+  EmptyInterfaceAdaptor(EmptyInterfaceInterface* /* interface */) {}
+  EmptyInterfaceAdaptor(const EmptyInterfaceAdaptor&) = delete;
+  EmptyInterfaceAdaptor& operator=(const EmptyInterfaceAdaptor&) = delete;
+
+  void RegisterWithDBusObject(brillo::dbus_utils::DBusObject* object) {
+    brillo::dbus_utils::DBusInterface* itf =
+        object->AddOrGetInterface("test.EmptyInterface");
+  }
+
+  static const char* GetIntrospectionXml() {
+    return
+        "  <interface name=\"test.EmptyInterface\">\n"
+        "  </interface>\n";
+  }
+
+ private:
+};
+
+}  // namespace test
+}  // export
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("GenerateModule failed (-got +want):\n%s", diff)
+	}
+}
+
+// TestGenerateAdaptorsSerialized tests that the adaptor of an interface with
+// serialized methods owns the weak pointers its queued calls are bound to.
+func TestGenerateAdaptorsSerialized(t *testing.T) {
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{{
+			Name: "org.chromium.Queue",
+			Methods: []introspect.Method{{
+				Name: "Flush",
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.Kind", Value: "async"},
+					{Name: "org.chromium.DBus.Method.Concurrency", Value: "serialized"},
+				},
+			}},
+		}},
+	}}
+
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/queue.h", nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - org.chromium.Queue
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_QUEUE_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_QUEUE_H
+#include <memory>
+#include <queue>
+#include <string>
+#include <tuple>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/location.h>
+#include <base/memory/weak_ptr.h>
+#include <base/task/sequenced_task_runner.h>
+#include <dbus/object_path.h>
+#include <dbus/property.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_object.h>
+#include <brillo/dbus/exported_object_manager.h>
+#include <brillo/variant_dictionary.h>
+
+namespace org {
+namespace chromium {
+
+// Interface definition for org::chromium::Queue.
+class QueueInterface {
+ public:
+  virtual ~QueueInterface() = default;
+
+  virtual void Flush(
+      std::unique_ptr<brillo::dbus_utils::DBusMethodResponse<>> response) = 0;
+};
+
+// Interface adaptor for org::chromium::Queue.
+class QueueAdaptor {
+ public:
+  QueueAdaptor(QueueInterface* interface) : interface_(interface) {}
+  QueueAdaptor(const QueueAdaptor&) = delete;
+  QueueAdaptor& operator=(const QueueAdaptor&) = delete;
+
+  ~QueueAdaptor() {
+    // Drop the queued calls, which can't be dispatched anymore, before the
+    // members they use.
+    weak_ptr_factory_.InvalidateWeakPtrs();
+    flush_pending_calls_ = {};
+  }
+
+  void RegisterWithDBusObject(brillo::dbus_utils::DBusObject* object) {
+    brillo::dbus_utils::DBusInterface* itf =
+        object->AddOrGetInterface("org.chromium.Queue");
+
+    itf->AddRawMethodHandler(
+        "Flush",
+        base::Unretained(this),
+        &QueueAdaptor::HandleFlush);
+  }
+
+  static const char* GetIntrospectionXml() {
+    return
+        "  <interface name=\"org.chromium.Queue\">\n"
+        "    <method name=\"Flush\">\n"
+        "    </method>\n"
+        "  </interface>\n";
+  }
+
+ private:
+  // Handles Flush one call at a time: calls arriving before the previous
+  // one has replied are queued, and dispatched in order.
+  void HandleFlush(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    flush_pending_calls_.push(base::BindOnce(
+        &QueueAdaptor::DispatchFlush, weak_ptr_factory_.GetWeakPtr(),
+        method_call, std::move(sender)));
+    if (!flush_running_)
+      DispatchNextFlush();
+  }
+
+  void DispatchNextFlush() {
+    if (flush_pending_calls_.empty()) {
+      flush_running_ = false;
+      return;
+    }
+    flush_running_ = true;
+    base::OnceClosure call = std::move(flush_pending_calls_.front());
+    flush_pending_calls_.pop();
+    std::move(call).Run();
+  }
+
+  // Dispatches a queued call of Flush.
+  void DispatchFlush(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    // Dispatch the next queued call once this one has replied, unless the
+    // adaptor is gone by then. The dispatch is posted, so that calls replying
+    // synchronously don't recurse through the whole queue.
+    sender = base::BindOnce(
+        [](base::WeakPtr<QueueAdaptor> self,
+           brillo::dbus_utils::ResponseSender sender,
+           std::unique_ptr<dbus::Response> response) {
+          std::move(sender).Run(std::move(response));
+          if (!self)
+            return;
+          base::SequencedTaskRunner::GetCurrentDefault()->PostTask(
+              FROM_HERE,
+              base::BindOnce(&QueueAdaptor::DispatchNextFlush, self));
+        },
+        weak_ptr_factory_.GetWeakPtr(), std::move(sender));
+    auto response = std::make_unique<brillo::dbus_utils::DBusMethodResponse<>>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+    if (reader.HasMoreData()) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+    interface_->Flush(std::move(response));
+  }
+
+  bool flush_running_ = false;
+  std::queue<base::OnceClosure> flush_pending_calls_;
+
+  QueueInterface* interface_;  // Owned by container of this adapter.
+  base::WeakPtrFactory<QueueAdaptor> weak_ptr_factory_{this};
+};
+
+}  // namespace chromium
+}  // namespace org
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_QUEUE_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+// TestGenerateAdaptorsTemplateOverrideFields tests that the templates are
+// accepted as overrides of themselves, i.e. that they only refer to the
+// fields of the data dotTypes gives them, and that overrides referring to
+// fields the views don't have are rejected.
+func TestGenerateAdaptorsTemplateOverrideFields(t *testing.T) {
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{{
+			Name:    "org.chromium.Test",
+			Methods: []introspect.Method{{Name: "Ping"}},
+		}},
+	}}
+
+	overrides := genutil.TemplateOverrides{
+		"interfaceMethodsTmpl":                interfaceMethodsTmpl,
+		"registerWithDBusObjectTmpl":          registerWithDBusObjectTmpl,
+		"sendSignalMethodsTmpl":               sendSignalMethodsTmpl,
+		"propertyMethodImplementationTmpl":    propertyMethodImplementationTmpl,
+		"notifyPropertiesChangedTmpl":         notifyPropertiesChangedTmpl,
+		"quotedIntrospectionForInterfaceTmpl": quotedIntrospectionForInterfaceTmpl,
+		"optionalArgHandlersTmpl":             optionalArgHandlersTmpl,
+		"signalDataMembersTmpl":               signalDataMembersTmpl,
+		"propertyDataMembersTmpl":             propertyDataMembersTmpl,
+		"propertyBatchMembersTmpl":            propertyBatchMembersTmpl,
+	}
+	if err := Generate(introspections, new(bytes.Buffer), "/tmp/adaptor.h", overrides); err != nil {
+		t.Errorf("Generate with the templates as overrides got error, want nil: %v", err)
+	}
+
+	overrides = genutil.TemplateOverrides{
+		"interfaceMethodsTmpl": `{{define "interfaceMethodsTmpl"}}{{range .Annotations}}{{.Name}}{{end}}{{end}}`,
+	}
+	if err := Generate(introspections, new(bytes.Buffer), "/tmp/adaptor.h", overrides); err == nil {
+		t.Error("Generate with an override referring to a missing field unexpectedly succeeded")
+	}
+}
+
 func TestInterfaceMethodsTempl(t *testing.T) {
 	cases := []struct {
 		input introspect.Interface
@@ -368,7 +700,11 @@ func TestInterfaceMethodsTempl(t *testing.T) {
 
 	for _, tc := range cases {
 		out := new(bytes.Buffer)
-		if err := tmpl.Execute(out, tc.input); err != nil {
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
 			t.Fatalf("interfaceMethodsTempl execute got error, want nil: %v", err)
 		}
 		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
@@ -426,6 +762,14 @@ func TestRegisterWithDBusObjectTmpl(t *testing.T) {
 							{Name: "org.freedesktop.DBus.GLib.Async"},
 							{Name: "org.chromium.DBus.Method.IncludeDBusMessage", Value: "true"},
 						},
+					}, {
+						Name: "OptionalArgMethod",
+						Args: []introspect.MethodArg{
+							{Name: "flags", Type: "u"},
+						},
+						Annotations: []introspect.Annotation{
+							{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+						},
 					},
 				},
 				Signals: []introspect.Signal{
@@ -473,6 +817,10 @@ func TestRegisterWithDBusObjectTmpl(t *testing.T) {
         "AMessageMethod",
         base::Unretained(interface_),
         &ItfAInterface::AMessageMethod);
+    itf->AddRawMethodHandler(
+        "OptionalArgMethod",
+        base::Unretained(this),
+        &ItfAAdaptor::HandleOptionalArgMethod);
 
     signal_FooSignal_ = itf->RegisterSignalOfType<SignalFooSignalType>("FooSignal");
     signal_BarSignal_ = itf->RegisterSignalOfType<SignalBarSignalType>("BarSignal");
@@ -490,6 +838,8 @@ func TestRegisterWithDBusObjectTmpl(t *testing.T) {
                             base::Unretained(this)));
     itf->AddProperty(BarPropertyName(), &bar_property_);
     itf->AddProperty(BazPropertyName(), &baz_property_);
+    dbus_object_ = object;
+    dbus_interface_ = itf;
   }
 `,
 		}, {
@@ -511,7 +861,11 @@ func TestRegisterWithDBusObjectTmpl(t *testing.T) {
 
 	for _, tc := range cases {
 		out := new(bytes.Buffer)
-		if err := tmpl.Execute(out, tc.input); err != nil {
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
 			t.Fatalf("registerWithDBusObjectTmpl execute got error, want nil: %v", err)
 		}
 		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
@@ -520,6 +874,317 @@ func TestRegisterWithDBusObjectTmpl(t *testing.T) {
 	}
 }
 
+func TestOptionalArgHandlersTmpl(t *testing.T) {
+	cases := []struct {
+		input introspect.Interface
+		want  string
+	}{
+		{
+			input: introspect.Interface{
+				Name: "itfWithNoOptionalArg",
+				Methods: []introspect.Method{
+					{Name: "NMethod"},
+				},
+			},
+			want: "",
+		}, {
+			input: introspect.Interface{
+				Name: "fi.w1.wpa_supplicant1.ItfA",
+				Methods: []introspect.Method{
+					{
+						Name: "SMethod",
+						Args: []introspect.MethodArg{
+							{Name: "flags", Type: "u"},
+							{Name: "ret", Type: "i", Direction: "out"},
+						},
+						Annotations: []introspect.Annotation{
+							{Name: "org.chromium.DBus.Method.Kind", Value: "simple"},
+							{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "1"},
+						},
+					}, {
+						Name: "NMessageMethod",
+						Args: []introspect.MethodArg{
+							{Name: "name", Type: "s"},
+							{Name: "flags", Type: "u"},
+							{Name: "x", Type: "s", Direction: "out"},
+							{Name: "y", Type: "x", Direction: "out"},
+						},
+						Annotations: []introspect.Annotation{
+							{Name: "org.chromium.DBus.Method.IncludeDBusMessage", Value: "true"},
+							{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+						},
+					}, {
+						Name: "AMethod",
+						Args: []introspect.MethodArg{
+							{Name: "name", Type: "s"},
+							{Name: "options", Type: "a{sv}"},
+							{Name: "ret", Type: "b", Direction: "out"},
+						},
+						Annotations: []introspect.Annotation{
+							{Name: "org.freedesktop.DBus.GLib.Async"},
+							{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "{}"},
+						},
+					}, {
+						Name: "VMethod",
+						Args: []introspect.MethodArg{
+							{
+								Name: "mode",
+								Type: "s",
+								Annotation: introspect.Annotation{
+									Name:  "org.chromium.DBus.Argument.AllowedValues",
+									Value: "Color,Grayscale",
+								},
+							},
+							{Name: "dpi", Type: "u"},
+						},
+						Annotations: []introspect.Annotation{
+							{Name: "org.chromium.DBus.Method.Kind", Value: "simple"},
+						},
+					}, {
+						Name: "PMethod",
+						Args: []introspect.MethodArg{
+							{
+								Name: "locale",
+								Type: "s",
+								Annotation: introspect.Annotation{
+									Name:  "org.chromium.DBus.Argument.AllowedValues",
+									Value: "en,fr",
+								},
+							},
+						},
+						Annotations: []introspect.Annotation{
+							{Name: "org.chromium.DBus.Method.Kind", Value: "simple"},
+							{Name: "org.chromium.DBus.Method.PrivacySensitive", Value: "true"},
+						},
+					},
+				},
+			},
+			want: `  // Handles SMethod, using 1 for |in_flags| if the caller omitted it.
+  void HandleSMethod(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    auto response = std::make_unique<brillo::dbus_utils::DBusMethodResponse<int32_t>>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+    uint32_t in_flags = 1;
+    if (reader.HasMoreData() &&
+        !brillo::dbus_utils::ReadDBusArgs(&reader, &in_flags)) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+    response->Return(interface_->SMethod(in_flags));
+  }
+
+  // Handles NMessageMethod, using 0 for |in_flags| if the caller omitted it.
+  void HandleNMessageMethod(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    auto response = std::make_unique<brillo::dbus_utils::DBusMethodResponse<std::string, int64_t>>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+    std::string in_name;
+    uint32_t in_flags = 0;
+    if (!brillo::dbus_utils::DBusType<std::string>::Read(&reader, &in_name) ||
+        (reader.HasMoreData() &&
+         !brillo::dbus_utils::ReadDBusArgs(&reader, &in_flags))) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+    std::string out_x;
+    int64_t out_y;
+    brillo::ErrorPtr error;
+    if (!interface_->NMessageMethod(&error, method_call, in_name, in_flags, &out_x, &out_y)) {
+      response->ReplyWithError(error.get());
+      return;
+    }
+    response->Return(out_x, out_y);
+  }
+
+  // Handles AMethod, using {} for |in_options| if the caller omitted it.
+  void HandleAMethod(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    auto response = std::make_unique<brillo::dbus_utils::DBusMethodResponse<bool>>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+    std::string in_name;
+    brillo::VariantDictionary in_options = {};
+    if (!brillo::dbus_utils::DBusType<std::string>::Read(&reader, &in_name) ||
+        (reader.HasMoreData() &&
+         !brillo::dbus_utils::ReadDBusArgs(&reader, &in_options))) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+    interface_->AMethod(std::move(response), in_name, in_options);
+  }
+
+  // Handles VMethod, rejecting unexpected argument values.
+  void HandleVMethod(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    auto response = std::make_unique<brillo::dbus_utils::DBusMethodResponse<>>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+    std::string in_mode;
+    uint32_t in_dpi;
+    if (!brillo::dbus_utils::DBusType<std::string>::Read(&reader, &in_mode) ||
+        !brillo::dbus_utils::DBusType<uint32_t>::Read(&reader, &in_dpi) ||
+        reader.HasMoreData()) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+    if (in_mode != "Color" &&
+        in_mode != "Grayscale") {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "unexpected value of mode: " + in_mode);
+      return;
+    }
+    interface_->VMethod(in_mode, in_dpi);
+    response->Return();
+  }
+
+  // Handles PMethod, rejecting unexpected argument values.
+  void HandlePMethod(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    auto response = std::make_unique<brillo::dbus_utils::DBusMethodResponse<>>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+    std::string in_locale;
+    if (!brillo::dbus_utils::DBusType<std::string>::Read(&reader, &in_locale) ||
+        reader.HasMoreData()) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+    if (in_locale != "en" &&
+        in_locale != "fr") {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "unexpected value of locale");
+      return;
+    }
+    interface_->PMethod(in_locale);
+    response->Return();
+  }
+
+`,
+		}, {
+			input: introspect.Interface{
+				Name: "org.chromium.Queue",
+				Methods: []introspect.Method{
+					{
+						Name: "Flush",
+						Args: []introspect.MethodArg{
+							{Name: "id", Type: "s"},
+							{Name: "count", Type: "i", Direction: "out"},
+						},
+						Annotations: []introspect.Annotation{
+							{Name: "org.chromium.DBus.Method.Kind", Value: "async"},
+							{Name: "org.chromium.DBus.Method.Concurrency", Value: "serialized"},
+						},
+					}, {
+						Name: "Peek",
+						Annotations: []introspect.Annotation{
+							{Name: "org.chromium.DBus.Method.Kind", Value: "async"},
+							{Name: "org.chromium.DBus.Method.Concurrency", Value: "parallel"},
+						},
+					},
+				},
+			},
+			want: `  // Handles Flush one call at a time: calls arriving before the previous
+  // one has replied are queued, and dispatched in order.
+  void HandleFlush(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    flush_pending_calls_.push(base::BindOnce(
+        &QueueAdaptor::DispatchFlush, weak_ptr_factory_.GetWeakPtr(),
+        method_call, std::move(sender)));
+    if (!flush_running_)
+      DispatchNextFlush();
+  }
+
+  void DispatchNextFlush() {
+    if (flush_pending_calls_.empty()) {
+      flush_running_ = false;
+      return;
+    }
+    flush_running_ = true;
+    base::OnceClosure call = std::move(flush_pending_calls_.front());
+    flush_pending_calls_.pop();
+    std::move(call).Run();
+  }
+
+  // Dispatches a queued call of Flush.
+  void DispatchFlush(
+      dbus::MethodCall* method_call,
+      brillo::dbus_utils::ResponseSender sender) {
+    // Dispatch the next queued call once this one has replied, unless the
+    // adaptor is gone by then. The dispatch is posted, so that calls replying
+    // synchronously don't recurse through the whole queue.
+    sender = base::BindOnce(
+        [](base::WeakPtr<QueueAdaptor> self,
+           brillo::dbus_utils::ResponseSender sender,
+           std::unique_ptr<dbus::Response> response) {
+          std::move(sender).Run(std::move(response));
+          if (!self)
+            return;
+          base::SequencedTaskRunner::GetCurrentDefault()->PostTask(
+              FROM_HERE,
+              base::BindOnce(&QueueAdaptor::DispatchNextFlush, self));
+        },
+        weak_ptr_factory_.GetWeakPtr(), std::move(sender));
+    auto response = std::make_unique<brillo::dbus_utils::DBusMethodResponse<int32_t>>(
+        method_call, std::move(sender));
+    dbus::MessageReader reader(method_call);
+    std::string in_id;
+    if (!brillo::dbus_utils::DBusType<std::string>::Read(&reader, &in_id) ||
+        reader.HasMoreData()) {
+      response->ReplyWithError(FROM_HERE, brillo::errors::dbus::kDomain,
+                               DBUS_ERROR_INVALID_ARGS,
+                               "failed to read arguments");
+      return;
+    }
+    interface_->Flush(std::move(response), in_id);
+  }
+
+  bool flush_running_ = false;
+  std::queue<base::OnceClosure> flush_pending_calls_;
+
+`,
+		},
+	}
+
+	tmpl := template.Must(template.New("optionalArgHandlersTmpl").Funcs(funcMap).Parse(`{{template "optionalArgHandlersTmpl" .}}`))
+	if _, err := tmpl.Parse(optionalArgHandlersTmpl); err != nil {
+		t.Fatalf("optionalArgHandlersTmpl parse got error, want nil: %v", err)
+	}
+
+	for _, tc := range cases {
+		out := new(bytes.Buffer)
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
+			t.Fatalf("optionalArgHandlersTmpl execute got error, want nil: %v", err)
+		}
+		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
+			t.Errorf("optionalArgHandlersTmpl execute faild, interface name is %s\n(-got +want):\n%s", tc.input.Name, diff)
+		}
+	}
+}
+
 func TestSendSignalMethodsTmpl(t *testing.T) {
 	cases := []struct {
 		input introspect.Interface
@@ -606,7 +1271,11 @@ func TestSendSignalMethodsTmpl(t *testing.T) {
 
 	for _, tc := range cases {
 		out := new(bytes.Buffer)
-		if err := tmpl.Execute(out, tc.input); err != nil {
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
 			t.Fatalf("sendSignalMethodsTmpl execute got error, want nil: %v", err)
 		}
 		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
@@ -704,7 +1373,11 @@ func TestQuotedIntrospectionForInterfaceTmpl(t *testing.T) {
 
 	for _, tc := range cases {
 		out := new(bytes.Buffer)
-		if err := tmpl.Execute(out, tc.input); err != nil {
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
 			t.Fatalf("quotedIntrospectionForInterfaceTmpl execute got error, want nil: %v", err)
 		}
 		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
@@ -787,7 +1460,11 @@ func TestSignalDataMembersTmpl(t *testing.T) {
 
 	for _, tc := range cases {
 		out := new(bytes.Buffer)
-		if err := tmpl.Execute(out, tc.input); err != nil {
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
 			t.Fatalf("signalDataMembersTmpl execute got error, want nil: %v", err)
 		}
 		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
@@ -829,7 +1506,11 @@ func TestPropertyDataMembersTmpl(t *testing.T) {
 
 	for _, tc := range cases {
 		out := new(bytes.Buffer)
-		if err := tmpl.Execute(out, tc.input); err != nil {
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
 			t.Fatalf("propertyDataMembersTmpl execute got error, want nil: %v", err)
 		}
 		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
@@ -837,3 +1518,85 @@ func TestPropertyDataMembersTmpl(t *testing.T) {
 		}
 	}
 }
+
+func TestNotifyPropertiesChangedTmpl(t *testing.T) {
+	cases := []struct {
+		input introspect.Interface
+		want  string
+	}{
+		{
+			input: introspect.Interface{
+				Name:       "fi.w1.wpa_supplicant1.EmptyItf",
+				Properties: nil,
+			},
+			want: "",
+		}, {
+			input: introspect.Interface{
+				Name: "fi.w1.wpa_supplicant1.ItfA",
+				Properties: []introspect.Property{
+					{Name: "FooProperty", Access: "read", Type: "i"},
+					{Name: "BarProperty", Access: "readwrite", Type: "s"},
+				},
+			},
+			want: `
+  // Sets the values of several properties at once. Unlike calling the
+  // individual setters, this emits a single
+  // org.freedesktop.DBus.Properties.PropertiesChanged signal carrying all the
+  // properties whose value changed. |properties| maps property names to their
+  // new values.
+  void NotifyPropertiesChanged(const brillo::VariantDictionary& properties) {
+    brillo::VariantDictionary changed_properties;
+    for (const auto& [name, value] : properties) {
+      if (name == FooPropertyName()) {
+        if (value.IsTypeCompatible<int32_t>() &&
+            SetPropertyValueSilently(name, &foo_property_,
+                                     value.Get<int32_t>())) {
+          changed_properties[name] = value;
+        }
+        continue;
+      }
+      if (name == BarPropertyName()) {
+        if (value.IsTypeCompatible<std::string>() &&
+            SetPropertyValueSilently(name, &bar_property_,
+                                     value.Get<std::string>())) {
+          changed_properties[name] = value;
+        }
+        continue;
+      }
+    }
+    if (changed_properties.empty() || !dbus_object_)
+      return;
+
+    dbus::Signal signal(dbus::kPropertiesInterface, dbus::kPropertiesChanged);
+    dbus::MessageWriter writer(&signal);
+    brillo::dbus_utils::AppendValueToWriter(&writer,
+                                            std::string{"fi.w1.wpa_supplicant1.ItfA"});
+    brillo::dbus_utils::AppendValueToWriter(&writer, changed_properties);
+    // No invalidated properties.
+    brillo::dbus_utils::AppendValueToWriter(&writer,
+                                            std::vector<std::string>{});
+    dbus_object_->SendSignal(&signal);
+  }
+`,
+		},
+	}
+
+	tmpl := template.Must(template.New("notifyPropertiesChangedTmpl").Funcs(funcMap).Parse(`{{template "notifyPropertiesChangedTmpl" .}}`))
+	if _, err := tmpl.Parse(notifyPropertiesChangedTmpl); err != nil {
+		t.Fatalf("notifyPropertiesChangedTmpl parse got error, want nil: %v", err)
+	}
+
+	for _, tc := range cases {
+		out := new(bytes.Buffer)
+		itf, err := makeInterfaceView(tc.input)
+		if err != nil {
+			t.Fatalf("makeInterfaceView got error, want nil: %v", err)
+		}
+		if err := tmpl.Execute(out, itf); err != nil {
+			t.Fatalf("notifyPropertiesChangedTmpl execute got error, want nil: %v", err)
+		}
+		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
+			t.Errorf("notifyPropertiesChangedTmpl execute faild, interface name is %s\n(-got +want):\n%s", tc.input.Name, diff)
+		}
+	}
+}
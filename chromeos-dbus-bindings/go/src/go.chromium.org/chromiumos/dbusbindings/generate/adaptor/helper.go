@@ -70,29 +70,168 @@ func makeMethodParams(method introspect.Method) ([]string, error) {
 		outputArguments = nil
 	}
 
-	index := 1
+	// Output arguments are numbered after the input arguments, as in
+	// proxies.
+	numInputs := len(inputArguments)
 	for _, c := range []struct {
 		args        []introspect.MethodArg
 		makeArgType func(*introspect.MethodArg) (string, error)
 		prefix      string
+		offset      int
 	}{
-		{inputArguments, (*introspect.MethodArg).InArgType, "in"},
-		{outputArguments, (*introspect.MethodArg).OutArgType, "out"},
+		{inputArguments, (*introspect.MethodArg).InArgType, "in", 0},
+		{outputArguments, (*introspect.MethodArg).OutArgType, "out", numInputs},
 	} {
-		for _, arg := range c.args {
+		names := genutil.MethodArgNames(c.prefix, c.args, c.offset)
+		for i, arg := range c.args {
 			paramType, err := c.makeArgType(&arg)
 			if err != nil {
 				return nil, err
 			}
-			paramName := genutil.ArgName(c.prefix, arg.Name, index)
-			index++
-			methodParams = append(methodParams, fmt.Sprintf("%s %s", paramType, paramName))
+			methodParams = append(methodParams, fmt.Sprintf("%s %s", paramType, names[i]))
 		}
 	}
 
+	// The optional argument can only have a C++ default value if it is the
+	// last parameter.
+	if d := method.DefaultLastInput(); d != "" && len(inputArguments) > 0 && len(outputArguments) == 0 {
+		methodParams[len(methodParams)-1] += " = " + d
+	}
+
 	return methodParams, nil
 }
 
+// handlerArg is a local variable of a generated method handler.
+type handlerArg struct {
+	Type, Name string
+}
+
+// allowedValuesCheck is the check of an input argument against the values
+// listed by its AllowedValues annotation.
+type allowedValuesCheck struct {
+	// Name is the local variable holding the argument.
+	Name string
+	// ArgName is the name of the argument in the introspection.
+	ArgName string
+	Values  []string
+}
+
+// optionalArgHandler describes the handler generated for a method with an
+// optional last input argument or with input arguments restricted to some
+// values. The handler reads the arguments itself, so that messages omitting
+// the optional argument are accepted, and so that it can reject unexpected
+// values before calling the interface.
+type optionalArgHandler struct {
+	// ResponseType is the DBusMethodResponse type used to reply.
+	ResponseType string
+	// Required are the input arguments which must be present.
+	Required []handlerArg
+	// Optional is the last input argument, initialized to Default. Both are
+	// empty if all the input arguments are required.
+	Optional handlerArg
+	Default  string
+	// Checks are the input arguments to check against their allowed values.
+	Checks []allowedValuesCheck
+	// Redact leaves the values of the arguments out of the error replies of
+	// privacy-sensitive methods, as callers may log them.
+	Redact bool
+	// Serialized queues the calls arriving before the previous one has
+	// replied, dispatching them one at a time.
+	Serialized bool
+	// Outputs are the output arguments passed by pointer to the interface.
+	Outputs []handlerArg
+	// Call is the call to the interface method.
+	Call string
+	// Kind is the kind of the method: "simple", "normal" or "async".
+	Kind string
+	// ReturnsValue is true if the interface method returns its only output
+	// argument.
+	ReturnsValue bool
+}
+
+func makeOptionalArgHandler(method introspect.Method) (optionalArgHandler, error) {
+	ret := optionalArgHandler{
+		Default:    method.DefaultLastInput(),
+		Kind:       method.Kind().String(),
+		Redact:     method.PrivacySensitive(),
+		Serialized: method.Serialized(),
+	}
+
+	var outTypes []string
+	for _, arg := range method.OutputArguments() {
+		t, err := arg.BaseType()
+		if err != nil {
+			return optionalArgHandler{}, err
+		}
+		outTypes = append(outTypes, t)
+	}
+	ret.ResponseType = fmt.Sprintf("brillo::dbus_utils::DBusMethodResponse<%s>", strings.Join(outTypes, ", "))
+
+	var callArgs []string
+	switch method.Kind() {
+	case introspect.MethodKindSimple:
+		ret.ReturnsValue = len(outTypes) == 1
+	case introspect.MethodKindNormal:
+		callArgs = append(callArgs, "&error")
+		if method.IncludeDBusMessage() {
+			callArgs = append(callArgs, "method_call")
+		}
+	case introspect.MethodKindAsync:
+		callArgs = append(callArgs, "std::move(response)")
+		if method.IncludeDBusMessage() {
+			callArgs = append(callArgs, "method_call")
+		}
+	default:
+		return optionalArgHandler{}, fmt.Errorf("method %s of kind %s cannot have a generated handler", method.Name, ret.Kind)
+	}
+
+	// Arguments are named and numbered as in makeMethodParams.
+	inputArguments := method.InputArguments()
+	inNames := genutil.MethodArgNames("in", inputArguments, 0)
+	for i, arg := range inputArguments {
+		t, err := arg.BaseType()
+		if err != nil {
+			return optionalArgHandler{}, err
+		}
+		a := handlerArg{t, inNames[i]}
+		if ret.Default != "" && i == len(inputArguments)-1 {
+			ret.Optional = a
+		} else {
+			ret.Required = append(ret.Required, a)
+		}
+		if values := arg.AllowedValues(); values != nil {
+			ret.Checks = append(ret.Checks, allowedValuesCheck{a.Name, arg.Name, values})
+		}
+		callArgs = append(callArgs, a.Name)
+	}
+	if method.Kind() != introspect.MethodKindAsync && !ret.ReturnsValue {
+		outNames := genutil.MethodArgNames("out", method.OutputArguments(), len(inputArguments))
+		for i := range method.OutputArguments() {
+			a := handlerArg{outTypes[i], outNames[i]}
+			ret.Outputs = append(ret.Outputs, a)
+			callArgs = append(callArgs, "&"+a.Name)
+		}
+	}
+
+	ret.Call = fmt.Sprintf("interface_->%s(%s)", method.Name, strings.Join(callArgs, ", "))
+	return ret, nil
+}
+
+// hasHandler returns whether the adaptor handles the calls of method itself,
+// instead of registering the interface method directly, which is needed to
+// default an optional argument, to check allowed values or to serialize calls.
+func hasHandler(method introspect.Method) bool {
+	if method.DefaultLastInput() != "" || method.Serialized() {
+		return true
+	}
+	for _, arg := range method.InputArguments() {
+		if arg.AllowedValues() != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func makeAddHandlerName(method introspect.Method) string {
 	switch method.Kind() {
 	case introspect.MethodKindSimple:
@@ -127,30 +266,21 @@ func makePropertyWriteAccess(property introspect.Property) string {
 
 func makeSignalParams(signal introspect.Signal) ([]string, error) {
 	var params []string
-	index := 1
-	for _, arg := range signal.Args {
+	names := genutil.SignalArgNames(signal)
+	for i, arg := range signal.Args {
 		// We are the sender for signals, so pretend we're a proxy
 		// when generating the type.
 		paramType, err := arg.InArgType()
 		if err != nil {
 			return nil, err
 		}
-		paramName := genutil.ArgName("in", arg.Name, index)
-		index++
-		params = append(params, fmt.Sprintf("%s %s", paramType, paramName))
+		params = append(params, fmt.Sprintf("%s %s", paramType, names[i]))
 	}
 	return params, nil
 }
 
 func makeSignalArgNames(signal introspect.Signal) string {
-	var paramNames []string
-	index := 1
-	for _, arg := range signal.Args {
-		paramName := genutil.ArgName("in", arg.Name, index)
-		index++
-		paramNames = append(paramNames, paramName)
-	}
-	return strings.Join(paramNames, ", ")
+	return strings.Join(genutil.SignalArgNames(signal), ", ")
 }
 
 func makeDBusSignalParams(signal introspect.Signal) ([]string, error) {
@@ -203,6 +203,32 @@ func TestMakeMethodArgs(t *testing.T) {
 			want: []string{
 				"const base::ScopedFD& in_x1", "const MyProtobufClass& in_2", "base::ScopedFD* out_x3", "MyProtobufClass* out_4",
 			},
+		}, {
+			input: introspect.Method{
+				Name: "methodWithDefaultLastInput",
+				Args: []introspect.MethodArg{
+					{Name: "x", Direction: "in", Type: "s"},
+					{Name: "flags", Direction: "in", Type: "u"},
+				},
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+				},
+			},
+			want: []string{"brillo::ErrorPtr* error", "const std::string& in_x", "uint32_t in_flags = 0"},
+		}, {
+			input: introspect.Method{
+				// The optional argument is followed by an output argument, so
+				// it can't have a C++ default value.
+				Name: "methodWithDefaultLastInputAndOutput",
+				Args: []introspect.MethodArg{
+					{Name: "flags", Direction: "in", Type: "u"},
+					{Name: "y", Direction: "out", Type: "i"},
+				},
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+				},
+			},
+			want: []string{"brillo::ErrorPtr* error", "uint32_t in_flags", "int32_t* out_y"},
 		},
 	}
 	for _, tc := range cases {
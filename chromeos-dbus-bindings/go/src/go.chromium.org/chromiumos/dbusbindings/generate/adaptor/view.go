@@ -0,0 +1,167 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package adaptor
+
+import (
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+)
+
+// The templates are executed on views of the introspections, holding the C++
+// types they print already resolved, so that invalid types are reported
+// before anything is written.
+
+type introspectionView struct {
+	Name       string
+	Interfaces []interfaceView
+}
+
+// interfaceView is an interface with the types of its members resolved.
+type interfaceView struct {
+	Name       string
+	DocString  introspect.DocString
+	Methods    []methodView
+	Signals    []signalView
+	Properties []propertyView
+	// HasSerializedMethods is true if the adaptor queues the calls of some of
+	// the methods, which requires it to hand out weak pointers to itself.
+	HasSerializedMethods bool
+}
+
+// methodView is a method with the types of its handler resolved.
+type methodView struct {
+	introspect.Method
+	// RetType and Params make the signature of the interface method.
+	RetType string
+	Params  []string
+	// AddHandlerName is the DBusInterface method registering the interface
+	// method as the handler of the D-Bus method.
+	AddHandlerName string
+	// Handler is the handler generated for the method, or nil if the
+	// interface method is registered directly.
+	Handler *optionalArgHandler
+}
+
+// signalView is a signal with the types of its arguments resolved.
+type signalView struct {
+	introspect.Signal
+	// Params are the parameters of the method sending the signal, and
+	// ArgNames their names, separated by commas.
+	Params   []string
+	ArgNames string
+	// DBusParams are the arguments of the DBusSignal template.
+	DBusParams []string
+}
+
+// propertyView is a property with its type and member name resolved.
+type propertyView struct {
+	introspect.Property
+	// VarName names the member holding the property.
+	VarName string
+	// ValueType is the type the property holds, and ArgType the type its
+	// setter takes.
+	ValueType, ArgType string
+	// WriteAccess is the access mode of properties which can be written, and
+	// empty for read-only ones.
+	WriteAccess string
+}
+
+func makeIntrospectionViews(introspects []introspect.Introspection) ([]introspectionView, error) {
+	var ret []introspectionView
+	for _, ii := range introspects {
+		iv := introspectionView{Name: ii.Name}
+		for _, itf := range ii.Interfaces {
+			v, err := makeInterfaceView(itf)
+			if err != nil {
+				return nil, err
+			}
+			iv.Interfaces = append(iv.Interfaces, v)
+		}
+		ret = append(ret, iv)
+	}
+	return ret, nil
+}
+
+func makeInterfaceView(itf introspect.Interface) (interfaceView, error) {
+	ret := interfaceView{Name: itf.Name, DocString: itf.DocString}
+	for _, m := range itf.Methods {
+		v, err := makeMethodView(m)
+		if err != nil {
+			return interfaceView{}, err
+		}
+		ret.Methods = append(ret.Methods, v)
+		if m.Serialized() {
+			ret.HasSerializedMethods = true
+		}
+	}
+	for _, s := range itf.Signals {
+		params, err := makeSignalParams(s)
+		if err != nil {
+			return interfaceView{}, err
+		}
+		dbusParams, err := makeDBusSignalParams(s)
+		if err != nil {
+			return interfaceView{}, err
+		}
+		ret.Signals = append(ret.Signals, signalView{
+			Signal:     s,
+			Params:     params,
+			ArgNames:   makeSignalArgNames(s),
+			DBusParams: dbusParams,
+		})
+	}
+	for _, p := range itf.Properties {
+		valueType, err := p.BaseType()
+		if err != nil {
+			return interfaceView{}, err
+		}
+		argType, err := p.InArgType()
+		if err != nil {
+			return interfaceView{}, err
+		}
+		ret.Properties = append(ret.Properties, propertyView{
+			Property:    p,
+			VarName:     genutil.MakeVariableName(p.VariableName()),
+			ValueType:   valueType,
+			ArgType:     argType,
+			WriteAccess: makePropertyWriteAccess(p),
+		})
+	}
+	return ret, nil
+}
+
+func makeMethodView(method introspect.Method) (methodView, error) {
+	ret := methodView{Method: method, AddHandlerName: makeAddHandlerName(method)}
+	var err error
+	if ret.RetType, err = makeMethodRetType(method); err != nil {
+		return methodView{}, err
+	}
+	if ret.Params, err = makeMethodParams(method); err != nil {
+		return methodView{}, err
+	}
+	if hasHandler(method) {
+		h, err := makeOptionalArgHandler(method)
+		if err != nil {
+			return methodView{}, err
+		}
+		ret.Handler = &h
+	}
+	return ret, nil
+}
+
+// hasSerializedMethods returns whether any method of introspects is
+// serialized, which requires the adaptor to queue calls.
+func hasSerializedMethods(introspects []introspectionView) bool {
+	for _, ii := range introspects {
+		for _, itf := range ii.Interfaces {
+			for _, m := range itf.Methods {
+				if m.Serialized() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package doc outputs a Markdown reference of the interfaces of introspects,
+// for the documentation of the services exporting them.
+package doc
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+)
+
+var funcMap = template.FuncMap{
+	"formatArgs": formatArgs,
+	"formatDoc":  formatDoc,
+}
+
+const templateText = `
+{{- range .}}{{$objectPath := .ObjectPath}}{{range .Interfaces}}
+## {{.Name}}
+{{- if $objectPath}}
+
+Object path: ` + "`{{$objectPath}}`" + `
+{{- end}}
+{{- with formatDoc .DocString}}
+
+{{.}}
+{{- end}}
+{{- if .Methods}}
+
+### Methods
+{{- range .Methods}}
+
+#### {{.Name}}
+
+` + "`{{.Name}}({{formatArgs .Args \"in\"}})" + `
+{{- with formatArgs .Args "out"}} → ({{.}}){{end}}` + "`" + `
+{{- if .PrivacySensitive}}
+
+**Privacy sensitive**: the arguments may hold user data and must never be logged.
+{{- end}}
+{{- with formatDoc .DocString}}
+
+{{.}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Signals}}
+
+### Signals
+{{- range .Signals}}
+
+#### {{.Name}}
+
+` + "`{{.Name}}({{formatArgs .Args \"\"}})`" + `
+{{- with formatDoc .DocString}}
+
+{{.}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Properties}}
+
+### Properties
+
+| Name | Type | Access |
+| ---- | ---- | ------ |
+{{- range .Properties}}
+| {{.Name}} | ` + "`{{.Type}}`" + ` | {{.Access}} |
+{{- end}}
+{{- range .Properties}}
+{{- if .DocString}}
+
+**{{.Name}}**: {{formatDoc .DocString}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{end}}{{end -}}
+`
+
+// formatArgs returns the D-Bus types and names of the args in direction, or of
+// all of them if direction is empty, separated by commas.
+func formatArgs(args []introspect.ModelArg, direction string) string {
+	var ret []string
+	for _, a := range args {
+		if direction != "" && a.Direction != direction {
+			continue
+		}
+		t := a.Type
+		if a.ProtobufClass != "" {
+			t = a.ProtobufClass
+		} else if a.CppType != "" {
+			t = fmt.Sprintf("%s as %s", a.Type, a.CppType)
+		}
+		if a.Name == "" {
+			ret = append(ret, t)
+			continue
+		}
+		ret = append(ret, t+" "+a.Name)
+	}
+	return strings.Join(ret, ", ")
+}
+
+// formatDoc removes the indentation of docString, which would otherwise turn
+// into Markdown code blocks.
+func formatDoc(docString string) string {
+	lines := strings.Split(docString, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Generate prints the Markdown reference of the interfaces of introspects,
+// with a section for each of them.
+func Generate(introspects []introspect.Introspection, f io.Writer) error {
+	tmpl, err := template.New("doc").Funcs(funcMap).Parse(templateText)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(f, introspect.NewModel(introspects))
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package doc_test
+
+import (
+	"bytes"
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/doc"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerate(t *testing.T) {
+	introspects := []introspect.Introspection{{
+		Name: "/org/chromium/Frobinator",
+		Interfaces: []introspect.Interface{{
+			Name: "org.chromium.Frobinator",
+			DocString: `
+      Frobinates things.
+      Really.
+    `,
+			Methods: []introspect.Method{{
+				Name: "Frobinate",
+				Args: []introspect.MethodArg{
+					{Name: "level", Type: "i"},
+					{
+						Name: "request",
+						Type: "ay",
+						Annotation: introspect.Annotation{
+							Name:  "org.chromium.DBus.Argument.ProtobufClass",
+							Value: "FrobinateRequest",
+						},
+					},
+					{Name: "result", Type: "s", Direction: "out"},
+				},
+				Annotations: []introspect.Annotation{{
+					Name:  "org.chromium.DBus.Method.PrivacySensitive",
+					Value: "true",
+				}},
+				DocString: "Frobinates at level.",
+			}, {
+				Name: "Reset",
+			}},
+			Signals: []introspect.Signal{{
+				Name: "Frobinated",
+				Args: []introspect.SignalArg{{Name: "count", Type: "u"}},
+			}},
+			Properties: []introspect.Property{{
+				Name:      "Level",
+				Type:      "i",
+				Access:    "readwrite",
+				DocString: "Current level.",
+			}},
+		}},
+	}, {
+		Interfaces: []introspect.Interface{{
+			Name: "org.chromium.Empty",
+		}},
+	}}
+
+	out := new(bytes.Buffer)
+	if err := doc.Generate(introspects, out); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = "\n## org.chromium.Frobinator\n" +
+		"\nObject path: `/org/chromium/Frobinator`\n" +
+		"\nFrobinates things.\nReally.\n" +
+		"\n### Methods\n" +
+		"\n#### Frobinate\n" +
+		"\n`Frobinate(i level, FrobinateRequest request) → (s result)`\n" +
+		"\n**Privacy sensitive**: the arguments may hold user data and must never be logged.\n" +
+		"\nFrobinates at level.\n" +
+		"\n#### Reset\n" +
+		"\n`Reset()`\n" +
+		"\n### Signals\n" +
+		"\n#### Frobinated\n" +
+		"\n`Frobinated(u count)`\n" +
+		"\n### Properties\n" +
+		"\n| Name | Type | Access |\n" +
+		"| ---- | ---- | ------ |\n" +
+		"| Level | `i` | readwrite |\n" +
+		"\n**Level**: Current level.\n" +
+		"\n## org.chromium.Empty\n"
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
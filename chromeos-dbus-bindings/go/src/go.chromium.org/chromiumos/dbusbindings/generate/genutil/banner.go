@@ -0,0 +1,26 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil
+
+import "strings"
+
+// FormatBanner returns lines formatted as a block of C++ comments followed by
+// an empty line, to be written at the top of a generated file. It returns ""
+// if lines is empty.
+func FormatBanner(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		if line == "" {
+			b.WriteString("//\n")
+			continue
+		}
+		b.WriteString("// " + line + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,33 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil_test
+
+import (
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormatBanner(t *testing.T) {
+	cases := []struct {
+		lines []string
+		want  string
+	}{
+		{lines: nil, want: ""},
+		{lines: []string{"Copyright"}, want: "// Copyright\n\n"},
+		{
+			lines: []string{"Copyright", "", "Bug: b/123"},
+			want:  "// Copyright\n//\n// Bug: b/123\n\n",
+		},
+	}
+
+	for _, tc := range cases {
+		if diff := cmp.Diff(genutil.FormatBanner(tc.lines), tc.want); diff != "" {
+			t.Errorf("FormatBanner(%q) diff (-got +want):\n%s", tc.lines, diff)
+		}
+	}
+}
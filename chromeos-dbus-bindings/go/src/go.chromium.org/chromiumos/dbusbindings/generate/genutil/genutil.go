@@ -8,6 +8,7 @@ package genutil
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -159,6 +160,54 @@ func ArgName(prefix, argName string, argIndex int) string {
 	return fmt.Sprintf("%s_%s", prefix, argName)
 }
 
+// ArgNames makes the names of consecutive method or signal arguments sharing
+// prefix, given their names in the introspection. The unnamed argument at i
+// is numbered offset+i+1 as by ArgName, so that the input and output arguments
+// of a method are numbered in a row, and gets '_' appended as long as its name
+// collides with another argument, e.g. one named "2". Every generated output
+// uses these names, so that they match across the proxy, mock and adaptor.
+func ArgNames(prefix string, argNames []string, offset int) []string {
+	taken := make(map[string]bool)
+	for _, n := range argNames {
+		if n != "" {
+			taken[ArgName(prefix, n, 0)] = true
+		}
+	}
+
+	ret := make([]string, len(argNames))
+	for i, n := range argNames {
+		ret[i] = ArgName(prefix, n, offset+i+1)
+		if n != "" {
+			continue
+		}
+		for taken[ret[i]] {
+			ret[i] += "_"
+		}
+		taken[ret[i]] = true
+	}
+	return ret
+}
+
+// MethodArgNames returns the names of args sharing prefix, numbered from
+// offset, as made by ArgNames.
+func MethodArgNames(prefix string, args []introspect.MethodArg, offset int) []string {
+	names := make([]string, len(args))
+	for i, a := range args {
+		names[i] = a.Name
+	}
+	return ArgNames(prefix, names, offset)
+}
+
+// SignalArgNames returns the names of the arguments of signal, as made by
+// ArgNames.
+func SignalArgNames(signal introspect.Signal) []string {
+	names := make([]string, len(signal.Args))
+	for i, a := range signal.Args {
+		names[i] = a.Name
+	}
+	return ArgNames("in", names, 0)
+}
+
 var insertRE = regexp.MustCompile(`([^A-Z])([A-Z])`)
 
 // MakeVariableName discards the namespace parts and converts CamelCase name to google_style variable name.
@@ -171,3 +220,22 @@ func MakeVariableName(s string) string {
 	}
 	return strings.ToLower(insertRE.ReplaceAllStringFunc(camelCase, f))
 }
+
+// CustomTypeIncludes returns the sorted, deduplicated headers required by all
+// interfaces in introspects.
+func CustomTypeIncludes(introspects []introspect.Introspection) []string {
+	seen := make(map[string]bool)
+	var ret []string
+	for _, i := range introspects {
+		for _, itf := range i.Interfaces {
+			for _, inc := range itf.Includes() {
+				if !seen[inc] {
+					seen[inc] = true
+					ret = append(ret, inc)
+				}
+			}
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -173,6 +173,28 @@ func TestArgName(t *testing.T) {
 	}
 }
 
+func TestArgNames(t *testing.T) {
+	cases := []struct {
+		prefix   string
+		argNames []string
+		offset   int
+		want     []string
+	}{
+		{prefix: "in", argNames: nil, offset: 0, want: []string{}},
+		{prefix: "in", argNames: []string{"", "name", ""}, offset: 0, want: []string{"in_1", "in_name", "in_3"}},
+		{prefix: "out", argNames: []string{"", "ret"}, offset: 2, want: []string{"out_3", "out_ret"}},
+		{prefix: "in", argNames: []string{"", "", "2"}, offset: 0, want: []string{"in_1", "in_2_", "in_2"}},
+		{prefix: "in", argNames: []string{"2_", "", "2"}, offset: 0, want: []string{"in_2_", "in_2__", "in_2"}},
+	}
+
+	for _, tc := range cases {
+		got := genutil.ArgNames(tc.prefix, tc.argNames, tc.offset)
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("ArgNames(%q, %q, %d) diff (-got +want):\n%s", tc.prefix, tc.argNames, tc.offset, diff)
+		}
+	}
+}
+
 func TestMakeVariableName(t *testing.T) {
 	cases := []struct {
 		input, want string
@@ -191,3 +213,26 @@ func TestMakeVariableName(t *testing.T) {
 		}
 	}
 }
+
+func TestCustomTypeIncludes(t *testing.T) {
+	include := func(v string) introspect.Annotation {
+		return introspect.Annotation{Name: "org.chromium.DBus.Interface.Include", Value: v}
+	}
+	introspects := []introspect.Introspection{
+		{
+			Interfaces: []introspect.Interface{
+				{Name: "itf1", Annotations: []introspect.Annotation{include("foo/b.h"), include("foo/a.h")}},
+				{Name: "itf2"},
+			},
+		}, {
+			Interfaces: []introspect.Interface{
+				{Name: "itf3", Annotations: []introspect.Annotation{include("foo/a.h")}},
+			},
+		},
+	}
+	got := genutil.CustomTypeIncludes(introspects)
+	want := []string{"foo/a.h", "foo/b.h"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("CustomTypeIncludes diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+)
+
+// DropFailingInterfaces returns introspects without the interfaces which
+// generate fails to output on their own, along with an error for each of them.
+// This lets the other interfaces be generated when a few are broken, e.g. by a
+// bad type signature.
+func DropFailingInterfaces(introspects []introspect.Introspection, generate func([]introspect.Introspection, io.Writer) error) ([]introspect.Introspection, []error) {
+	var ret []introspect.Introspection
+	var errs []error
+	for _, ii := range introspects {
+		kept := ii
+		kept.Interfaces = nil
+		for _, itf := range ii.Interfaces {
+			alone := ii
+			alone.Interfaces = []introspect.Interface{itf}
+			if err := generate([]introspect.Introspection{alone}, ioutil.Discard); err != nil {
+				errs = append(errs, fmt.Errorf("%s interface: %v", itf.Name, err))
+				continue
+			}
+			kept.Interfaces = append(kept.Interfaces, itf)
+		}
+		ret = append(ret, kept)
+	}
+	return ret, errs
+}
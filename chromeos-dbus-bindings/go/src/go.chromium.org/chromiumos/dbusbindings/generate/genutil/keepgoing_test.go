@@ -0,0 +1,61 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDropFailingInterfaces(t *testing.T) {
+	introspects := []introspect.Introspection{{
+		Name: "/org/chromium/Test",
+		Interfaces: []introspect.Interface{
+			{Name: "org.chromium.Good"},
+			{Name: "org.chromium.Bad"},
+		},
+	}, {
+		Name: "/org/chromium/Other",
+		Interfaces: []introspect.Interface{
+			{Name: "org.chromium.AlsoGood"},
+		},
+	}}
+	generate := func(introspects []introspect.Introspection, f io.Writer) error {
+		for _, ii := range introspects {
+			for _, itf := range ii.Interfaces {
+				if itf.Name == "org.chromium.Bad" {
+					return errors.New("unsupported type")
+				}
+			}
+		}
+		return nil
+	}
+
+	got, errs := genutil.DropFailingInterfaces(introspects, generate)
+
+	want := []introspect.Introspection{{
+		Name: "/org/chromium/Test",
+		Interfaces: []introspect.Interface{
+			{Name: "org.chromium.Good"},
+		},
+	}, {
+		Name: "/org/chromium/Other",
+		Interfaces: []introspect.Interface{
+			{Name: "org.chromium.AlsoGood"},
+		},
+	}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("DropFailingInterfaces diff (-got +want):\n%s", diff)
+	}
+	if len(errs) != 1 || errs[0].Error() != "org.chromium.Bad interface: unsupported type" {
+		t.Errorf("Unexpected errors: got %v, want [org.chromium.Bad interface: unsupported type]", errs)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// FileBoundaryTemplates defines the templates opening and closing generated
+// files, which are either headers or, if the ModuleName template argument is
+// set, experimental C++20 module interface units. "fileStartTmpl" must be
+// immediately followed by the includes, which become the global module
+// fragment of a module. "fileBodyStartTmpl" must immediately follow them, and
+// starts exporting all the declarations up to "fileEndTmpl".
+const FileBoundaryTemplates = `{{define "fileStartTmpl" -}}
+{{if .ModuleName -}}
+module;
+{{else -}}
+#ifndef {{.HeaderGuard}}
+#define {{.HeaderGuard}}
+{{end -}}
+{{end}}{{define "fileBodyStartTmpl" -}}
+{{if .ModuleName}}
+
+export module {{.ModuleName}};
+
+export {
+{{- end}}
+{{- end}}{{define "fileEndTmpl" -}}
+{{if .ModuleName -}}
+}  // export
+{{else -}}
+#endif  // {{.HeaderGuard}}
+{{end -}}
+{{end}}`
+
+// MakeModuleName returns the name of the C++20 module of the given kind, e.g.
+// "proxy", generated for the D-Bus service serviceName. Characters of the
+// service name which can't appear in a module name are replaced by '_'.
+func MakeModuleName(serviceName, kind string) (string, error) {
+	if serviceName == "" {
+		return "", errors.New("module names are derived from the service name, which must be set in the service config")
+	}
+
+	var parts []string
+	for _, part := range strings.Split(serviceName, ".") {
+		part = strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return unicode.ToLower(r)
+			}
+			return '_'
+		}, part)
+		if part == "" || unicode.IsDigit(rune(part[0])) {
+			part = "_" + part
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(append(parts, kind), "."), nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil_test
+
+import (
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMakeModuleName(t *testing.T) {
+	cases := []struct {
+		serviceName, kind, want string
+	}{
+		{serviceName: "org.chromium.Foo", kind: "proxy", want: "org.chromium.foo.proxy"},
+		{serviceName: "org.chromium.Foo-Bar", kind: "adaptor", want: "org.chromium.foo_bar.adaptor"},
+		{serviceName: "org.chromium.2nd", kind: "proxy", want: "org.chromium._2nd.proxy"},
+		{serviceName: "org..chromium", kind: "proxy", want: "org._.chromium.proxy"},
+	}
+
+	for _, tc := range cases {
+		got, err := genutil.MakeModuleName(tc.serviceName, tc.kind)
+		if err != nil {
+			t.Errorf("MakeModuleName(%q, %q) got error, want nil: %v", tc.serviceName, tc.kind, err)
+			continue
+		}
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("MakeModuleName(%q, %q) diff (-got +want):\n%s", tc.serviceName, tc.kind, diff)
+		}
+	}
+
+	if _, err := genutil.MakeModuleName("", "proxy"); err == nil {
+		t.Error("MakeModuleName with an empty service name got nil error, want error")
+	}
+}
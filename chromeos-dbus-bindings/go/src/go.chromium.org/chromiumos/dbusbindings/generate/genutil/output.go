@@ -0,0 +1,53 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Output is a file the generator was asked to write.
+type Output struct {
+	// Name of the output, e.g. the flag which requested it.
+	Name string
+	// Path of the file. Outputs with an empty Path are not generated.
+	Path string
+	// Whether the generated file is guarded by GenerateHeaderGuard(Path).
+	HasHeaderGuard bool
+}
+
+// CheckOutputCollisions returns an error if two of outputs would overwrite
+// each other, either because their paths are the same or only differ in case,
+// or because their header guards are the same.
+func CheckOutputCollisions(outputs []Output) error {
+	paths := make(map[string]Output)
+	guards := make(map[string]Output)
+	for _, o := range outputs {
+		if o.Path == "" {
+			continue
+		}
+
+		p := filepath.Clean(o.Path)
+		if prev, ok := paths[strings.ToLower(p)]; ok {
+			if filepath.Clean(prev.Path) == p {
+				return fmt.Errorf("%s and %s outputs are both written to %s", prev.Name, o.Name, o.Path)
+			}
+			return fmt.Errorf("%s output %s and %s output %s only differ in case", prev.Name, prev.Path, o.Name, o.Path)
+		}
+		paths[strings.ToLower(p)] = o
+
+		if !o.HasHeaderGuard {
+			continue
+		}
+		g := GenerateHeaderGuard(o.Path)
+		if prev, ok := guards[g]; ok {
+			return fmt.Errorf("%s output %s and %s output %s have the same header guard %s", prev.Name, prev.Path, o.Name, o.Path, g)
+		}
+		guards[g] = o
+	}
+	return nil
+}
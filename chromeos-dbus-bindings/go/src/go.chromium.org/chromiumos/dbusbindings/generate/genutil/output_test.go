@@ -0,0 +1,67 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil_test
+
+import (
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+)
+
+func TestCheckOutputCollisions(t *testing.T) {
+	cases := []struct {
+		name    string
+		outputs []genutil.Output
+		wantErr bool
+	}{
+		{
+			name: "distinct",
+			outputs: []genutil.Output{
+				{Name: "adaptor", Path: "out/adaptor.h", HasHeaderGuard: true},
+				{Name: "proxy", Path: "out/proxy.h", HasHeaderGuard: true},
+				{Name: "method-names", Path: "out/method_names.h"},
+			},
+		}, {
+			name: "unrequested",
+			outputs: []genutil.Output{
+				{Name: "adaptor", Path: "", HasHeaderGuard: true},
+				{Name: "proxy", Path: "", HasHeaderGuard: true},
+			},
+		}, {
+			name: "same path",
+			outputs: []genutil.Output{
+				{Name: "adaptor", Path: "out/bindings.h", HasHeaderGuard: true},
+				{Name: "proxy", Path: "out/./bindings.h", HasHeaderGuard: true},
+			},
+			wantErr: true,
+		}, {
+			name: "case collision",
+			outputs: []genutil.Output{
+				{Name: "proxy", Path: "out/Proxy.h", HasHeaderGuard: true},
+				{Name: "mock", Path: "out/proxy.h", HasHeaderGuard: true},
+			},
+			wantErr: true,
+		}, {
+			name: "header guard collision",
+			outputs: []genutil.Output{
+				{Name: "proxy", Path: "out/foo-proxy.h", HasHeaderGuard: true},
+				{Name: "mock", Path: "out/foo_proxy.h", HasHeaderGuard: true},
+			},
+			wantErr: true,
+		}, {
+			name: "header guard collision without guard",
+			outputs: []genutil.Output{
+				{Name: "proxy", Path: "out/foo-proxy.h", HasHeaderGuard: true},
+				{Name: "method-names", Path: "out/foo_proxy.h"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		err := genutil.CheckOutputCollisions(tc.outputs)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("CheckOutputCollisions with %s: got error %v, want error %t", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil
+
+import (
+	"fmt"
+	"strings"
+
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+)
+
+// SelectInterfaces returns introspects with only the interfaces named in names,
+// e.g. to generate the client-relevant interfaces of a large service. It fails
+// if any of names is not an interface of introspects.
+func SelectInterfaces(introspects []introspect.Introspection, names []string) ([]introspect.Introspection, error) {
+	selected := make(map[string]bool)
+	for _, name := range names {
+		selected[name] = false
+	}
+
+	var ret []introspect.Introspection
+	for _, ii := range introspects {
+		kept := ii
+		kept.Interfaces = nil
+		for _, itf := range ii.Interfaces {
+			if _, ok := selected[itf.Name]; !ok {
+				continue
+			}
+			selected[itf.Name] = true
+			kept.Interfaces = append(kept.Interfaces, itf)
+		}
+		ret = append(ret, kept)
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !selected[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no such interface(s): %s", strings.Join(missing, ", "))
+	}
+	return ret, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil_test
+
+import (
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var selectIntrospects = []introspect.Introspection{{
+	Name: "/org/chromium/Test",
+	Interfaces: []introspect.Interface{
+		{Name: "org.chromium.Client"},
+		{Name: "org.chromium.Internal"},
+	},
+}, {
+	Name: "/org/chromium/Other",
+	Interfaces: []introspect.Interface{
+		{Name: "org.chromium.OtherClient"},
+	},
+}}
+
+func TestSelectInterfaces(t *testing.T) {
+	got, err := genutil.SelectInterfaces(selectIntrospects, []string{"org.chromium.OtherClient", "org.chromium.Client"})
+	if err != nil {
+		t.Fatalf("SelectInterfaces failed: %v", err)
+	}
+
+	want := []introspect.Introspection{{
+		Name: "/org/chromium/Test",
+		Interfaces: []introspect.Interface{
+			{Name: "org.chromium.Client"},
+		},
+	}, {
+		Name: "/org/chromium/Other",
+		Interfaces: []introspect.Interface{
+			{Name: "org.chromium.OtherClient"},
+		},
+	}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("SelectInterfaces diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestSelectInterfacesMissing(t *testing.T) {
+	_, err := genutil.SelectInterfaces(selectIntrospects, []string{"org.chromium.Client", "org.chromium.Typo", "org.chromium.Gone"})
+	if err == nil || err.Error() != "no such interface(s): org.chromium.Typo, org.chromium.Gone" {
+		t.Errorf("Unexpected error: got %v, want no such interface(s): org.chromium.Typo, org.chromium.Gone", err)
+	}
+}
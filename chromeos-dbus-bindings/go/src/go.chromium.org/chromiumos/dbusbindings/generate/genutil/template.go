@@ -0,0 +1,246 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// templateOverrideExt is the extension of files in a template override directory.
+const templateOverrideExt = ".tmpl"
+
+// TemplateOverrides maps names of templates to the text of files replacing them.
+// Each text must {{define}} the template it overrides.
+type TemplateOverrides map[string]string
+
+// LoadTemplateOverrides reads all the files named <template name>.tmpl in dir.
+func LoadTemplateOverrides(dir string) (TemplateOverrides, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+templateOverrideExt))
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(TemplateOverrides)
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		overrides[strings.TrimSuffix(filepath.Base(path), templateOverrideExt)] = string(b)
+	}
+	return overrides, nil
+}
+
+// DotTypes maps names of templates to the type of the data they are executed
+// on.
+type DotTypes map[string]reflect.Type
+
+// Apply replaces the templates associated with tmpl by their overrides.
+// Overrides for templates which tmpl doesn't define are ignored, as they are
+// meant for another generator. Overrides of the templates in dots are checked
+// against the type of their data, so that an override referring to a field or
+// method the data doesn't have is rejected here, rather than failing halfway
+// through the output.
+func (overrides TemplateOverrides) Apply(tmpl *template.Template, dots DotTypes) error {
+	var names []string
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		orig := tmpl.Lookup(name)
+		if orig == nil {
+			continue
+		}
+		if name == tmpl.Name() {
+			return fmt.Errorf("top-level template %q cannot be overridden", name)
+		}
+
+		trees := make(map[string]*parse.Tree)
+		for _, t := range tmpl.Templates() {
+			trees[t.Name()] = t.Tree
+		}
+
+		fileName := name + templateOverrideExt
+		if _, err := tmpl.New(fileName).Parse(overrides[name]); err != nil {
+			return fmt.Errorf("failed to parse override of template %q: %v", name, err)
+		}
+		for _, t := range tmpl.Templates() {
+			switch n := t.Name(); {
+			case n == name:
+				if t.Tree == orig.Tree {
+					return fmt.Errorf("%s does not define template %q", fileName, name)
+				}
+			case n == fileName:
+				// The text outside of any {{define}}, which is unused.
+			case t.Tree != trees[n]:
+				return fmt.Errorf("%s defines unexpected template %q", fileName, n)
+			}
+		}
+		if dot := dots[name]; dot != nil {
+			c := fieldChecker{root: dot}
+			if err := c.checkNode(tmpl.Lookup(name).Root, dot); err != nil {
+				return fmt.Errorf("%s: %v", fileName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// fieldChecker checks the fields and methods referred to by a template
+// against the type of its data. Types it can't follow, e.g. the results of
+// functions, are nil, and anything referred to through them is accepted.
+type fieldChecker struct {
+	// root is the type of $.
+	root reflect.Type
+}
+
+// checkNode checks node, in which dot has type dot.
+func (c fieldChecker) checkNode(node parse.Node, dot reflect.Type) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := c.checkNode(child, dot); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		return c.checkPipe(n.Pipe, dot)
+	case *parse.TemplateNode:
+		return c.checkPipe(n.Pipe, dot)
+	case *parse.IfNode:
+		return c.checkBranch(&n.BranchNode, dot, dot)
+	case *parse.WithNode:
+		inner, err := c.pipeType(n.Pipe, dot)
+		if err != nil {
+			return err
+		}
+		return c.checkBranch(&n.BranchNode, dot, indirect(inner))
+	case *parse.RangeNode:
+		inner, err := c.pipeType(n.Pipe, dot)
+		if err != nil {
+			return err
+		}
+		return c.checkBranch(&n.BranchNode, dot, elemType(inner))
+	}
+	return nil
+}
+
+// checkBranch checks the pipeline and the lists of an if, with or range, in
+// which dot has type inner.
+func (c fieldChecker) checkBranch(n *parse.BranchNode, dot, inner reflect.Type) error {
+	if err := c.checkPipe(n.Pipe, dot); err != nil {
+		return err
+	}
+	if err := c.checkNode(n.List, inner); err != nil {
+		return err
+	}
+	return c.checkNode(n.ElseList, dot)
+}
+
+// checkPipe checks the arguments of the commands of pipe.
+func (c fieldChecker) checkPipe(pipe *parse.PipeNode, dot reflect.Type) error {
+	if pipe == nil {
+		return nil
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			if _, err := c.argType(arg, dot); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pipeType checks pipe, and returns the type of its value if it only refers
+// to a field or method, or nil.
+func (c fieldChecker) pipeType(pipe *parse.PipeNode, dot reflect.Type) (reflect.Type, error) {
+	if err := c.checkPipe(pipe, dot); err != nil {
+		return nil, err
+	}
+	if len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil, nil
+	}
+	return c.argType(pipe.Cmds[0].Args[0], dot)
+}
+
+// argType checks arg, and returns its type if it is dot, or refers to a field
+// or method, or nil.
+func (c fieldChecker) argType(arg parse.Node, dot reflect.Type) (reflect.Type, error) {
+	switch a := arg.(type) {
+	case *parse.DotNode:
+		return dot, nil
+	case *parse.FieldNode:
+		return resolveFields(dot, a.Ident)
+	case *parse.VariableNode:
+		if a.Ident[0] == "$" {
+			return resolveFields(c.root, a.Ident[1:])
+		}
+	case *parse.PipeNode:
+		return nil, c.checkPipe(a, dot)
+	}
+	return nil, nil
+}
+
+// resolveFields returns the type of the chain of fields and methods names
+// starting from t, or an error if one of them doesn't exist.
+func resolveFields(t reflect.Type, names []string) (reflect.Type, error) {
+	for _, name := range names {
+		if t == nil || t.Kind() == reflect.Interface {
+			return nil, nil
+		}
+		if m, ok := reflect.PtrTo(indirect(t)).MethodByName(name); ok {
+			if m.Type.NumOut() == 0 {
+				return nil, fmt.Errorf("method %s of %s returns no value", name, indirect(t))
+			}
+			t = m.Type.Out(0)
+			continue
+		}
+		switch s := indirect(t); s.Kind() {
+		case reflect.Struct:
+			f, ok := s.FieldByName(name)
+			if !ok || !f.IsExported() {
+				return nil, fmt.Errorf("%s has no field or method %s", s, name)
+			}
+			t = f.Type
+		case reflect.Map:
+			t = s.Elem()
+		default:
+			return nil, fmt.Errorf("can't refer to field %s of %s", name, s)
+		}
+	}
+	return t, nil
+}
+
+// indirect returns the type t points to, if it is a pointer.
+func indirect(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+// elemType returns the type of the elements ranged over in t, or nil.
+func elemType(t reflect.Type) reflect.Type {
+	switch t := indirect(t); {
+	case t == nil:
+		return nil
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Array, t.Kind() == reflect.Map, t.Kind() == reflect.Chan:
+		return t.Elem()
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package genutil_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestTemplate(t *testing.T) *template.Template {
+	t.Helper()
+	tmpl, err := template.New("main").Parse(`[{{template "item" .}}|{{template "other" .}}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.Parse(`{{define "item"}}item {{.}}{{end}}{{define "other"}}other{{end}}`); err != nil {
+		t.Fatal(err)
+	}
+	return tmpl
+}
+
+func TestLoadTemplateOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template-overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"item.tmpl":  `{{define "item"}}new{{end}}`,
+		"README.txt": "not a template",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := genutil.LoadTemplateOverrides(dir)
+	if err != nil {
+		t.Fatalf("LoadTemplateOverrides failed: %v", err)
+	}
+	want := genutil.TemplateOverrides{"item": `{{define "item"}}new{{end}}`}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("LoadTemplateOverrides diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestTemplateOverridesApply(t *testing.T) {
+	tmpl := newTestTemplate(t)
+	overrides := genutil.TemplateOverrides{
+		"item":    `{{define "item"}}overridden {{.}}{{end}}`,
+		"unknown": `{{define "unknown"}}ignored{{end}}`,
+	}
+	if err := overrides.Apply(tmpl, nil); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, "x"); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if diff := cmp.Diff(out.String(), "[overridden x|other]"); diff != "" {
+		t.Errorf("Apply diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestTemplateOverridesApplyErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		overrides genutil.TemplateOverrides
+	}{
+		{
+			name:      "missing define",
+			overrides: genutil.TemplateOverrides{"item": `{{define "items"}}typo{{end}}`},
+		}, {
+			name:      "another template",
+			overrides: genutil.TemplateOverrides{"item": `{{define "item"}}a{{end}}{{define "other"}}b{{end}}`},
+		}, {
+			name:      "top-level template",
+			overrides: genutil.TemplateOverrides{"main": `{{define "main"}}a{{end}}`},
+		}, {
+			name:      "syntax error",
+			overrides: genutil.TemplateOverrides{"item": `{{define "item"}}{{.Foo{{end}}`},
+		},
+	}
+	for _, tc := range cases {
+		if err := tc.overrides.Apply(newTestTemplate(t), nil); err == nil {
+			t.Errorf("Apply with %s unexpectedly succeeded", tc.name)
+		}
+	}
+}
+
+// testDot is the data of the "item" template in the tests of the fields
+// checked by Apply.
+type testDot struct {
+	Name  string
+	Items []testItem
+	Ptr   *testItem
+	Attrs map[string]testItem
+}
+
+type testItem struct {
+	Value string
+}
+
+func (testItem) Upper() string { return "" }
+
+func TestTemplateOverridesApplyFields(t *testing.T) {
+	dots := genutil.DotTypes{"item": reflect.TypeOf(testDot{})}
+	valid := []string{
+		`{{.Name}}`,
+		`{{range .Items}}{{.Value}}{{.Upper}}{{else}}{{.Name}}{{end}}`,
+		`{{range $i, $item := .Items}}{{.Value}}{{$item.Anything}}{{$.Name}}{{end}}`,
+		`{{with .Ptr}}{{.Value}}{{end}}`,
+		`{{.Attrs.key.Value}}`,
+		`{{if .Name}}{{printf "%s" .Name | len}}{{end}}`,
+		`{{with printf "%s" .Name}}{{.Unknown}}{{end}}`,
+	}
+	for _, text := range valid {
+		overrides := genutil.TemplateOverrides{"item": `{{define "item"}}` + text + `{{end}}`}
+		if err := overrides.Apply(newTestTemplate(t), dots); err != nil {
+			t.Errorf("Apply with %s failed: %v", text, err)
+		}
+	}
+
+	invalid := []string{
+		`{{.Missing}}`,
+		`{{.Name.Value}}`,
+		`{{range .Items}}{{.Name}}{{end}}`,
+		`{{range .Items}}{{else}}{{.Value}}{{end}}`,
+		`{{with .Ptr}}{{.Name}}{{end}}`,
+		`{{range .Items}}{{$.Value}}{{end}}`,
+		`{{if .Name}}{{printf "%s" .Missing}}{{end}}`,
+		`{{template "other" .Missing}}`,
+	}
+	for _, text := range invalid {
+		overrides := genutil.TemplateOverrides{"item": `{{define "item"}}` + text + `{{end}}`}
+		if err := overrides.Apply(newTestTemplate(t), dots); err == nil {
+			t.Errorf("Apply with %s unexpectedly succeeded", text)
+		}
+	}
+}
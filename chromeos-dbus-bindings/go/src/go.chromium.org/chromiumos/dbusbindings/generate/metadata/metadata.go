@@ -0,0 +1,141 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package metadata records which generator version and inputs a generated file
+// was generated from, so that prebuilt headers can be audited.
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// GeneratorVersion identifies the generator in embedded metadata. It should be
+// bumped whenever a change to the generator modifies its output.
+const GeneratorVersion = "1"
+
+// linePrefix starts every line of the metadata trailer.
+const linePrefix = "// dbus-bindings-metadata: "
+
+// Input is an introspection XML file read by the generator.
+type Input struct {
+	Path    string
+	Content []byte
+}
+
+// InputHash identifies the content of an introspection XML file.
+type InputHash struct {
+	Name   string
+	SHA256 string
+}
+
+// Metadata describes how a file was generated.
+type Metadata struct {
+	GeneratorVersion string
+	Inputs           []InputHash
+	// ServiceConfigSHA256 is empty if no service config was used.
+	ServiceConfigSHA256 string
+}
+
+func hash(b []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(b))
+}
+
+// New returns the metadata of a file generated by this generator from inputs
+// and serviceConfig, which is nil if no service config was used. Inputs are
+// identified by base name, so that metadata doesn't depend on the build
+// directory.
+func New(inputs []Input, serviceConfig []byte) Metadata {
+	m := Metadata{GeneratorVersion: GeneratorVersion}
+	for _, input := range inputs {
+		m.Inputs = append(m.Inputs, InputHash{
+			Name:   filepath.Base(input.Path),
+			SHA256: hash(input.Content),
+		})
+	}
+	if serviceConfig != nil {
+		m.ServiceConfigSHA256 = hash(serviceConfig)
+	}
+	return m
+}
+
+// Trailer returns m formatted as C++ comments to be appended to a generated file.
+func (m Metadata) Trailer() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%sgenerator-version %s\n", linePrefix, m.GeneratorVersion)
+	for _, input := range m.Inputs {
+		fmt.Fprintf(&b, "%sinput %s sha256:%s\n", linePrefix, input.Name, input.SHA256)
+	}
+	if m.ServiceConfigSHA256 != "" {
+		fmt.Fprintf(&b, "%sservice-config sha256:%s\n", linePrefix, m.ServiceConfigSHA256)
+	}
+	return b.String()
+}
+
+// Parse extracts the metadata from the trailer of a generated file.
+func Parse(generated []byte) (Metadata, error) {
+	var m Metadata
+	found := false
+	s := bufio.NewScanner(bytes.NewReader(generated))
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, linePrefix) {
+			continue
+		}
+		found = true
+
+		fields := strings.Fields(strings.TrimPrefix(line, linePrefix))
+		switch {
+		case len(fields) == 2 && fields[0] == "generator-version":
+			m.GeneratorVersion = fields[1]
+		case len(fields) == 3 && fields[0] == "input" && strings.HasPrefix(fields[2], "sha256:"):
+			m.Inputs = append(m.Inputs, InputHash{
+				Name:   fields[1],
+				SHA256: strings.TrimPrefix(fields[2], "sha256:"),
+			})
+		case len(fields) == 2 && fields[0] == "service-config" && strings.HasPrefix(fields[1], "sha256:"):
+			m.ServiceConfigSHA256 = strings.TrimPrefix(fields[1], "sha256:")
+		default:
+			return Metadata{}, fmt.Errorf("malformed metadata line %q", line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return Metadata{}, err
+	}
+	if !found {
+		return Metadata{}, errors.New("no generation metadata found")
+	}
+	return m, nil
+}
+
+// Verify checks that generated was generated by this generator version from
+// inputs and serviceConfig, which is nil if no service config is expected.
+func Verify(generated []byte, inputs []Input, serviceConfig []byte) error {
+	got, err := Parse(generated)
+	if err != nil {
+		return err
+	}
+	want := New(inputs, serviceConfig)
+
+	if got.GeneratorVersion != want.GeneratorVersion {
+		return fmt.Errorf("generated by generator version %s, want %s", got.GeneratorVersion, want.GeneratorVersion)
+	}
+	if len(got.Inputs) != len(want.Inputs) {
+		return fmt.Errorf("generated from %d inputs, want %d", len(got.Inputs), len(want.Inputs))
+	}
+	for i := range got.Inputs {
+		if got.Inputs[i] != want.Inputs[i] {
+			return fmt.Errorf("input %d is %s with sha256 %s, want %s with sha256 %s", i, got.Inputs[i].Name, got.Inputs[i].SHA256, want.Inputs[i].Name, want.Inputs[i].SHA256)
+		}
+	}
+	if got.ServiceConfigSHA256 != want.ServiceConfigSHA256 {
+		return fmt.Errorf("service config sha256 is %q, want %q", got.ServiceConfigSHA256, want.ServiceConfigSHA256)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package metadata_test
+
+import (
+	"fmt"
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/metadata"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var (
+	testInputs = []metadata.Input{
+		{Path: "/build/dbus_bindings/org.chromium.Foo.xml", Content: []byte("<node/>")},
+		{Path: "org.chromium.Bar.xml", Content: []byte("<node></node>")},
+	}
+	testServiceConfig = []byte(`{"service_name": "org.chromium.Foo"}`)
+)
+
+func TestTrailer(t *testing.T) {
+	got := metadata.New(testInputs, testServiceConfig).Trailer()
+	want := fmt.Sprintf(`
+// dbus-bindings-metadata: generator-version %s
+// dbus-bindings-metadata: input org.chromium.Foo.xml sha256:fc2cdda67a4e845899692e57d0e54e12d10dbd2e3f7ef65f50c340426c4ff0d8
+// dbus-bindings-metadata: input org.chromium.Bar.xml sha256:126174824563b9767c2d707d91c7887b3ca764b4a817971b0472efe8a216c0cb
+// dbus-bindings-metadata: service-config sha256:36c3b197e989be0f4aa5ac6a6c0c8234e8336c32401a8664279ab286c9af6f34
+`, metadata.GeneratorVersion)
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Trailer failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	want := metadata.New(testInputs, nil)
+	generated := "#endif  // GUARD\n" + want.Trailer()
+
+	got, err := metadata.Parse([]byte(generated))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Parse failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name      string
+		generated string
+	}{
+		{
+			name:      "no metadata",
+			generated: "#endif  // GUARD\n",
+		}, {
+			name:      "malformed line",
+			generated: "// dbus-bindings-metadata: input foo.xml\n",
+		},
+	}
+	for _, tc := range cases {
+		if _, err := metadata.Parse([]byte(tc.generated)); err == nil {
+			t.Errorf("Parse with %s unexpectedly succeeded", tc.name)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	generated := []byte("#endif  // GUARD\n" + metadata.New(testInputs, testServiceConfig).Trailer())
+
+	if err := metadata.Verify(generated, testInputs, testServiceConfig); err != nil {
+		t.Errorf("Verify got error, want nil: %v", err)
+	}
+
+	modified := []metadata.Input{testInputs[0], {Path: testInputs[1].Path, Content: []byte("<node/>")}}
+	cases := []struct {
+		name          string
+		inputs        []metadata.Input
+		serviceConfig []byte
+	}{
+		{
+			name:          "modified input",
+			inputs:        modified,
+			serviceConfig: testServiceConfig,
+		}, {
+			name:          "missing input",
+			inputs:        testInputs[:1],
+			serviceConfig: testServiceConfig,
+		}, {
+			name:   "missing service config",
+			inputs: testInputs,
+		},
+	}
+	for _, tc := range cases {
+		if err := metadata.Verify(generated, tc.inputs, tc.serviceConfig); err == nil {
+			t.Errorf("Verify with %s unexpectedly succeeded", tc.name)
+		}
+	}
+}
@@ -12,15 +12,30 @@ import (
 
 	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
 	"go.chromium.org/chromiumos/dbusbindings/introspect"
+	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
 )
 
+type templateArgs struct {
+	Introspects []introspect.Introspection
+	// Namespace wraps the namespaces named after the interfaces, if set.
+	Namespace    string
+	IncludeGuard string
+	HeaderGuard  string
+}
+
 var funcMap = template.FuncMap{
 	"reverse": genutil.Reverse,
 	"split":   strings.Split,
 }
 
 const templateText = `
-{{- range .}}{{range $itf := .Interfaces}}
+{{- if eq .IncludeGuard "pragma_once"}}#pragma once
+{{else if eq .IncludeGuard "ifndef"}}#ifndef {{.HeaderGuard}}
+#define {{.HeaderGuard}}
+{{end -}}
+{{range .Introspects}}{{range $itf := .Interfaces}}
+{{with $.Namespace}}namespace {{.}} {
+{{end -}}
 {{range split $itf.Name "." -}}
 namespace {{.}} {
 {{end -}}
@@ -30,14 +45,48 @@ const char k{{.Name}}Method[] = "{{.Name}}";
 {{range split $itf.Name "." | reverse -}}
 }  // namespace {{.}}
 {{end -}}
+{{with $.Namespace}}}  // namespace {{.}}
+{{end -}}
 {{end}}{{end -}}
+{{if eq .IncludeGuard "ifndef"}}
+#endif  // {{.HeaderGuard}}
+{{end -}}
 `
 
-// Generate prints a list of method names included in introspects.
-func Generate(introspects []introspect.Introspection, f io.Writer) error {
+// Generate prints a list of method names included in introspects, in the
+// namespace and with the include guard set in the method names settings of
+// config, if any. outputFilePath is used to make a unique header guard.
+func Generate(introspects []introspect.Introspection, f io.Writer, outputFilePath string, config serviceconfig.Config) error {
 	tmpl, err := template.New("methodNames").Funcs(funcMap).Parse(templateText)
 	if err != nil {
 		return err
 	}
-	return tmpl.Execute(f, introspects)
+
+	args := templateArgs{Introspects: introspects, HeaderGuard: genutil.GenerateHeaderGuard(outputFilePath)}
+	if config.MethodNames != nil {
+		args.Namespace = config.MethodNames.Namespace
+		args.IncludeGuard = config.MethodNames.IncludeGuard
+	}
+	return tmpl.Execute(f, args)
+}
+
+// InterfaceFileName returns the name of the file holding the method names of
+// the interface itfName alone, e.g. "org.chromium.Frobinator-method-names.h".
+func InterfaceFileName(itfName string) string {
+	return itfName + "-method-names.h"
+}
+
+// SplitInterfaces returns an introspection per interface of introspects,
+// holding that interface alone, so that the method names of each interface can
+// be written to a file of its own.
+func SplitInterfaces(introspects []introspect.Introspection) []introspect.Introspection {
+	var ret []introspect.Introspection
+	for _, ii := range introspects {
+		for _, itf := range ii.Interfaces {
+			single := ii
+			single.Interfaces = []introspect.Interface{itf}
+			ret = append(ret, single)
+		}
+	}
+	return ret
 }
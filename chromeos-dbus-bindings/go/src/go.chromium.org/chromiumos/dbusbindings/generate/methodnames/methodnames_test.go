@@ -10,6 +10,7 @@ import (
 
 	"go.chromium.org/chromiumos/dbusbindings/generate/methodnames"
 	"go.chromium.org/chromiumos/dbusbindings/introspect"
+	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -93,7 +94,7 @@ func TestGenerateMethodnames(t *testing.T) {
 	}
 
 	out := new(bytes.Buffer)
-	err := methodnames.Generate(introspections, out)
+	err := methodnames.Generate(introspections, out, "out/method_names.h", serviceconfig.Config{})
 	if err != nil {
 		t.Errorf("Generate got error, want nil: %v", err)
 	}
@@ -102,3 +103,113 @@ func TestGenerateMethodnames(t *testing.T) {
 		t.Errorf(" failed (-got +want):\n%s", diff)
 	}
 }
+
+func TestGenerateMethodnamesWithConfig(t *testing.T) {
+	introspections := []introspect.Introspection{
+		{
+			Interfaces: []introspect.Interface{
+				{
+					Name: "fi.w1.wpa_supplicant1.Interface",
+					Methods: []introspect.Method{
+						{
+							Name: "Scan",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		config serviceconfig.MethodNamesConfig
+		want   string
+	}{
+		{
+			config: serviceconfig.MethodNamesConfig{
+				Namespace:    "wpa::dbus",
+				IncludeGuard: serviceconfig.IncludeGuardPragmaOnce,
+			},
+			want: `#pragma once
+
+namespace wpa::dbus {
+namespace fi {
+namespace w1 {
+namespace wpa_supplicant1 {
+namespace Interface {
+const char kScanMethod[] = "Scan";
+}  // namespace Interface
+}  // namespace wpa_supplicant1
+}  // namespace w1
+}  // namespace fi
+}  // namespace wpa::dbus
+`,
+		}, {
+			config: serviceconfig.MethodNamesConfig{
+				IncludeGuard: serviceconfig.IncludeGuardIfndef,
+			},
+			want: `#ifndef ____CHROMEOS_DBUS_BINDING__OUT_METHOD_NAMES_H
+#define ____CHROMEOS_DBUS_BINDING__OUT_METHOD_NAMES_H
+
+namespace fi {
+namespace w1 {
+namespace wpa_supplicant1 {
+namespace Interface {
+const char kScanMethod[] = "Scan";
+}  // namespace Interface
+}  // namespace wpa_supplicant1
+}  // namespace w1
+}  // namespace fi
+
+#endif  // ____CHROMEOS_DBUS_BINDING__OUT_METHOD_NAMES_H
+`,
+		},
+	}
+
+	for _, tc := range cases {
+		config := tc.config
+		out := new(bytes.Buffer)
+		if err := methodnames.Generate(introspections, out, "out/method_names.h", serviceconfig.Config{MethodNames: &config}); err != nil {
+			t.Errorf("Generate got error, want nil: %v", err)
+			continue
+		}
+		if diff := cmp.Diff(out.String(), tc.want); diff != "" {
+			t.Errorf("Generate with %+v failed (-got +want):\n%s", tc.config, diff)
+		}
+	}
+}
+
+func TestSplitInterfaces(t *testing.T) {
+	introspections := []introspect.Introspection{
+		{
+			Name: "/org/chromium/Test",
+			Interfaces: []introspect.Interface{
+				{Name: "org.chromium.Test1"},
+				{Name: "org.chromium.Test2"},
+			},
+		}, {
+			Interfaces: []introspect.Interface{
+				{Name: "org.chromium.Test3"},
+			},
+		},
+	}
+
+	got := methodnames.SplitInterfaces(introspections)
+	want := []introspect.Introspection{
+		{
+			Name:       "/org/chromium/Test",
+			Interfaces: []introspect.Interface{{Name: "org.chromium.Test1"}},
+		}, {
+			Name:       "/org/chromium/Test",
+			Interfaces: []introspect.Interface{{Name: "org.chromium.Test2"}},
+		}, {
+			Interfaces: []introspect.Interface{{Name: "org.chromium.Test3"}},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("SplitInterfaces failed (-got +want):\n%s", diff)
+	}
+
+	if got, want := methodnames.InterfaceFileName("org.chromium.Test1"), "org.chromium.Test1-method-names.h"; got != want {
+		t.Errorf("InterfaceFileName: got %q, want %q", got, want)
+	}
+}
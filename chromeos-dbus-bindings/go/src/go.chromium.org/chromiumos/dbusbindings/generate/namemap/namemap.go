@@ -0,0 +1,182 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package namemap outputs, as JSON, the C++ identifiers generated for each
+// D-Bus interface, method, signal and property, so that tools can find the
+// generated code for a D-Bus name without reading the templates.
+package namemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
+)
+
+// Symbol is a fully qualified C++ identifier, along with the role it plays in
+// the generated code, e.g. "adaptor_getter".
+type Symbol struct {
+	Role       string `json:"role"`
+	Identifier string `json:"identifier"`
+}
+
+// Member lists the symbols generated for a method, signal or property.
+// Members of the proxy interface classes are also overridden by the proxy
+// and mock classes, which are not listed separately.
+type Member struct {
+	DBusName string   `json:"dbus_name"`
+	Symbols  []Symbol `json:"symbols"`
+	// PrivacySensitive is set for methods whose arguments must not be
+	// logged, so that privacy review tooling can find the code handling them.
+	PrivacySensitive bool `json:"privacy_sensitive,omitempty"`
+}
+
+// Interface lists the symbols generated for an interface and its members.
+type Interface struct {
+	DBusName   string   `json:"dbus_name"`
+	Symbols    []Symbol `json:"symbols"`
+	Methods    []Member `json:"methods"`
+	Signals    []Member `json:"signals"`
+	Properties []Member `json:"properties"`
+}
+
+// NameMap lists the symbols generated for all interfaces.
+type NameMap struct {
+	// ObjectManager is empty if no object manager proxy is generated.
+	ObjectManager string      `json:"object_manager,omitempty"`
+	Interfaces    []Interface `json:"interfaces"`
+}
+
+// New returns the symbols generated for introspects with config.
+func New(introspects []introspect.Introspection, config serviceconfig.Config) NameMap {
+	var objectManager string
+	if config.ObjectManager != nil {
+		objectManager = genutil.MakeFullProxyName(config.ObjectManager.Name)
+	}
+
+	var methodNamesNamespace string
+	if config.MethodNames != nil {
+		methodNamesNamespace = config.MethodNames.Namespace
+	}
+
+	ret := NameMap{ObjectManager: objectManager, Interfaces: []Interface{}}
+	for _, is := range introspects {
+		for _, itf := range is.Interfaces {
+			ret.Interfaces = append(ret.Interfaces, newInterface(itf, is.Name != "", objectManager, methodNamesNamespace, config.ExpectedMethods))
+		}
+	}
+	return ret
+}
+
+func newInterface(itf introspect.Interface, hasObjectPath bool, objectManager, methodNamesNamespace string, expectedMethods bool) Interface {
+	full := genutil.MakeFullItfName(itf.Name)
+	methodNames := full
+	if methodNamesNamespace != "" {
+		methodNames = methodNamesNamespace + "::" + full
+	}
+	itfClass := full + "Interface"
+	adaptor := full + "Adaptor"
+	proxy := genutil.MakeFullProxyName(itf.Name)
+	proxyItf := genutil.MakeFullProxyInterfaceName(itf.Name)
+	scoped := func(class, name string) string {
+		return class + "::" + name
+	}
+
+	ret := Interface{
+		DBusName: itf.Name,
+		Symbols: []Symbol{
+			{"method_names_namespace", methodNames},
+			{"interface", itfClass},
+			{"adaptor", adaptor},
+			{"proxy_interface", proxyItf},
+			{"proxy", proxy},
+			{"mock", proxy + "Mock"},
+		},
+		Methods:    []Member{},
+		Signals:    []Member{},
+		Properties: []Member{},
+	}
+	if objectManager != "" {
+		typeName := genutil.MakeTypeName(itf.Name)
+		ret.Symbols = append(ret.Symbols,
+			Symbol{"object_manager_getter", scoped(objectManager, "Get"+genutil.MakeProxyName(itf.Name))},
+			Symbol{"object_manager_instances", scoped(objectManager, "Get"+typeName+"Instances")},
+			Symbol{"object_manager_added_callback", scoped(objectManager, "Set"+typeName+"AddedCallback")},
+			Symbol{"object_manager_removed_callback", scoped(objectManager, "Set"+typeName+"RemovedCallback")},
+		)
+	}
+	if hasObjectPath {
+		ret.Symbols = append(ret.Symbols, Symbol{"adaptor_object_path", scoped(adaptor, "GetObjectPath")})
+	}
+
+	for _, m := range itf.Methods {
+		member := Member{
+			DBusName: m.Name,
+			Symbols: []Symbol{
+				{"method_name", scoped(methodNames, fmt.Sprintf("k%sMethod", m.Name))},
+				{"interface_method", scoped(itfClass, m.Name)},
+				{"proxy_method", scoped(proxyItf, m.Name)},
+				{"proxy_async_method", scoped(proxyItf, m.Name+"Async")},
+			},
+			PrivacySensitive: m.PrivacySensitive(),
+		}
+		if m.DefaultLastInput() != "" {
+			member.Symbols = append(member.Symbols, Symbol{"adaptor_optional_arg_handler", scoped(adaptor, "Handle"+m.Name)})
+		}
+		if expectedMethods {
+			member.Symbols = append(member.Symbols, Symbol{"proxy_expected_method", scoped(proxyItf, m.Name+"Expected")})
+		}
+		ret.Methods = append(ret.Methods, member)
+	}
+
+	for _, s := range itf.Signals {
+		ret.Signals = append(ret.Signals, Member{
+			DBusName: s.Name,
+			Symbols: []Symbol{
+				{"adaptor_send", scoped(adaptor, "Send"+s.Name+"Signal")},
+				{"adaptor_signal_type", scoped(adaptor, "Signal"+s.Name+"Type")},
+				{"proxy_signal_name", scoped(proxyItf, s.Name+"SignalName")},
+				{"proxy_register_handler", scoped(proxyItf, "Register"+s.Name+"SignalHandler")},
+			},
+		})
+	}
+
+	for _, p := range itf.Properties {
+		name := genutil.MakeVariableName(p.VariableName())
+		member := Member{
+			DBusName: p.Name,
+			Symbols: []Symbol{
+				{"adaptor_name", scoped(adaptor, p.Name+"Name")},
+				{"adaptor_getter", scoped(adaptor, "Get"+p.Name)},
+				{"adaptor_setter", scoped(adaptor, "Set"+p.Name)},
+			},
+		}
+		if p.Access != "read" {
+			member.Symbols = append(member.Symbols, Symbol{"adaptor_validator", scoped(adaptor, "Validate"+p.Name)})
+		}
+		member.Symbols = append(member.Symbols,
+			Symbol{"adaptor_member", scoped(adaptor, name+"_")},
+			Symbol{"proxy_name", scoped(proxyItf, p.Name+"Name")},
+			Symbol{"proxy_getter", scoped(proxyItf, name)},
+			Symbol{"proxy_is_valid", scoped(proxyItf, "is_"+name+"_valid")},
+		)
+		if p.Access == "readwrite" {
+			member.Symbols = append(member.Symbols, Symbol{"proxy_setter", scoped(proxyItf, "set_"+name)})
+		}
+		ret.Properties = append(ret.Properties, member)
+	}
+
+	return ret
+}
+
+// Generate writes the symbols generated for introspects with config into f as
+// JSON.
+func Generate(introspects []introspect.Introspection, config serviceconfig.Config, f io.Writer) error {
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(New(introspects, config))
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package namemap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/namemap"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNew(t *testing.T) {
+	is, err := introspect.Parse([]byte(`
+<node name="/org/chromium/Test">
+  <interface name="org.chromium.Test">
+    <method name="Frobinate">
+      <arg name="foo" type="i"/>
+      <annotation name="org.chromium.DBus.Method.DefaultLastInput" value="0"/>
+      <annotation name="org.chromium.DBus.Method.PrivacySensitive" value="true"/>
+    </method>
+    <signal name="Frobinated">
+      <arg type="u"/>
+    </signal>
+    <property name="WiFi.TDLSState" type="s" access="readwrite"/>
+    <property name="Class" type="u" access="read">
+      <annotation name="org.chromium.DBus.Argument.VariableName" value="BluetoothClass"/>
+    </property>
+  </interface>
+</node>`))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+
+	config := serviceconfig.Config{
+		ExpectedMethods: true,
+		ObjectManager:   &serviceconfig.ObjectManagerConfig{Name: "org.chromium.ObjectManager"},
+	}
+	got := namemap.New([]introspect.Introspection{is}, config)
+
+	want := namemap.NameMap{
+		ObjectManager: "org::chromium::ObjectManagerProxy",
+		Interfaces: []namemap.Interface{{
+			DBusName: "org.chromium.Test",
+			Symbols: []namemap.Symbol{
+				{Role: "method_names_namespace", Identifier: "org::chromium::Test"},
+				{Role: "interface", Identifier: "org::chromium::TestInterface"},
+				{Role: "adaptor", Identifier: "org::chromium::TestAdaptor"},
+				{Role: "proxy_interface", Identifier: "org::chromium::TestProxyInterface"},
+				{Role: "proxy", Identifier: "org::chromium::TestProxy"},
+				{Role: "mock", Identifier: "org::chromium::TestProxyMock"},
+				{Role: "object_manager_getter", Identifier: "org::chromium::ObjectManagerProxy::GetTestProxy"},
+				{Role: "object_manager_instances", Identifier: "org::chromium::ObjectManagerProxy::GetTestInstances"},
+				{Role: "object_manager_added_callback", Identifier: "org::chromium::ObjectManagerProxy::SetTestAddedCallback"},
+				{Role: "object_manager_removed_callback", Identifier: "org::chromium::ObjectManagerProxy::SetTestRemovedCallback"},
+				{Role: "adaptor_object_path", Identifier: "org::chromium::TestAdaptor::GetObjectPath"},
+			},
+			Methods: []namemap.Member{{
+				DBusName: "Frobinate",
+				Symbols: []namemap.Symbol{
+					{Role: "method_name", Identifier: "org::chromium::Test::kFrobinateMethod"},
+					{Role: "interface_method", Identifier: "org::chromium::TestInterface::Frobinate"},
+					{Role: "proxy_method", Identifier: "org::chromium::TestProxyInterface::Frobinate"},
+					{Role: "proxy_async_method", Identifier: "org::chromium::TestProxyInterface::FrobinateAsync"},
+					{Role: "adaptor_optional_arg_handler", Identifier: "org::chromium::TestAdaptor::HandleFrobinate"},
+					{Role: "proxy_expected_method", Identifier: "org::chromium::TestProxyInterface::FrobinateExpected"},
+				},
+				PrivacySensitive: true,
+			}},
+			Signals: []namemap.Member{{
+				DBusName: "Frobinated",
+				Symbols: []namemap.Symbol{
+					{Role: "adaptor_send", Identifier: "org::chromium::TestAdaptor::SendFrobinatedSignal"},
+					{Role: "adaptor_signal_type", Identifier: "org::chromium::TestAdaptor::SignalFrobinatedType"},
+					{Role: "proxy_signal_name", Identifier: "org::chromium::TestProxyInterface::FrobinatedSignalName"},
+					{Role: "proxy_register_handler", Identifier: "org::chromium::TestProxyInterface::RegisterFrobinatedSignalHandler"},
+				},
+			}},
+			Properties: []namemap.Member{{
+				DBusName: "WiFi.TDLSState",
+				Symbols: []namemap.Symbol{
+					{Role: "adaptor_name", Identifier: "org::chromium::TestAdaptor::WiFi.TDLSStateName"},
+					{Role: "adaptor_getter", Identifier: "org::chromium::TestAdaptor::GetWiFi.TDLSState"},
+					{Role: "adaptor_setter", Identifier: "org::chromium::TestAdaptor::SetWiFi.TDLSState"},
+					{Role: "adaptor_validator", Identifier: "org::chromium::TestAdaptor::ValidateWiFi.TDLSState"},
+					{Role: "adaptor_member", Identifier: "org::chromium::TestAdaptor::tdlsstate_"},
+					{Role: "proxy_name", Identifier: "org::chromium::TestProxyInterface::WiFi.TDLSStateName"},
+					{Role: "proxy_getter", Identifier: "org::chromium::TestProxyInterface::tdlsstate"},
+					{Role: "proxy_is_valid", Identifier: "org::chromium::TestProxyInterface::is_tdlsstate_valid"},
+					{Role: "proxy_setter", Identifier: "org::chromium::TestProxyInterface::set_tdlsstate"},
+				},
+			}, {
+				DBusName: "Class",
+				Symbols: []namemap.Symbol{
+					{Role: "adaptor_name", Identifier: "org::chromium::TestAdaptor::ClassName"},
+					{Role: "adaptor_getter", Identifier: "org::chromium::TestAdaptor::GetClass"},
+					{Role: "adaptor_setter", Identifier: "org::chromium::TestAdaptor::SetClass"},
+					{Role: "adaptor_member", Identifier: "org::chromium::TestAdaptor::bluetooth_class_"},
+					{Role: "proxy_name", Identifier: "org::chromium::TestProxyInterface::ClassName"},
+					{Role: "proxy_getter", Identifier: "org::chromium::TestProxyInterface::bluetooth_class"},
+					{Role: "proxy_is_valid", Identifier: "org::chromium::TestProxyInterface::is_bluetooth_class_valid"},
+				},
+			}},
+		}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("New failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestNewMethodNamesNamespace(t *testing.T) {
+	is, err := introspect.Parse([]byte(`
+<node>
+  <interface name="org.chromium.Test">
+    <method name="Frobinate"/>
+  </interface>
+</node>`))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+
+	config := serviceconfig.Config{
+		MethodNames: &serviceconfig.MethodNamesConfig{Namespace: "test::dbus"},
+	}
+	got := namemap.New([]introspect.Introspection{is}, config)
+
+	if diff := cmp.Diff(got.Interfaces[0].Symbols[0], namemap.Symbol{Role: "method_names_namespace", Identifier: "test::dbus::org::chromium::Test"}); diff != "" {
+		t.Errorf("New failed (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(got.Interfaces[0].Methods[0].Symbols[0], namemap.Symbol{Role: "method_name", Identifier: "test::dbus::org::chromium::Test::kFrobinateMethod"}); diff != "" {
+		t.Errorf("New failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	is, err := introspect.Parse([]byte(`<node><interface name="EmptyInterface"/></node>`))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+
+	out := new(bytes.Buffer)
+	if err := namemap.Generate([]introspect.Introspection{is}, serviceconfig.Config{}, out); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	var got namemap.NameMap
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Generate output is not valid JSON: %v\n%s", err, out)
+	}
+	want := namemap.New([]introspect.Introspection{is}, serviceconfig.Config{})
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
@@ -4,10 +4,6 @@
 
 package proxy
 
-import (
-	"go.chromium.org/chromiumos/dbusbindings/introspect"
-)
-
 const proxyInterfaceTemplate = `{{define "proxyInterface" -}}
 {{- with .Itf -}}
 {{range extractNameSpaces .Name -}}
@@ -19,9 +15,31 @@ namespace {{.}} {
 class {{$itfName}} {
  public:
   virtual ~{{$itfName}}() = default;
+
+  static const char* DBusInterfaceName() { return "{{.Name}}"; }
+{{- range .Signals}}
+  static const char* {{.Name}}SignalName() { return "{{.Name}}"; }
+{{- end}}
 {{- range .Methods}}
-{{- $inParams := makeMethodParams 0 .InputArguments -}}
-{{- $outParams := makeMethodParams (len .InputArguments) .OutputArguments}}
+{{- $inParams := .InParams -}}
+{{- $outParams := .OutParams}}
+{{- $methodName := .Name}}
+{{- range .AllowedValuesEnums}}
+
+  // Values allowed for the {{.ArgName}} argument of {{$methodName}}().
+  enum class {{.Type}} {
+{{- range .Values}}
+    {{.Enumerator}},
+{{- end}}
+  };
+  static const char* {{.Type}}ToString({{.Type}} value) {
+    static constexpr const char* kValues[] = {
+{{- range $i, $v := .Values}}{{if $i}},{{end}}
+        "{{$v.Value}}"
+{{- end}}};
+    return kValues[static_cast<size_t>(value)];
+  }
+{{- end}}
 
 {{formatComment .DocString 2 -}}
 {{"  "}}virtual bool {{.Name}}(
@@ -33,26 +51,89 @@ class {{$itfName}} {
 {{- end}}
       brillo::ErrorPtr* error,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+{{- if $.Itf.ExpectedMethods}}
+
+  // Same as {{.Name}}(), returning the output arguments or the error.
+  virtual base::expected<{{.ExpectedResultType}}, brillo::ErrorPtr> {{.Name}}Expected(
+{{- range $inParams}}
+      {{.Type}} {{.Name}},
+{{- end}}
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+{{- end}}
+
+{{- with .AsyncResponse}}
+
+  // Response of {{$methodName}}Async(), owning the file descriptors it received.
+  struct {{.Type}} {
+{{- range .Members}}
+    {{.Type}} {{.Name}};
+{{- end}}
+  };
+{{- end}}
 
 {{formatComment .DocString 2 -}}
 {{"  "}}virtual void {{.Name}}Async(
 {{- range $inParams}}
       {{.Type}} {{.Name}},
 {{- end}}
-      {{makeMethodCallbackType .OutputArguments}} success_callback,
+      {{.AsyncCallbackType}} success_callback,
       base::OnceCallback<void(brillo::Error*)> error_callback,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+{{- if .DefaultLastInput}}
+{{- $requiredParams := .RequiredParams}}
+{{- $optionalParam := .OptionalParam}}
+
+  // Same as {{.Name}}(), using {{.DefaultLastInput}} for |{{$optionalParam.Name}}|.
+  bool {{.Name}}(
+{{- range $requiredParams }}
+      {{.Type}} {{.Name}},
+{{- end}}
+{{- range $outParams }}
+      {{.Type}} {{.Name}},
+{{- end}}
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) {
+    return {{.Name}}(
+{{- range $requiredParams }}
+        {{.Name}},
+{{- end}}
+        {{.DefaultLastInput}},
+{{- range $outParams }}
+        {{.Name}},
+{{- end}}
+        error,
+        timeout_ms);
+  }
+
+  // Same as {{.Name}}Async(), using {{.DefaultLastInput}} for |{{$optionalParam.Name}}|.
+  void {{.Name}}Async(
+{{- range $requiredParams}}
+      {{.Type}} {{.Name}},
+{{- end}}
+      {{.AsyncCallbackType}} success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) {
+    {{.Name}}Async(
+{{- range $requiredParams}}
+        {{.Name}},
+{{- end}}
+        {{.DefaultLastInput}},
+        std::move(success_callback),
+        std::move(error_callback),
+        timeout_ms);
+  }
+{{- end}}
 {{- end}}
 {{- range .Signals}}
 
   virtual void Register{{.Name}}SignalHandler(
-      {{- makeSignalCallbackType .Args | nindent 6}} signal_callback,
+      {{- .CallbackType | nindent 6}} signal_callback,
       dbus::ObjectProxy::OnConnectedCallback on_connected_callback) = 0;
 {{- end}}
 {{- if .Properties}}{{"\n"}}{{end}}
 {{- range .Properties}}
-{{- $name := makePropertyVariableName . | makeVariableName -}}
-{{- $type := makeProxyInArgTypeProxy . }}
+{{- $name := .VarName -}}
+{{- $type := .ArgType}}
   static const char* {{.Name}}Name() { return "{{.Name}}"; }
   virtual {{$type}} {{$name}}() const = 0;
   virtual bool is_{{$name}}_valid() const = 0;
@@ -82,10 +163,10 @@ class {{$itfName}} {
 {{- end}}`
 
 type proxyInterfaceArgs struct {
-	Itf               introspect.Interface
+	Itf               interfaceView
 	ObjectManagerName string
 }
 
-func makeProxyInterfaceArgs(itf introspect.Interface, omName string) proxyInterfaceArgs {
+func makeProxyInterfaceArgs(itf interfaceView, omName string) proxyInterfaceArgs {
 	return proxyInterfaceArgs{Itf: itf, ObjectManagerName: omName}
 }
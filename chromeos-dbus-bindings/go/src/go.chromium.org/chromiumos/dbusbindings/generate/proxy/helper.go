@@ -6,6 +6,7 @@ package proxy
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
@@ -16,57 +17,126 @@ type param struct {
 	Type, Name string
 }
 
+// argNames returns the names of args, which are all input or all output
+// arguments, numbered from offset.
+func argNames(offset int, args []introspect.MethodArg) []string {
+	prefix := "in"
+	if len(args) > 0 && args[0].Direction == "out" {
+		prefix = "out"
+	}
+	return genutil.MethodArgNames(prefix, args, offset)
+}
+
 func makeMethodParams(offset int, args []introspect.MethodArg) ([]param, error) {
 	var ret []param
+	names := argNames(offset, args)
 	for i, a := range args {
-		argType, prefix := a.InArgType, "in"
+		argType := a.InArgType
 		if a.Direction == "out" {
-			argType, prefix = a.OutArgType, "out"
+			argType = a.OutArgType
 		}
 		t, err := argType()
 		if err != nil {
 			return nil, err
 		}
-		// The number-suffix is 1-indexed.
-		ret = append(ret, param{t, genutil.ArgName(prefix, a.Name, i+offset+1)})
+		ret = append(ret, param{t, names[i]})
 	}
 
 	return ret, nil
 }
 
-func makeMethodCallbackType(args []introspect.MethodArg) (string, error) {
+// makeMethodCallbackType returns the type of the callback receiving the
+// output arguments args, numbered from offset. Unnamed arguments are commented
+// with the name of the matching parameter of the synchronous call.
+func makeMethodCallbackType(offset int, args []introspect.MethodArg) (string, error) {
 	var params []string
-	for _, a := range args {
+	names := argNames(offset, args)
+	for i, a := range args {
 		t, err := a.CallbackType()
 		if err != nil {
 			return "", err
 		}
-		if a.Name == "" {
-			params = append(params, t)
-		} else {
-			params = append(params, fmt.Sprintf("%s /*%s*/", t, a.Name))
+		name := a.Name
+		if name == "" {
+			name = names[i]
 		}
+		params = append(params, fmt.Sprintf("%s /*%s*/", t, name))
 	}
 	return fmt.Sprintf("base::OnceCallback<void(%s)>", strings.Join(params, ", ")), nil
 
 }
 
-func makeMockMethodParams(args []introspect.MethodArg) ([]param, error) {
-	var ret []param
+// asyncResponse describes the struct passed to the success callback of the
+// asynchronous call of a method with move-only output arguments, so that the
+// callback takes ownership of e.g. the file descriptors it receives rather than
+// getting const references to them.
+type asyncResponse struct {
+	Type    string
+	Members []param
+}
+
+// makeAsyncResponse returns the response struct of the asynchronous call of
+// method, or nil if its output arguments can all be copied and are passed to
+// the success callback one by one.
+func makeAsyncResponse(method introspect.Method) (*asyncResponse, error) {
+	args := method.OutputArguments()
+	moveOnly := false
 	for _, a := range args {
-		argType, prefix := a.InArgType, "in"
-		if a.Direction == "out" {
-			argType, prefix = a.OutArgType, "out"
+		m, err := a.MoveOnly()
+		if err != nil {
+			return nil, err
 		}
-		t, err := argType()
+		moveOnly = moveOnly || m
+	}
+	if !moveOnly {
+		return nil, nil
+	}
+
+	ret := &asyncResponse{Type: method.Name + "Response"}
+	names := argNames(len(method.InputArguments()), args)
+	for i, a := range args {
+		t, err := a.BaseType()
 		if err != nil {
 			return nil, err
 		}
-		if a.Name == "" {
-			ret = append(ret, param{t, ""})
-		} else {
-			ret = append(ret, param{t, fmt.Sprintf("/*%s_%s*/", prefix, a.Name)})
+		ret.Members = append(ret.Members, param{t, names[i]})
+	}
+	return ret, nil
+}
+
+// makeAsyncCallbackType returns the type of the success callback of the
+// asynchronous call of method, taking resp, its response struct, if it has one.
+func makeAsyncCallbackType(method introspect.Method, resp *asyncResponse) (string, error) {
+	if resp != nil {
+		return fmt.Sprintf("base::OnceCallback<void(%s)>", resp.Type), nil
+	}
+	return makeMethodCallbackType(len(method.InputArguments()), method.OutputArguments())
+}
+
+// makeExpectedResultType returns the std::tuple of the base types of args,
+// holding the results of a successful method call.
+func makeExpectedResultType(args []introspect.MethodArg) (string, error) {
+	var types []string
+	for _, a := range args {
+		t, err := a.BaseType()
+		if err != nil {
+			return "", err
 		}
+		types = append(types, t)
+	}
+	return fmt.Sprintf("std::tuple<%s>", strings.Join(types, ", ")), nil
+}
+
+// makeMockMethodParams returns the parameters of the mock of a method taking
+// args, numbered from offset, whose names are commented out.
+func makeMockMethodParams(offset int, args []introspect.MethodArg) ([]param, error) {
+	params, err := makeMethodParams(offset, args)
+	if err != nil {
+		return nil, err
+	}
+	var ret []param
+	for _, p := range params {
+		ret = append(ret, param{p.Type, fmt.Sprintf("/*%s*/", p.Name)})
 	}
 
 	return ret, nil
@@ -94,15 +164,112 @@ func makeSignalCallbackType(args []introspect.SignalArg) (string, error) {
 	return fmt.Sprintf("%s%s%s", prefix, strings.Join(lines, ",\n"+indent), suffix), nil
 }
 
-// extractInterfacesWithProperties returns an array of Interfaces that have Properties.
-func extractInterfacesWithProperties(iss []introspect.Introspection) []introspect.Interface {
-	var ret []introspect.Interface
-	for _, is := range iss {
-		for _, itf := range is.Interfaces {
-			if len(itf.Properties) > 0 {
-				ret = append(ret, itf)
+// makeServiceNameCandidates returns the service names a proxy tries to connect
+// to, in order of preference.
+func makeServiceNameCandidates(serviceName string, fallbacks []string) []string {
+	return append([]string{serviceName}, fallbacks...)
+}
+
+// usesDedicatedBus returns whether the proxy of the interface itfName is to be
+// created on its own bus, according to dedicatedBusInterfaces.
+func usesDedicatedBus(dedicatedBusInterfaces []string, itfName string) bool {
+	for _, name := range dedicatedBusInterfaces {
+		if name == itfName {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyBundlePathRegexp matches the elements of object paths which can name a
+// C++ namespace or struct.
+var proxyBundlePathRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// makeProxyBundleName returns the name of the struct bundling the proxies of
+// all the interfaces of ii, in the dotted form of interface names and derived
+// from its object path, e.g. org.chromium.Foo for /org/chromium/Foo. It
+// returns "" if ii has no bundle: if it has a single interface, no fixed object
+// path other than the root one, or proxies created by the object manager named
+// objectManagerName.
+func makeProxyBundleName(ii introspect.Introspection, objectManagerName string) (string, error) {
+	if len(ii.Interfaces) < 2 || ii.Name == "" || ii.Name == "/" {
+		return "", nil
+	}
+	fields := make(map[string]string)
+	for _, itf := range ii.Interfaces {
+		if objectManagerName != "" && len(itf.Properties) > 0 {
+			return "", nil
+		}
+		field := genutil.MakeVariableName(itf.Name)
+		if other, ok := fields[field]; ok {
+			return "", fmt.Errorf("interfaces %s and %s of %s would both be bundled as %s", other, itf.Name, ii.Name, field)
+		}
+		fields[field] = itf.Name
+	}
+
+	elems := strings.Split(strings.TrimPrefix(ii.Name, "/"), "/")
+	for _, e := range elems {
+		if !proxyBundlePathRegexp.MatchString(e) {
+			return "", fmt.Errorf("object path %s can't name a proxy bundle", ii.Name)
+		}
+	}
+	return strings.Join(elems, "."), nil
+}
+
+// allowedValue is a value allowed for a string argument, along with the C++
+// enumerator standing for it.
+type allowedValue struct {
+	Enumerator, Value string
+}
+
+// allowedValuesEnum describes the enum generated for an input argument
+// restricted to some values by an AllowedValues annotation, so that callers
+// don't need to spell the strings themselves.
+type allowedValuesEnum struct {
+	// Type is the name of the enum, made of the method and argument names.
+	Type    string
+	ArgName string
+	Values  []allowedValue
+}
+
+// enumeratorSeparatorRegexp matches the characters of allowed values which
+// don't make it into enumerator names.
+var enumeratorSeparatorRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// makeAllowedValuesEnums returns the enums of the input arguments of method
+// which have allowed values, e.g. ScanMode for the mode argument of Scan, with
+// the enumerator kColor for the value "Color".
+func makeAllowedValuesEnums(method introspect.Method) ([]allowedValuesEnum, error) {
+	var ret []allowedValuesEnum
+	for _, arg := range method.InputArguments() {
+		values := arg.AllowedValues()
+		if values == nil {
+			continue
+		}
+		e := allowedValuesEnum{Type: method.Name + camelCase(arg.Name), ArgName: arg.Name}
+		enumerators := make(map[string]string)
+		for _, v := range values {
+			enumerator := "k" + camelCase(v)
+			if other, ok := enumerators[enumerator]; ok {
+				return nil, fmt.Errorf("allowed values %q and %q of %s argument would both be %s", other, v, arg.Name, enumerator)
 			}
+			enumerators[enumerator] = v
+			e.Values = append(e.Values, allowedValue{enumerator, v})
+		}
+		ret = append(ret, e)
+	}
+	return ret, nil
+}
+
+// camelCase joins the alphanumeric parts of s, capitalizing each of them, e.g.
+// ScanMode for scan_mode.
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, part := range enumeratorSeparatorRegexp.Split(s, -1) {
+		if part == "" {
+			continue
 		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
 	}
-	return ret
+	return b.String()
 }
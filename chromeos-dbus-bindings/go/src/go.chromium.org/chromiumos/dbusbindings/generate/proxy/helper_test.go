@@ -99,8 +99,9 @@ func TestMakeMethodParams(t *testing.T) {
 
 func TestMakeMockMethodParams(t *testing.T) {
 	cases := []struct {
-		args []introspect.MethodArg
-		want []param
+		offset int
+		args   []introspect.MethodArg
+		want   []param
 	}{{
 		args: []introspect.MethodArg{{
 			Name: "iarg1", Type: "i",
@@ -127,11 +128,11 @@ func TestMakeMockMethodParams(t *testing.T) {
 			Type: "i",
 		}},
 		want: []param{
-			{"int32_t", ""},
+			{"int32_t", "/*in_1*/"},
 			{"int32_t", "/*in_iarg2*/"},
-			{"int32_t", ""},
+			{"int32_t", "/*in_3*/"},
 			{"int32_t", "/*in_iarg4*/"},
-			{"int32_t", ""},
+			{"int32_t", "/*in_5*/"},
 		},
 	}, {
 		args: []introspect.MethodArg{{
@@ -147,6 +148,7 @@ func TestMakeMockMethodParams(t *testing.T) {
 			{"dbus::ObjectPath*", "/*out_oarg3*/"},
 		},
 	}, {
+		offset: 5,
 		args: []introspect.MethodArg{{
 			Type: "i", Direction: "out",
 		}, {
@@ -159,16 +161,16 @@ func TestMakeMockMethodParams(t *testing.T) {
 			Type: "i", Direction: "out",
 		}},
 		want: []param{
-			{"int32_t*", ""},
+			{"int32_t*", "/*out_6*/"},
 			{"int32_t*", "/*out_oarg2*/"},
-			{"int32_t*", ""},
+			{"int32_t*", "/*out_8*/"},
 			{"int32_t*", "/*out_oarg4*/"},
-			{"int32_t*", ""},
+			{"int32_t*", "/*out_10*/"},
 		},
 	}}
 
 	for _, tc := range cases {
-		got, err := makeMockMethodParams(tc.args)
+		got, err := makeMockMethodParams(tc.offset, tc.args)
 		if err != nil {
 			t.Errorf("Unexpected method params format error: %v", err)
 		} else if diff := cmp.Diff(got, tc.want); diff != "" {
@@ -179,8 +181,9 @@ func TestMakeMockMethodParams(t *testing.T) {
 
 func TestMakeMethodCallbackType(t *testing.T) {
 	cases := []struct {
-		args []introspect.MethodArg
-		want string
+		offset int
+		args   []introspect.MethodArg
+		want   string
 	}{{
 		args: []introspect.MethodArg{},
 		want: "base::OnceCallback<void()>",
@@ -200,10 +203,22 @@ func TestMakeMethodCallbackType(t *testing.T) {
 		want: ("base::OnceCallback<void(int32_t /*arg1*/, " +
 			"int64_t /*arg2*/, " +
 			"const std::tuple<std::string, base::ScopedFD>& /*arg3*/)>"),
+	}, {
+		offset: 1,
+		args: []introspect.MethodArg{{
+			Type: "i", Direction: "out",
+		}, {
+			Name: "4", Type: "x", Direction: "out",
+		}, {
+			Type: "s", Direction: "out",
+		}},
+		want: ("base::OnceCallback<void(int32_t /*out_2*/, " +
+			"int64_t /*4*/, " +
+			"const std::string& /*out_4_*/)>"),
 	}}
 
 	for _, tc := range cases {
-		got, err := makeMethodCallbackType(tc.args)
+		got, err := makeMethodCallbackType(tc.offset, tc.args)
 		if err != nil {
 			t.Errorf("Unexpected method callback type format error: %v", err)
 		} else if got != tc.want {
@@ -246,3 +261,123 @@ func TestMakeSignalCallbackType(t *testing.T) {
 		}
 	}
 }
+
+func TestMakeExpectedResultType(t *testing.T) {
+	cases := []struct {
+		args []introspect.MethodArg
+		want string
+	}{{
+		args: []introspect.MethodArg{},
+		want: "std::tuple<>",
+	}, {
+		args: []introspect.MethodArg{{
+			Name: "arg1", Type: "q", Direction: "out",
+		}, {
+			Name: "arg2", Type: "(sh)", Direction: "out",
+		}},
+		want: "std::tuple<uint16_t, std::tuple<std::string, base::ScopedFD>>",
+	}}
+
+	for _, tc := range cases {
+		got, err := makeExpectedResultType(tc.args)
+		if err != nil {
+			t.Errorf("Unexpected expected result type format error: %v", err)
+		} else if got != tc.want {
+			t.Errorf("Unexpected expected result type format: got %v, want %v", got, tc.want)
+		}
+	}
+}
+
+func TestMakeProxyBundleName(t *testing.T) {
+	twoItfs := []introspect.Interface{{Name: "test.Frob"}, {Name: "test.FrobDebug"}}
+	withProperties := []introspect.Interface{{
+		Name:       "test.Frob",
+		Properties: []introspect.Property{{Name: "Count", Type: "i", Access: "read"}},
+	}, {
+		Name: "test.FrobDebug",
+	}}
+	cases := []struct {
+		ii                introspect.Introspection
+		objectManagerName string
+		want              string
+	}{{
+		ii:   introspect.Introspection{Name: "/org/chromium/Frob", Interfaces: twoItfs},
+		want: "org.chromium.Frob",
+	}, {
+		ii:                introspect.Introspection{Name: "/org/chromium/Frob", Interfaces: twoItfs},
+		objectManagerName: "test.ObjectManager",
+		want:              "org.chromium.Frob",
+	}, {
+		ii:   introspect.Introspection{Name: "/org/chromium/Frob", Interfaces: twoItfs[:1]},
+		want: "",
+	}, {
+		ii:   introspect.Introspection{Interfaces: twoItfs},
+		want: "",
+	}, {
+		ii:   introspect.Introspection{Name: "/", Interfaces: twoItfs},
+		want: "",
+	}, {
+		ii:                introspect.Introspection{Name: "/org/chromium/Frob", Interfaces: withProperties},
+		objectManagerName: "test.ObjectManager",
+		want:              "",
+	}}
+
+	for _, tc := range cases {
+		got, err := makeProxyBundleName(tc.ii, tc.objectManagerName)
+		if err != nil {
+			t.Errorf("Unexpected proxy bundle name error for %s: %v", tc.ii.Name, err)
+		} else if got != tc.want {
+			t.Errorf("Unexpected proxy bundle name for %s: got %q, want %q", tc.ii.Name, got, tc.want)
+		}
+	}
+
+	if _, err := makeProxyBundleName(introspect.Introspection{Name: "/org/chromium/1st", Interfaces: twoItfs}, ""); err == nil {
+		t.Error("makeProxyBundleName of an object path which isn't a C++ name got nil error, want error")
+	}
+	sameType := []introspect.Interface{{Name: "test.Frob"}, {Name: "other.Frob"}}
+	if _, err := makeProxyBundleName(introspect.Introspection{Name: "/org/chromium/Frob", Interfaces: sameType}, ""); err == nil {
+		t.Error("makeProxyBundleName of interfaces with the same type name got nil error, want error")
+	}
+}
+
+func TestMakeAllowedValuesEnums(t *testing.T) {
+	method := introspect.Method{
+		Name: "Scan",
+		Args: []introspect.MethodArg{{
+			Name: "color_mode",
+			Type: "s",
+			Annotation: introspect.Annotation{
+				Name:  "org.chromium.DBus.Argument.AllowedValues",
+				Value: "Color,gray-8,Lineart",
+			},
+		}, {
+			Name: "source",
+			Type: "s",
+		}, {
+			Name:      "id",
+			Type:      "s",
+			Direction: "out",
+		}},
+	}
+	got, err := makeAllowedValuesEnums(method)
+	if err != nil {
+		t.Fatalf("makeAllowedValuesEnums got error, want nil: %v", err)
+	}
+	want := []allowedValuesEnum{{
+		Type:    "ScanColorMode",
+		ArgName: "color_mode",
+		Values: []allowedValue{
+			{Enumerator: "kColor", Value: "Color"},
+			{Enumerator: "kGray8", Value: "gray-8"},
+			{Enumerator: "kLineart", Value: "Lineart"},
+		},
+	}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("makeAllowedValuesEnums failed (-got +want):\n%s", diff)
+	}
+
+	method.Args[0].Annotation.Value = "Gray_8,gray-8"
+	if _, err := makeAllowedValuesEnums(method); err == nil {
+		t.Error("makeAllowedValuesEnums of values with the same enumerator got nil error, want error")
+	}
+}
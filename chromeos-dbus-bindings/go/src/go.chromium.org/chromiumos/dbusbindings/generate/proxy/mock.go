@@ -23,14 +23,24 @@ const mockTemplateText = `// Automatic generation of D-Bus interface mock proxie
 #ifndef {{.HeaderGuard}}
 #define {{.HeaderGuard}}
 #include <string>
+{{- if .ExpectedMethods}}
+#include <tuple>
+{{- end}}
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
 #include <base/logging.h>
+{{- if .ExpectedMethods}}
+#include <base/types/expected.h>
+{{- end}}
 #include <brillo/any.h>
 #include <brillo/errors/error.h>
 #include <brillo/variant_dictionary.h>
 #include <gmock/gmock.h>
+{{- range .Includes}}
+#include <{{.}}>
+{{- end}}
 {{- if $.ProxyFilePath}}
 
 #include "{{$.ProxyFilePath}}"
@@ -52,46 +62,40 @@ class {{$mockName}} : public {{$itfName}} {
   {{$mockName}}(const {{$mockName}}&) = delete;
   {{$mockName}}& operator=(const {{$mockName}}&) = delete;
 {{- range .Methods}}
-{{- $inParams := makeMockMethodParams .InputArguments}}
-{{- $outParams := makeMockMethodParams .OutputArguments}}
+{{- if .DefaultLastInput}}
 
-  MOCK_METHOD(bool,
-              {{.Name}},
-              ({{- range $inParams}}{{maybeWrap .Type}}{{if .Name}} {{.Name}}{{end}},
-               {{end -}}
-               {{- range $outParams}}{{maybeWrap .Type}}{{if .Name}} {{.Name}}{{end}},
-               {{end -}}
-               brillo::ErrorPtr* /*error*/,
-               int /*timeout_ms*/),
-              (override));
-  MOCK_METHOD(void,
-              {{.Name}}Async,
-              ({{- range $inParams}}{{maybeWrap .Type}}{{if .Name}} {{.Name}}{{end}},
+  using {{$itfName}}::{{.Name}};
+  using {{$itfName}}::{{.Name}}Async;
+{{- end}}
+{{- template "mockMethod" .}}
+{{- if $itf.ExpectedMethods}}
+  MOCK_METHOD({{maybeWrap (printf "base::expected<%s, brillo::ErrorPtr>" .ExpectedResultType)}},
+              {{.Name}}Expected,
+              ({{- range .MockInParams}}{{maybeWrap .Type}} {{.Name}},
                {{end -}}
-               {{- makeMethodCallbackType .OutputArguments | maybeWrap}} /*success_callback*/,
-               base::OnceCallback<void(brillo::Error*)> /*error_callback*/,
                int /*timeout_ms*/),
               (override));
 {{- end}}
+{{- end}}
 
 {{- range .Signals}}
 
   {{/* TODO(b/288402584): get rid of DoRegister* function */ -}}
   void Register{{.Name}}SignalHandler(
     {{- /* TODO(crbug.com/983008): fix the indent to meet style guide. */ -}}
-    {{- makeSignalCallbackType .Args | nindent 4}} signal_callback,
+    {{- .CallbackType | nindent 4}} signal_callback,
     dbus::ObjectProxy::OnConnectedCallback on_connected_callback) override {
     DoRegister{{.Name}}SignalHandler(signal_callback, &on_connected_callback);
   }
   MOCK_METHOD(void,
               DoRegister{{.Name}}SignalHandler,
-              ({{makeSignalCallbackType .Args | nindent 15 | trimLeft " \n"}} /*signal_callback*/,
+              ({{.CallbackType | nindent 15 | trimLeft " \n"}} /*signal_callback*/,
                dbus::ObjectProxy::OnConnectedCallback* /*on_connected_callback*/));
 {{- end}}
 
 {{- range .Properties}}
-{{- $name := makePropertyVariableName . | makeVariableName -}}
-{{- $type := makeProxyInArgTypeProxy . }}
+{{- $name := .VarName -}}
+{{- $type := .ArgType}}
 
   MOCK_METHOD({{$type}}, {{$name}}, (), (const, override));
   MOCK_METHOD(bool, is_{{$name}}_valid, (), (const, override));
@@ -124,6 +128,17 @@ class {{$mockName}} : public {{$itfName}} {
 {{- end}}
 {{- end}}
 };
+
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<{{$mockName}}>,
+              "{{$mockName}} must mock all the methods of {{$itfName}}; "
+              "regenerate it along with the proxy header.");
+{{- if and $.ProxyConcepts $.ProxyFilePath}}
+static_assert({{makeProxyName .Name}}Like<{{$mockName}}>,
+              "{{$mockName}} must provide the calls required by "
+              "{{makeProxyName .Name}}Like.");
+{{- end}}
 {{range extractNameSpaces .Name | reverse -}}
 }  // namespace {{.}}
 {{end}}
@@ -132,9 +147,39 @@ class {{$mockName}} : public {{$itfName}} {
 #endif  // {{.HeaderGuard}}
 `
 
+// mockMethodTemplate generates the gmock methods for a single D-Bus method.
+const mockMethodTemplate = `{{define "mockMethod"}}
+{{- $inParams := .MockInParams}}
+{{- $outParams := .MockOutParams}}
+
+  MOCK_METHOD(bool,
+              {{.Name}},
+              ({{- range $inParams}}{{maybeWrap .Type}} {{.Name}},
+               {{end -}}
+               {{- range $outParams}}{{maybeWrap .Type}} {{.Name}},
+               {{end -}}
+               brillo::ErrorPtr* /*error*/,
+               int /*timeout_ms*/),
+              (override));
+  MOCK_METHOD(void,
+              {{.Name}}Async,
+              ({{- range $inParams}}{{maybeWrap .Type}} {{.Name}},
+               {{end -}}
+               {{- maybeWrap .AsyncCallbackType}} /*success_callback*/,
+               base::OnceCallback<void(brillo::Error*)> /*error_callback*/,
+               int /*timeout_ms*/),
+              (override));
+{{- end}}`
+
 // GenerateMock outputs the header file containing gmock proxy interfaces into f.
-// outputFilePath is used to make a unique header guard.
-func GenerateMock(introspects []introspect.Introspection, f io.Writer, outputFilePath string, proxyFilePath string, config serviceconfig.Config) error {
+// outputFilePath is used to make a unique header guard. Templates are replaced
+// by their overrides, if any.
+func GenerateMock(introspects []introspect.Introspection, f io.Writer, outputFilePath string, proxyFilePath string, config serviceconfig.Config, overrides genutil.TemplateOverrides) error {
+	views, err := makeIntrospectionViews(introspects, config)
+	if err != nil {
+		return err
+	}
+
 	mockFuncMap := make(template.FuncMap)
 	for k, v := range funcMap {
 		mockFuncMap[k] = v
@@ -158,6 +203,14 @@ func GenerateMock(introspects []introspect.Introspection, f io.Writer, outputFil
 		return err
 	}
 
+	if _, err := tmpl.Parse(mockMethodTemplate); err != nil {
+		return err
+	}
+
+	if err := overrides.Apply(tmpl, dotTypes); err != nil {
+		return err
+	}
+
 	var omName string
 	if config.ObjectManager != nil {
 		omName = config.ObjectManager.Name
@@ -165,16 +218,22 @@ func GenerateMock(introspects []introspect.Introspection, f io.Writer, outputFil
 
 	headerGuard := genutil.GenerateHeaderGuard(outputFilePath)
 	return tmpl.Execute(f, struct {
-		Introspects       []introspect.Introspection
+		Introspects       []introspectionView
+		Includes          []string
 		HeaderGuard       string
 		ProxyFilePath     string
 		ServiceName       string
 		ObjectManagerName string
+		ExpectedMethods   bool
+		ProxyConcepts     bool
 	}{
-		Introspects:       introspects,
+		Introspects:       views,
+		Includes:          genutil.CustomTypeIncludes(introspects),
 		HeaderGuard:       headerGuard,
 		ProxyFilePath:     proxyFilePath,
 		ServiceName:       config.ServiceName,
 		ObjectManagerName: omName,
+		ExpectedMethods:   config.ExpectedMethods,
+		ProxyConcepts:     config.ProxyConcepts,
 	})
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
 	"go.chromium.org/chromiumos/dbusbindings/introspect"
 	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
 )
@@ -106,7 +107,7 @@ func TestGenerateMockProxies(t *testing.T) {
 		},
 	}
 	out := new(bytes.Buffer)
-	if err := GenerateMock(introspections, out, "/tmp/mock.h", "", sc); err != nil {
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -116,6 +117,7 @@ func TestGenerateMockProxies(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #include <string>
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
@@ -135,6 +137,9 @@ class InterfaceProxyInterface {
  public:
   virtual ~InterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "fi.w1.wpa_supplicant1.Interface"; }
+  static const char* BSSRemovedSignalName() { return "BSSRemoved"; }
+
   virtual bool Scan(
       const std::vector<base::ScopedFD>& in_args,
       brillo::ErrorPtr* error,
@@ -248,6 +253,12 @@ class InterfaceProxyMock : public InterfaceProxyInterface {
                                                    const std::string&)>&)),
               (override));
 };
+
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<InterfaceProxyMock>,
+              "InterfaceProxyMock must mock all the methods of InterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
 }  // namespace wpa_supplicant1
 }  // namespace w1
 }  // namespace fi
@@ -258,6 +269,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "EmptyInterface"; }
+
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
 };
@@ -275,6 +288,12 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
   MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
 };
 
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<EmptyInterfaceProxyMock>,
+              "EmptyInterfaceProxyMock must mock all the methods of EmptyInterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+
 #endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 `
 
@@ -294,7 +313,7 @@ func TestGenerateMockProxiesEmpty(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := GenerateMock(introspections, out, "/tmp/mock.h", "", sc); err != nil {
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -303,6 +322,7 @@ func TestGenerateMockProxiesEmpty(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #include <string>
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
@@ -318,6 +338,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "EmptyInterface"; }
+
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
 };
@@ -335,6 +357,12 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
   MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
 };
 
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<EmptyInterfaceProxyMock>,
+              "EmptyInterfaceProxyMock must mock all the methods of EmptyInterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+
 #endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 `
 
@@ -354,7 +382,7 @@ func TestGenerateMockProxiesWithProxyPath(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc); err != nil {
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -363,6 +391,7 @@ func TestGenerateMockProxiesWithProxyPath(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #include <string>
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
@@ -386,6 +415,261 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
   MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
 };
 
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<EmptyInterfaceProxyMock>,
+              "EmptyInterfaceProxyMock must mock all the methods of EmptyInterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateMockProxiesWithProxyConcepts(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "test.Interface",
+		Methods: []introspect.Method{{
+			Name: "Poke",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "x"},
+			},
+		}},
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{itf},
+	}}
+
+	sc := serviceconfig.Config{
+		ProxyConcepts: true,
+	}
+	out := new(bytes.Buffer)
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interface mock proxies for:
+//  - test.Interface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#include <string>
+#include <type_traits>
+#include <vector>
+
+#include <base/functional/callback_forward.h>
+#include <base/logging.h>
+#include <brillo/any.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <gmock/gmock.h>
+
+#include "../proxy.h"
+
+namespace test {
+
+// Mock object for InterfaceProxyInterface.
+class InterfaceProxyMock : public InterfaceProxyInterface {
+ public:
+  InterfaceProxyMock() = default;
+  InterfaceProxyMock(const InterfaceProxyMock&) = delete;
+  InterfaceProxyMock& operator=(const InterfaceProxyMock&) = delete;
+
+  MOCK_METHOD(bool,
+              Poke,
+              (int64_t /*in_iarg1*/,
+               brillo::ErrorPtr* /*error*/,
+               int /*timeout_ms*/),
+              (override));
+  MOCK_METHOD(void,
+              PokeAsync,
+              (int64_t /*in_iarg1*/,
+               base::OnceCallback<void()> /*success_callback*/,
+               base::OnceCallback<void(brillo::Error*)> /*error_callback*/,
+               int /*timeout_ms*/),
+              (override));
+
+  MOCK_METHOD(const dbus::ObjectPath&, GetObjectPath, (), (const, override));
+  MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
+};
+
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<InterfaceProxyMock>,
+              "InterfaceProxyMock must mock all the methods of InterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+static_assert(InterfaceProxyLike<InterfaceProxyMock>,
+              "InterfaceProxyMock must provide the calls required by "
+              "InterfaceProxyLike.");
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateMockProxiesWithExpectedMethods(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "test.Interface",
+		Methods: []introspect.Method{{
+			Name: "MethodWithMixedArgs",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "x"},
+				{Name: "oarg1", Type: "q", Direction: "out"},
+				{Name: "oarg2", Type: "d", Direction: "out"},
+			},
+		}},
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{itf},
+	}}
+
+	sc := serviceconfig.Config{
+		ExpectedMethods: true,
+	}
+	out := new(bytes.Buffer)
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interface mock proxies for:
+//  - test.Interface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#include <string>
+#include <tuple>
+#include <type_traits>
+#include <vector>
+
+#include <base/functional/callback_forward.h>
+#include <base/logging.h>
+#include <base/types/expected.h>
+#include <brillo/any.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <gmock/gmock.h>
+
+#include "../proxy.h"
+
+namespace test {
+
+// Mock object for InterfaceProxyInterface.
+class InterfaceProxyMock : public InterfaceProxyInterface {
+ public:
+  InterfaceProxyMock() = default;
+  InterfaceProxyMock(const InterfaceProxyMock&) = delete;
+  InterfaceProxyMock& operator=(const InterfaceProxyMock&) = delete;
+
+  MOCK_METHOD(bool,
+              MethodWithMixedArgs,
+              (int64_t /*in_iarg1*/,
+               uint16_t* /*out_oarg1*/,
+               double* /*out_oarg2*/,
+               brillo::ErrorPtr* /*error*/,
+               int /*timeout_ms*/),
+              (override));
+  MOCK_METHOD(void,
+              MethodWithMixedArgsAsync,
+              (int64_t /*in_iarg1*/,
+               (base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)>) /*success_callback*/,
+               base::OnceCallback<void(brillo::Error*)> /*error_callback*/,
+               int /*timeout_ms*/),
+              (override));
+  MOCK_METHOD((base::expected<std::tuple<uint16_t, double>, brillo::ErrorPtr>),
+              MethodWithMixedArgsExpected,
+              (int64_t /*in_iarg1*/,
+               int /*timeout_ms*/),
+              (override));
+
+  MOCK_METHOD(const dbus::ObjectPath&, GetObjectPath, (), (const, override));
+  MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
+};
+
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<InterfaceProxyMock>,
+              "InterfaceProxyMock must mock all the methods of InterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateMockProxiesWithTemplateOverride(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "Itf",
+		Methods: []introspect.Method{
+			{
+				Name: "Ping",
+			},
+		},
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{itf},
+	}}
+
+	sc := serviceconfig.Config{}
+	overrides := genutil.TemplateOverrides{
+		"mockMethod": `{{define "mockMethod"}}
+
+  // Custom mock for {{.Name}}.
+{{- end}}`,
+	}
+	out := new(bytes.Buffer)
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, overrides); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interface mock proxies for:
+//  - Itf
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#include <string>
+#include <type_traits>
+#include <vector>
+
+#include <base/functional/callback_forward.h>
+#include <base/logging.h>
+#include <brillo/any.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <gmock/gmock.h>
+
+#include "../proxy.h"
+
+
+// Mock object for ItfProxyInterface.
+class ItfProxyMock : public ItfProxyInterface {
+ public:
+  ItfProxyMock() = default;
+  ItfProxyMock(const ItfProxyMock&) = delete;
+  ItfProxyMock& operator=(const ItfProxyMock&) = delete;
+
+  // Custom mock for Ping.
+
+  MOCK_METHOD(const dbus::ObjectPath&, GetObjectPath, (), (const, override));
+  MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
+};
+
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<ItfProxyMock>,
+              "ItfProxyMock must mock all the methods of ItfProxyInterface; "
+              "regenerate it along with the proxy header.");
+
 #endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 `
 
@@ -506,7 +790,7 @@ func TestGenerateMockProxiesWithMethods(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc); err != nil {
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -515,6 +799,7 @@ func TestGenerateMockProxiesWithMethods(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #include <string>
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
@@ -577,7 +862,7 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
               (override));
   MOCK_METHOD(void,
               MethodWithOutArgsAsync,
-              ((base::OnceCallback<void(int64_t /*oarg1*/, const std::vector<uint8_t>& /*oarg2*/, const std::tuple<int32_t, base::ScopedFD>& /*oarg3*/, const ResponseProto& /*oprotoArg*/)>) /*success_callback*/,
+              (base::OnceCallback<void(MethodWithOutArgsResponse)> /*success_callback*/,
                base::OnceCallback<void(brillo::Error*)> /*error_callback*/,
                int /*timeout_ms*/),
               (override));
@@ -730,6 +1015,106 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
   MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
 };
 
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<EmptyInterfaceProxyMock>,
+              "EmptyInterfaceProxyMock must mock all the methods of EmptyInterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateMockProxiesWithDefaultLastInput(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "test.Interface",
+		Methods: []introspect.Method{{
+			Name: "MethodWithOptionalArg",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "s"},
+				{Name: "iarg2", Type: "u"},
+				{Name: "oarg1", Type: "x", Direction: "out"},
+			},
+			Annotations: []introspect.Annotation{
+				{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+			},
+		}},
+	}
+
+	introspections := []introspect.Introspection{{
+		Name:       "/test/Object",
+		Interfaces: []introspect.Interface{itf},
+	}}
+
+	sc := serviceconfig.Config{
+		ServiceName: "test.ServiceName",
+	}
+	out := new(bytes.Buffer)
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interface mock proxies for:
+//  - test.Interface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
+#include <string>
+#include <type_traits>
+#include <vector>
+
+#include <base/functional/callback_forward.h>
+#include <base/logging.h>
+#include <brillo/any.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <gmock/gmock.h>
+
+#include "proxy.h"
+
+namespace test {
+
+// Mock object for InterfaceProxyInterface.
+class InterfaceProxyMock : public InterfaceProxyInterface {
+ public:
+  InterfaceProxyMock() = default;
+  InterfaceProxyMock(const InterfaceProxyMock&) = delete;
+  InterfaceProxyMock& operator=(const InterfaceProxyMock&) = delete;
+
+  using InterfaceProxyInterface::MethodWithOptionalArg;
+  using InterfaceProxyInterface::MethodWithOptionalArgAsync;
+
+  MOCK_METHOD(bool,
+              MethodWithOptionalArg,
+              (const std::string& /*in_iarg1*/,
+               uint32_t /*in_iarg2*/,
+               int64_t* /*out_oarg1*/,
+               brillo::ErrorPtr* /*error*/,
+               int /*timeout_ms*/),
+              (override));
+  MOCK_METHOD(void,
+              MethodWithOptionalArgAsync,
+              (const std::string& /*in_iarg1*/,
+               uint32_t /*in_iarg2*/,
+               base::OnceCallback<void(int64_t /*oarg1*/)> /*success_callback*/,
+               base::OnceCallback<void(brillo::Error*)> /*error_callback*/,
+               int /*timeout_ms*/),
+              (override));
+
+  MOCK_METHOD(const dbus::ObjectPath&, GetObjectPath, (), (const, override));
+  MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
+};
+
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<InterfaceProxyMock>,
+              "InterfaceProxyMock must mock all the methods of InterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+}  // namespace test
+
 #endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 `
 
@@ -781,7 +1166,7 @@ func TestGenerateMockProxiesWithSignals(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc); err != nil {
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -790,6 +1175,7 @@ func TestGenerateMockProxiesWithSignals(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #include <string>
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
@@ -837,6 +1223,12 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
   MOCK_METHOD(dbus::ObjectProxy*, GetObjectProxy, (), (const, override));
 };
 
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<EmptyInterfaceProxyMock>,
+              "EmptyInterfaceProxyMock must mock all the methods of EmptyInterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+
 #endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 `
 
@@ -870,7 +1262,7 @@ func TestGenerateMockProxiesWithProperties(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc); err != nil {
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -879,6 +1271,7 @@ func TestGenerateMockProxiesWithProperties(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #include <string>
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
@@ -918,6 +1311,12 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
               (override));
 };
 
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<EmptyInterfaceProxyMock>,
+              "EmptyInterfaceProxyMock must mock all the methods of EmptyInterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+
 #endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 `
 
@@ -955,7 +1354,7 @@ func TestGenerateMockProxiesWithPropertiesAndObjectManager(t *testing.T) {
 		},
 	}
 	out := new(bytes.Buffer)
-	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc); err != nil {
+	if err := GenerateMock(introspections, out, "/tmp/mock.h", "../proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -964,6 +1363,7 @@ func TestGenerateMockProxiesWithPropertiesAndObjectManager(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 #include <string>
+#include <type_traits>
 #include <vector>
 
 #include <base/functional/callback_forward.h>
@@ -1003,6 +1403,12 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
               (override));
 };
 
+// Fails here, rather than in the tests using the mock, if the interface
+// gained methods which the mock doesn't override.
+static_assert(!std::is_abstract_v<EmptyInterfaceProxyMock>,
+              "EmptyInterfaceProxyMock must mock all the methods of EmptyInterfaceProxyInterface; "
+              "regenerate it along with the proxy header.");
+
 #endif  // ____CHROMEOS_DBUS_BINDING___TMP_MOCK_H
 `
 
@@ -1010,3 +1416,30 @@ class EmptyInterfaceProxyMock : public EmptyInterfaceProxyInterface {
 		t.Errorf("Generate failed (-got +want):\n%s", diff)
 	}
 }
+
+// TestGenerateMockProxiesTemplateOverrideFields tests that the templates are
+// accepted as overrides of themselves, and that overrides referring to
+// fields the views don't have are rejected.
+func TestGenerateMockProxiesTemplateOverrideFields(t *testing.T) {
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{{
+			Name:    "Itf",
+			Methods: []introspect.Method{{Name: "Ping"}},
+		}},
+	}}
+
+	overrides := genutil.TemplateOverrides{
+		"proxyInterface": proxyInterfaceTemplate,
+		"mockMethod":     mockMethodTemplate,
+	}
+	if err := GenerateMock(introspections, new(bytes.Buffer), "/tmp/mock.h", "", serviceconfig.Config{}, overrides); err != nil {
+		t.Errorf("GenerateMock with the templates as overrides got error, want nil: %v", err)
+	}
+
+	overrides = genutil.TemplateOverrides{
+		"proxyInterface": `{{define "proxyInterface"}}{{range .Itf.Annotations}}{{.Name}}{{end}}{{end}}`,
+	}
+	if err := GenerateMock(introspections, new(bytes.Buffer), "/tmp/mock.h", "", serviceconfig.Config{}, overrides); err == nil {
+		t.Error("GenerateMock with an override referring to a missing field unexpectedly succeeded")
+	}
+}
@@ -6,7 +6,9 @@
 package proxy
 
 import (
+	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"text/template"
 
@@ -15,33 +17,42 @@ import (
 	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
 )
 
+type templateArgs struct {
+	Introspects []introspectionView
+	// Includes are the headers declaring the custom types of introspects.
+	Includes                 []string
+	HasAsyncResponses        bool
+	InterfacesWithProperties []interfaceView
+	HeaderGuard              string
+	// ModuleName is set when generating a C++20 module interface unit.
+	ModuleName           string
+	ServiceName          string
+	FallbackServiceNames []string
+	// ServiceNameParam is true if the constructors take the service name,
+	// i.e. if there is none, or if it is resolved among fallbacks.
+	ServiceNameParam  bool
+	ObjectManagerName string
+	ObjectManagerPath string
+	ExpectedMethods   bool
+	ProxyFactories    bool
+	ProxyTraits       bool
+	ProxyConcepts     bool
+}
+
 var funcMap = template.FuncMap{
-	"add":                             func(a, b int) int { return a + b },
-	"extractInterfacesWithProperties": extractInterfacesWithProperties,
-	"extractNameSpaces":               genutil.ExtractNameSpaces,
-	"formatComment":                   genutil.FormatComment,
-	"makeFullItfName":                 genutil.MakeFullItfName,
-	"makeFullProxyName":               genutil.MakeFullProxyName,
-	"makeFullProxyInterfaceName":      genutil.MakeFullProxyInterfaceName,
-	"makeMethodParams":                makeMethodParams,
-	"makeMethodCallbackType":          makeMethodCallbackType,
-	"makeMockMethodParams":            makeMockMethodParams,
-	"makeProxyInterfaceArgs":          makeProxyInterfaceArgs,
-	"makeProxyInterfaceName":          genutil.MakeProxyInterfaceName,
-	"makeProxyName":                   genutil.MakeProxyName,
-	"makePropertyVariableName": func(p *introspect.Property) string {
-		return p.VariableName()
-	},
-	"makePropertyBaseTypeExtract": func(p *introspect.Property) (string, error) {
-		return p.BaseType()
-	},
-	"makeProxyInArgTypeProxy": func(p *introspect.Property) (string, error) {
-		return p.InArgType()
-	},
-	"makeSignalCallbackType": makeSignalCallbackType,
-	"makeTypeName":           genutil.MakeTypeName,
-	"makeVariableName":       genutil.MakeVariableName,
-	"nindent":                genutil.Nindent,
+	"add":                        func(a, b int) int { return a + b },
+	"extractNameSpaces":          genutil.ExtractNameSpaces,
+	"formatComment":              genutil.FormatComment,
+	"makeFullItfName":            genutil.MakeFullItfName,
+	"makeFullProxyName":          genutil.MakeFullProxyName,
+	"makeFullProxyInterfaceName": genutil.MakeFullProxyInterfaceName,
+	"makeProxyInterfaceArgs":     makeProxyInterfaceArgs,
+	"makeProxyInterfaceName":     genutil.MakeProxyInterfaceName,
+	"makeProxyName":              genutil.MakeProxyName,
+	"makeServiceNameCandidates":  makeServiceNameCandidates,
+	"makeTypeName":               genutil.MakeTypeName,
+	"makeVariableName":           genutil.MakeVariableName,
+	"nindent":                    genutil.Nindent,
 	"trimLeft": func(cutset, s string) string {
 		// Swap the args to fit with template's context.
 		return strings.TrimLeft(s, cutset)
@@ -50,23 +61,53 @@ var funcMap = template.FuncMap{
 	"reverse": genutil.Reverse,
 }
 
+// dotTypes are the types of the data the templates of the proxy and the mock
+// are executed on, which their overrides are checked against.
+var dotTypes = genutil.DotTypes{
+	"fileStartTmpl":      reflect.TypeOf(templateArgs{}),
+	"fileBodyStartTmpl":  reflect.TypeOf(templateArgs{}),
+	"fileEndTmpl":        reflect.TypeOf(templateArgs{}),
+	"proxyInterface":     reflect.TypeOf(proxyInterfaceArgs{}),
+	"resolveServiceName": reflect.TypeOf(templateArgs{}),
+	"mockMethod":         reflect.TypeOf(methodView{}),
+}
+
 const (
 	templateText = `// Automatic generation of D-Bus interfaces:
 {{range .Introspects}}{{range .Interfaces -}}
 //  - {{.Name}}
 {{end}}{{end -}}
 
-#ifndef {{.HeaderGuard}}
-#define {{.HeaderGuard}}
+{{template "fileStartTmpl" .}}
+{{- if .ProxyConcepts}}#include <concepts>
+{{end}}
+{{- if .ProxyTraits}}#include <cstddef>
+{{end -}}
 #include <memory>
+#include <optional>
 #include <string>
+{{- if .ExpectedMethods}}
+#include <tuple>
+{{- end}}
 #include <vector>
 
 #include <base/files/scoped_file.h>
 #include <base/functional/bind.h>
 #include <base/functional/callback.h>
+{{- if .HasAsyncResponses}}
+#include <base/functional/callback_helpers.h>
+{{- end}}
+{{- if .FallbackServiceNames}}
+#include <base/location.h>
+{{- end}}
 #include <base/logging.h>
 #include <base/memory/ref_counted.h>
+{{- if .FallbackServiceNames}}
+#include <base/task/task_runner.h>
+{{- end}}
+{{- if .ExpectedMethods}}
+#include <base/types/expected.h>
+{{- end}}
 #include <brillo/any.h>
 #include <brillo/dbus/dbus_method_invoker.h>
 #include <brillo/dbus/dbus_property.h>
@@ -78,6 +119,9 @@ const (
 #include <dbus/object_manager.h>
 #include <dbus/object_path.h>
 #include <dbus/object_proxy.h>
+{{- range .Includes}}
+#include <{{.}}>
+{{- end}}{{template "fileBodyStartTmpl" .}}
 {{if .ObjectManagerName}}
 {{range extractNameSpaces .ObjectManagerName -}}
 namespace {{.}} {
@@ -107,22 +151,22 @@ class {{$proxyName}} final : public {{$itfName}} {
                             "{{.Name}}",
                             callback} {
 {{- range .Properties}}
-{{- $name := makePropertyVariableName . | makeVariableName}}
+{{- $name := .VarName}}
       RegisterProperty({{.Name}}Name(), &{{$name}});
 {{- end}}
     }
     PropertySet(const PropertySet&) = delete;
     PropertySet& operator=(const PropertySet&) = delete;
 {{range .Properties}}
-{{- $name := makePropertyVariableName . | makeVariableName}}
-    brillo::dbus_utils::Property<{{makePropertyBaseTypeExtract .}}> {{$name}};
+{{- $name := .VarName}}
+    brillo::dbus_utils::Property<{{.ValueType}}> {{$name}};
 {{- end}}
 
   };
 {{end}}
 
 {{- /* TODO(crbug.com/983008): Simplify the format into Chromium style. */ -}}
-{{- if and $.ServiceName $introspect.Name (or (not $.ObjectManagerName) (not .Properties))}}
+{{- if and (not $.ServiceNameParam) $introspect.Name (or (not $.ObjectManagerName) (not .Properties))}}
   {{$proxyName}}(const scoped_refptr<dbus::Bus>& bus) :
       bus_{bus},
       dbus_object_proxy_{
@@ -131,7 +175,7 @@ class {{$proxyName}} final : public {{$itfName}} {
 {{- else}}
   {{$proxyName}}(
       const scoped_refptr<dbus::Bus>& bus
-{{- if not $.ServiceName}},
+{{- if $.ServiceNameParam}},
       const std::string& service_name
 {{- end}}
 {{- if not $introspect.Name}},
@@ -141,7 +185,7 @@ class {{$proxyName}} final : public {{$itfName}} {
       PropertySet* property_set
 {{- end}}) :
           bus_{bus},
-{{- if not $.ServiceName}}
+{{- if $.ServiceNameParam}}
           service_name_{service_name},
 {{- end}}
 {{- if not $introspect.Name}}
@@ -157,13 +201,94 @@ class {{$proxyName}} final : public {{$itfName}} {
 
   {{$proxyName}}(const {{$proxyName}}&) = delete;
   {{$proxyName}}& operator=(const {{$proxyName}}&) = delete;
+{{- if $.FallbackServiceNames}}
+
+{{template "resolveServiceName" $}}
+{{- end}}
+{{- with $tmpl := $itf.ObjectPathTemplate}}
+{{- if not (and $.ObjectManagerName $itf.Properties)}}
+
+  // Returns the path of the object at {{.Prefix}}{{"{"}}{{.Param}}{{"}"}}{{.Suffix}}, with |{{.Param}}|
+  // escaped into a single element of the path.
+  static dbus::ObjectPath MakeObjectPath(const std::string& {{.Param}}) {
+    return dbus::ObjectPath{"{{.Prefix}}" + EscapeObjectPathElement({{.Param}}){{if .Suffix}} + "{{.Suffix}}"{{end}}};
+  }
+
+  // Escapes |element| so that any string forms a valid object path element:
+  // ASCII letters and digits are kept, other bytes become _ and their two
+  // lowercase hex digits, and the empty string becomes a lone _.
+  static std::string EscapeObjectPathElement(const std::string& element) {
+    if (element.empty())
+      return "_";
+    static constexpr char kHexDigits[] = "0123456789abcdef";
+    std::string escaped;
+    for (unsigned char c : element) {
+      if ((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
+          (c >= '0' && c <= '9')) {
+        escaped.push_back(c);
+      } else {
+        escaped.push_back('_');
+        escaped.push_back(kHexDigits[c >> 4]);
+        escaped.push_back(kHexDigits[c & 0xf]);
+      }
+    }
+    return escaped;
+  }
+
+  // Creates a proxy for the instance of {{$itf.Name}} identified by |{{.Param}}|.
+  static std::unique_ptr<{{$proxyName}}> CreateForInstance(
+      const scoped_refptr<dbus::Bus>& bus,
+{{- if $.ServiceNameParam}}
+      const std::string& service_name,
+{{- end}}
+      const std::string& {{.Param}}) {
+    return std::make_unique<{{$proxyName}}>(
+        bus{{if $.ServiceNameParam}}, service_name{{end}}, MakeObjectPath({{.Param}}));
+  }
+{{- end}}
+{{- end}}
+{{- $dedicatedBus := .DedicatedBus}}
+{{- if $dedicatedBus}}
+
+  // Creates a proxy on its own connection to a bus of |bus_type|, so that
+  // blocking calls through it don't delay other users of a shared connection.
+  // The connection is shut down when the proxy is destroyed. Returns nullptr
+  // if the connection fails.
+  static std::unique_ptr<{{$proxyName}}> CreateWithDedicatedBus(
+      dbus::Bus::BusType bus_type
+{{- if not $.ServiceName}},
+      const std::string& service_name
+{{- end}}
+{{- if not $introspect.Name}},
+      const dbus::ObjectPath& object_path
+{{- end}}) {
+    dbus::Bus::Options options;
+    options.bus_type = bus_type;
+    auto bus = base::MakeRefCounted<dbus::Bus>(options);
+    if (!bus->Connect()) {
+      LOG(ERROR) << "Failed to connect a dedicated bus for {{.Name}}";
+      return nullptr;
+    }
+    auto proxy = std::make_unique<{{$proxyName}}>(
+        bus
+{{- if $.FallbackServiceNames}}, ResolveServiceName(bus)
+{{- else if not $.ServiceName}}, service_name{{end}}
+{{- if not $introspect.Name}}, object_path{{end}});
+    proxy->owns_bus_ = true;
+    return proxy;
+  }
+{{- end}}
 
   ~{{$proxyName}}() override {
+{{- if $dedicatedBus}}
+    if (owns_bus_)
+      bus_->ShutdownAndBlock();
+{{- end}}
   }
 {{- range .Signals}}
 
   void Register{{.Name}}SignalHandler(
-      {{- makeSignalCallbackType .Args | nindent 6}} signal_callback,
+      {{- .CallbackType | nindent 6}} signal_callback,
       dbus::ObjectProxy::OnConnectedCallback on_connected_callback) override {
     brillo::dbus_utils::ConnectToSignal(
         dbus_object_proxy_,
@@ -205,11 +330,38 @@ class {{$proxyName}} final : public {{$itfName}} {
 
   const PropertySet* GetProperties() const { return &(*property_set_); }
   PropertySet* GetProperties() { return &(*property_set_); }
+
+  // Copy of the cached property values. Properties which aren't valid are
+  // left empty.
+  struct PropertiesSnapshot {
+{{- range .Properties}}
+{{- $name := .VarName}}
+    std::optional<{{.ValueType}}> {{$name}};
+{{- end}}
+  };
+
+  // Returns a copy of all the cached property values, so that callers don't
+  // need to hold references into the PropertySet.
+  PropertiesSnapshot GetAllPropertiesSnapshot() const {
+    PropertiesSnapshot snapshot;
+{{- range .Properties}}
+{{- $name := .VarName}}
+    if (property_set_->{{$name}}.is_valid())
+      snapshot.{{$name}} = property_set_->{{$name}}.value();
+{{- end}}
+    return snapshot;
+  }
 {{- end}}
 
 {{- range .Methods}}
-{{- $inParams := makeMethodParams 0 .InputArguments -}}
-{{- $outParams := makeMethodParams (len .InputArguments) .OutputArguments}}
+{{- $inParams := .InParams -}}
+{{- $outParams := .OutParams}}
+{{- $methodName := .Name}}
+{{- if .DefaultLastInput}}
+
+  using {{$itfName}}::{{.Name}};
+  using {{$itfName}}::{{.Name}}Async;
+{{- end}}
 
 {{formatComment .DocString 2 -}}
 {{"  "}}bool {{.Name}}(
@@ -234,14 +386,71 @@ class {{$proxyName}} final : public {{$itfName}} {
         response.get(), error{{range $i, $param := $outParams}}, {{.Name}}{{end}});
   }
 
+{{- if $.ExpectedMethods}}
+{{- $resultType := .ExpectedResultType}}
+
+  base::expected<{{$resultType}}, brillo::ErrorPtr> {{.Name}}Expected(
+{{- range $inParams}}
+      {{.Type}} {{.Name}},
+{{- end}}
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    {{$resultType}} results;
+    brillo::ErrorPtr error;
+    if (!{{.Name}}(
+{{- range $inParams}}
+            {{.Name}},
+{{- end}}
+{{- range $i, $param := $outParams}}
+            &std::get<{{$i}}>(results),
+{{- end}}
+            &error,
+            timeout_ms)) {
+      return base::unexpected(std::move(error));
+    }
+    return results;
+  }
+{{- end}}
+
 {{formatComment .DocString 2 -}}
 {{"  "}}void {{.Name}}Async(
 {{- range $inParams}}
       {{.Type}} {{.Name}},
 {{- end}}
-      {{makeMethodCallbackType .OutputArguments}} success_callback,
+      {{.AsyncCallbackType}} success_callback,
       base::OnceCallback<void(brillo::Error*)> error_callback,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+{{- with .AsyncResponse}}
+    // Extracts the response here rather than in brillo::dbus_utils, which
+    // only hands const references to the output arguments to the callback,
+    // so the call is made on the object proxy directly.
+    dbus::MethodCall method_call("{{$itf.Name}}", "{{$methodName}}");
+    dbus::MessageWriter writer(&method_call);
+    brillo::dbus_utils::WriteDBusArgs(&writer
+{{- range $inParams}}, {{.Name}}{{end}});
+    auto split_error_callback =
+        base::SplitOnceCallback(std::move(error_callback));
+    dbus_object_proxy_->CallMethodWithErrorCallback(
+        &method_call,
+        timeout_ms,
+        base::BindOnce(
+            [](base::OnceCallback<void({{.Type}})> success_callback,
+               base::OnceCallback<void(brillo::Error*)> error_callback,
+               dbus::Response* response) {
+              {{.Type}} results;
+              brillo::ErrorPtr error;
+              if (!brillo::dbus_utils::ExtractMethodCallResults(
+                      response, &error
+{{- range .Members}}, &results.{{.Name}}{{end}})) {
+                std::move(error_callback).Run(error.get());
+                return;
+              }
+              std::move(success_callback).Run(std::move(results));
+            },
+            std::move(success_callback),
+            std::move(split_error_callback.first)),
+        base::BindOnce(&brillo::dbus_utils::TranslateErrorResponse,
+                       std::move(split_error_callback.second)));
+{{- else}}
     brillo::dbus_utils::CallMethodWithTimeout(
         timeout_ms,
         dbus_object_proxy_,
@@ -252,13 +461,14 @@ class {{$proxyName}} final : public {{$itfName}} {
 {{- range $inParams}},
         {{.Name}}
 {{- end}});
+{{- end}}
   }
 
 {{- end}}
 
 {{- range .Properties}}
-{{- $name := makePropertyVariableName . | makeVariableName -}}
-{{- $type := makeProxyInArgTypeProxy . }}
+{{- $name := .VarName -}}
+{{- $type := .ArgType}}
 
   {{$type}} {{$name}}() const override {
     return property_set_->{{$name}}.value();
@@ -285,10 +495,10 @@ class {{$proxyName}} final : public {{$itfName}} {
 {{/* blank line separator */}}
 {{- end}}
   scoped_refptr<dbus::Bus> bus_;
-{{- if $.ServiceName}}
-  const std::string service_name_{"{{$.ServiceName}}"};
-{{- else}}
+{{- if $.ServiceNameParam}}
   std::string service_name_;
+{{- else}}
+  const std::string service_name_{"{{$.ServiceName}}"};
 {{- end}}
 
 {{- if $introspect.Name}}
@@ -303,16 +513,116 @@ class {{$proxyName}} final : public {{$itfName}} {
   dbus::ObjectProxy* dbus_object_proxy_;
 {{- if and (not $.ObjectManagerName) .Properties}}
   std::unique_ptr<PropertySet> property_set_;
+{{- end}}
+{{- if $dedicatedBus}}
+  bool owns_bus_ = false;
 {{- end}}{{"\n"}}
 {{- if and $.ObjectManagerName .Properties}}
   friend class {{makeFullProxyName $.ObjectManagerName}};
 {{- end}}
 };
+{{- if and $.ProxyFactories (not (and $.ObjectManagerName .Properties))}}
+
+// Creates a {{$proxyName}}, returned as its interface so that it can be
+// injected into code depending on {{$itfName}}.
+inline std::unique_ptr<{{$itfName}}> Create{{$proxyName}}(
+    const scoped_refptr<dbus::Bus>& bus
+{{- if $.ServiceNameParam}},
+    const std::string& service_name
+{{- end}}
+{{- if not $introspect.Name}},
+    const dbus::ObjectPath& object_path
+{{- end}}) {
+  return std::make_unique<{{$proxyName}}>(
+      bus{{if $.ServiceNameParam}}, service_name{{end}}{{if not $introspect.Name}}, object_path{{end}});
+}
+{{- end}}
+{{- if $.ProxyTraits}}
+
+// Compile-time description of the {{.Name}} proxies, for utilities
+// written once for all generated proxies, e.g. retry wrappers.
+struct {{$proxyName}}Traits {
+  using Interface = {{$itfName}};
+  using Proxy = {{$proxyName}};
+  static constexpr char kInterfaceName[] = "{{.Name}}";
+  static constexpr size_t kMethodCount = {{len .Methods}};
+  static constexpr bool kHasProperties = {{if .Properties}}true{{else}}false{{end}};
+  static constexpr bool kHasSignals = {{if .Signals}}true{{else}}false{{end}};
+};
+{{- end}}
+{{- if $.ProxyConcepts}}
+
+// Satisfied by the types providing the calls of {{$itfName}}, e.g.
+// {{$proxyName}} and its mock, to constrain templates on them. The timeouts
+// are passed explicitly, since the mock doesn't declare their defaults.
+template <typename T>
+concept {{$proxyName}}Like =
+    requires(T& proxy) {
+      { proxy.GetObjectPath() } -> std::convertible_to<const dbus::ObjectPath&>;
+      { proxy.GetObjectProxy() } -> std::same_as<dbus::ObjectProxy*>;
+    }
+{{- range .Methods}} &&
+    requires(T& proxy,
+{{- range .InParams}}
+             {{.Type}} {{.Name}},
+{{- end}}
+{{- range .OutParams}}
+             {{.Type}} {{.Name}},
+{{- end}}
+             brillo::ErrorPtr* error,
+             int timeout_ms) {
+      { proxy.{{.Name}}({{range .InParams}}{{.Name}}, {{end}}{{range .OutParams}}{{.Name}}, {{end}}error, timeout_ms) } -> std::same_as<bool>;
+    }
+{{- end}}
+{{- range .Signals}} &&
+    requires(T& proxy,
+{{- .CallbackType | nindent 13}} signal_callback,
+             dbus::ObjectProxy::OnConnectedCallback on_connected_callback) {
+      proxy.Register{{.Name}}SignalHandler(signal_callback, std::move(on_connected_callback));
+    }
+{{- end}}
+{{- range .Properties}} &&
+    requires(T& proxy) {
+      { proxy.{{.VarName}}() } -> std::same_as<{{.ArgType}}>;
+      { proxy.is_{{.VarName}}_valid() } -> std::same_as<bool>;
+    }
+{{- end}};
+{{- end}}
 
 {{range extractNameSpaces .Name | reverse -}}
 }  // namespace {{.}}
 {{end}}
-{{- end}}{{end}}
+{{- end}}
+{{- if $.ProxyFactories}}{{with $introspect.BundleName}}
+{{- $bundleName := printf "%sBundle" (makeProxyName .)}}
+{{range extractNameSpaces . -}}
+namespace {{.}} {
+{{end}}
+// Proxies of all the interfaces of the {{$introspect.Name}} object.
+struct {{$bundleName}} {
+{{- range $introspect.Interfaces}}
+  std::unique_ptr<{{makeFullProxyInterfaceName .Name}}> {{makeVariableName .Name}};
+{{- end}}
+};
+
+// Creates the proxies of all the interfaces of the {{$introspect.Name}} object.
+inline {{$bundleName}} Create{{$bundleName}}(
+    const scoped_refptr<dbus::Bus>& bus
+{{- if $.ServiceNameParam}},
+    const std::string& service_name
+{{- end}}) {
+  {{$bundleName}} bundle;
+{{- range $introspect.Interfaces}}
+  bundle.{{makeVariableName .Name}} = std::make_unique<{{makeFullProxyName .Name}}>(
+      bus{{if $.ServiceNameParam}}, service_name{{end}});
+{{- end}}
+  return bundle;
+}
+
+{{range extractNameSpaces . | reverse -}}
+}  // namespace {{.}}
+{{end}}
+{{- end}}{{end}}{{end}}
 {{- if .ObjectManagerName }}
 {{- range extractNameSpaces .ObjectManagerName}}
 namespace {{.}} {
@@ -322,18 +632,18 @@ namespace {{.}} {
 class {{$className}} : public dbus::ObjectManager::Interface {
  public:
   {{$className}}(const scoped_refptr<dbus::Bus>& bus
-{{- if (not .ServiceName) }},
+{{- if .ServiceNameParam }},
   {{repeat " " (len $className)}} const std::string& service_name
 {{- end}})
       : bus_{bus},
-{{- if (not .ServiceName) }}
+{{- if .ServiceNameParam }}
         service_name_{service_name},
 {{- end}}
         dbus_object_manager_{bus->GetObjectManager(
-{{- if .ServiceName }}
-            "{{.ServiceName}}",
-{{- else}}
+{{- if .ServiceNameParam }}
             service_name,
+{{- else}}
+            "{{.ServiceName}}",
 {{- end}}
             dbus::ObjectPath{"{{.ObjectManagerPath}}"})} {
 {{- range .Introspects}}{{range .Interfaces}}
@@ -343,6 +653,10 @@ class {{$className}} : public dbus::ObjectManager::Interface {
 
   {{$className}}(const {{$className}}&) = delete;
   {{$className}}& operator=(const {{$className}}&) = delete;
+{{- if .FallbackServiceNames}}
+
+{{template "resolveServiceName" $}}
+{{- end}}
 
   ~{{$className}}() override {
 {{- range .Introspects}}{{range .Interfaces}}
@@ -394,7 +708,7 @@ class {{$className}} : public dbus::ObjectManager::Interface {
   }
 {{end}}{{end}}
  private:
-{{- $itfsWithProps := extractInterfacesWithProperties .Introspects -}}
+{{- $itfsWithProps := .InterfacesWithProperties -}}
 {{- if $itfsWithProps }}
   void OnPropertyChanged(const dbus::ObjectPath& object_path,
                          const std::string& interface_name,
@@ -430,7 +744,7 @@ class {{$className}} : public dbus::ObjectManager::Interface {
 {{- end }}
       std::unique_ptr<{{$fullProxyName}}> {{$varName}}_proxy{
         new {{$fullProxyName}}{bus_
-{{- if (not $.ServiceName)}}, service_name_{{end}}
+{{- if $.ServiceNameParam}}, service_name_{{end}}
 {{- if (not $introspect.Name)}}, object_path{{end}}
 {{- if .Properties}}, property_set{{end}}}
       };
@@ -480,7 +794,7 @@ class {{$className}} : public dbus::ObjectManager::Interface {
   }
 
   scoped_refptr<dbus::Bus> bus_;
-{{- if not $.ServiceName }}
+{{- if $.ServiceNameParam }}
   std::string service_name_;
 {{- end }}
   dbus::ObjectManager* dbus_object_manager_;
@@ -498,40 +812,126 @@ class {{$className}} : public dbus::ObjectManager::Interface {
 }  // namespace {{.}}
 {{- end}}
 {{end}}
-#endif  // {{.HeaderGuard}}
-`
+{{template "fileEndTmpl" .}}`
+
+	// resolveServiceNameTemplate picks the service name to connect to among
+	// the primary and fallback ones. The constructors take the service name
+	// it picks, as picking it blocks on the bus.
+	resolveServiceNameTemplate = `{{define "resolveServiceName" -}}
+{{"  "}}// Returns the first of the candidate service names which currently has an
+  // owner on |bus|, or the primary service name if none of them has. Blocks
+  // on |bus|, so it must run on its D-Bus thread, if it has one; see
+  // ResolveServiceNameAsync() otherwise.
+  static std::string ResolveServiceName(const scoped_refptr<dbus::Bus>& bus) {
+    for (const char* service_name : {
+{{- range $i, $name := makeServiceNameCandidates .ServiceName .FallbackServiceNames}}
+{{- if $i}},{{end}}
+             "{{$name}}"
+{{- end}}}) {
+      if (!bus->GetServiceOwnerAndBlock(service_name,
+                                        dbus::Bus::SUPPRESS_ERRORS).empty()) {
+        return service_name;
+      }
+    }
+    return "{{.ServiceName}}";
+  }
+
+  // Same as ResolveServiceName(), but resolves the service name on the D-Bus
+  // thread of |bus| and passes it to |callback| on the current sequence. Call
+  // it again, e.g. when the owner of a candidate changes, to pick the service
+  // name anew.
+  static void ResolveServiceNameAsync(
+      const scoped_refptr<dbus::Bus>& bus,
+      base::OnceCallback<void(const std::string&)> callback) {
+    bus->GetDBusTaskRunner()->PostTaskAndReplyWithResult(
+        FROM_HERE, base::BindOnce(&ResolveServiceName, bus),
+        std::move(callback));
+  }
+{{- end}}`
 )
 
 // Generate outputs the header file containing proxy interfaces into f.
-// outputFilePath is used to make a unique header guard.
-func Generate(introspects []introspect.Introspection, f io.Writer, outputFilePath string, config serviceconfig.Config) error {
+// outputFilePath is used to make a unique header guard. Templates are replaced
+// by their overrides, if any.
+func Generate(introspects []introspect.Introspection, f io.Writer, outputFilePath string, config serviceconfig.Config, overrides genutil.TemplateOverrides) error {
+	return generate(introspects, f, genutil.GenerateHeaderGuard(outputFilePath), "", config, overrides)
+}
+
+// GenerateModule is like Generate, but outputs an experimental C++20 module
+// interface unit named moduleName instead of a header.
+func GenerateModule(introspects []introspect.Introspection, f io.Writer, moduleName string, config serviceconfig.Config, overrides genutil.TemplateOverrides) error {
+	return generate(introspects, f, "", moduleName, config, overrides)
+}
+
+func generate(introspects []introspect.Introspection, f io.Writer, headerGuard, moduleName string, config serviceconfig.Config, overrides genutil.TemplateOverrides) error {
+	if err := checkDedicatedBusInterfaces(introspects, config.DedicatedBusInterfaces); err != nil {
+		return err
+	}
+
+	views, err := makeIntrospectionViews(introspects, config)
+	if err != nil {
+		return err
+	}
+
 	tmpl, err := template.New("proxy").Funcs(funcMap).Parse(templateText)
 	if err != nil {
 		return err
 	}
 
+	if _, err := tmpl.Parse(genutil.FileBoundaryTemplates); err != nil {
+		return err
+	}
+
 	if _, err := tmpl.Parse(proxyInterfaceTemplate); err != nil {
 		return err
 	}
 
+	if _, err := tmpl.Parse(resolveServiceNameTemplate); err != nil {
+		return err
+	}
+
+	if err := overrides.Apply(tmpl, dotTypes); err != nil {
+		return err
+	}
+
 	var omName, omPath string
 	if config.ObjectManager != nil {
 		omName = config.ObjectManager.Name
 		omPath = config.ObjectManager.ObjectPath
 	}
 
-	headerGuard := genutil.GenerateHeaderGuard(outputFilePath)
-	return tmpl.Execute(f, struct {
-		Introspects       []introspect.Introspection
-		HeaderGuard       string
-		ServiceName       string
-		ObjectManagerName string
-		ObjectManagerPath string
-	}{
-		Introspects:       introspects,
-		HeaderGuard:       headerGuard,
-		ServiceName:       config.ServiceName,
-		ObjectManagerName: omName,
-		ObjectManagerPath: omPath,
+	return tmpl.Execute(f, templateArgs{
+		Introspects:              views,
+		Includes:                 genutil.CustomTypeIncludes(introspects),
+		HasAsyncResponses:        hasAsyncResponses(views),
+		InterfacesWithProperties: extractInterfacesWithProperties(views),
+		HeaderGuard:              headerGuard,
+		ModuleName:               moduleName,
+		ServiceName:              config.ServiceName,
+		FallbackServiceNames:     config.FallbackServiceNames,
+		ServiceNameParam:         config.ServiceName == "" || len(config.FallbackServiceNames) > 0,
+		ObjectManagerName:        omName,
+		ObjectManagerPath:        omPath,
+		ExpectedMethods:          config.ExpectedMethods,
+		ProxyFactories:           config.ProxyFactories,
+		ProxyTraits:              config.ProxyTraits,
+		ProxyConcepts:            config.ProxyConcepts,
 	})
 }
+
+// checkDedicatedBusInterfaces returns an error if an interface requiring a
+// dedicated bus isn't one of introspects, which is likely a typo.
+func checkDedicatedBusInterfaces(introspects []introspect.Introspection, dedicatedBusInterfaces []string) error {
+	names := make(map[string]bool)
+	for _, ii := range introspects {
+		for _, itf := range ii.Interfaces {
+			names[itf.Name] = true
+		}
+	}
+	for _, name := range dedicatedBusInterfaces {
+		if !names[name] {
+			return fmt.Errorf("dedicated bus interface %q is not defined", name)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"testing"
 
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
 	"go.chromium.org/chromiumos/dbusbindings/introspect"
 	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
 
@@ -106,7 +107,7 @@ func TestGenerateProxies(t *testing.T) {
 		},
 	}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -116,6 +117,7 @@ func TestGenerateProxies(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -152,6 +154,9 @@ class InterfaceProxyInterface {
  public:
   virtual ~InterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "fi.w1.wpa_supplicant1.Interface"; }
+  static const char* BSSRemovedSignalName() { return "BSSRemoved"; }
+
   virtual bool Scan(
       const std::vector<base::ScopedFD>& in_args,
       brillo::ErrorPtr* error,
@@ -274,6 +279,24 @@ class InterfaceProxy final : public InterfaceProxyInterface {
   const PropertySet* GetProperties() const { return &(*property_set_); }
   PropertySet* GetProperties() { return &(*property_set_); }
 
+  // Copy of the cached property values. Properties which aren't valid are
+  // left empty.
+  struct PropertiesSnapshot {
+    std::optional<brillo::VariantDictionary> capabilities;
+    std::optional<uint32_t> bluetooth_class;
+  };
+
+  // Returns a copy of all the cached property values, so that callers don't
+  // need to hold references into the PropertySet.
+  PropertiesSnapshot GetAllPropertiesSnapshot() const {
+    PropertiesSnapshot snapshot;
+    if (property_set_->capabilities.is_valid())
+      snapshot.capabilities = property_set_->capabilities.value();
+    if (property_set_->bluetooth_class.is_valid())
+      snapshot.bluetooth_class = property_set_->bluetooth_class.value();
+    return snapshot;
+  }
+
   bool Scan(
       const std::vector<base::ScopedFD>& in_args,
       brillo::ErrorPtr* error,
@@ -378,6 +401,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "EmptyInterface"; }
+
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
 };
@@ -636,7 +661,7 @@ func TestGenerateProxiesEmpty(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -645,6 +670,7 @@ func TestGenerateProxiesEmpty(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -672,6 +698,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
 };
@@ -730,7 +758,7 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
 	}
 }
 
-func TestGenerateProxiesWithServiceName(t *testing.T) {
+func TestGenerateProxiesWithDedicatedBus(t *testing.T) {
 	emptyItf := introspect.Interface{
 		Name: "test.EmptyInterface",
 	}
@@ -740,10 +768,10 @@ func TestGenerateProxiesWithServiceName(t *testing.T) {
 	}}
 
 	sc := serviceconfig.Config{
-		ServiceName: "test.ServiceName",
+		DedicatedBusInterfaces: []string{"test.EmptyInterface"},
 	}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -752,6 +780,7 @@ func TestGenerateProxiesWithServiceName(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -779,6 +808,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
 };
@@ -792,8 +823,10 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
  public:
   EmptyInterfaceProxy(
       const scoped_refptr<dbus::Bus>& bus,
+      const std::string& service_name,
       const dbus::ObjectPath& object_path) :
           bus_{bus},
+          service_name_{service_name},
           object_path_{object_path},
           dbus_object_proxy_{
               bus_->GetObjectProxy(service_name_, object_path_)} {
@@ -802,7 +835,30 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
   EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
   EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
 
+  // Creates a proxy on its own connection to a bus of |bus_type|, so that
+  // blocking calls through it don't delay other users of a shared connection.
+  // The connection is shut down when the proxy is destroyed. Returns nullptr
+  // if the connection fails.
+  static std::unique_ptr<EmptyInterfaceProxy> CreateWithDedicatedBus(
+      dbus::Bus::BusType bus_type,
+      const std::string& service_name,
+      const dbus::ObjectPath& object_path) {
+    dbus::Bus::Options options;
+    options.bus_type = bus_type;
+    auto bus = base::MakeRefCounted<dbus::Bus>(options);
+    if (!bus->Connect()) {
+      LOG(ERROR) << "Failed to connect a dedicated bus for test.EmptyInterface";
+      return nullptr;
+    }
+    auto proxy = std::make_unique<EmptyInterfaceProxy>(
+        bus, service_name, object_path);
+    proxy->owns_bus_ = true;
+    return proxy;
+  }
+
   ~EmptyInterfaceProxy() override {
+    if (owns_bus_)
+      bus_->ShutdownAndBlock();
   }
 
   void ReleaseObjectProxy(base::OnceClosure callback) {
@@ -819,9 +875,10 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
 
  private:
   scoped_refptr<dbus::Bus> bus_;
-  const std::string service_name_{"test.ServiceName"};
+  std::string service_name_;
   dbus::ObjectPath object_path_;
   dbus::ObjectProxy* dbus_object_proxy_;
+  bool owns_bus_ = false;
 
 };
 
@@ -835,27 +892,63 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
 	}
 }
 
-func TestGenerateProxiesWithNodeName(t *testing.T) {
-	emptyItf := introspect.Interface{
-		Name: "test.EmptyInterface",
+func TestGenerateProxiesWithUnknownDedicatedBusInterface(t *testing.T) {
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{{Name: "test.EmptyInterface"}},
+	}}
+
+	sc := serviceconfig.Config{
+		DedicatedBusInterfaces: []string{"test.MistypedInterface"},
+	}
+	if err := Generate(introspections, new(bytes.Buffer), "/tmp/proxy.h", sc, nil); err == nil {
+		t.Error("Generate got nil error, want error")
 	}
+}
 
+func TestGenerateProxiesWithInvalidTypeWritesNothing(t *testing.T) {
 	introspections := []introspect.Introspection{{
-		Name:       "test.node.Name",
-		Interfaces: []introspect.Interface{emptyItf},
+		Interfaces: []introspect.Interface{{
+			Name: "test.Itf",
+			Methods: []introspect.Method{{
+				Name: "Frob",
+				Args: []introspect.MethodArg{{Name: "x", Direction: "in", Type: "a"}},
+			}},
+		}},
+	}}
+
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", serviceconfig.Config{}, nil); err == nil {
+		t.Error("Generate got nil error, want error")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Generate wrote %q before failing, want nothing", out.String())
+	}
+}
+
+func TestGenerateProxiesWithObjectPathTemplate(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "test.Device",
+		Annotations: []introspect.Annotation{
+			{Name: "org.chromium.DBus.Interface.ObjectPathTemplate", Value: "/org/chromium/Device/{id}/Port"},
+		},
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{itf},
 	}}
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
 	const want = `// Automatic generation of D-Bus interfaces:
-//  - test.EmptyInterface
+//  - test.Device
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -878,10 +971,12 @@ func TestGenerateProxiesWithNodeName(t *testing.T) {
 
 namespace test {
 
-// Abstract interface proxy for test::EmptyInterface.
-class EmptyInterfaceProxyInterface {
+// Abstract interface proxy for test::Device.
+class DeviceProxyInterface {
  public:
-  virtual ~EmptyInterfaceProxyInterface() = default;
+  virtual ~DeviceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.Device"; }
 
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
@@ -891,22 +986,60 @@ class EmptyInterfaceProxyInterface {
 
 namespace test {
 
-// Interface proxy for test::EmptyInterface.
-class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
+// Interface proxy for test::Device.
+class DeviceProxy final : public DeviceProxyInterface {
  public:
-  EmptyInterfaceProxy(
+  DeviceProxy(
       const scoped_refptr<dbus::Bus>& bus,
-      const std::string& service_name) :
+      const std::string& service_name,
+      const dbus::ObjectPath& object_path) :
           bus_{bus},
           service_name_{service_name},
+          object_path_{object_path},
           dbus_object_proxy_{
               bus_->GetObjectProxy(service_name_, object_path_)} {
   }
 
-  EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
-  EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
+  DeviceProxy(const DeviceProxy&) = delete;
+  DeviceProxy& operator=(const DeviceProxy&) = delete;
+
+  // Returns the path of the object at /org/chromium/Device/{id}/Port, with |id|
+  // escaped into a single element of the path.
+  static dbus::ObjectPath MakeObjectPath(const std::string& id) {
+    return dbus::ObjectPath{"/org/chromium/Device/" + EscapeObjectPathElement(id) + "/Port"};
+  }
+
+  // Escapes |element| so that any string forms a valid object path element:
+  // ASCII letters and digits are kept, other bytes become _ and their two
+  // lowercase hex digits, and the empty string becomes a lone _.
+  static std::string EscapeObjectPathElement(const std::string& element) {
+    if (element.empty())
+      return "_";
+    static constexpr char kHexDigits[] = "0123456789abcdef";
+    std::string escaped;
+    for (unsigned char c : element) {
+      if ((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') ||
+          (c >= '0' && c <= '9')) {
+        escaped.push_back(c);
+      } else {
+        escaped.push_back('_');
+        escaped.push_back(kHexDigits[c >> 4]);
+        escaped.push_back(kHexDigits[c & 0xf]);
+      }
+    }
+    return escaped;
+  }
 
-  ~EmptyInterfaceProxy() override {
+  // Creates a proxy for the instance of test.Device identified by |id|.
+  static std::unique_ptr<DeviceProxy> CreateForInstance(
+      const scoped_refptr<dbus::Bus>& bus,
+      const std::string& service_name,
+      const std::string& id) {
+    return std::make_unique<DeviceProxy>(
+        bus, service_name, MakeObjectPath(id));
+  }
+
+  ~DeviceProxy() override {
   }
 
   void ReleaseObjectProxy(base::OnceClosure callback) {
@@ -924,7 +1057,7 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
  private:
   scoped_refptr<dbus::Bus> bus_;
   std::string service_name_;
-  const dbus::ObjectPath object_path_{"test.node.Name"};
+  dbus::ObjectPath object_path_;
   dbus::ObjectProxy* dbus_object_proxy_;
 
 };
@@ -939,80 +1072,30 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
 	}
 }
 
-func TestGenerateProxiesWithMethods(t *testing.T) {
-	emptyItf := introspect.Interface{
-		Name: "test.EmptyInterface",
-		Methods: []introspect.Method{{
-			Name: "MethodNoArg",
-			Args: []introspect.MethodArg{},
-		}, {
-			Name: "MethodWithInArgs",
-			Args: []introspect.MethodArg{
-				{Name: "iarg1", Type: "x"},
-				{Name: "iarg2", Type: "ay"},
-				{Name: "iarg3", Type: "(ih)"},
-				{
-					Name: "iprotoArg",
-					Type: "ay",
-					Annotation: introspect.Annotation{
-						Name:  "org.chromium.DBus.Argument.ProtobufClass",
-						Value: "RequestProto",
-					},
-				},
-			},
-		}, {
-			Name: "MethodWithOutArgs",
-			Args: []introspect.MethodArg{
-				{Name: "oarg1", Type: "x", Direction: "out"},
-				{Name: "oarg2", Type: "ay", Direction: "out"},
-				{Name: "oarg3", Type: "(ih)", Direction: "out"},
-				{
-					Name:      "oprotoArg",
-					Type:      "ay",
-					Direction: "out",
-					Annotation: introspect.Annotation{
-						Name:  "org.chromium.DBus.Argument.ProtobufClass",
-						Value: "ResponseProto",
-					},
-				},
-			},
-		}, {
-			Name: "MethodWithBothArgs",
-			Args: []introspect.MethodArg{
-				{Name: "iarg1", Type: "x"},
-				{Name: "iarg2", Type: "ay"},
-				{Name: "oarg1", Type: "q", Direction: "out"},
-				{Name: "oarg2", Type: "d", Direction: "out"},
-			},
-		}, {
-			Name: "MethodWithMixedArgs",
-			Args: []introspect.MethodArg{
-				{Name: "iarg1", Type: "x"},
-				{Name: "oarg1", Type: "q", Direction: "out"},
-				{Name: "iarg2", Type: "ay"},
-				{Name: "oarg2", Type: "d", Direction: "out"},
-			},
-		}, {
-			Name:      "MethodWithDoc",
-			DocString: "\n        method doc\n      ",
-		}},
-	}
-
+func TestGenerateProxiesWithProxyFactories(t *testing.T) {
 	introspections := []introspect.Introspection{{
-		Interfaces: []introspect.Interface{emptyItf},
+		Name: "/test/Frob",
+		Interfaces: []introspect.Interface{
+			{Name: "test.Frob"},
+			{Name: "test.FrobDebug"},
+		},
 	}}
 
-	sc := serviceconfig.Config{}
+	sc := serviceconfig.Config{
+		ProxyFactories: true,
+	}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
 	const want = `// Automatic generation of D-Bus interfaces:
-//  - test.EmptyInterface
+//  - test.Frob
+//  - test.FrobDebug
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -1035,90 +1118,78 @@ func TestGenerateProxiesWithMethods(t *testing.T) {
 
 namespace test {
 
-// Abstract interface proxy for test::EmptyInterface.
-class EmptyInterfaceProxyInterface {
+// Abstract interface proxy for test::Frob.
+class FrobProxyInterface {
  public:
-  virtual ~EmptyInterfaceProxyInterface() = default;
+  virtual ~FrobProxyInterface() = default;
 
-  virtual bool MethodNoArg(
-      brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+  static const char* DBusInterfaceName() { return "test.Frob"; }
 
-  virtual void MethodNoArgAsync(
-      base::OnceCallback<void()> success_callback,
-      base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
 
-  virtual bool MethodWithInArgs(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
-      const RequestProto& in_iprotoArg,
-      brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+}  // namespace test
 
-  virtual void MethodWithInArgsAsync(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
-      const RequestProto& in_iprotoArg,
-      base::OnceCallback<void()> success_callback,
-      base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+namespace test {
 
-  virtual bool MethodWithOutArgs(
-      int64_t* out_oarg1,
-      std::vector<uint8_t>* out_oarg2,
-      std::tuple<int32_t, base::ScopedFD>* out_oarg3,
-      ResponseProto* out_oprotoArg,
-      brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+// Interface proxy for test::Frob.
+class FrobProxy final : public FrobProxyInterface {
+ public:
+  FrobProxy(
+      const scoped_refptr<dbus::Bus>& bus,
+      const std::string& service_name) :
+          bus_{bus},
+          service_name_{service_name},
+          dbus_object_proxy_{
+              bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
 
-  virtual void MethodWithOutArgsAsync(
-      base::OnceCallback<void(int64_t /*oarg1*/, const std::vector<uint8_t>& /*oarg2*/, const std::tuple<int32_t, base::ScopedFD>& /*oarg3*/, const ResponseProto& /*oprotoArg*/)> success_callback,
-      base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+  FrobProxy(const FrobProxy&) = delete;
+  FrobProxy& operator=(const FrobProxy&) = delete;
 
-  virtual bool MethodWithBothArgs(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      uint16_t* out_oarg1,
-      double* out_oarg2,
-      brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+  ~FrobProxy() override {
+  }
 
-  virtual void MethodWithBothArgsAsync(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
-      base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
 
-  virtual bool MethodWithMixedArgs(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      uint16_t* out_oarg1,
-      double* out_oarg2,
-      brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
 
-  virtual void MethodWithMixedArgsAsync(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
-      base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
 
-  // method doc
-  virtual bool MethodWithDoc(
-      brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  std::string service_name_;
+  const dbus::ObjectPath object_path_{"/test/Frob"};
+  dbus::ObjectProxy* dbus_object_proxy_;
 
-  // method doc
-  virtual void MethodWithDocAsync(
-      base::OnceCallback<void()> success_callback,
-      base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+};
+
+// Creates a FrobProxy, returned as its interface so that it can be
+// injected into code depending on FrobProxyInterface.
+inline std::unique_ptr<FrobProxyInterface> CreateFrobProxy(
+    const scoped_refptr<dbus::Bus>& bus,
+    const std::string& service_name) {
+  return std::make_unique<FrobProxy>(
+      bus, service_name);
+}
+
+}  // namespace test
+
+namespace test {
+
+// Abstract interface proxy for test::FrobDebug.
+class FrobDebugProxyInterface {
+ public:
+  virtual ~FrobDebugProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.FrobDebug"; }
 
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
@@ -1128,24 +1199,22 @@ class EmptyInterfaceProxyInterface {
 
 namespace test {
 
-// Interface proxy for test::EmptyInterface.
-class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
+// Interface proxy for test::FrobDebug.
+class FrobDebugProxy final : public FrobDebugProxyInterface {
  public:
-  EmptyInterfaceProxy(
+  FrobDebugProxy(
       const scoped_refptr<dbus::Bus>& bus,
-      const std::string& service_name,
-      const dbus::ObjectPath& object_path) :
+      const std::string& service_name) :
           bus_{bus},
           service_name_{service_name},
-          object_path_{object_path},
           dbus_object_proxy_{
               bus_->GetObjectProxy(service_name_, object_path_)} {
   }
 
-  EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
-  EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
+  FrobDebugProxy(const FrobDebugProxy&) = delete;
+  FrobDebugProxy& operator=(const FrobDebugProxy&) = delete;
 
-  ~EmptyInterfaceProxy() override {
+  ~FrobDebugProxy() override {
   }
 
   void ReleaseObjectProxy(base::OnceClosure callback) {
@@ -1160,20 +1229,1298 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
     return dbus_object_proxy_;
   }
 
-  bool MethodNoArg(
-      brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
-    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
-        timeout_ms,
-        dbus_object_proxy_,
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  std::string service_name_;
+  const dbus::ObjectPath object_path_{"/test/Frob"};
+  dbus::ObjectProxy* dbus_object_proxy_;
+
+};
+
+// Creates a FrobDebugProxy, returned as its interface so that it can be
+// injected into code depending on FrobDebugProxyInterface.
+inline std::unique_ptr<FrobDebugProxyInterface> CreateFrobDebugProxy(
+    const scoped_refptr<dbus::Bus>& bus,
+    const std::string& service_name) {
+  return std::make_unique<FrobDebugProxy>(
+      bus, service_name);
+}
+
+}  // namespace test
+
+namespace test {
+
+// Proxies of all the interfaces of the /test/Frob object.
+struct FrobProxyBundle {
+  std::unique_ptr<test::FrobProxyInterface> frob;
+  std::unique_ptr<test::FrobDebugProxyInterface> frob_debug;
+};
+
+// Creates the proxies of all the interfaces of the /test/Frob object.
+inline FrobProxyBundle CreateFrobProxyBundle(
+    const scoped_refptr<dbus::Bus>& bus,
+    const std::string& service_name) {
+  FrobProxyBundle bundle;
+  bundle.frob = std::make_unique<test::FrobProxy>(
+      bus, service_name);
+  bundle.frob_debug = std::make_unique<test::FrobDebugProxy>(
+      bus, service_name);
+  return bundle;
+}
+
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesWithProxyTraits(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "test.Frob",
+		Methods: []introspect.Method{{
+			Name: "Poke",
+		}, {
+			Name: "Frobnicate",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "x"},
+				{Name: "iarg2", Type: "s"},
+				{Name: "oarg1", Type: "b", Direction: "out"},
+			},
+		}},
+		Signals: []introspect.Signal{{
+			Name: "Frobbed",
+			Args: []introspect.SignalArg{
+				{Name: "count", Type: "i"},
+				{Name: "name", Type: "s"},
+			},
+		}},
+		Properties: []introspect.Property{{
+			Name:   "Level",
+			Type:   "i",
+			Access: "read",
+		}},
+	}
+
+	introspections := []introspect.Introspection{{
+		Name:       "/test/Frob",
+		Interfaces: []introspect.Interface{itf},
+	}}
+
+	sc := serviceconfig.Config{
+		ServiceName:   "test.Frob",
+		ProxyTraits:   true,
+		ProxyConcepts: true,
+	}
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.Frob
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#include <concepts>
+#include <cstddef>
+#include <memory>
+#include <optional>
+#include <string>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+namespace test {
+
+// Abstract interface proxy for test::Frob.
+class FrobProxyInterface {
+ public:
+  virtual ~FrobProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.Frob"; }
+  static const char* FrobbedSignalName() { return "Frobbed"; }
+
+  virtual bool Poke(
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void PokeAsync(
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual bool Frobnicate(
+      int64_t in_iarg1,
+      const std::string& in_iarg2,
+      bool* out_oarg1,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void FrobnicateAsync(
+      int64_t in_iarg1,
+      const std::string& in_iarg2,
+      base::OnceCallback<void(bool /*oarg1*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void RegisterFrobbedSignalHandler(
+      const base::RepeatingCallback<void(int32_t,
+                                         const std::string&)>& signal_callback,
+      dbus::ObjectProxy::OnConnectedCallback on_connected_callback) = 0;
+
+  static const char* LevelName() { return "Level"; }
+  virtual int32_t level() const = 0;
+  virtual bool is_level_valid() const = 0;
+
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+
+  virtual void InitializeProperties(
+      const base::RepeatingCallback<void(FrobProxyInterface*, const std::string&)>& callback) = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::Frob.
+class FrobProxy final : public FrobProxyInterface {
+ public:
+  class PropertySet : public dbus::PropertySet {
+   public:
+    PropertySet(dbus::ObjectProxy* object_proxy,
+                const PropertyChangedCallback& callback)
+        : dbus::PropertySet{object_proxy,
+                            "test.Frob",
+                            callback} {
+      RegisterProperty(LevelName(), &level);
+    }
+    PropertySet(const PropertySet&) = delete;
+    PropertySet& operator=(const PropertySet&) = delete;
+
+    brillo::dbus_utils::Property<int32_t> level;
+
+  };
+
+  FrobProxy(const scoped_refptr<dbus::Bus>& bus) :
+      bus_{bus},
+      dbus_object_proxy_{
+          bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  FrobProxy(const FrobProxy&) = delete;
+  FrobProxy& operator=(const FrobProxy&) = delete;
+
+  ~FrobProxy() override {
+  }
+
+  void RegisterFrobbedSignalHandler(
+      const base::RepeatingCallback<void(int32_t,
+                                         const std::string&)>& signal_callback,
+      dbus::ObjectProxy::OnConnectedCallback on_connected_callback) override {
+    brillo::dbus_utils::ConnectToSignal(
+        dbus_object_proxy_,
+        "test.Frob",
+        "Frobbed",
+        signal_callback,
+        std::move(on_connected_callback));
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+  void InitializeProperties(
+      const base::RepeatingCallback<void(FrobProxyInterface*, const std::string&)>& callback) override {
+    property_set_.reset(
+        new PropertySet(dbus_object_proxy_, base::BindRepeating(callback, this)));
+    property_set_->ConnectSignals();
+    property_set_->GetAll();
+  }
+
+  const PropertySet* GetProperties() const { return &(*property_set_); }
+  PropertySet* GetProperties() { return &(*property_set_); }
+
+  // Copy of the cached property values. Properties which aren't valid are
+  // left empty.
+  struct PropertiesSnapshot {
+    std::optional<int32_t> level;
+  };
+
+  // Returns a copy of all the cached property values, so that callers don't
+  // need to hold references into the PropertySet.
+  PropertiesSnapshot GetAllPropertiesSnapshot() const {
+    PropertiesSnapshot snapshot;
+    if (property_set_->level.is_valid())
+      snapshot.level = property_set_->level.value();
+    return snapshot;
+  }
+
+  bool Poke(
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.Frob",
+        "Poke",
+        error);
+    return response && brillo::dbus_utils::ExtractMethodCallResults(
+        response.get(), error);
+  }
+
+  void PokeAsync(
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    brillo::dbus_utils::CallMethodWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.Frob",
+        "Poke",
+        std::move(success_callback),
+        std::move(error_callback));
+  }
+
+  bool Frobnicate(
+      int64_t in_iarg1,
+      const std::string& in_iarg2,
+      bool* out_oarg1,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.Frob",
+        "Frobnicate",
+        error,
+        in_iarg1,
+        in_iarg2);
+    return response && brillo::dbus_utils::ExtractMethodCallResults(
+        response.get(), error, out_oarg1);
+  }
+
+  void FrobnicateAsync(
+      int64_t in_iarg1,
+      const std::string& in_iarg2,
+      base::OnceCallback<void(bool /*oarg1*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    brillo::dbus_utils::CallMethodWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.Frob",
+        "Frobnicate",
+        std::move(success_callback),
+        std::move(error_callback),
+        in_iarg1,
+        in_iarg2);
+  }
+
+  int32_t level() const override {
+    return property_set_->level.value();
+  }
+
+  bool is_level_valid() const override {
+    return property_set_->level.is_valid();
+  }
+
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  const std::string service_name_{"test.Frob"};
+  const dbus::ObjectPath object_path_{"/test/Frob"};
+  dbus::ObjectProxy* dbus_object_proxy_;
+  std::unique_ptr<PropertySet> property_set_;
+
+};
+
+// Compile-time description of the test.Frob proxies, for utilities
+// written once for all generated proxies, e.g. retry wrappers.
+struct FrobProxyTraits {
+  using Interface = FrobProxyInterface;
+  using Proxy = FrobProxy;
+  static constexpr char kInterfaceName[] = "test.Frob";
+  static constexpr size_t kMethodCount = 2;
+  static constexpr bool kHasProperties = true;
+  static constexpr bool kHasSignals = true;
+};
+
+// Satisfied by the types providing the calls of FrobProxyInterface, e.g.
+// FrobProxy and its mock, to constrain templates on them. The timeouts
+// are passed explicitly, since the mock doesn't declare their defaults.
+template <typename T>
+concept FrobProxyLike =
+    requires(T& proxy) {
+      { proxy.GetObjectPath() } -> std::convertible_to<const dbus::ObjectPath&>;
+      { proxy.GetObjectProxy() } -> std::same_as<dbus::ObjectProxy*>;
+    } &&
+    requires(T& proxy,
+             brillo::ErrorPtr* error,
+             int timeout_ms) {
+      { proxy.Poke(error, timeout_ms) } -> std::same_as<bool>;
+    } &&
+    requires(T& proxy,
+             int64_t in_iarg1,
+             const std::string& in_iarg2,
+             bool* out_oarg1,
+             brillo::ErrorPtr* error,
+             int timeout_ms) {
+      { proxy.Frobnicate(in_iarg1, in_iarg2, out_oarg1, error, timeout_ms) } -> std::same_as<bool>;
+    } &&
+    requires(T& proxy,
+             const base::RepeatingCallback<void(int32_t,
+                                                const std::string&)>& signal_callback,
+             dbus::ObjectProxy::OnConnectedCallback on_connected_callback) {
+      proxy.RegisterFrobbedSignalHandler(signal_callback, std::move(on_connected_callback));
+    } &&
+    requires(T& proxy) {
+      { proxy.level() } -> std::same_as<int32_t>;
+      { proxy.is_level_valid() } -> std::same_as<bool>;
+    };
+
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesModule(t *testing.T) {
+	emptyItf := introspect.Interface{
+		Name: "test.EmptyInterface",
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{emptyItf},
+	}}
+
+	sc := serviceconfig.Config{}
+	out := new(bytes.Buffer)
+	if err := GenerateModule(introspections, out, "test.proxy", sc, nil); err != nil {
+		t.Fatalf("GenerateModule got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.EmptyInterface
+module;
+#include <memory>
+#include <optional>
+#include <string>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+export module test.proxy;
+
+export {
+
+namespace test {
+
+// Abstract interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxyInterface {
+ public:
+  virtual ~EmptyInterfaceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
+ public:
+  EmptyInterfaceProxy(
+      const scoped_refptr<dbus::Bus>& bus,
+      const std::string& service_name,
+      const dbus::ObjectPath& object_path) :
+          bus_{bus},
+          service_name_{service_name},
+          object_path_{object_path},
+          dbus_object_proxy_{
+              bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
+  EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
+
+  ~EmptyInterfaceProxy() override {
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  std::string service_name_;
+  dbus::ObjectPath object_path_;
+  dbus::ObjectProxy* dbus_object_proxy_;
+
+};
+
+}  // namespace test
+
+}  // export
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("GenerateModule failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesWithServiceName(t *testing.T) {
+	emptyItf := introspect.Interface{
+		Name: "test.EmptyInterface",
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{emptyItf},
+	}}
+
+	sc := serviceconfig.Config{
+		ServiceName: "test.ServiceName",
+	}
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.EmptyInterface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#include <memory>
+#include <optional>
+#include <string>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+namespace test {
+
+// Abstract interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxyInterface {
+ public:
+  virtual ~EmptyInterfaceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
+ public:
+  EmptyInterfaceProxy(
+      const scoped_refptr<dbus::Bus>& bus,
+      const dbus::ObjectPath& object_path) :
+          bus_{bus},
+          object_path_{object_path},
+          dbus_object_proxy_{
+              bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
+  EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
+
+  ~EmptyInterfaceProxy() override {
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  const std::string service_name_{"test.ServiceName"};
+  dbus::ObjectPath object_path_;
+  dbus::ObjectProxy* dbus_object_proxy_;
+
+};
+
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesWithFallbackServiceNames(t *testing.T) {
+	emptyItf := introspect.Interface{
+		Name: "test.EmptyInterface",
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{emptyItf},
+	}}
+
+	sc := serviceconfig.Config{
+		ServiceName:          "test.ServiceName",
+		FallbackServiceNames: []string{"test.OldServiceName"},
+	}
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.EmptyInterface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#include <memory>
+#include <optional>
+#include <string>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/location.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <base/task/task_runner.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+namespace test {
+
+// Abstract interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxyInterface {
+ public:
+  virtual ~EmptyInterfaceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
+ public:
+  EmptyInterfaceProxy(
+      const scoped_refptr<dbus::Bus>& bus,
+      const std::string& service_name,
+      const dbus::ObjectPath& object_path) :
+          bus_{bus},
+          service_name_{service_name},
+          object_path_{object_path},
+          dbus_object_proxy_{
+              bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
+  EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
+
+  // Returns the first of the candidate service names which currently has an
+  // owner on |bus|, or the primary service name if none of them has. Blocks
+  // on |bus|, so it must run on its D-Bus thread, if it has one; see
+  // ResolveServiceNameAsync() otherwise.
+  static std::string ResolveServiceName(const scoped_refptr<dbus::Bus>& bus) {
+    for (const char* service_name : {
+             "test.ServiceName",
+             "test.OldServiceName"}) {
+      if (!bus->GetServiceOwnerAndBlock(service_name,
+                                        dbus::Bus::SUPPRESS_ERRORS).empty()) {
+        return service_name;
+      }
+    }
+    return "test.ServiceName";
+  }
+
+  // Same as ResolveServiceName(), but resolves the service name on the D-Bus
+  // thread of |bus| and passes it to |callback| on the current sequence. Call
+  // it again, e.g. when the owner of a candidate changes, to pick the service
+  // name anew.
+  static void ResolveServiceNameAsync(
+      const scoped_refptr<dbus::Bus>& bus,
+      base::OnceCallback<void(const std::string&)> callback) {
+    bus->GetDBusTaskRunner()->PostTaskAndReplyWithResult(
+        FROM_HERE, base::BindOnce(&ResolveServiceName, bus),
+        std::move(callback));
+  }
+
+  ~EmptyInterfaceProxy() override {
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  std::string service_name_;
+  dbus::ObjectPath object_path_;
+  dbus::ObjectProxy* dbus_object_proxy_;
+
+};
+
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesWithNodeName(t *testing.T) {
+	emptyItf := introspect.Interface{
+		Name: "test.EmptyInterface",
+	}
+
+	introspections := []introspect.Introspection{{
+		Name:       "test.node.Name",
+		Interfaces: []introspect.Interface{emptyItf},
+	}}
+
+	sc := serviceconfig.Config{}
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.EmptyInterface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#include <memory>
+#include <optional>
+#include <string>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+namespace test {
+
+// Abstract interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxyInterface {
+ public:
+  virtual ~EmptyInterfaceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
+ public:
+  EmptyInterfaceProxy(
+      const scoped_refptr<dbus::Bus>& bus,
+      const std::string& service_name) :
+          bus_{bus},
+          service_name_{service_name},
+          dbus_object_proxy_{
+              bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
+  EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
+
+  ~EmptyInterfaceProxy() override {
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  std::string service_name_;
+  const dbus::ObjectPath object_path_{"test.node.Name"};
+  dbus::ObjectProxy* dbus_object_proxy_;
+
+};
+
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesWithMethods(t *testing.T) {
+	emptyItf := introspect.Interface{
+		Name: "test.EmptyInterface",
+		Methods: []introspect.Method{{
+			Name: "MethodNoArg",
+			Args: []introspect.MethodArg{},
+		}, {
+			Name: "MethodWithInArgs",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "x"},
+				{Name: "iarg2", Type: "ay"},
+				{Name: "iarg3", Type: "(ih)"},
+				{
+					Name: "iprotoArg",
+					Type: "ay",
+					Annotation: introspect.Annotation{
+						Name:  "org.chromium.DBus.Argument.ProtobufClass",
+						Value: "RequestProto",
+					},
+				},
+			},
+		}, {
+			Name: "MethodWithOutArgs",
+			Args: []introspect.MethodArg{
+				{Name: "oarg1", Type: "x", Direction: "out"},
+				{Name: "oarg2", Type: "ay", Direction: "out"},
+				{Name: "oarg3", Type: "(ih)", Direction: "out"},
+				{
+					Name:      "oprotoArg",
+					Type:      "ay",
+					Direction: "out",
+					Annotation: introspect.Annotation{
+						Name:  "org.chromium.DBus.Argument.ProtobufClass",
+						Value: "ResponseProto",
+					},
+				},
+			},
+		}, {
+			Name: "MethodWithBothArgs",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "x"},
+				{Name: "iarg2", Type: "ay"},
+				{Name: "oarg1", Type: "q", Direction: "out"},
+				{Name: "oarg2", Type: "d", Direction: "out"},
+			},
+		}, {
+			Name: "MethodWithMixedArgs",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "x"},
+				{Name: "oarg1", Type: "q", Direction: "out"},
+				{Name: "iarg2", Type: "ay"},
+				{Name: "oarg2", Type: "d", Direction: "out"},
+			},
+		}, {
+			Name:      "MethodWithDoc",
+			DocString: "\n        method doc\n      ",
+		}},
+	}
+
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{emptyItf},
+	}}
+
+	sc := serviceconfig.Config{}
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.EmptyInterface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#include <memory>
+#include <optional>
+#include <string>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/functional/callback_helpers.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+namespace test {
+
+// Abstract interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxyInterface {
+ public:
+  virtual ~EmptyInterfaceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
+  virtual bool MethodNoArg(
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void MethodNoArgAsync(
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual bool MethodWithInArgs(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
+      const RequestProto& in_iprotoArg,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void MethodWithInArgsAsync(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
+      const RequestProto& in_iprotoArg,
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual bool MethodWithOutArgs(
+      int64_t* out_oarg1,
+      std::vector<uint8_t>* out_oarg2,
+      std::tuple<int32_t, base::ScopedFD>* out_oarg3,
+      ResponseProto* out_oprotoArg,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  // Response of MethodWithOutArgsAsync(), owning the file descriptors it received.
+  struct MethodWithOutArgsResponse {
+    int64_t out_oarg1;
+    std::vector<uint8_t> out_oarg2;
+    std::tuple<int32_t, base::ScopedFD> out_oarg3;
+    ResponseProto out_oprotoArg;
+  };
+
+  virtual void MethodWithOutArgsAsync(
+      base::OnceCallback<void(MethodWithOutArgsResponse)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual bool MethodWithBothArgs(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      uint16_t* out_oarg1,
+      double* out_oarg2,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void MethodWithBothArgsAsync(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual bool MethodWithMixedArgs(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      uint16_t* out_oarg1,
+      double* out_oarg2,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void MethodWithMixedArgsAsync(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  // method doc
+  virtual bool MethodWithDoc(
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  // method doc
+  virtual void MethodWithDocAsync(
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::EmptyInterface.
+class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
+ public:
+  EmptyInterfaceProxy(
+      const scoped_refptr<dbus::Bus>& bus,
+      const std::string& service_name,
+      const dbus::ObjectPath& object_path) :
+          bus_{bus},
+          service_name_{service_name},
+          object_path_{object_path},
+          dbus_object_proxy_{
+              bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  EmptyInterfaceProxy(const EmptyInterfaceProxy&) = delete;
+  EmptyInterfaceProxy& operator=(const EmptyInterfaceProxy&) = delete;
+
+  ~EmptyInterfaceProxy() override {
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+  bool MethodNoArg(
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodNoArg",
+        error);
+    return response && brillo::dbus_utils::ExtractMethodCallResults(
+        response.get(), error);
+  }
+
+  void MethodNoArgAsync(
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    brillo::dbus_utils::CallMethodWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodNoArg",
+        std::move(success_callback),
+        std::move(error_callback));
+  }
+
+  bool MethodWithInArgs(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
+      const RequestProto& in_iprotoArg,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodWithInArgs",
+        error,
+        in_iarg1,
+        in_iarg2,
+        in_iarg3,
+        in_iprotoArg);
+    return response && brillo::dbus_utils::ExtractMethodCallResults(
+        response.get(), error);
+  }
+
+  void MethodWithInArgsAsync(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
+      const RequestProto& in_iprotoArg,
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    brillo::dbus_utils::CallMethodWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
         "test.EmptyInterface",
-        "MethodNoArg",
+        "MethodWithInArgs",
+        std::move(success_callback),
+        std::move(error_callback),
+        in_iarg1,
+        in_iarg2,
+        in_iarg3,
+        in_iprotoArg);
+  }
+
+  bool MethodWithOutArgs(
+      int64_t* out_oarg1,
+      std::vector<uint8_t>* out_oarg2,
+      std::tuple<int32_t, base::ScopedFD>* out_oarg3,
+      ResponseProto* out_oprotoArg,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodWithOutArgs",
+        error);
+    return response && brillo::dbus_utils::ExtractMethodCallResults(
+        response.get(), error, out_oarg1, out_oarg2, out_oarg3, out_oprotoArg);
+  }
+
+  void MethodWithOutArgsAsync(
+      base::OnceCallback<void(MethodWithOutArgsResponse)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    // Extracts the response here rather than in brillo::dbus_utils, which
+    // only hands const references to the output arguments to the callback,
+    // so the call is made on the object proxy directly.
+    dbus::MethodCall method_call("test.EmptyInterface", "MethodWithOutArgs");
+    dbus::MessageWriter writer(&method_call);
+    brillo::dbus_utils::WriteDBusArgs(&writer);
+    auto split_error_callback =
+        base::SplitOnceCallback(std::move(error_callback));
+    dbus_object_proxy_->CallMethodWithErrorCallback(
+        &method_call,
+        timeout_ms,
+        base::BindOnce(
+            [](base::OnceCallback<void(MethodWithOutArgsResponse)> success_callback,
+               base::OnceCallback<void(brillo::Error*)> error_callback,
+               dbus::Response* response) {
+              MethodWithOutArgsResponse results;
+              brillo::ErrorPtr error;
+              if (!brillo::dbus_utils::ExtractMethodCallResults(
+                      response, &error, &results.out_oarg1, &results.out_oarg2, &results.out_oarg3, &results.out_oprotoArg)) {
+                std::move(error_callback).Run(error.get());
+                return;
+              }
+              std::move(success_callback).Run(std::move(results));
+            },
+            std::move(success_callback),
+            std::move(split_error_callback.first)),
+        base::BindOnce(&brillo::dbus_utils::TranslateErrorResponse,
+                       std::move(split_error_callback.second)));
+  }
+
+  bool MethodWithBothArgs(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      uint16_t* out_oarg1,
+      double* out_oarg2,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodWithBothArgs",
+        error,
+        in_iarg1,
+        in_iarg2);
+    return response && brillo::dbus_utils::ExtractMethodCallResults(
+        response.get(), error, out_oarg1, out_oarg2);
+  }
+
+  void MethodWithBothArgsAsync(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    brillo::dbus_utils::CallMethodWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodWithBothArgs",
+        std::move(success_callback),
+        std::move(error_callback),
+        in_iarg1,
+        in_iarg2);
+  }
+
+  bool MethodWithMixedArgs(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      uint16_t* out_oarg1,
+      double* out_oarg2,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodWithMixedArgs",
+        error,
+        in_iarg1,
+        in_iarg2);
+    return response && brillo::dbus_utils::ExtractMethodCallResults(
+        response.get(), error, out_oarg1, out_oarg2);
+  }
+
+  void MethodWithMixedArgsAsync(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    brillo::dbus_utils::CallMethodWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodWithMixedArgs",
+        std::move(success_callback),
+        std::move(error_callback),
+        in_iarg1,
+        in_iarg2);
+  }
+
+  // method doc
+  bool MethodWithDoc(
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
+        timeout_ms,
+        dbus_object_proxy_,
+        "test.EmptyInterface",
+        "MethodWithDoc",
         error);
     return response && brillo::dbus_utils::ExtractMethodCallResults(
         response.get(), error);
   }
 
-  void MethodNoArgAsync(
+  // method doc
+  void MethodWithDocAsync(
       base::OnceCallback<void()> success_callback,
       base::OnceCallback<void(brillo::Error*)> error_callback,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
@@ -1181,84 +2528,205 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
         timeout_ms,
         dbus_object_proxy_,
         "test.EmptyInterface",
-        "MethodNoArg",
+        "MethodWithDoc",
         std::move(success_callback),
         std::move(error_callback));
   }
 
-  bool MethodWithInArgs(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
-      const RequestProto& in_iprotoArg,
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  std::string service_name_;
+  dbus::ObjectPath object_path_;
+  dbus::ObjectProxy* dbus_object_proxy_;
+
+};
+
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesWithExpectedMethods(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "test.Interface",
+		Methods: []introspect.Method{{
+			Name: "MethodNoArg",
+			Args: []introspect.MethodArg{},
+		}, {
+			Name: "MethodWithMixedArgs",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "x"},
+				{Name: "oarg1", Type: "q", Direction: "out"},
+				{Name: "iarg2", Type: "ay"},
+				{Name: "oarg2", Type: "d", Direction: "out"},
+			},
+		}},
+	}
+
+	introspections := []introspect.Introspection{{
+		Name:       "/test/Object",
+		Interfaces: []introspect.Interface{itf},
+	}}
+
+	sc := serviceconfig.Config{
+		ServiceName:     "test.ServiceName",
+		ExpectedMethods: true,
+	}
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.Interface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#include <memory>
+#include <optional>
+#include <string>
+#include <tuple>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <base/types/expected.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+namespace test {
+
+// Abstract interface proxy for test::Interface.
+class InterfaceProxyInterface {
+ public:
+  virtual ~InterfaceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.Interface"; }
+
+  virtual bool MethodNoArg(
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  // Same as MethodNoArg(), returning the output arguments or the error.
+  virtual base::expected<std::tuple<>, brillo::ErrorPtr> MethodNoArgExpected(
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void MethodNoArgAsync(
+      base::OnceCallback<void()> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual bool MethodWithMixedArgs(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      uint16_t* out_oarg1,
+      double* out_oarg2,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  // Same as MethodWithMixedArgs(), returning the output arguments or the error.
+  virtual base::expected<std::tuple<uint16_t, double>, brillo::ErrorPtr> MethodWithMixedArgsExpected(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void MethodWithMixedArgsAsync(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::Interface.
+class InterfaceProxy final : public InterfaceProxyInterface {
+ public:
+  InterfaceProxy(const scoped_refptr<dbus::Bus>& bus) :
+      bus_{bus},
+      dbus_object_proxy_{
+          bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  InterfaceProxy(const InterfaceProxy&) = delete;
+  InterfaceProxy& operator=(const InterfaceProxy&) = delete;
+
+  ~InterfaceProxy() override {
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+  bool MethodNoArg(
       brillo::ErrorPtr* error,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
     auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
         timeout_ms,
         dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithInArgs",
-        error,
-        in_iarg1,
-        in_iarg2,
-        in_iarg3,
-        in_iprotoArg);
+        "test.Interface",
+        "MethodNoArg",
+        error);
     return response && brillo::dbus_utils::ExtractMethodCallResults(
         response.get(), error);
   }
 
-  void MethodWithInArgsAsync(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      const std::tuple<int32_t, base::ScopedFD>& in_iarg3,
-      const RequestProto& in_iprotoArg,
-      base::OnceCallback<void()> success_callback,
-      base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
-    brillo::dbus_utils::CallMethodWithTimeout(
-        timeout_ms,
-        dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithInArgs",
-        std::move(success_callback),
-        std::move(error_callback),
-        in_iarg1,
-        in_iarg2,
-        in_iarg3,
-        in_iprotoArg);
-  }
-
-  bool MethodWithOutArgs(
-      int64_t* out_oarg1,
-      std::vector<uint8_t>* out_oarg2,
-      std::tuple<int32_t, base::ScopedFD>* out_oarg3,
-      ResponseProto* out_oprotoArg,
-      brillo::ErrorPtr* error,
+  base::expected<std::tuple<>, brillo::ErrorPtr> MethodNoArgExpected(
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
-    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
-        timeout_ms,
-        dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithOutArgs",
-        error);
-    return response && brillo::dbus_utils::ExtractMethodCallResults(
-        response.get(), error, out_oarg1, out_oarg2, out_oarg3, out_oprotoArg);
+    std::tuple<> results;
+    brillo::ErrorPtr error;
+    if (!MethodNoArg(
+            &error,
+            timeout_ms)) {
+      return base::unexpected(std::move(error));
+    }
+    return results;
   }
 
-  void MethodWithOutArgsAsync(
-      base::OnceCallback<void(int64_t /*oarg1*/, const std::vector<uint8_t>& /*oarg2*/, const std::tuple<int32_t, base::ScopedFD>& /*oarg3*/, const ResponseProto& /*oprotoArg*/)> success_callback,
+  void MethodNoArgAsync(
+      base::OnceCallback<void()> success_callback,
       base::OnceCallback<void(brillo::Error*)> error_callback,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
     brillo::dbus_utils::CallMethodWithTimeout(
         timeout_ms,
         dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithOutArgs",
+        "test.Interface",
+        "MethodNoArg",
         std::move(success_callback),
         std::move(error_callback));
   }
 
-  bool MethodWithBothArgs(
+  bool MethodWithMixedArgs(
       int64_t in_iarg1,
       const std::vector<uint8_t>& in_iarg2,
       uint16_t* out_oarg1,
@@ -1268,8 +2736,8 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
     auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
         timeout_ms,
         dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithBothArgs",
+        "test.Interface",
+        "MethodWithMixedArgs",
         error,
         in_iarg1,
         in_iarg2);
@@ -1277,7 +2745,25 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
         response.get(), error, out_oarg1, out_oarg2);
   }
 
-  void MethodWithBothArgsAsync(
+  base::expected<std::tuple<uint16_t, double>, brillo::ErrorPtr> MethodWithMixedArgsExpected(
+      int64_t in_iarg1,
+      const std::vector<uint8_t>& in_iarg2,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
+    std::tuple<uint16_t, double> results;
+    brillo::ErrorPtr error;
+    if (!MethodWithMixedArgs(
+            in_iarg1,
+            in_iarg2,
+            &std::get<0>(results),
+            &std::get<1>(results),
+            &error,
+            timeout_ms)) {
+      return base::unexpected(std::move(error));
+    }
+    return results;
+  }
+
+  void MethodWithMixedArgsAsync(
       int64_t in_iarg1,
       const std::vector<uint8_t>& in_iarg2,
       base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
@@ -1286,82 +2772,215 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
     brillo::dbus_utils::CallMethodWithTimeout(
         timeout_ms,
         dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithBothArgs",
+        "test.Interface",
+        "MethodWithMixedArgs",
         std::move(success_callback),
         std::move(error_callback),
         in_iarg1,
         in_iarg2);
   }
 
-  bool MethodWithMixedArgs(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      uint16_t* out_oarg1,
-      double* out_oarg2,
+ private:
+  scoped_refptr<dbus::Bus> bus_;
+  const std::string service_name_{"test.ServiceName"};
+  const dbus::ObjectPath object_path_{"/test/Object"};
+  dbus::ObjectProxy* dbus_object_proxy_;
+
+};
+
+}  // namespace test
+
+#endif  // ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+`
+
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("Generate failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestGenerateProxiesWithDefaultLastInput(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "test.Interface",
+		Methods: []introspect.Method{{
+			Name: "MethodWithOptionalArg",
+			Args: []introspect.MethodArg{
+				{Name: "iarg1", Type: "s"},
+				{Name: "iarg2", Type: "u"},
+				{Name: "oarg1", Type: "x", Direction: "out"},
+			},
+			Annotations: []introspect.Annotation{
+				{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+			},
+		}},
+	}
+
+	introspections := []introspect.Introspection{{
+		Name:       "/test/Object",
+		Interfaces: []introspect.Interface{itf},
+	}}
+
+	sc := serviceconfig.Config{
+		ServiceName: "test.ServiceName",
+	}
+	out := new(bytes.Buffer)
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
+		t.Fatalf("Generate got error, want nil: %v", err)
+	}
+
+	const want = `// Automatic generation of D-Bus interfaces:
+//  - test.Interface
+#ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
+#include <memory>
+#include <optional>
+#include <string>
+#include <vector>
+
+#include <base/files/scoped_file.h>
+#include <base/functional/bind.h>
+#include <base/functional/callback.h>
+#include <base/logging.h>
+#include <base/memory/ref_counted.h>
+#include <brillo/any.h>
+#include <brillo/dbus/dbus_method_invoker.h>
+#include <brillo/dbus/dbus_property.h>
+#include <brillo/dbus/dbus_signal_handler.h>
+#include <brillo/errors/error.h>
+#include <brillo/variant_dictionary.h>
+#include <dbus/bus.h>
+#include <dbus/message.h>
+#include <dbus/object_manager.h>
+#include <dbus/object_path.h>
+#include <dbus/object_proxy.h>
+
+namespace test {
+
+// Abstract interface proxy for test::Interface.
+class InterfaceProxyInterface {
+ public:
+  virtual ~InterfaceProxyInterface() = default;
+
+  static const char* DBusInterfaceName() { return "test.Interface"; }
+
+  virtual bool MethodWithOptionalArg(
+      const std::string& in_iarg1,
+      uint32_t in_iarg2,
+      int64_t* out_oarg1,
       brillo::ErrorPtr* error,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
-    auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
-        timeout_ms,
-        dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithMixedArgs",
-        error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  virtual void MethodWithOptionalArgAsync(
+      const std::string& in_iarg1,
+      uint32_t in_iarg2,
+      base::OnceCallback<void(int64_t /*oarg1*/)> success_callback,
+      base::OnceCallback<void(brillo::Error*)> error_callback,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) = 0;
+
+  // Same as MethodWithOptionalArg(), using 0 for |in_iarg2|.
+  bool MethodWithOptionalArg(
+      const std::string& in_iarg1,
+      int64_t* out_oarg1,
+      brillo::ErrorPtr* error,
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) {
+    return MethodWithOptionalArg(
         in_iarg1,
-        in_iarg2);
-    return response && brillo::dbus_utils::ExtractMethodCallResults(
-        response.get(), error, out_oarg1, out_oarg2);
+        0,
+        out_oarg1,
+        error,
+        timeout_ms);
   }
 
-  void MethodWithMixedArgsAsync(
-      int64_t in_iarg1,
-      const std::vector<uint8_t>& in_iarg2,
-      base::OnceCallback<void(uint16_t /*oarg1*/, double /*oarg2*/)> success_callback,
+  // Same as MethodWithOptionalArgAsync(), using 0 for |in_iarg2|.
+  void MethodWithOptionalArgAsync(
+      const std::string& in_iarg1,
+      base::OnceCallback<void(int64_t /*oarg1*/)> success_callback,
       base::OnceCallback<void(brillo::Error*)> error_callback,
-      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
-    brillo::dbus_utils::CallMethodWithTimeout(
-        timeout_ms,
-        dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithMixedArgs",
+      int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) {
+    MethodWithOptionalArgAsync(
+        in_iarg1,
+        0,
         std::move(success_callback),
         std::move(error_callback),
-        in_iarg1,
-        in_iarg2);
+        timeout_ms);
   }
 
-  // method doc
-  bool MethodWithDoc(
+  virtual const dbus::ObjectPath& GetObjectPath() const = 0;
+  virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
+};
+
+}  // namespace test
+
+namespace test {
+
+// Interface proxy for test::Interface.
+class InterfaceProxy final : public InterfaceProxyInterface {
+ public:
+  InterfaceProxy(const scoped_refptr<dbus::Bus>& bus) :
+      bus_{bus},
+      dbus_object_proxy_{
+          bus_->GetObjectProxy(service_name_, object_path_)} {
+  }
+
+  InterfaceProxy(const InterfaceProxy&) = delete;
+  InterfaceProxy& operator=(const InterfaceProxy&) = delete;
+
+  ~InterfaceProxy() override {
+  }
+
+  void ReleaseObjectProxy(base::OnceClosure callback) {
+    bus_->RemoveObjectProxy(service_name_, object_path_, std::move(callback));
+  }
+
+  const dbus::ObjectPath& GetObjectPath() const override {
+    return object_path_;
+  }
+
+  dbus::ObjectProxy* GetObjectProxy() const override {
+    return dbus_object_proxy_;
+  }
+
+  using InterfaceProxyInterface::MethodWithOptionalArg;
+  using InterfaceProxyInterface::MethodWithOptionalArgAsync;
+
+  bool MethodWithOptionalArg(
+      const std::string& in_iarg1,
+      uint32_t in_iarg2,
+      int64_t* out_oarg1,
       brillo::ErrorPtr* error,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
     auto response = brillo::dbus_utils::CallMethodAndBlockWithTimeout(
         timeout_ms,
         dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithDoc",
-        error);
+        "test.Interface",
+        "MethodWithOptionalArg",
+        error,
+        in_iarg1,
+        in_iarg2);
     return response && brillo::dbus_utils::ExtractMethodCallResults(
-        response.get(), error);
+        response.get(), error, out_oarg1);
   }
 
-  // method doc
-  void MethodWithDocAsync(
-      base::OnceCallback<void()> success_callback,
+  void MethodWithOptionalArgAsync(
+      const std::string& in_iarg1,
+      uint32_t in_iarg2,
+      base::OnceCallback<void(int64_t /*oarg1*/)> success_callback,
       base::OnceCallback<void(brillo::Error*)> error_callback,
       int timeout_ms = dbus::ObjectProxy::TIMEOUT_USE_DEFAULT) override {
     brillo::dbus_utils::CallMethodWithTimeout(
         timeout_ms,
         dbus_object_proxy_,
-        "test.EmptyInterface",
-        "MethodWithDoc",
+        "test.Interface",
+        "MethodWithOptionalArg",
         std::move(success_callback),
-        std::move(error_callback));
+        std::move(error_callback),
+        in_iarg1,
+        in_iarg2);
   }
 
  private:
   scoped_refptr<dbus::Bus> bus_;
-  std::string service_name_;
-  dbus::ObjectPath object_path_;
+  const std::string service_name_{"test.ServiceName"};
+  const dbus::ObjectPath object_path_{"/test/Object"};
   dbus::ObjectProxy* dbus_object_proxy_;
 
 };
@@ -1419,7 +3038,7 @@ func TestGenerateProxiesWithSignals(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -1428,6 +3047,7 @@ func TestGenerateProxiesWithSignals(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -1455,6 +3075,10 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+  static const char* Signal1SignalName() { return "Signal1"; }
+  static const char* Signal2SignalName() { return "Signal2"; }
+
   virtual void RegisterSignal1SignalHandler(
       const base::RepeatingCallback<void(const YetAnotherProto&,
                                          const std::tuple<int32_t, base::ScopedFD>&)>& signal_callback,
@@ -1572,7 +3196,7 @@ func TestGenerateProxiesWithProperties(t *testing.T) {
 
 	sc := serviceconfig.Config{}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -1581,6 +3205,7 @@ func TestGenerateProxiesWithProperties(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -1608,6 +3233,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
   static const char* ReadonlyPropertyName() { return "ReadonlyProperty"; }
   virtual const brillo::VariantDictionary& readonly_property() const = 0;
   virtual bool is_readonly_property_valid() const = 0;
@@ -1689,6 +3316,24 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
   const PropertySet* GetProperties() const { return &(*property_set_); }
   PropertySet* GetProperties() { return &(*property_set_); }
 
+  // Copy of the cached property values. Properties which aren't valid are
+  // left empty.
+  struct PropertiesSnapshot {
+    std::optional<brillo::VariantDictionary> readonly_property;
+    std::optional<brillo::VariantDictionary> writable_property;
+  };
+
+  // Returns a copy of all the cached property values, so that callers don't
+  // need to hold references into the PropertySet.
+  PropertiesSnapshot GetAllPropertiesSnapshot() const {
+    PropertiesSnapshot snapshot;
+    if (property_set_->readonly_property.is_valid())
+      snapshot.readonly_property = property_set_->readonly_property.value();
+    if (property_set_->writable_property.is_valid())
+      snapshot.writable_property = property_set_->writable_property.value();
+    return snapshot;
+  }
+
   const brillo::VariantDictionary& readonly_property() const override {
     return property_set_->readonly_property.value();
   }
@@ -1744,7 +3389,7 @@ func TestGenerateProxiesWithObjectManager(t *testing.T) {
 		},
 	}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -1753,6 +3398,7 @@ func TestGenerateProxiesWithObjectManager(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -1784,6 +3430,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
 };
@@ -1983,7 +3631,7 @@ func TestGenerateProxiesWithObjectManagerAndServiceName(t *testing.T) {
 		},
 	}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -1992,6 +3640,7 @@ func TestGenerateProxiesWithObjectManagerAndServiceName(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -2023,6 +3672,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
   virtual const dbus::ObjectPath& GetObjectPath() const = 0;
   virtual dbus::ObjectProxy* GetObjectProxy() const = 0;
 };
@@ -2224,7 +3875,7 @@ func TestGenerateProxiesWithPropertiesAndObjectManager(t *testing.T) {
 		},
 	}
 	out := new(bytes.Buffer)
-	if err := Generate(introspections, out, "/tmp/proxy.h", sc); err != nil {
+	if err := Generate(introspections, out, "/tmp/proxy.h", sc, nil); err != nil {
 		t.Fatalf("Generate got error, want nil: %v", err)
 	}
 
@@ -2233,6 +3884,7 @@ func TestGenerateProxiesWithPropertiesAndObjectManager(t *testing.T) {
 #ifndef ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #define ____CHROMEOS_DBUS_BINDING___TMP_PROXY_H
 #include <memory>
+#include <optional>
 #include <string>
 #include <vector>
 
@@ -2264,6 +3916,8 @@ class EmptyInterfaceProxyInterface {
  public:
   virtual ~EmptyInterfaceProxyInterface() = default;
 
+  static const char* DBusInterfaceName() { return "test.EmptyInterface"; }
+
   static const char* CapabilitiesName() { return "Capabilities"; }
   virtual const brillo::VariantDictionary& capabilities() const = 0;
   virtual bool is_capabilities_valid() const = 0;
@@ -2337,6 +3991,21 @@ class EmptyInterfaceProxy final : public EmptyInterfaceProxyInterface {
   const PropertySet* GetProperties() const { return &(*property_set_); }
   PropertySet* GetProperties() { return &(*property_set_); }
 
+  // Copy of the cached property values. Properties which aren't valid are
+  // left empty.
+  struct PropertiesSnapshot {
+    std::optional<brillo::VariantDictionary> capabilities;
+  };
+
+  // Returns a copy of all the cached property values, so that callers don't
+  // need to hold references into the PropertySet.
+  PropertiesSnapshot GetAllPropertiesSnapshot() const {
+    PropertiesSnapshot snapshot;
+    if (property_set_->capabilities.is_valid())
+      snapshot.capabilities = property_set_->capabilities.value();
+    return snapshot;
+  }
+
   const brillo::VariantDictionary& capabilities() const override {
     return property_set_->capabilities.value();
   }
@@ -2492,3 +4161,23 @@ class ObjectManagerProxy : public dbus::ObjectManager::Interface {
 		t.Errorf("Generate failed (-got +want):\n%s", diff)
 	}
 }
+
+// TestGenerateProxiesTemplateOverrideFields tests that the templates are
+// accepted as overrides of themselves, i.e. that they only refer to the
+// fields of the data dotTypes gives them.
+func TestGenerateProxiesTemplateOverrideFields(t *testing.T) {
+	introspections := []introspect.Introspection{{
+		Interfaces: []introspect.Interface{{
+			Name:    "Itf",
+			Methods: []introspect.Method{{Name: "Ping"}},
+		}},
+	}}
+
+	overrides := genutil.TemplateOverrides{
+		"proxyInterface":     proxyInterfaceTemplate,
+		"resolveServiceName": resolveServiceNameTemplate,
+	}
+	if err := Generate(introspections, new(bytes.Buffer), "/tmp/proxy.h", serviceconfig.Config{}, overrides); err != nil {
+		t.Errorf("Generate with the templates as overrides got error, want nil: %v", err)
+	}
+}
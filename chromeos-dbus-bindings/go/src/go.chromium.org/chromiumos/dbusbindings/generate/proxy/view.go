@@ -0,0 +1,215 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package proxy
+
+import (
+	"go.chromium.org/chromiumos/dbusbindings/generate/genutil"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+	"go.chromium.org/chromiumos/dbusbindings/serviceconfig"
+)
+
+// The templates are executed on views of the introspections, holding the C++
+// types they print already resolved. Resolving them up front reports invalid
+// types before anything is written, and resolves each of them once, even
+// though the proxy interface, the proxy and the mock all print them.
+
+// introspectionView is an introspection, along with the name of the bundle of
+// the proxies of its interfaces, if any.
+type introspectionView struct {
+	Name       string
+	Interfaces []interfaceView
+	BundleName string
+}
+
+// interfaceView is an interface with the types of its members resolved.
+type interfaceView struct {
+	Name       string
+	DocString  introspect.DocString
+	Methods    []methodView
+	Signals    []signalView
+	Properties []propertyView
+	// ObjectPathTemplate is the template of the paths of the instances of
+	// the interface, or nil if it has none.
+	ObjectPathTemplate *introspect.ObjectPathTemplate
+	// DedicatedBus is true if the proxy is to be created on its own bus.
+	DedicatedBus bool
+	// ExpectedMethods is true if the proxy interface, the proxy and the mock
+	// also provide the Expected() variant of each method.
+	ExpectedMethods bool
+}
+
+// methodView is a method with the types of its calls resolved.
+type methodView struct {
+	introspect.Method
+	// InParams and OutParams are the parameters of the synchronous call.
+	InParams, OutParams []param
+	// RequiredParams are InParams without OptionalParam, the last one, if the
+	// method has a default value for it.
+	RequiredParams []param
+	OptionalParam  param
+	// MockInParams and MockOutParams are InParams and OutParams with their
+	// names commented out.
+	MockInParams, MockOutParams []param
+	// AsyncResponse is the response struct of the asynchronous call, or nil
+	// if the success callback takes the output arguments one by one.
+	AsyncResponse      *asyncResponse
+	AsyncCallbackType  string
+	ExpectedResultType string
+	AllowedValuesEnums []allowedValuesEnum
+}
+
+// signalView is a signal with the type of its handlers resolved.
+type signalView struct {
+	introspect.Signal
+	CallbackType string
+}
+
+// propertyView is a property with its type and accessor name resolved.
+type propertyView struct {
+	introspect.Property
+	// VarName names the accessors of the property.
+	VarName string
+	// ValueType is the type the property holds, and ArgType the type it is
+	// passed and returned as.
+	ValueType, ArgType string
+}
+
+// makeIntrospectionViews returns the views of introspects, as generated for
+// config. Proxy bundles are only named if config asks for proxy factories.
+func makeIntrospectionViews(introspects []introspect.Introspection, config serviceconfig.Config) ([]introspectionView, error) {
+	var omName string
+	if config.ObjectManager != nil {
+		omName = config.ObjectManager.Name
+	}
+
+	var ret []introspectionView
+	for _, ii := range introspects {
+		iv := introspectionView{Name: ii.Name}
+		if config.ProxyFactories {
+			bundleName, err := makeProxyBundleName(ii, omName)
+			if err != nil {
+				return nil, err
+			}
+			iv.BundleName = bundleName
+		}
+		for _, itf := range ii.Interfaces {
+			v, err := makeInterfaceView(itf)
+			if err != nil {
+				return nil, err
+			}
+			v.DedicatedBus = usesDedicatedBus(config.DedicatedBusInterfaces, itf.Name)
+			v.ExpectedMethods = config.ExpectedMethods
+			iv.Interfaces = append(iv.Interfaces, v)
+		}
+		ret = append(ret, iv)
+	}
+	return ret, nil
+}
+
+func makeInterfaceView(itf introspect.Interface) (interfaceView, error) {
+	ret := interfaceView{
+		Name:               itf.Name,
+		DocString:          itf.DocString,
+		ObjectPathTemplate: itf.ObjectPathTemplate(),
+	}
+	for _, m := range itf.Methods {
+		v, err := makeMethodView(m)
+		if err != nil {
+			return interfaceView{}, err
+		}
+		ret.Methods = append(ret.Methods, v)
+	}
+	for _, s := range itf.Signals {
+		t, err := makeSignalCallbackType(s.Args)
+		if err != nil {
+			return interfaceView{}, err
+		}
+		ret.Signals = append(ret.Signals, signalView{s, t})
+	}
+	for _, p := range itf.Properties {
+		valueType, err := p.BaseType()
+		if err != nil {
+			return interfaceView{}, err
+		}
+		argType, err := p.InArgType()
+		if err != nil {
+			return interfaceView{}, err
+		}
+		ret.Properties = append(ret.Properties, propertyView{
+			Property:  p,
+			VarName:   genutil.MakeVariableName(p.VariableName()),
+			ValueType: valueType,
+			ArgType:   argType,
+		})
+	}
+	return ret, nil
+}
+
+func makeMethodView(method introspect.Method) (methodView, error) {
+	ret := methodView{Method: method}
+	in := method.InputArguments()
+	out := method.OutputArguments()
+
+	var err error
+	if ret.InParams, err = makeMethodParams(0, in); err != nil {
+		return methodView{}, err
+	}
+	if ret.OutParams, err = makeMethodParams(len(in), out); err != nil {
+		return methodView{}, err
+	}
+	if method.DefaultLastInput() != "" && len(ret.InParams) > 0 {
+		last := len(ret.InParams) - 1
+		ret.RequiredParams = ret.InParams[:last]
+		ret.OptionalParam = ret.InParams[last]
+	}
+	if ret.MockInParams, err = makeMockMethodParams(0, in); err != nil {
+		return methodView{}, err
+	}
+	if ret.MockOutParams, err = makeMockMethodParams(len(in), out); err != nil {
+		return methodView{}, err
+	}
+	if ret.AsyncResponse, err = makeAsyncResponse(method); err != nil {
+		return methodView{}, err
+	}
+	if ret.AsyncCallbackType, err = makeAsyncCallbackType(method, ret.AsyncResponse); err != nil {
+		return methodView{}, err
+	}
+	if ret.ExpectedResultType, err = makeExpectedResultType(out); err != nil {
+		return methodView{}, err
+	}
+	if ret.AllowedValuesEnums, err = makeAllowedValuesEnums(method); err != nil {
+		return methodView{}, err
+	}
+	return ret, nil
+}
+
+// hasAsyncResponses returns whether a method of introspects has a response
+// struct for its asynchronous call.
+func hasAsyncResponses(introspects []introspectionView) bool {
+	for _, ii := range introspects {
+		for _, itf := range ii.Interfaces {
+			for _, m := range itf.Methods {
+				if m.AsyncResponse != nil {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// extractInterfacesWithProperties returns the interfaces of introspects which
+// have properties.
+func extractInterfacesWithProperties(introspects []introspectionView) []interfaceView {
+	var ret []interfaceView
+	for _, ii := range introspects {
+		for _, itf := range ii.Interfaces {
+			if len(itf.Properties) > 0 {
+				ret = append(ret, itf)
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package stats reports the size of the API surface of the generated
+// interfaces and of the generated outputs, along with the time spent
+// generating them, so that their growth can be tracked across runs.
+package stats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+)
+
+// Surface is the size of the API surface of a set of interfaces.
+type Surface struct {
+	Interfaces int `json:"interfaces"`
+	Methods    int `json:"methods"`
+	Signals    int `json:"signals"`
+	Properties int `json:"properties"`
+}
+
+// CountSurface returns the size of the API surface of introspects.
+func CountSurface(introspects []introspect.Introspection) Surface {
+	var s Surface
+	for _, ii := range introspects {
+		for _, itf := range ii.Interfaces {
+			s.Interfaces++
+			s.Methods += len(itf.Methods)
+			s.Signals += len(itf.Signals)
+			s.Properties += len(itf.Properties)
+		}
+	}
+	return s
+}
+
+// Output is the size of a generated file, and the time spent generating it,
+// mostly executing templates.
+type Output struct {
+	Name             string  `json:"name"`
+	Path             string  `json:"path"`
+	Lines            int     `json:"lines"`
+	Bytes            int64   `json:"bytes"`
+	GenerationMillis float64 `json:"generation_ms"`
+}
+
+// Report describes a run of the generator.
+type Report struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Surface
+	Outputs []Output `json:"outputs"`
+}
+
+// String summarizes r on a single line.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d interfaces, %d methods, %d signals, %d properties", r.Command, r.Interfaces, r.Methods, r.Signals, r.Properties)
+	for _, o := range r.Outputs {
+		fmt.Fprintf(&b, "; %s: %d lines in %.1fms", o.Path, o.Lines, o.GenerationMillis)
+	}
+	return b.String()
+}
+
+// Counter is an io.Writer counting the bytes and lines written through it to
+// W.
+type Counter struct {
+	W     io.Writer
+	Bytes int64
+	Lines int
+}
+
+func (c *Counter) Write(p []byte) (int, error) {
+	n, err := c.W.Write(p)
+	c.Bytes += int64(n)
+	c.Lines += bytes.Count(p[:n], []byte("\n"))
+	return n, err
+}
+
+// Append appends r to the history of reports at path, which holds a JSON
+// report per line, creating the file if needed.
+func Append(path string, r Report) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a history of reports written by Append.
+func Load(r io.Reader) ([]Report, error) {
+	var ret []Report
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for line := 1; s.Scan(); line++ {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		var report Report
+		if err := json.Unmarshal(s.Bytes(), &report); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		ret = append(ret, report)
+	}
+	return ret, s.Err()
+}
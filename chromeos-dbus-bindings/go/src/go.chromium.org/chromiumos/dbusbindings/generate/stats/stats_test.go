@@ -0,0 +1,99 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package stats_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.chromium.org/chromiumos/dbusbindings/generate/stats"
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCountSurface(t *testing.T) {
+	introspects := []introspect.Introspection{
+		{
+			Interfaces: []introspect.Interface{
+				{
+					Name:       "org.chromium.Test1",
+					Methods:    []introspect.Method{{Name: "A"}, {Name: "B"}},
+					Signals:    []introspect.Signal{{Name: "C"}},
+					Properties: []introspect.Property{{Name: "D"}},
+				}, {
+					Name: "org.chromium.Test2",
+				},
+			},
+		}, {
+			Interfaces: []introspect.Interface{
+				{
+					Name:    "org.chromium.Test3",
+					Methods: []introspect.Method{{Name: "E"}},
+				},
+			},
+		},
+	}
+
+	got := stats.CountSurface(introspects)
+	want := stats.Surface{Interfaces: 3, Methods: 3, Signals: 1, Properties: 1}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("CountSurface failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	var out bytes.Buffer
+	c := &stats.Counter{W: &out}
+	for _, s := range []string{"#ifndef GUARD\n#define", " GUARD\n", "#endif"} {
+		if _, err := c.Write([]byte(s)); err != nil {
+			t.Fatalf("Write got error, want nil: %v", err)
+		}
+	}
+	if c.Lines != 2 || c.Bytes != int64(out.Len()) {
+		t.Errorf("Counter got %d lines and %d bytes, want 2 lines and %d bytes", c.Lines, c.Bytes, out.Len())
+	}
+}
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.jsonl")
+	want := []stats.Report{
+		{
+			Time:    time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+			Command: "proxy",
+			Surface: stats.Surface{Interfaces: 1, Methods: 2},
+			Outputs: []stats.Output{{Name: "proxy", Path: "out/dbus-proxies.h", Lines: 100, Bytes: 2000, GenerationMillis: 1.5}},
+		}, {
+			Time:    time.Date(2022, 1, 3, 3, 4, 5, 0, time.UTC),
+			Command: "validate",
+			Surface: stats.Surface{Interfaces: 2, Methods: 3},
+		},
+	}
+	for _, r := range want {
+		if err := stats.Append(path, r); err != nil {
+			t.Fatalf("Append got error, want nil: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := stats.Load(f)
+	if err != nil {
+		t.Fatalf("Load got error, want nil: %v", err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Load failed (-got +want):\n%s", diff)
+	}
+
+	if _, err := stats.Load(bytes.NewReader([]byte("{}\nnot json\n"))); err == nil {
+		t.Error("Load got nil error, want an error for invalid JSON")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package introspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LoadModel reads a model written by DumpModel, e.g. from a previous version of
+// the introspection files.
+func LoadModel(r io.Reader) ([]ModelIntrospection, error) {
+	var ret []ModelIntrospection
+	if err := json.NewDecoder(r).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// modelInterfaces indexes the interfaces of model by name.
+func modelInterfaces(model []ModelIntrospection) map[string]ModelInterface {
+	ret := make(map[string]ModelInterface)
+	for _, mi := range model {
+		for _, itf := range mi.Interfaces {
+			ret[itf.Name] = itf
+		}
+	}
+	return ret
+}
+
+// argTypes returns the D-Bus types of the args of the given direction, where
+// "" matches all of them.
+func argTypes(args []ModelArg, direction string) []string {
+	ret := []string{}
+	for _, a := range args {
+		if direction == "" || a.Direction == direction {
+			ret = append(ret, a.Type)
+		}
+	}
+	return ret
+}
+
+// methodSignature describes the D-Bus signature of m.
+func methodSignature(m ModelMethod) string {
+	return fmt.Sprintf("in (%s) out (%s)", strings.Join(argTypes(m.Args, "in"), ""), strings.Join(argTypes(m.Args, "out"), ""))
+}
+
+// isPrefix returns true if prefix is the first len(prefix) elements of s.
+func isPrefix(prefix, s []string) bool {
+	if len(prefix) > len(s) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// methodCompatible returns whether callers of the baseline method can call the
+// current one, whose only allowed change is an optional last input argument.
+func methodCompatible(baseline, current ModelMethod) bool {
+	if strings.Join(argTypes(baseline.Args, "out"), "") != strings.Join(argTypes(current.Args, "out"), "") {
+		return false
+	}
+	if baseline.DefaultLastInput != "" && current.DefaultLastInput == "" {
+		return false
+	}
+	bIn, cIn := argTypes(baseline.Args, "in"), argTypes(current.Args, "in")
+	if len(bIn) == len(cIn) {
+		return isPrefix(bIn, cIn)
+	}
+	return current.DefaultLastInput != "" && len(cIn) == len(bIn)+1 && isPrefix(bIn, cIn)
+}
+
+// propertyCanRead and propertyCanWrite return whether users may get or set a
+// property with the given access.
+func propertyCanRead(access string) bool  { return access == "read" || access == "readwrite" }
+func propertyCanWrite(access string) bool { return access == "write" || access == "readwrite" }
+
+// BreakingChanges describes each change from the baseline model to the current
+// one, both as made by NewModel, which can break existing users of the
+// interfaces: removed interfaces, methods, signals and properties, changed
+// D-Bus signatures, and reduced property access. Additions are allowed,
+// including an input argument appended to a method with DefaultLastInput.
+func BreakingChanges(baseline, current []ModelIntrospection) []string {
+	var ret []string
+	currentItfs := modelInterfaces(current)
+	for _, mi := range baseline {
+		for _, bItf := range mi.Interfaces {
+			cItf, ok := currentItfs[bItf.Name]
+			if !ok {
+				ret = append(ret, fmt.Sprintf("interface %s was removed", bItf.Name))
+				continue
+			}
+
+			methods := make(map[string]ModelMethod)
+			for _, m := range cItf.Methods {
+				methods[m.Name] = m
+			}
+			for _, bm := range bItf.Methods {
+				cm, ok := methods[bm.Name]
+				if !ok {
+					ret = append(ret, fmt.Sprintf("%s: method %s was removed", bItf.Name, bm.Name))
+				} else if !methodCompatible(bm, cm) {
+					ret = append(ret, fmt.Sprintf("%s: method %s changed from %s to %s", bItf.Name, bm.Name, methodSignature(bm), methodSignature(cm)))
+				}
+			}
+
+			signals := make(map[string]ModelSignal)
+			for _, s := range cItf.Signals {
+				signals[s.Name] = s
+			}
+			for _, bs := range bItf.Signals {
+				cs, ok := signals[bs.Name]
+				if !ok {
+					ret = append(ret, fmt.Sprintf("%s: signal %s was removed", bItf.Name, bs.Name))
+					continue
+				}
+				bTypes, cTypes := strings.Join(argTypes(bs.Args, ""), ""), strings.Join(argTypes(cs.Args, ""), "")
+				if bTypes != cTypes {
+					ret = append(ret, fmt.Sprintf("%s: signal %s changed from (%s) to (%s)", bItf.Name, bs.Name, bTypes, cTypes))
+				}
+			}
+
+			properties := make(map[string]ModelProperty)
+			for _, p := range cItf.Properties {
+				properties[p.Name] = p
+			}
+			for _, bp := range bItf.Properties {
+				cp, ok := properties[bp.Name]
+				switch {
+				case !ok:
+					ret = append(ret, fmt.Sprintf("%s: property %s was removed", bItf.Name, bp.Name))
+				case bp.Type != cp.Type:
+					ret = append(ret, fmt.Sprintf("%s: property %s changed from type %s to %s", bItf.Name, bp.Name, bp.Type, cp.Type))
+				case propertyCanRead(bp.Access) && !propertyCanRead(cp.Access),
+					propertyCanWrite(bp.Access) && !propertyCanWrite(cp.Access):
+					ret = append(ret, fmt.Sprintf("%s: property %s changed from access %s to %s", bItf.Name, bp.Name, bp.Access, cp.Access))
+				}
+			}
+		}
+	}
+	return ret
+}
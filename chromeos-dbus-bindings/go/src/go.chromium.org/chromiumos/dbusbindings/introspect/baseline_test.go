@@ -0,0 +1,133 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package introspect_test
+
+import (
+	"bytes"
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const baselineXML = `
+<node name="/org/chromium/Test">
+  <interface name="org.chromium.Test">
+    <method name="Frobinate">
+      <arg name="foo" type="i" direction="in"/>
+      <arg name="bar" type="s" direction="out"/>
+    </method>
+    <method name="Kick"/>
+    <signal name="Frobinated">
+      <arg type="u"/>
+    </signal>
+    <property name="Class" type="u" access="readwrite"/>
+    <property name="Name" type="s" access="read"/>
+  </interface>
+  <interface name="org.chromium.Other"/>
+</node>`
+
+func mustModel(t *testing.T, xml string) []introspect.ModelIntrospection {
+	t.Helper()
+	is, err := introspect.Parse([]byte(xml))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+	return introspect.NewModel([]introspect.Introspection{is})
+}
+
+func TestLoadModel(t *testing.T) {
+	is, err := introspect.Parse([]byte(baselineXML))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+	out := new(bytes.Buffer)
+	if err := introspect.DumpModel([]introspect.Introspection{is}, out); err != nil {
+		t.Fatalf("DumpModel got error, want nil: %v", err)
+	}
+
+	got, err := introspect.LoadModel(out)
+	if err != nil {
+		t.Fatalf("LoadModel got error, want nil: %v", err)
+	}
+	if diff := cmp.Diff(got, introspect.NewModel([]introspect.Introspection{is})); diff != "" {
+		t.Errorf("LoadModel failed (-got +want):\n%s", diff)
+	}
+
+	if _, err := introspect.LoadModel(bytes.NewBufferString("{")); err == nil {
+		t.Error("LoadModel of a malformed model got nil error, want error")
+	}
+}
+
+func TestBreakingChanges(t *testing.T) {
+	cases := []struct {
+		name    string
+		current string
+		want    []string
+	}{{
+		name:    "unchanged",
+		current: baselineXML,
+	}, {
+		name: "additions",
+		current: `
+<node name="/org/chromium/Other">
+  <interface name="org.chromium.Test">
+    <method name="Frobinate">
+      <arg name="foo" type="i" direction="in"/>
+      <arg name="baz" type="b" direction="in"/>
+      <arg name="bar" type="s" direction="out"/>
+      <annotation name="org.chromium.DBus.Method.DefaultLastInput" value="false"/>
+    </method>
+    <method name="Kick"/>
+    <method name="Punch"/>
+    <signal name="Frobinated">
+      <arg type="u"/>
+    </signal>
+    <signal name="Punched"/>
+    <property name="Class" type="u" access="readwrite"/>
+    <property name="Name" type="s" access="readwrite"/>
+    <property name="Age" type="u" access="read"/>
+  </interface>
+  <interface name="org.chromium.Other"/>
+  <interface name="org.chromium.New"/>
+</node>`,
+	}, {
+		name: "removals and changes",
+		current: `
+<node name="/org/chromium/Test">
+  <interface name="org.chromium.Test">
+    <method name="Frobinate">
+      <arg name="foo" type="i" direction="in"/>
+      <arg name="baz" type="b" direction="in"/>
+      <arg name="bar" type="s" direction="out"/>
+    </method>
+    <signal name="Frobinated">
+      <arg type="x"/>
+    </signal>
+    <property name="Class" type="u" access="read"/>
+    <property name="Name" type="u" access="read"/>
+  </interface>
+</node>`,
+		want: []string{
+			"org.chromium.Test: method Frobinate changed from in (i) out (s) to in (ib) out (s)",
+			"org.chromium.Test: method Kick was removed",
+			"org.chromium.Test: signal Frobinated changed from (u) to (x)",
+			"org.chromium.Test: property Class changed from access readwrite to read",
+			"org.chromium.Test: property Name changed from type s to u",
+			"interface org.chromium.Other was removed",
+		},
+	}}
+
+	baseline := mustModel(t, baselineXML)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := introspect.BreakingChanges(baseline, mustModel(t, tc.current))
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("BreakingChanges failed (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
@@ -9,6 +9,8 @@ package introspect
 import (
 	"encoding/xml"
 	"fmt"
+	"regexp"
+	"strings"
 
 	"go.chromium.org/chromiumos/dbusbindings/dbustype"
 )
@@ -40,6 +42,35 @@ type Annotation struct {
 	Value string `xml:"value,attr"`
 }
 
+// Extension is an element in a vendor namespace which the generators don't
+// interpret, e.g. routing or policy hints for other tools.
+type Extension struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXML string     `xml:",innerxml"`
+}
+
+// Attr returns the value of the attribute of e with the given local name.
+func (e *Extension) Attr(name string) (string, bool) {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// filterExtensions returns the extensions in the namespace space.
+func filterExtensions(exts []Extension, space string) []Extension {
+	var ret []Extension
+	for _, e := range exts {
+		if e.XMLName.Space == space {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
 // encoding/xml package cannot handle conflicting attributes
 // in different namespaces, specificaly if one of them is root namespace. But we have such cases,
 // e.g. a method argument may contain both type and tp:type, and we have to select type.
@@ -62,8 +93,8 @@ type MethodArg struct {
 	Name      string             `xml:"name,attr"`
 	Type      NonNamespaceString `xml:"type,attr"`
 	Direction string             `xml:"direction,attr"`
-	// For now, MethodArg supports only ProtobufClass annotation only,
-	// so it can have at most one annotation.
+	// For now, MethodArg supports only ProtobufClass, CppType or AllowedValues
+	// annotation, so it can have at most one annotation.
 	Annotation Annotation `xml:"annotation"`
 }
 
@@ -88,13 +119,14 @@ type Method struct {
 	Args        []MethodArg  `xml:"arg"`
 	Annotations []Annotation `xml:"annotation"`
 	DocString   DocString    `xml:"docstring"`
+	Extensions  []Extension  `xml:",any"`
 }
 
 // SignalArg represents signal message.
 type SignalArg struct {
 	Name string `xml:"name,attr"`
 	Type string `xml:"type,attr"`
-	// For now, MethodArg supports only ProtobufClass annotation only,
+	// For now, SignalArg supports only ProtobufClass or CppType annotation,
 	// so it can have at most one annotation.
 	Annotation Annotation `xml:"annotation"`
 }
@@ -128,11 +160,73 @@ type Property struct {
 // "http://telepathy.freedesktop.org/wiki/DbusSpec#extensions-v0" xml tag to DocString after
 // fixing.
 type Interface struct {
-	Name       string     `xml:"name,attr"`
-	Methods    []Method   `xml:"method"`
-	Signals    []Signal   `xml:"signal"`
-	Properties []Property `xml:"property"`
-	DocString  DocString  `xml:"docstring"`
+	Name        string       `xml:"name,attr"`
+	Methods     []Method     `xml:"method"`
+	Signals     []Signal     `xml:"signal"`
+	Properties  []Property   `xml:"property"`
+	DocString   DocString    `xml:"docstring"`
+	Annotations []Annotation `xml:"annotation"`
+	Extensions  []Extension  `xml:",any"`
+}
+
+// Includes returns the headers which the interface requires to be included,
+// e.g. ones declaring the types used in CppType annotations.
+func (itf *Interface) Includes() []string {
+	var ret []string
+	for _, a := range itf.Annotations {
+		if a.Name == "org.chromium.DBus.Interface.Include" {
+			ret = append(ret, a.Value)
+		}
+	}
+	return ret
+}
+
+// ObjectPathTemplate is the pattern of the object paths at which a service
+// exposes instances of an interface, e.g. /org/chromium/Foo/{id}, where the
+// placeholder stands for exactly one element of the path.
+type ObjectPathTemplate struct {
+	// Prefix is the part of the path before the placeholder, e.g.
+	// "/org/chromium/Foo/".
+	Prefix string
+	// Param names the placeholder, e.g. "id".
+	Param string
+	// Suffix is the part of the path after the placeholder, possibly empty.
+	Suffix string
+}
+
+// objectPathTemplateRegexp matches object path templates with one placeholder
+// element named by a C++ identifier.
+var objectPathTemplateRegexp = regexp.MustCompile(`^((?:/[A-Za-z0-9_]+)*/)\{([A-Za-z_][A-Za-z0-9_]*)\}((?:/[A-Za-z0-9_]+)*)$`)
+
+// parseObjectPathTemplate parses the value of an ObjectPathTemplate annotation.
+func parseObjectPathTemplate(s string) (*ObjectPathTemplate, error) {
+	m := objectPathTemplateRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return nil, fmt.Errorf("invalid object path template %q, want a path with one {name} element", s)
+	}
+	return &ObjectPathTemplate{Prefix: m[1], Param: m[2], Suffix: m[3]}, nil
+}
+
+// ObjectPathTemplate returns the object path template given by the
+// ObjectPathTemplate annotation of the interface, or nil if there is none.
+func (itf *Interface) ObjectPathTemplate() *ObjectPathTemplate {
+	for _, a := range itf.Annotations {
+		if a.Name == "org.chromium.DBus.Interface.ObjectPathTemplate" {
+			// The value was checked by verifyInterface.
+			t, err := parseObjectPathTemplate(a.Value)
+			if err != nil {
+				return nil
+			}
+			return t
+		}
+	}
+	return nil
+}
+
+// ExtensionsIn returns the extension elements of the interface in the XML
+// namespace space.
+func (itf *Interface) ExtensionsIn(space string) []Extension {
+	return filterExtensions(itf.Extensions, space)
 }
 
 // Introspection represents object specification required for generating
@@ -164,6 +258,12 @@ func (m *Method) OutputArguments() []MethodArg {
 	return ret
 }
 
+// ExtensionsIn returns the extension elements of the method in the XML
+// namespace space.
+func (m *Method) ExtensionsIn(space string) []Extension {
+	return filterExtensions(m.Extensions, space)
+}
+
 // Kind returns the kind of method.
 func (m *Method) Kind() MethodKind {
 	for _, a := range m.Annotations {
@@ -209,6 +309,42 @@ func (m *Method) Const() bool {
 	return false
 }
 
+// PrivacySensitive returns true if the arguments of the method may hold user
+// data and must never be logged, as flagged by the PrivacySensitive annotation.
+func (m *Method) PrivacySensitive() bool {
+	for _, a := range m.Annotations {
+		if a.Name == "org.chromium.DBus.Method.PrivacySensitive" {
+			return a.Value == "true"
+		}
+	}
+	return false
+}
+
+// Serialized returns true if calls of the method must not overlap, as declared
+// by the Concurrency annotation with value "serialized". By default, and with
+// value "parallel", a call may start before the previous ones have replied.
+func (m *Method) Serialized() bool {
+	for _, a := range m.Annotations {
+		if a.Name == "org.chromium.DBus.Method.Concurrency" {
+			return a.Value == "serialized"
+		}
+	}
+	return false
+}
+
+// DefaultLastInput returns the C++ expression given by the DefaultLastInput
+// annotation, which makes the last input argument optional: callers may omit
+// it, and the method is then called with this value. Returns an empty string
+// if the method has no optional argument.
+func (m *Method) DefaultLastInput() string {
+	for _, a := range m.Annotations {
+		if a.Name == "org.chromium.DBus.Method.DefaultLastInput" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
 // BaseType returns the C++ type corresponding to the type that the argument describes.
 func (a *MethodArg) BaseType() (string, error) {
 	return baseTypeInternal(string(a.Type), &a.Annotation)
@@ -226,6 +362,33 @@ func (a *MethodArg) OutArgType() (string, error) {
 	return outArgTypeInternal(string(a.Type), &a.Annotation)
 }
 
+// AllowedValues returns the values given by the AllowedValues annotation, a
+// comma-separated list of the only strings the argument may hold, or nil if
+// the argument has no such annotation.
+func (a *MethodArg) AllowedValues() []string {
+	if a.Annotation.Name != "org.chromium.DBus.Argument.AllowedValues" {
+		return nil
+	}
+	var values []string
+	for _, v := range strings.Split(a.Annotation.Value, ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+	return values
+}
+
+// MoveOnly tells whether the C++ type of the argument can't be copied, i.e.
+// whether it holds a file descriptor.
+func (a *MethodArg) MoveOnly() (bool, error) {
+	if customCppType(&a.Annotation) != "" {
+		return false, nil
+	}
+	typ, err := dbustype.Parse(string(a.Type))
+	if err != nil {
+		return false, err
+	}
+	return typ.MoveOnly(), nil
+}
+
 // CallbackType returns the C++ type to be used as a callback's argument.
 func (a *MethodArg) CallbackType() (string, error) {
 	// This is workaround to deal with current function layering structure.
@@ -285,10 +448,28 @@ func (p *Property) VariableName() string {
 	return p.Name
 }
 
-func baseTypeInternal(s string, a *Annotation) (string, error) {
+// customCppType returns the C++ type specified by a ProtobufClass or CppType
+// annotation, or an empty string if there is none.
+func customCppType(a *Annotation) string {
+	if a == nil {
+		return ""
+	}
+	switch a.Name {
 	// chromeos-dbus-binding supports native protobuf types.
-	if a != nil && a.Name == "org.chromium.DBus.Argument.ProtobufClass" {
-		return a.Value, nil
+	case "org.chromium.DBus.Argument.ProtobufClass":
+		return a.Value
+	// Other types are serialized by brillo::dbus_utils::DBusType
+	// specializations, declared in the headers listed in the Include
+	// annotations of the interface.
+	case "org.chromium.DBus.Argument.CppType":
+		return a.Value
+	}
+	return ""
+}
+
+func baseTypeInternal(s string, a *Annotation) (string, error) {
+	if t := customCppType(a); t != "" {
+		return t, nil
 	}
 
 	typ, err := dbustype.Parse(s)
@@ -299,9 +480,8 @@ func baseTypeInternal(s string, a *Annotation) (string, error) {
 }
 
 func inArgTypeInternal(s string, a *Annotation) (string, error) {
-	// chromeos-dbus-binding supports native protobuf types.
-	if a != nil && a.Name == "org.chromium.DBus.Argument.ProtobufClass" {
-		return fmt.Sprintf("const %s&", a.Value), nil
+	if t := customCppType(a); t != "" {
+		return fmt.Sprintf("const %s&", t), nil
 	}
 
 	typ, err := dbustype.Parse(s)
@@ -312,9 +492,8 @@ func inArgTypeInternal(s string, a *Annotation) (string, error) {
 }
 
 func outArgTypeInternal(s string, a *Annotation) (string, error) {
-	// chromeos-dbus-binding supports native protobuf types.
-	if a != nil && a.Name == "org.chromium.DBus.Argument.ProtobufClass" {
-		return fmt.Sprintf("%s*", a.Value), nil
+	if t := customCppType(a); t != "" {
+		return fmt.Sprintf("%s*", t), nil
 	}
 
 	typ, err := dbustype.Parse(s)
@@ -160,12 +160,141 @@ func TestConst(t *testing.T) {
 	}
 }
 
+func TestPrivacySensitive(t *testing.T) {
+	cases := []struct {
+		input introspect.Method
+		want  bool
+	}{
+		{
+			input: introspect.Method{
+				Name: "f1",
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.PrivacySensitive", Value: "true"},
+				},
+			},
+			want: true,
+		}, {
+			input: introspect.Method{
+				Name: "f2",
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.PrivacySensitive", Value: "false"},
+				},
+			},
+			want: false,
+		}, {
+			input: introspect.Method{
+				Name: "f3",
+			},
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		got := tc.input.PrivacySensitive()
+		if got != tc.want {
+			t.Errorf("PrivacySensitive failed, method name is %s\n got %t, want %t", tc.input.Name, got, tc.want)
+		}
+	}
+}
+
+func TestSerialized(t *testing.T) {
+	cases := []struct {
+		input introspect.Method
+		want  bool
+	}{
+		{
+			input: introspect.Method{
+				Name: "f1",
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.Concurrency", Value: "serialized"},
+				},
+			},
+			want: true,
+		}, {
+			input: introspect.Method{
+				Name: "f2",
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.Concurrency", Value: "parallel"},
+				},
+			},
+			want: false,
+		}, {
+			input: introspect.Method{
+				Name: "f3",
+			},
+			want: false,
+		},
+	}
+	for _, tc := range cases {
+		got := tc.input.Serialized()
+		if got != tc.want {
+			t.Errorf("Serialized failed, method name is %s\n got %t, want %t", tc.input.Name, got, tc.want)
+		}
+	}
+}
+
+func TestDefaultLastInput(t *testing.T) {
+	cases := []struct {
+		input introspect.Method
+		want  string
+	}{
+		{
+			input: introspect.Method{
+				Name: "f1",
+				Annotations: []introspect.Annotation{
+					{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+				},
+			},
+			want: "0",
+		}, {
+			input: introspect.Method{
+				Name: "f2",
+			},
+			want: "",
+		},
+	}
+	for _, tc := range cases {
+		got := tc.input.DefaultLastInput()
+		if got != tc.want {
+			t.Errorf("DefaultLastInput failed, method name is %s\n got %q, want %q", tc.input.Name, got, tc.want)
+		}
+	}
+}
+
+func TestAllowedValues(t *testing.T) {
+	cases := []struct {
+		input introspect.MethodArg
+		want  []string
+	}{
+		{
+			input: introspect.MethodArg{
+				Name: "mode",
+				Type: "s",
+				Annotation: introspect.Annotation{
+					Name:  "org.chromium.DBus.Argument.AllowedValues",
+					Value: "Color, Grayscale,Lineart",
+				},
+			},
+			want: []string{"Color", "Grayscale", "Lineart"},
+		}, {
+			input: introspect.MethodArg{Name: "name", Type: "s"},
+			want:  nil,
+		},
+	}
+	for _, tc := range cases {
+		got := tc.input.AllowedValues()
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("AllowedValues failed, argument name is %s\n(-got +want):\n%s", tc.input.Name, diff)
+		}
+	}
+}
+
 func TestMethodArgMethods(t *testing.T) {
 	cases := []struct {
 		receiver   introspect.MethodArg
 		BaseType   string
 		InArgType  string
 		OutArgType string
+		MoveOnly   bool
 	}{
 		{
 			receiver: introspect.MethodArg{
@@ -179,6 +308,18 @@ func TestMethodArgMethods(t *testing.T) {
 			BaseType:   "MyProtobufClass",
 			InArgType:  "const MyProtobufClass&",
 			OutArgType: "MyProtobufClass*",
+		}, {
+			receiver: introspect.MethodArg{
+				Name: "arg5",
+				Type: "x",
+				Annotation: introspect.Annotation{
+					Name:  "org.chromium.DBus.Argument.CppType",
+					Value: "base::Time",
+				},
+			},
+			BaseType:   "base::Time",
+			InArgType:  "const base::Time&",
+			OutArgType: "base::Time*",
 		}, {
 			receiver: introspect.MethodArg{
 				Name: "arg2",
@@ -187,6 +328,7 @@ func TestMethodArgMethods(t *testing.T) {
 			BaseType:   "base::ScopedFD",
 			InArgType:  "const base::ScopedFD&",
 			OutArgType: "base::ScopedFD*",
+			MoveOnly:   true,
 		},
 	}
 
@@ -212,6 +354,13 @@ func TestMethodArgMethods(t *testing.T) {
 		if got != tc.OutArgType {
 			t.Fatalf("Unexpected out arg type of %q; want %s, got %s", tc.receiver.Name, tc.OutArgType, got)
 		}
+		moveOnly, err := tc.receiver.MoveOnly()
+		if err != nil {
+			t.Fatalf("Failed to tell whether %q is move-only: %v", tc.receiver.Name, err)
+		}
+		if moveOnly != tc.MoveOnly {
+			t.Fatalf("Unexpected move-only of %q; want %t, got %t", tc.receiver.Name, tc.MoveOnly, moveOnly)
+		}
 	}
 }
 
@@ -332,3 +481,46 @@ func TestPropertyMethods(t *testing.T) {
 		}
 	}
 }
+
+func TestInterfaceIncludes(t *testing.T) {
+	itf := introspect.Interface{
+		Name: "itf",
+		Annotations: []introspect.Annotation{
+			{Name: "org.chromium.DBus.Interface.Include", Value: "base/time/time.h"},
+			{Name: "ignored", Value: "foo"},
+			{Name: "org.chromium.DBus.Interface.Include", Value: "net-base/ip_address.h"},
+		},
+	}
+	got := itf.Includes()
+	want := []string{"base/time/time.h", "net-base/ip_address.h"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Includes failed (-got +want):\n%s", diff)
+	}
+}
+
+func TestInterfaceObjectPathTemplate(t *testing.T) {
+	cases := []struct {
+		value string
+		want  *introspect.ObjectPathTemplate
+	}{
+		{"/org/chromium/Foo/{id}", &introspect.ObjectPathTemplate{Prefix: "/org/chromium/Foo/", Param: "id"}},
+		{"/org/chromium/{device}/Port", &introspect.ObjectPathTemplate{Prefix: "/org/chromium/", Param: "device", Suffix: "/Port"}},
+		{"/{name}", &introspect.ObjectPathTemplate{Prefix: "/", Param: "name"}},
+	}
+	for _, tc := range cases {
+		itf := introspect.Interface{
+			Name: "itf",
+			Annotations: []introspect.Annotation{
+				{Name: "org.chromium.DBus.Interface.ObjectPathTemplate", Value: tc.value},
+			},
+		}
+		if diff := cmp.Diff(itf.ObjectPathTemplate(), tc.want); diff != "" {
+			t.Errorf("ObjectPathTemplate of %q failed (-got +want):\n%s", tc.value, diff)
+		}
+	}
+
+	itf := introspect.Interface{Name: "itf"}
+	if got := itf.ObjectPathTemplate(); got != nil {
+		t.Errorf("ObjectPathTemplate without annotation got %v, want nil", got)
+	}
+}
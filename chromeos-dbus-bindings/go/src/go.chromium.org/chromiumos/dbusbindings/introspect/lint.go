@@ -0,0 +1,178 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package introspect
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// LintIssue is an annotation which doesn't fit the element it annotates.
+type LintIssue struct {
+	// Line and Column locate the annotation in the XML, starting at 1.
+	Line   int
+	Column int
+	// Element names the annotated element, e.g. "Foo method: bar argument".
+	Element string
+	Message string
+}
+
+// String formats i as "line:column: element: message".
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Element, i.Message)
+}
+
+// LintError lists all the issues found by Lint, in the order of the XML.
+type LintError []LintIssue
+
+// Error lists the issues of e, one per line.
+func (e LintError) Error() string {
+	var lines []string
+	for _, i := range e {
+		lines = append(lines, i.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// identifierRegexp matches valid C++ identifiers.
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// lintFrame holds the attributes Lint looks at of an element in the root
+// namespace. kind is empty for elements in other namespaces.
+type lintFrame struct {
+	kind, name, typ, value string
+}
+
+// Lint checks that the annotations in the introspection XML content are
+// consistent with the elements they annotate: ProtobufClass annotations only
+// appear on "ay" arguments, VariableName annotations give valid C++
+// identifiers, and EmitsChangedSignal annotations have a legal value. Rather
+// than stopping at the first violation, it returns a LintError listing all of
+// them.
+func Lint(content []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(content))
+	var stack []lintFrame
+	var issues LintError
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			frame := lintFrame{kind: t.Name.Local}
+			if t.Name.Space != "" {
+				frame.kind = ""
+			}
+			for _, a := range t.Attr {
+				if a.Name.Space != "" {
+					continue
+				}
+				switch a.Name.Local {
+				case "name":
+					frame.name = a.Value
+				case "type":
+					frame.typ = a.Value
+				case "value":
+					frame.value = a.Value
+				}
+			}
+			if frame.kind == "annotation" {
+				if msg := lintAnnotation(frame, stack); msg != "" {
+					line, column := position(content, offset)
+					issues = append(issues, LintIssue{
+						Line:    line,
+						Column:  column,
+						Element: describeFrames(stack),
+						Message: msg,
+					})
+				}
+			}
+			stack = append(stack, frame)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if len(issues) > 0 {
+		return issues
+	}
+	return nil
+}
+
+// lintAnnotation returns what is wrong with annotation, enclosed by the
+// elements in stack, if anything.
+func lintAnnotation(annotation lintFrame, stack []lintFrame) string {
+	var parent lintFrame
+	if len(stack) > 0 {
+		parent = stack[len(stack)-1]
+	}
+
+	switch annotation.name {
+	case "org.chromium.DBus.Argument.ProtobufClass":
+		if parent.kind != "arg" {
+			return fmt.Sprintf("%s annotation only applies to arguments", annotation.name)
+		}
+		if parent.typ != "ay" {
+			return fmt.Sprintf("%s annotation requires type ay, got %q", annotation.name, parent.typ)
+		}
+	case "org.chromium.DBus.Method.PrivacySensitive", "org.chromium.DBus.Method.Concurrency":
+		if parent.kind != "method" {
+			return fmt.Sprintf("%s annotation only applies to methods", annotation.name)
+		}
+	case "org.chromium.DBus.Interface.ObjectPathTemplate":
+		if parent.kind != "interface" {
+			return fmt.Sprintf("%s annotation only applies to interfaces", annotation.name)
+		}
+	case "org.chromium.DBus.Argument.VariableName":
+		if !identifierRegexp.MatchString(annotation.value) {
+			return fmt.Sprintf("%s annotation value %q is not a valid C++ identifier", annotation.name, annotation.value)
+		}
+	case "org.freedesktop.DBus.Property.EmitsChangedSignal":
+		switch annotation.value {
+		case "true", "invalidates", "const", "false":
+		default:
+			return fmt.Sprintf("%s annotation value %q is not one of true, invalidates, const or false", annotation.name, annotation.value)
+		}
+	}
+	return ""
+}
+
+// describeFrames names the innermost element of stack along with its
+// enclosing interface, method or signal, like verifyIntrospection errors.
+func describeFrames(stack []lintFrame) string {
+	var parts []string
+	for _, f := range stack {
+		switch f.kind {
+		case "interface", "method", "signal", "property", "arg":
+			kind := f.kind
+			if kind == "arg" {
+				kind = "argument"
+			}
+			parts = append(parts, fmt.Sprintf("%s %s", f.name, kind))
+		}
+	}
+	if len(parts) == 0 {
+		return "node"
+	}
+	return strings.Join(parts, ": ")
+}
+
+// position returns the 1-based line and column of offset in content.
+func position(content []byte, offset int64) (line, column int) {
+	before := content[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package introspect
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	const content = `<node>
+  <interface name="test.Itf">
+    <annotation name="org.freedesktop.DBus.Property.EmitsChangedSignal" value="sometimes"/>
+    <method name="Method">
+      <arg name="ok" type="ay" direction="in">
+        <annotation name="org.chromium.DBus.Argument.ProtobufClass" value="Proto"/>
+      </arg>
+      <arg name="bad" type="s" direction="out">
+        <annotation name="org.chromium.DBus.Argument.ProtobufClass" value="Proto"/>
+      </arg>
+      <annotation name="org.chromium.DBus.Argument.ProtobufClass" value="Proto"/>
+    </method>
+    <signal name="Signal">
+      <arg name="bad" type="u">
+        <annotation name="org.chromium.DBus.Argument.ProtobufClass" value="Proto"/>
+      </arg>
+      <annotation name="org.chromium.DBus.Method.PrivacySensitive" value="true"/>
+    </signal>
+    <property name="Prop" type="u" access="read">
+      <annotation name="org.chromium.DBus.Argument.VariableName" value="1st-prop"/>
+      <annotation name="org.freedesktop.DBus.Property.EmitsChangedSignal" value="invalidates"/>
+    </property>
+    <property name="Other" type="u" access="read">
+      <annotation name="org.chromium.DBus.Argument.VariableName" value="other_prop"/>
+    </property>
+  </interface>
+</node>
+`
+	err := Lint([]byte(content))
+	got, ok := err.(LintError)
+	if !ok {
+		t.Fatalf("Lint got %v, want LintError", err)
+	}
+
+	want := LintError{
+		{
+			Line:    3,
+			Column:  5,
+			Element: "test.Itf interface",
+			Message: `org.freedesktop.DBus.Property.EmitsChangedSignal annotation value "sometimes" is not one of true, invalidates, const or false`,
+		}, {
+			Line:    9,
+			Column:  9,
+			Element: "test.Itf interface: Method method: bad argument",
+			Message: `org.chromium.DBus.Argument.ProtobufClass annotation requires type ay, got "s"`,
+		}, {
+			Line:    11,
+			Column:  7,
+			Element: "test.Itf interface: Method method",
+			Message: "org.chromium.DBus.Argument.ProtobufClass annotation only applies to arguments",
+		}, {
+			Line:    15,
+			Column:  9,
+			Element: "test.Itf interface: Signal signal: bad argument",
+			Message: `org.chromium.DBus.Argument.ProtobufClass annotation requires type ay, got "u"`,
+		}, {
+			Line:    17,
+			Column:  7,
+			Element: "test.Itf interface: Signal signal",
+			Message: "org.chromium.DBus.Method.PrivacySensitive annotation only applies to methods",
+		}, {
+			Line:    20,
+			Column:  7,
+			Element: "test.Itf interface: Prop property",
+			Message: `org.chromium.DBus.Argument.VariableName annotation value "1st-prop" is not a valid C++ identifier`,
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Lint diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestLintValid(t *testing.T) {
+	const content = `<node xmlns:tp="http://telepathy.freedesktop.org/wiki/DbusSpec#extensions-v0">
+  <interface name="test.Itf">
+    <method name="Method">
+      <arg name="in" type="ay" tp:type="Proto" direction="in">
+        <annotation name="org.chromium.DBus.Argument.ProtobufClass" value="Proto"/>
+      </arg>
+      <annotation name="org.chromium.DBus.Method.PrivacySensitive" value="true"/>
+    </method>
+    <property name="Prop" type="u" access="read">
+      <annotation name="org.freedesktop.DBus.Property.EmitsChangedSignal" value="const"/>
+    </property>
+  </interface>
+</node>
+`
+	if err := Lint([]byte(content)); err != nil {
+		t.Errorf("Lint got error, want nil: %v", err)
+	}
+}
+
+func TestLintMalformed(t *testing.T) {
+	if err := Lint([]byte("<node><interface></node>")); err == nil {
+		t.Error("Lint unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package introspect
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// The types below describe the introspection model as seen by the generators,
+// i.e. with defaults applied and annotations resolved, so that external tools
+// consuming the JSON dump do not need to re-implement the XML quirks handling.
+
+// ModelArg is a resolved method or signal argument.
+type ModelArg struct {
+	Name          string `json:"name,omitempty"`
+	Type          string `json:"type"`
+	Direction     string `json:"direction,omitempty"`
+	ProtobufClass string `json:"protobuf_class,omitempty"`
+	CppType       string `json:"cpp_type,omitempty"`
+}
+
+// ModelMethod is a resolved method.
+type ModelMethod struct {
+	Name               string     `json:"name"`
+	Kind               string     `json:"kind"`
+	Const              bool       `json:"const"`
+	IncludeDBusMessage bool       `json:"include_dbus_message"`
+	DefaultLastInput   string     `json:"default_last_input,omitempty"`
+	PrivacySensitive   bool       `json:"privacy_sensitive,omitempty"`
+	Serialized         bool       `json:"serialized,omitempty"`
+	Args               []ModelArg `json:"args"`
+	DocString          string     `json:"docstring,omitempty"`
+}
+
+// ModelSignal is a resolved signal.
+type ModelSignal struct {
+	Name      string     `json:"name"`
+	Args      []ModelArg `json:"args"`
+	DocString string     `json:"docstring,omitempty"`
+}
+
+// ModelProperty is a resolved property.
+type ModelProperty struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Access       string `json:"access"`
+	VariableName string `json:"variable_name"`
+	DocString    string `json:"docstring,omitempty"`
+}
+
+// ModelInterface is a resolved interface.
+type ModelInterface struct {
+	Name       string          `json:"name"`
+	Methods    []ModelMethod   `json:"methods"`
+	Signals    []ModelSignal   `json:"signals"`
+	Properties []ModelProperty `json:"properties"`
+	DocString  string          `json:"docstring,omitempty"`
+	Includes   []string        `json:"includes,omitempty"`
+}
+
+// ModelIntrospection is a resolved introspection, i.e. one input file.
+type ModelIntrospection struct {
+	ObjectPath string           `json:"object_path,omitempty"`
+	Interfaces []ModelInterface `json:"interfaces"`
+}
+
+// String returns the name of the kind as used in the Kind annotation.
+func (k MethodKind) String() string {
+	switch k {
+	case MethodKindSimple:
+		return "simple"
+	case MethodKindNormal:
+		return "normal"
+	case MethodKindAsync:
+		return "async"
+	case MethodKindRaw:
+		return "raw"
+	}
+	return "unknown"
+}
+
+func protobufClass(a Annotation) string {
+	if a.Name == "org.chromium.DBus.Argument.ProtobufClass" {
+		return a.Value
+	}
+	return ""
+}
+
+func cppType(a Annotation) string {
+	if a.Name == "org.chromium.DBus.Argument.CppType" {
+		return a.Value
+	}
+	return ""
+}
+
+// NewModel converts introspects into the resolved model.
+func NewModel(introspects []Introspection) []ModelIntrospection {
+	ret := []ModelIntrospection{}
+	for _, is := range introspects {
+		mi := ModelIntrospection{ObjectPath: is.Name, Interfaces: []ModelInterface{}}
+		for _, itf := range is.Interfaces {
+			mi.Interfaces = append(mi.Interfaces, newModelInterface(itf))
+		}
+		ret = append(ret, mi)
+	}
+	return ret
+}
+
+func newModelInterface(itf Interface) ModelInterface {
+	ret := ModelInterface{
+		Name:       itf.Name,
+		Methods:    []ModelMethod{},
+		Signals:    []ModelSignal{},
+		Properties: []ModelProperty{},
+		DocString:  strings.TrimSpace(string(itf.DocString)),
+		Includes:   itf.Includes(),
+	}
+	for _, m := range itf.Methods {
+		mm := ModelMethod{
+			Name:               m.Name,
+			Kind:               m.Kind().String(),
+			Const:              m.Const(),
+			IncludeDBusMessage: m.IncludeDBusMessage(),
+			DefaultLastInput:   m.DefaultLastInput(),
+			PrivacySensitive:   m.PrivacySensitive(),
+			Serialized:         m.Serialized(),
+			Args:               []ModelArg{},
+			DocString:          strings.TrimSpace(string(m.DocString)),
+		}
+		for _, a := range m.Args {
+			dir := a.Direction
+			if dir == "" { // default direction is "in"
+				dir = "in"
+			}
+			mm.Args = append(mm.Args, ModelArg{
+				Name:          a.Name,
+				Type:          string(a.Type),
+				Direction:     dir,
+				ProtobufClass: protobufClass(a.Annotation),
+				CppType:       cppType(a.Annotation),
+			})
+		}
+		ret.Methods = append(ret.Methods, mm)
+	}
+	for _, s := range itf.Signals {
+		ms := ModelSignal{
+			Name:      s.Name,
+			Args:      []ModelArg{},
+			DocString: strings.TrimSpace(string(s.DocString)),
+		}
+		for _, a := range s.Args {
+			ms.Args = append(ms.Args, ModelArg{
+				Name:          a.Name,
+				Type:          a.Type,
+				ProtobufClass: protobufClass(a.Annotation),
+				CppType:       cppType(a.Annotation),
+			})
+		}
+		ret.Signals = append(ret.Signals, ms)
+	}
+	for _, p := range itf.Properties {
+		ret.Properties = append(ret.Properties, ModelProperty{
+			Name:         p.Name,
+			Type:         p.Type,
+			Access:       p.Access,
+			VariableName: p.VariableName(),
+			DocString:    strings.TrimSpace(string(p.DocString)),
+		})
+	}
+	return ret
+}
+
+// DumpModel writes the resolved model of introspects into f as JSON.
+func DumpModel(introspects []Introspection, f io.Writer) error {
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewModel(introspects))
+}
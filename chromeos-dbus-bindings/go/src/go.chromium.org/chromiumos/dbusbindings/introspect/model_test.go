@@ -0,0 +1,117 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package introspect_test
+
+import (
+	"bytes"
+	"testing"
+
+	"go.chromium.org/chromiumos/dbusbindings/introspect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDumpModel(t *testing.T) {
+	is, err := introspect.Parse([]byte(`
+<node name="/org/chromium/Test"
+      xmlns:tp="http://telepathy.freedesktop.org/wiki/DbusSpec#extensions-v0">
+  <interface name="org.chromium.Test">
+    <method name="Frobinate">
+      <arg name="foo" type="i"/>
+      <arg name="request" type="ay" direction="in">
+        <annotation name="org.chromium.DBus.Argument.ProtobufClass" value="FrobinateRequest" />
+      </arg>
+      <arg name="bar" type="s" direction="out"/>
+      <annotation name="org.freedesktop.DBus.GLib.Async"/>
+      <annotation name="org.chromium.DBus.Method.PrivacySensitive" value="true"/>
+      <tp:docstring>
+        method doc
+      </tp:docstring>
+    </method>
+    <signal name="Frobinated">
+      <arg type="u"/>
+    </signal>
+    <property name="Class" type="u" access="read">
+      <annotation name="org.chromium.DBus.Argument.VariableName" value="bluetooth_class"/>
+    </property>
+  </interface>
+  <interface name="EmptyInterface" />
+</node>`))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+
+	out := new(bytes.Buffer)
+	if err := introspect.DumpModel([]introspect.Introspection{is}, out); err != nil {
+		t.Fatalf("DumpModel got error, want nil: %v", err)
+	}
+
+	const want = `[
+  {
+    "object_path": "/org/chromium/Test",
+    "interfaces": [
+      {
+        "name": "org.chromium.Test",
+        "methods": [
+          {
+            "name": "Frobinate",
+            "kind": "async",
+            "const": false,
+            "include_dbus_message": false,
+            "privacy_sensitive": true,
+            "args": [
+              {
+                "name": "foo",
+                "type": "i",
+                "direction": "in"
+              },
+              {
+                "name": "request",
+                "type": "ay",
+                "direction": "in",
+                "protobuf_class": "FrobinateRequest"
+              },
+              {
+                "name": "bar",
+                "type": "s",
+                "direction": "out"
+              }
+            ],
+            "docstring": "method doc"
+          }
+        ],
+        "signals": [
+          {
+            "name": "Frobinated",
+            "args": [
+              {
+                "type": "u"
+              }
+            ]
+          }
+        ],
+        "properties": [
+          {
+            "name": "Class",
+            "type": "u",
+            "access": "read",
+            "variable_name": "bluetooth_class"
+          }
+        ]
+      },
+      {
+        "name": "EmptyInterface",
+        "methods": [],
+        "signals": [],
+        "properties": []
+      }
+    ]
+  }
+]
+`
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("DumpModel failed (-got +want):\n%s", diff)
+	}
+}
@@ -6,6 +6,7 @@ package introspect
 
 import (
 	"encoding/xml"
+	"fmt"
 )
 
 // Parse converts introspection from the XML to a structure.
@@ -14,8 +15,54 @@ func Parse(content []byte) (Introspection, error) {
 	if err := xml.Unmarshal(content, &i); err != nil {
 		return Introspection{}, err
 	}
+	dropUnqualifiedExtensions(&i)
 	if err := verifyIntrospection(&i); err != nil {
 		return Introspection{}, err
 	}
 	return i, nil
 }
+
+// ParseInterfaces is like Parse, but leaves out the interfaces which fail
+// verification instead of failing, returning an error for each of them. err is
+// only set if content can't be unmarshaled.
+func ParseInterfaces(content []byte) (i Introspection, itfErrs []error, err error) {
+	if err := xml.Unmarshal(content, &i); err != nil {
+		return Introspection{}, nil, err
+	}
+	dropUnqualifiedExtensions(&i)
+
+	var itfs []Interface
+	for _, itf := range i.Interfaces {
+		if err := verifyInterface(&itf); err != nil {
+			itfErrs = append(itfErrs, fmt.Errorf("%s interface: %v", itf.Name, err))
+			continue
+		}
+		itfs = append(itfs, itf)
+	}
+	i.Interfaces = itfs
+	return i, itfErrs, nil
+}
+
+// dropUnqualifiedExtensions removes the unknown elements without a namespace
+// from i. Only elements in a vendor namespace are kept as extensions.
+func dropUnqualifiedExtensions(i *Introspection) {
+	for j := range i.Interfaces {
+		itf := &i.Interfaces[j]
+		itf.Extensions = qualifiedExtensions(itf.Extensions)
+		for k := range itf.Methods {
+			m := &itf.Methods[k]
+			m.Extensions = qualifiedExtensions(m.Extensions)
+		}
+	}
+}
+
+// qualifiedExtensions returns the extensions which are in a namespace.
+func qualifiedExtensions(exts []Extension) []Extension {
+	var ret []Extension
+	for _, e := range exts {
+		if e.XMLName.Space != "" {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
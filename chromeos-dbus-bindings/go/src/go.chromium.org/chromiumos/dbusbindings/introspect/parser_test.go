@@ -143,7 +143,7 @@ func TestGoodXMLContents(t *testing.T) {
 		Name: "/org/chromium/Test",
 		Interfaces: []introspect.Interface{
 			itf,
-			{"DummyInterface", nil, nil, nil, ""},
+			{Name: "DummyInterface"},
 		},
 	}
 
@@ -151,3 +151,89 @@ func TestGoodXMLContents(t *testing.T) {
 		t.Errorf("Parse failed (-got +want):\n%s", diff)
 	}
 }
+
+func TestExtensions(t *testing.T) {
+	const contents = `
+<node xmlns:acme="http://example.com/acme">
+  <interface name="org.chromium.Test">
+    <acme:routing target="primary"/>
+    <method name="Frob">
+      <acme:policy level="admin">only <acme:b>admins</acme:b></acme:policy>
+    </method>
+    <unknown/>
+  </interface>
+</node>`
+
+	got, err := introspect.Parse([]byte(contents))
+	if err != nil {
+		t.Fatalf("Parse got error, want nil: %v", err)
+	}
+
+	const acme = "http://example.com/acme"
+	itf := &got.Interfaces[0]
+	if n := len(itf.Extensions); n != 1 {
+		t.Fatalf("Unexpected number of interface extensions: got %d, want 1", n)
+	}
+	routing := itf.ExtensionsIn(acme)
+	if len(routing) != 1 || routing[0].XMLName.Local != "routing" {
+		t.Fatalf("Unexpected interface extensions in %s: %v", acme, routing)
+	}
+	if v, ok := routing[0].Attr("target"); !ok || v != "primary" {
+		t.Errorf("Unexpected target attribute: got %q, %t, want \"primary\", true", v, ok)
+	}
+	if _, ok := routing[0].Attr("missing"); ok {
+		t.Error("Unexpected missing attribute")
+	}
+	if exts := itf.ExtensionsIn("http://example.com/other"); len(exts) != 0 {
+		t.Errorf("Unexpected extensions in another namespace: %v", exts)
+	}
+
+	policy := itf.Methods[0].ExtensionsIn(acme)
+	if len(policy) != 1 || policy[0].XMLName.Local != "policy" {
+		t.Fatalf("Unexpected method extensions in %s: %v", acme, policy)
+	}
+	if want := "only <acme:b>admins</acme:b>"; policy[0].InnerXML != want {
+		t.Errorf("Unexpected policy contents: got %q, want %q", policy[0].InnerXML, want)
+	}
+}
+
+func TestParseInterfaces(t *testing.T) {
+	const contents = `
+<node>
+  <interface name="org.chromium.Good">
+    <method name="Ping"/>
+  </interface>
+  <interface name="org.chromium.Bad">
+    <method name="">
+      <arg name="x" type="i"/>
+    </method>
+  </interface>
+  <interface name="org.chromium.AlsoGood"/>
+</node>`
+
+	got, itfErrs, err := introspect.ParseInterfaces([]byte(contents))
+	if err != nil {
+		t.Fatalf("ParseInterfaces got error, want nil: %v", err)
+	}
+
+	var names []string
+	for _, itf := range got.Interfaces {
+		names = append(names, itf.Name)
+	}
+	if diff := cmp.Diff(names, []string{"org.chromium.Good", "org.chromium.AlsoGood"}); diff != "" {
+		t.Errorf("ParseInterfaces interfaces diff (-got +want):\n%s", diff)
+	}
+
+	var errs []string
+	for _, e := range itfErrs {
+		errs = append(errs, e.Error())
+	}
+	want := []string{"org.chromium.Bad interface:  method: empty method name specified"}
+	if diff := cmp.Diff(errs, want); diff != "" {
+		t.Errorf("ParseInterfaces errors diff (-got +want):\n%s", diff)
+	}
+
+	if _, _, err := introspect.ParseInterfaces([]byte(ungrammaticalXMLContents)); err == nil {
+		t.Error("ParseInterfaces of ungrammatical XML got nil error, want error")
+	}
+}
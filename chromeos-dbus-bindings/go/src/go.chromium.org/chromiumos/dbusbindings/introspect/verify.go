@@ -7,8 +7,13 @@ package introspect
 import (
 	"errors"
 	"fmt"
+	"regexp"
 )
 
+// allowedValueRegexp matches the values which an AllowedValues annotation can
+// list, so that they can be quoted in C++ and name enumerators.
+var allowedValueRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
 // TODO(chromium:983008): Add validations for the type signatures.
 
 // verifyIntrospection verifies that introspection does not contain invalid values.
@@ -17,6 +22,9 @@ func verifyIntrospection(i *Introspection) error {
 		if err := verifyInterface(&itf); err != nil {
 			return fmt.Errorf("%s interface: %v", itf.Name, err)
 		}
+		if i.Name != "" && itf.ObjectPathTemplate() != nil {
+			return fmt.Errorf("%s interface: object path template cannot be used with the fixed object path %s", itf.Name, i.Name)
+		}
 	}
 	return nil
 }
@@ -26,11 +34,29 @@ func verifyInterface(itf *Interface) error {
 		return errors.New("empty interface name specified")
 	}
 
+	for _, a := range itf.Annotations {
+		if a.Name == "org.chromium.DBus.Interface.Include" && a.Value == "" {
+			return fmt.Errorf("empty annotation value for %s", a.Name)
+		}
+		if a.Name == "org.chromium.DBus.Interface.ObjectPathTemplate" {
+			if _, err := parseObjectPathTemplate(a.Value); err != nil {
+				return err
+			}
+		}
+	}
+
 	for _, m := range itf.Methods {
 		if err := verifyMethod(&m); err != nil {
 			return fmt.Errorf("%s method: %v", m.Name, err)
 		}
 	}
+	for _, p := range itf.Properties {
+		// The proxy interfaces name each property with a <name>Name()
+		// accessor, next to their DBusInterfaceName().
+		if p.Name == "DBusInterface" {
+			return fmt.Errorf("%s property: name clashes with the DBusInterfaceName() of the proxy interface", p.Name)
+		}
+	}
 	// TODO(chromium:983008): Add validations for signals and properties.
 	return nil
 }
@@ -44,6 +70,9 @@ func verifyMethod(method *Method) error {
 		if err := verifyMethodArg(&arg); err != nil {
 			return fmt.Errorf("%s argument: %v", arg.Name, err)
 		}
+		if arg.AllowedValues() != nil && method.Kind() == MethodKindRaw {
+			return fmt.Errorf("%s argument: %s cannot be used with raw methods", arg.Name, arg.Annotation.Name)
+		}
 	}
 
 	// Verify that method annotation name is not duplicated.
@@ -76,6 +105,39 @@ func verifyMethod(method *Method) error {
 			default:
 				return fmt.Errorf("invalid annotation value for %s", annotation.Name)
 			}
+		case "org.chromium.DBus.Method.PrivacySensitive":
+			switch annotation.Value {
+			case "true", "false":
+			default:
+				return fmt.Errorf("invalid annotation value for %s", annotation.Name)
+			}
+			// Raw methods read the message themselves, so there is no
+			// generated code to redact.
+			if method.Kind() == MethodKindRaw {
+				return fmt.Errorf("%s cannot be used with raw methods", annotation.Name)
+			}
+		case "org.chromium.DBus.Method.Concurrency":
+			switch annotation.Value {
+			case "parallel":
+			case "serialized":
+				// Methods of other kinds reply before returning, so their calls
+				// never overlap anyway.
+				if method.Kind() != MethodKindAsync {
+					return fmt.Errorf("%s value serialized requires an async method", annotation.Name)
+				}
+			default:
+				return fmt.Errorf("invalid annotation value for %s", annotation.Name)
+			}
+		case "org.chromium.DBus.Method.DefaultLastInput":
+			if annotation.Value == "" {
+				return fmt.Errorf("empty annotation value for %s", annotation.Name)
+			}
+			if len(method.InputArguments()) == 0 {
+				return fmt.Errorf("%s requires an input argument", annotation.Name)
+			}
+			if method.Kind() == MethodKindRaw {
+				return fmt.Errorf("%s cannot be used with raw methods", annotation.Name)
+			}
 		case "org.freedesktop.DBus.GLib.Async":
 		}
 	}
@@ -100,8 +162,65 @@ func verifyMethodArg(arg *MethodArg) error {
 		if arg.Type != "ay" {
 			return fmt.Errorf("when using the %s annotation, the argument type must be %s", arg.Annotation.Name, "ay")
 		}
+	case "org.chromium.DBus.Argument.CppType":
+		if arg.Annotation.Value == "" {
+			return fmt.Errorf("empty annotation value for %s", arg.Annotation.Name)
+		}
+	case "org.chromium.DBus.Argument.AllowedValues":
+		if arg.Type != "s" {
+			return fmt.Errorf("when using the %s annotation, the argument type must be %s", arg.Annotation.Name, "s")
+		}
+		if arg.Direction == "out" {
+			return fmt.Errorf("%s only applies to input arguments", arg.Annotation.Name)
+		}
+		if arg.Name == "" {
+			return fmt.Errorf("%s requires a named argument", arg.Annotation.Name)
+		}
+		seen := make(map[string]bool)
+		for _, v := range arg.AllowedValues() {
+			if !allowedValueRegexp.MatchString(v) {
+				return fmt.Errorf("invalid value %q in %s", v, arg.Annotation.Name)
+			}
+			if seen[v] {
+				return fmt.Errorf("duplicate value %q in %s", v, arg.Annotation.Name)
+			}
+			seen[v] = true
+		}
 	case "":
 	}
 
 	return nil
 }
+
+// VerifyStrictKinds verifies that every method in introspection explicitly
+// specifies its kind, and that every method argument explicitly specifies its
+// direction, instead of relying on defaults.
+func VerifyStrictKinds(i *Introspection) error {
+	for _, itf := range i.Interfaces {
+		for _, m := range itf.Methods {
+			if err := verifyStrictMethod(&m); err != nil {
+				return fmt.Errorf("%s interface: %s method: %v", itf.Name, m.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func verifyStrictMethod(method *Method) error {
+	hasKind := false
+	for _, a := range method.Annotations {
+		if a.Name == "org.chromium.DBus.Method.Kind" || a.Name == "org.freedesktop.DBus.GLib.Async" {
+			hasKind = true
+		}
+	}
+	if !hasKind {
+		return errors.New("missing org.chromium.DBus.Method.Kind annotation")
+	}
+
+	for _, arg := range method.Args {
+		if arg.Direction == "" {
+			return fmt.Errorf("%s argument: missing direction", arg.Name)
+		}
+	}
+	return nil
+}
@@ -79,6 +79,23 @@ func TestInvalidMethodInterface(t *testing.T) {
 	}
 }
 
+func TestDBusInterfacePropertyInterface(t *testing.T) {
+	itf := Interface{
+		Name: "itf",
+		Properties: []Property{
+			{Name: "DBusInterface", Type: "s", Access: "read"},
+		},
+	}
+	err := verifyInterface(&itf)
+	if err == nil {
+		t.Fatal("verifyInterface unexpectedly succeeded")
+	}
+	const want = "DBusInterface property: name clashes with the DBusInterfaceName() of the proxy interface"
+	if err.Error() != want {
+		t.Errorf("verifyInterface err mismatch: got %q, want %q", err, want)
+	}
+}
+
 func TestValidInterface(t *testing.T) {
 	itf := Interface{
 		Name: "itf",
@@ -189,6 +206,81 @@ func TestInvalidMessageAnnotationMethod(t *testing.T) {
 	}
 }
 
+func TestInvalidPrivacySensitiveAnnotationMethod(t *testing.T) {
+	m := Method{
+		Name: "f",
+		Annotations: []Annotation{
+			{Name: "org.chromium.DBus.Method.PrivacySensitive", Value: "yes"},
+		},
+	}
+	err := verifyMethod(&m)
+	if err == nil {
+		t.Fatal("verifyMethod unexpectedly succeeded")
+	}
+	const want = "invalid annotation value for org.chromium.DBus.Method.PrivacySensitive"
+	if err.Error() != want {
+		t.Errorf("verifyMethod err mismatch: got %q, want %q", err, want)
+	}
+}
+
+func TestPrivacySensitiveRawMethod(t *testing.T) {
+	m := Method{
+		Name: "f",
+		Annotations: []Annotation{
+			{Name: "org.chromium.DBus.Method.Kind", Value: "raw"},
+			{Name: "org.chromium.DBus.Method.PrivacySensitive", Value: "true"},
+		},
+	}
+	err := verifyMethod(&m)
+	if err == nil {
+		t.Fatal("verifyMethod unexpectedly succeeded")
+	}
+	const want = "org.chromium.DBus.Method.PrivacySensitive cannot be used with raw methods"
+	if err.Error() != want {
+		t.Errorf("verifyMethod err mismatch: got %q, want %q", err, want)
+	}
+
+	m.Annotations[0].Value = "simple"
+	if err := verifyMethod(&m); err != nil {
+		t.Errorf("verifyMethod got error, want nil: %v", err)
+	}
+}
+
+func TestInvalidConcurrencyAnnotationMethod(t *testing.T) {
+	cases := []struct {
+		method Method
+		want   string
+	}{
+		{
+			method: Method{
+				Name: "f",
+				Annotations: []Annotation{
+					{Name: "org.chromium.DBus.Method.Concurrency", Value: "exclusive"},
+				},
+			},
+			want: "invalid annotation value for org.chromium.DBus.Method.Concurrency",
+		}, {
+			method: Method{
+				Name: "f",
+				Annotations: []Annotation{
+					{Name: "org.chromium.DBus.Method.Concurrency", Value: "serialized"},
+				},
+			},
+			want: "org.chromium.DBus.Method.Concurrency value serialized requires an async method",
+		},
+	}
+	for _, tc := range cases {
+		err := verifyMethod(&tc.method)
+		if err == nil {
+			t.Errorf("verifyMethod with annotations %v unexpectedly succeeded", tc.method.Annotations)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("verifyMethod err mismatch: got %q, want %q", err, tc.want)
+		}
+	}
+}
+
 func TestValidMethod(t *testing.T) {
 	m := Method{
 		Name: "f",
@@ -271,3 +363,252 @@ func TestValidArg(t *testing.T) {
 		}
 	}
 }
+
+func TestInvalidAllowedValuesArg(t *testing.T) {
+	const name = "org.chromium.DBus.Argument.AllowedValues"
+	cases := []struct {
+		arg  MethodArg
+		want string
+	}{
+		{
+			arg:  MethodArg{Name: "mode", Type: "i", Annotation: Annotation{Name: name, Value: "1,2"}},
+			want: "when using the org.chromium.DBus.Argument.AllowedValues annotation, the argument type must be s",
+		}, {
+			arg:  MethodArg{Name: "mode", Type: "s", Direction: "out", Annotation: Annotation{Name: name, Value: "Color"}},
+			want: "org.chromium.DBus.Argument.AllowedValues only applies to input arguments",
+		}, {
+			arg:  MethodArg{Type: "s", Annotation: Annotation{Name: name, Value: "Color"}},
+			want: "org.chromium.DBus.Argument.AllowedValues requires a named argument",
+		}, {
+			arg:  MethodArg{Name: "mode", Type: "s", Annotation: Annotation{Name: name, Value: "Color,,Gray"}},
+			want: `invalid value "" in org.chromium.DBus.Argument.AllowedValues`,
+		}, {
+			arg:  MethodArg{Name: "mode", Type: "s", Annotation: Annotation{Name: name, Value: "Color,Colour\""}},
+			want: `invalid value "Colour\"" in org.chromium.DBus.Argument.AllowedValues`,
+		}, {
+			arg:  MethodArg{Name: "mode", Type: "s", Annotation: Annotation{Name: name, Value: "Color,Gray,Color"}},
+			want: `duplicate value "Color" in org.chromium.DBus.Argument.AllowedValues`,
+		},
+	}
+	for _, tc := range cases {
+		err := verifyMethodArg(&tc.arg)
+		if err == nil {
+			t.Errorf("verifyMethodArg of %q unexpectedly succeeded", tc.arg.Annotation.Value)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("verifyMethodArg err mismatch: got %q, want %q", err, tc.want)
+		}
+	}
+
+	method := Method{
+		Name: "f",
+		Args: []MethodArg{{Name: "mode", Type: "s", Annotation: Annotation{Name: name, Value: "Color"}}},
+		Annotations: []Annotation{
+			{Name: "org.chromium.DBus.Method.Kind", Value: "raw"},
+		},
+	}
+	const want = "mode argument: org.chromium.DBus.Argument.AllowedValues cannot be used with raw methods"
+	if err := verifyMethod(&method); err == nil || err.Error() != want {
+		t.Errorf("verifyMethod err mismatch: got %v, want %q", err, want)
+	}
+}
+
+func TestStrictKindsMissingKind(t *testing.T) {
+	i := Introspection{
+		Interfaces: []Interface{
+			{
+				Name: "itf",
+				Methods: []Method{
+					{
+						Name: "f",
+						Args: []MethodArg{
+							{Name: "n", Direction: "in", Type: "i"},
+						},
+					},
+				},
+			},
+		},
+	}
+	err := VerifyStrictKinds(&i)
+	if err == nil {
+		t.Fatal("VerifyStrictKinds unexpectedly succeeded")
+	}
+	const want = "itf interface: f method: missing org.chromium.DBus.Method.Kind annotation"
+	if err.Error() != want {
+		t.Errorf("VerifyStrictKinds err mismatch: got %q, want %q", err, want)
+	}
+}
+
+func TestStrictKindsMissingDirection(t *testing.T) {
+	i := Introspection{
+		Interfaces: []Interface{
+			{
+				Name: "itf",
+				Methods: []Method{
+					{
+						Name: "f",
+						Args: []MethodArg{
+							{Name: "n", Type: "i"},
+						},
+						Annotations: []Annotation{
+							{Name: "org.chromium.DBus.Method.Kind", Value: "normal"},
+						},
+					},
+				},
+			},
+		},
+	}
+	err := VerifyStrictKinds(&i)
+	if err == nil {
+		t.Fatal("VerifyStrictKinds unexpectedly succeeded")
+	}
+	const want = "itf interface: f method: n argument: missing direction"
+	if err.Error() != want {
+		t.Errorf("VerifyStrictKinds err mismatch: got %q, want %q", err, want)
+	}
+}
+
+func TestStrictKindsValid(t *testing.T) {
+	i := Introspection{
+		Interfaces: []Interface{
+			{
+				Name: "itf",
+				Methods: []Method{
+					{
+						Name: "f",
+						Args: []MethodArg{
+							{Name: "n", Direction: "in", Type: "i"},
+						},
+						Annotations: []Annotation{
+							{Name: "org.chromium.DBus.Method.Kind", Value: "simple"},
+						},
+					}, {
+						Name: "g",
+						Annotations: []Annotation{
+							{Name: "org.freedesktop.DBus.GLib.Async"},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := VerifyStrictKinds(&i); err != nil {
+		t.Errorf("VerifyStrictKinds got error, want nil: %q", err)
+	}
+}
+
+func TestEmptyIncludeInterface(t *testing.T) {
+	itf := Interface{
+		Name: "itf",
+		Annotations: []Annotation{
+			{Name: "org.chromium.DBus.Interface.Include"},
+		},
+	}
+	err := verifyInterface(&itf)
+	if err == nil {
+		t.Fatal("verifyInterface unexpectedly succeeded")
+	}
+	const want = "empty annotation value for org.chromium.DBus.Interface.Include"
+	if err.Error() != want {
+		t.Errorf("verifyInterface err mismatch: got %q, want %q", err, want)
+	}
+}
+
+func TestEmptyCppTypeArg(t *testing.T) {
+	arg := MethodArg{
+		Annotation: Annotation{Name: "org.chromium.DBus.Argument.CppType"},
+		Type:       "x",
+	}
+	err := verifyMethodArg(&arg)
+	if err == nil {
+		t.Fatal("verifyMethodArg unexpectedly succeeded")
+	}
+	const want = "empty annotation value for org.chromium.DBus.Argument.CppType"
+	if err.Error() != want {
+		t.Errorf("verifyMethodArg err mismatch: got %q, want %q", err, want)
+	}
+}
+
+func TestInvalidDefaultLastInputMethod(t *testing.T) {
+	cases := []struct {
+		method Method
+		want   string
+	}{
+		{
+			method: Method{
+				Name: "f",
+				Args: []MethodArg{{Name: "n", Type: "i"}},
+				Annotations: []Annotation{
+					{Name: "org.chromium.DBus.Method.DefaultLastInput"},
+				},
+			},
+			want: "empty annotation value for org.chromium.DBus.Method.DefaultLastInput",
+		}, {
+			method: Method{
+				Name: "f",
+				Args: []MethodArg{{Name: "n", Direction: "out", Type: "i"}},
+				Annotations: []Annotation{
+					{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+				},
+			},
+			want: "org.chromium.DBus.Method.DefaultLastInput requires an input argument",
+		}, {
+			method: Method{
+				Name: "f",
+				Args: []MethodArg{{Name: "n", Type: "i"}},
+				Annotations: []Annotation{
+					{Name: "org.chromium.DBus.Method.Kind", Value: "raw"},
+					{Name: "org.chromium.DBus.Method.DefaultLastInput", Value: "0"},
+				},
+			},
+			want: "org.chromium.DBus.Method.DefaultLastInput cannot be used with raw methods",
+		},
+	}
+	for _, tc := range cases {
+		err := verifyMethod(&tc.method)
+		if err == nil {
+			t.Errorf("verifyMethod unexpectedly succeeded, want %q", tc.want)
+			continue
+		}
+		if err.Error() != tc.want {
+			t.Errorf("verifyMethod err mismatch: got %q, want %q", err, tc.want)
+		}
+	}
+}
+
+func TestInvalidObjectPathTemplateInterface(t *testing.T) {
+	for _, value := range []string{"", "/org/chromium/Foo", "/org/chromium/Foo{id}", "/org/{a}/{b}", "/org/{1st}", "org/{id}", "/org/{id}/"} {
+		itf := Interface{
+			Name: "itf",
+			Annotations: []Annotation{
+				{Name: "org.chromium.DBus.Interface.ObjectPathTemplate", Value: value},
+			},
+		}
+		if err := verifyInterface(&itf); err == nil {
+			t.Errorf("verifyInterface with object path template %q unexpectedly succeeded", value)
+		}
+	}
+}
+
+func TestObjectPathTemplateWithFixedPath(t *testing.T) {
+	i := Introspection{
+		Name: "/org/chromium/Foo",
+		Interfaces: []Interface{
+			{
+				Name: "itf",
+				Annotations: []Annotation{
+					{Name: "org.chromium.DBus.Interface.ObjectPathTemplate", Value: "/org/chromium/Foo/{id}"},
+				},
+			},
+		},
+	}
+	err := verifyIntrospection(&i)
+	if err == nil {
+		t.Fatal("verifyIntrospection unexpectedly succeeded")
+	}
+	const want = "itf interface: object path template cannot be used with the fixed object path /org/chromium/Foo"
+	if err.Error() != want {
+		t.Errorf("verifyIntrospection err mismatch: got %q, want %q", err, want)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"regexp"
+	"strings"
 )
 
 // ObjectManagerConfig is a way to configure the object manager class generation.
@@ -24,14 +26,71 @@ type ObjectManagerConfig struct {
 	ObjectPath string `json:"object_path"`
 }
 
+// Include guards of the method names headers.
+const (
+	IncludeGuardNone       = ""
+	IncludeGuardPragmaOnce = "pragma_once"
+	IncludeGuardIfndef     = "ifndef"
+)
+
+// MethodNamesConfig is a way to configure the method name constants.
+type MethodNamesConfig struct {
+	// Namespace is a C++ namespace, e.g. "frobinator::dbus", wrapping the
+	// namespaces named after the interfaces, so that the constants don't
+	// collide with those generated for the same interfaces by other
+	// services, e.g. in unity builds. If empty, the constants are only in the
+	// namespaces named after the interfaces.
+	Namespace string `json:"namespace"`
+	// IncludeGuard is IncludeGuardPragmaOnce or IncludeGuardIfndef to guard
+	// the generated headers, which are otherwise left unguarded.
+	IncludeGuard string `json:"include_guard"`
+}
+
+// cxxNamespaceRegexp matches C++ namespaces, possibly nested, e.g. "a::b".
+var cxxNamespaceRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(::[A-Za-z_][A-Za-z0-9_]*)*$`)
+
 // Config contains a way to configure header generations.
 type Config struct {
 	// ServiceName is a D-Bus service name to be used when constructing proxy objects.
 	// If omitted (empty), the service name parameter will be added to the
 	// constructor of generated proxy class(es).
 	ServiceName string `json:"service_name"`
+	// FallbackServiceNames lists alternate D-Bus service names, e.g. the old
+	// name of a service being renamed. Generated proxies use the first one of
+	// ServiceName followed by FallbackServiceNames which has an owner on the
+	// bus at construction time. Requires ServiceName to be set.
+	FallbackServiceNames []string `json:"fallback_service_names"`
+	// ExpectedMethods makes generated proxies also provide, for each method
+	// Foo, a FooExpected() call returning the output arguments as a
+	// base::expected instead of out-parameters and a bool.
+	ExpectedMethods bool `json:"expected_methods"`
+	// DedicatedBusInterfaces lists the interfaces whose generated proxies also
+	// provide a CreateWithDedicatedBus() factory, connecting each proxy to the
+	// bus on its own dbus::Bus instead of sharing the caller's connection.
+	// Can't be used with ObjectManager, which creates proxies on its own bus.
+	DedicatedBusInterfaces []string `json:"dedicated_bus_interfaces"`
+	// ProxyFactories makes generated proxies come with a CreateFooProxy()
+	// function returning each proxy as its interface, and, for objects with
+	// several interfaces, a struct bundling the proxies of all of them, to
+	// plug them into dependency injection.
+	ProxyFactories bool `json:"proxy_factories"`
+	// ProxyTraits makes generated proxies come with a FooProxyTraits struct
+	// describing each proxy interface at compile time: its name, number of
+	// methods and whether it has properties and signals, so that generic
+	// client-side utilities can be written once for all generated proxies.
+	ProxyTraits bool `json:"proxy_traits"`
+	// ProxyConcepts makes generated proxies come with a C++20 FooProxyLike
+	// concept per proxy interface, satisfied by the types providing its
+	// calls, e.g. the proxy and its mock. Requires C++20.
+	ProxyConcepts bool `json:"proxy_concepts"`
+	// Banner lists the lines of a comment block, e.g. a copyright notice,
+	// written at the top of every generated C++ file. Each line becomes a
+	// "//" comment of its own.
+	Banner []string `json:"banner"`
 	// ObjectManger contains the settings of ObjectManager outputs.
 	ObjectManager *ObjectManagerConfig `json:"object_manager"`
+	// MethodNames contains the settings of the method names outputs.
+	MethodNames *MethodNamesConfig `json:"method_names"`
 }
 
 // Load reads and parses a file at path into Config.
@@ -50,6 +109,31 @@ func parse(b []byte) (*Config, error) {
 		return nil, err
 	}
 
+	if len(c.FallbackServiceNames) > 0 && c.ServiceName == "" {
+		return nil, fmt.Errorf("fallback_service_names requires service_name")
+	}
+
+	if len(c.DedicatedBusInterfaces) > 0 && c.ObjectManager != nil {
+		return nil, fmt.Errorf("dedicated_bus_interfaces cannot be used with object_manager")
+	}
+
+	for _, line := range c.Banner {
+		if strings.ContainsAny(line, "\r\n") {
+			return nil, fmt.Errorf("banner line %q contains a line break", line)
+		}
+	}
+
+	if m := c.MethodNames; m != nil {
+		if m.Namespace != "" && !cxxNamespaceRegexp.MatchString(m.Namespace) {
+			return nil, fmt.Errorf("method_names.namespace %q is not a C++ namespace", m.Namespace)
+		}
+		switch m.IncludeGuard {
+		case IncludeGuardNone, IncludeGuardPragmaOnce, IncludeGuardIfndef:
+		default:
+			return nil, fmt.Errorf("method_names.include_guard must be %q or %q, got %q", IncludeGuardPragmaOnce, IncludeGuardIfndef, m.IncludeGuard)
+		}
+	}
+
 	// If object_manager.name is not explicitly specified,
 	// derive it from service_name.
 	if c.ObjectManager != nil && c.ObjectManager.Name == "" {
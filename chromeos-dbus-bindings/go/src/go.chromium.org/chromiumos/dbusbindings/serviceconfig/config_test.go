@@ -61,3 +61,108 @@ func TestParseFallbackObjectName(t *testing.T) {
 		t.Fatalf("Unexpected object_manager.name: got %q, want test.ServiceName.ObjectManager", c.ObjectManager.Name)
 	}
 }
+
+func TestParseFallbackServiceNames(t *testing.T) {
+	if _, err := parse([]byte(`{"fallback_service_names": ["test.OldServiceName"]}`)); err == nil {
+		t.Fatal("Unexpected success of parse")
+	}
+
+	c, err := parse([]byte(`{
+	  "service_name": "test.ServiceName",
+	  "fallback_service_names": ["test.OldServiceName", "test.OlderServiceName"]
+	}`))
+	if err != nil {
+		t.Fatal("Unexpected failure of parse: ", err)
+	}
+	if len(c.FallbackServiceNames) != 2 || c.FallbackServiceNames[0] != "test.OldServiceName" || c.FallbackServiceNames[1] != "test.OlderServiceName" {
+		t.Errorf("Unexpected fallback_service_names: got %q, want [test.OldServiceName test.OlderServiceName]", c.FallbackServiceNames)
+	}
+}
+
+func TestParseExpectedMethods(t *testing.T) {
+	c, err := parse([]byte(`{"expected_methods": true}`))
+	if err != nil {
+		t.Fatal("Unexpected failure of parse: ", err)
+	}
+	if !c.ExpectedMethods {
+		t.Error("Unexpected expected_methods: got false, want true")
+	}
+}
+
+func TestParseDedicatedBusInterfaces(t *testing.T) {
+	if _, err := parse([]byte(`{
+	  "service_name": "test.ServiceName",
+	  "dedicated_bus_interfaces": ["test.Interface"],
+	  "object_manager": {}
+	}`)); err == nil {
+		t.Fatal("Unexpected success of parse")
+	}
+
+	c, err := parse([]byte(`{"dedicated_bus_interfaces": ["test.Interface"]}`))
+	if err != nil {
+		t.Fatal("Unexpected failure of parse: ", err)
+	}
+	if len(c.DedicatedBusInterfaces) != 1 || c.DedicatedBusInterfaces[0] != "test.Interface" {
+		t.Errorf("Unexpected dedicated_bus_interfaces: got %v, want [test.Interface]", c.DedicatedBusInterfaces)
+	}
+}
+
+func TestParseProxyFactories(t *testing.T) {
+	c, err := parse([]byte(`{"proxy_factories": true}`))
+	if err != nil {
+		t.Fatal("Unexpected failure of parse: ", err)
+	}
+	if !c.ProxyFactories {
+		t.Error("Unexpected proxy_factories: got false, want true")
+	}
+}
+
+func TestParseProxyTraits(t *testing.T) {
+	c, err := parse([]byte(`{"proxy_traits": true, "proxy_concepts": true}`))
+	if err != nil {
+		t.Fatal("Unexpected failure of parse: ", err)
+	}
+	if !c.ProxyTraits || !c.ProxyConcepts {
+		t.Errorf("Unexpected proxy_traits and proxy_concepts: got %v and %v, want true and true", c.ProxyTraits, c.ProxyConcepts)
+	}
+}
+
+func TestParseBanner(t *testing.T) {
+	if _, err := parse([]byte(`{"banner": ["Copyright\nOwners"]}`)); err == nil {
+		t.Fatal("Unexpected success of parse")
+	}
+
+	c, err := parse([]byte(`{"banner": ["Copyright", "", "Owners"]}`))
+	if err != nil {
+		t.Fatal("Unexpected failure of parse: ", err)
+	}
+	if len(c.Banner) != 3 || c.Banner[0] != "Copyright" || c.Banner[1] != "" || c.Banner[2] != "Owners" {
+		t.Errorf("Unexpected banner: got %q, want [\"Copyright\" \"\" \"Owners\"]", c.Banner)
+	}
+}
+
+func TestParseMethodNames(t *testing.T) {
+	for _, bad := range []string{
+		`{"method_names": {"namespace": "frobinator.dbus"}}`,
+		`{"method_names": {"namespace": "frobinator::"}}`,
+		`{"method_names": {"include_guard": "pragma"}}`,
+	} {
+		if _, err := parse([]byte(bad)); err == nil {
+			t.Errorf("Unexpected success of parse of %s", bad)
+		}
+	}
+
+	c, err := parse([]byte(`{"method_names": {"namespace": "frobinator::dbus", "include_guard": "pragma_once"}}`))
+	if err != nil {
+		t.Fatal("Unexpected failure of parse: ", err)
+	}
+	if c.MethodNames == nil {
+		t.Fatal("Unexpected method_names: got nil, want non-nil")
+	}
+	if c.MethodNames.Namespace != "frobinator::dbus" {
+		t.Errorf("Unexpected method_names.namespace: got %q, want frobinator::dbus", c.MethodNames.Namespace)
+	}
+	if c.MethodNames.IncludeGuard != IncludeGuardPragmaOnce {
+		t.Errorf("Unexpected method_names.include_guard: got %q, want %q", c.MethodNames.IncludeGuard, IncludeGuardPragmaOnce)
+	}
+}
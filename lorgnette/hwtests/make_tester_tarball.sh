@@ -45,6 +45,7 @@ SYSROOT="/build/${BOARD}"
 # Files that need to be copied with their shared libraries.
 SYSROOT_BINS=(
   /usr/bin/lorgnette_cli
+  /usr/bin/xmllint
   /usr/local/bin/identify
 )
 
@@ -97,6 +98,11 @@ for f in "${SYSROOT_STATIC_BINS[@]}"; do
 done
 install -m 0755 -t "${PREFIX}/usr/bin" "${HWTESTS_BINS[@]}"
 
+# ChromeOS approximations of the schemas of eSCL documents, against which
+# test_scanner_capabilities validates them.
+mkdir -p "${PREFIX}/usr/share/wwcb_mfp"
+install -m 0644 -t "${PREFIX}/usr/share/wwcb_mfp" schemas/*.xsd
+
 # ImageMagick has a lot of extra modules and config files that need to be
 # included manually.
 mkdir -p "${PREFIX}/usr/local/etc"
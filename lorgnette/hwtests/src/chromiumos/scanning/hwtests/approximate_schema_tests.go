@@ -0,0 +1,45 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"fmt"
+
+	"chromiumos/scanning/utils"
+)
+
+// ApproximateSchemaConformanceTest validates `rawCaps`, the
+// ScannerCapabilities document exactly as reported by the scanner, against the
+// ChromeOS approximation of the eSCL schema at `xsdPath`, which isn't the
+// schema published with the eSCL specification. The parser used by the other
+// tests tolerates many deviations from the specification, which other eSCL
+// clients may not, so each violation is returned as a separate "needs audit"
+// failure even if the capabilities could be parsed, for a human to check
+// against the specification.
+// `rawCaps` should be the output from a call to
+// utils.GetRawScannerCapabilities(). The validation is aborted once `ctx`
+// expires.
+func ApproximateSchemaConformanceTest(ctx context.Context, rawCaps []byte, xsdPath string) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		violations, err := utils.ValidateXMLSchema(ctx, rawCaps, xsdPath)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		for _, violation := range violations {
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("Line %d violates the ChromeOS approximation of the eSCL schema: %s", violation.Line, violation.Message)})
+		}
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+
+		return
+	}
+}
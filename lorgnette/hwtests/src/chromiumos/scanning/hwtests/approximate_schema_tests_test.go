@@ -0,0 +1,78 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"chromiumos/scanning/utils"
+)
+
+// Path of the bundled approximation of the eSCL schema, relative to this
+// package.
+const eSCLSchemaPath = "../../../../schemas/eSCLApproximation.xsd"
+
+// TestApproximateSchemaConformanceTest tests that
+// ApproximateSchemaConformanceTest functions correctly.
+func TestApproximateSchemaConformanceTest(t *testing.T) {
+	if _, err := exec.LookPath("xmllint"); err != nil {
+		t.Skip("xmllint is not installed")
+	}
+
+	tests := []struct {
+		rawCaps  string
+		result   utils.TestResult
+		failures []utils.FailureType
+	}{
+		{
+			rawCaps: `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<pwg:MakeAndModel>Test Scanner</pwg:MakeAndModel>
+	<scan:BlankPageDetection>true</scan:BlankPageDetection>
+</scan:ScannerCapabilities>`,
+			result:   utils.Passed,
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should fail: unknown element and a malformed boolean,
+			// both of which lorgnette's own parser tolerates.
+			rawCaps: `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<pwg:MakeAndModel>Test Scanner</pwg:MakeAndModel>
+	<scan:BlankPageDetection>yes</scan:BlankPageDetection>
+	<scan:VendorSpecific>1</scan:VendorSpecific>
+</scan:ScannerCapabilities>`,
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.NeedsAudit, utils.NeedsAudit},
+		},
+		{
+			rawCaps:  `<scan:ScannerCapabilities`,
+			result:   utils.Error,
+			failures: []utils.FailureType{},
+		},
+	}
+
+	for _, tc := range tests {
+		result, failures, _ := ApproximateSchemaConformanceTest(context.Background(), []byte(tc.rawCaps), eSCLSchemaPath)()
+
+		if result != tc.result {
+			t.Errorf("Result: expected %d, got %d", tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Number of failures: expected %d, got %d", len(tc.failures), len(failures))
+			continue
+		}
+		for i, failure := range failures {
+			if failure.Type != tc.failures[i] {
+				t.Errorf("FailureType: expected %d, got %d", tc.failures[i], failure.Type)
+			}
+		}
+	}
+}
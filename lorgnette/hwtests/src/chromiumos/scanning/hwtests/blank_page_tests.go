@@ -0,0 +1,177 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"chromiumos/scanning/utils"
+)
+
+// ParsePageStack parses a description of the pages loaded in the ADF, in
+// feeding order, where 'p' is a printed page and 'b' a blank one, e.g. "pbpb".
+// It returns whether each page is blank.
+func ParsePageStack(stack string) (blank []bool, err error) {
+	if stack == "" {
+		err = fmt.Errorf("Empty page stack")
+		return
+	}
+	for i, page := range strings.ToLower(stack) {
+		switch page {
+		case 'p':
+			blank = append(blank, false)
+		case 'b':
+			blank = append(blank, true)
+		default:
+			err = fmt.Errorf("Invalid page %q at position %d of page stack %q", page, i, stack)
+			return
+		}
+	}
+	return
+}
+
+// classifyBlankPageResults compares the `pages` returned by a scan of the ADF
+// stack described by `stack` against the expected blank page handling. With
+// `removal`, only the printed pages are expected; otherwise all pages are
+// expected, and the blank ones must be reported as such.
+func classifyBlankPageResults(stack []bool, pages []utils.ScannedPage, removal bool) (failures []utils.TestFailure) {
+	if removal {
+		printed := 0
+		for _, blank := range stack {
+			if !blank {
+				printed++
+			}
+		}
+		if len(pages) != printed {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Expected %d pages after blank page removal, got %d.", printed, len(pages))})
+		}
+		return
+	}
+
+	if len(pages) != len(stack) {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Expected %d pages with blank page detection, got %d.", len(stack), len(pages))})
+		return
+	}
+	for i, page := range pages {
+		if page.BlankPageDetected != stack[i] {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Page %d: expected blank page detected %v, got %v.", i+1, stack[i], page.BlankPageDetected)})
+		}
+	}
+	return
+}
+
+// blankPageScanSettings returns settings scanning from the ADF with `adfCaps`
+// at its lowest resolution, requesting blank page detection, and removal if
+// `removal` is set.
+func blankPageScanSettings(adfCaps utils.SourceCapabilities, removal bool) (settings utils.ScanSettings, err error) {
+	settings, err = adfScanSettings(adfCaps)
+	if err != nil {
+		return
+	}
+	if removal {
+		settings.BlankPageDetectionAndRemoval = true
+	} else {
+		settings.BlankPageDetection = true
+	}
+	return
+}
+
+// adfScanSettings returns settings scanning from the ADF with `adfCaps` at its
+// lowest resolution, preferring JPEG to keep transfers short.
+func adfScanSettings(adfCaps utils.SourceCapabilities) (utils.ScanSettings, error) {
+	return sourceScanSettings(adfCaps, "Feeder")
+}
+
+// sourceScanSettings returns settings scanning from the eSCL input source
+// `inputSource` with `sourceCaps` at its lowest resolution, preferring JPEG to
+// keep transfers short.
+func sourceScanSettings(sourceCaps utils.SourceCapabilities, inputSource string) (settings utils.ScanSettings, err error) {
+	profile := sourceCaps.SettingProfile
+	resolutions := profile.SupportedResolutions.ToLorgnetteResolutions()
+	if len(profile.ColorModes) == 0 || len(resolutions) == 0 {
+		err = fmt.Errorf("%s advertises no color mode or resolution", inputSource)
+		return
+	}
+
+	settings = utils.ScanSettings{
+		InputSource: inputSource,
+		ColorMode:   profile.ColorModes[0],
+		Resolution:  resolutions[0],
+	}
+	for _, resolution := range resolutions {
+		if resolution < settings.Resolution {
+			settings.Resolution = resolution
+		}
+	}
+	for _, formats := range [][]string{profile.DocumentFormatsExt, profile.DocumentFormats} {
+		for _, format := range formats {
+			if format == "image/jpeg" {
+				settings.DocumentFormat = format
+			}
+		}
+	}
+	if settings.DocumentFormat == "" && len(profile.DocumentFormats) != 0 {
+		settings.DocumentFormat = profile.DocumentFormats[0]
+	}
+	return
+}
+
+// BlankPageTest scans the mixed stack of printed and blank pages described by
+// `stack` from the ADF of the scanner represented by `info`, with blank page
+// detection enabled, or detection and removal if `removal` is set. It is
+// skipped unless `caps` advertises the corresponding capability and an ADF.
+// `loadStack` is called before scanning, so that the stack can be (re)loaded
+// into the ADF. See classifyBlankPageResults for how failures are reported,
+// and verifyScannedPages for how the returned pages are checked. Requests are
+// aborted once `ctx` expires.
+func BlankPageTest(ctx context.Context, info utils.LorgnetteScannerInfo, caps utils.ScannerCapabilities, stack []bool, removal bool, loadStack func() error) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		advertised := caps.BlankPageDetection
+		if removal {
+			advertised = caps.BlankPageDetectionAndRemoval
+		}
+		adfCaps := caps.AdfCapabilities.AdfSimplexInputCaps
+		if !advertised || !adfCaps.IsPopulated() {
+			result = utils.Skipped
+			return
+		}
+
+		settings, err := blankPageScanSettings(adfCaps, removal)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		if err = loadStack(); err != nil {
+			result = utils.Error
+			return
+		}
+
+		pages, err := utils.RunESCLScanJob(ctx, info, settings)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		log.Printf("INFO: Scanned %d pages from a stack of %d", len(pages), len(stack))
+
+		failures = classifyBlankPageResults(stack, pages, removal)
+		var pageFailures []utils.TestFailure
+		pageFailures, err = verifyScannedPages(pages, settings)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		failures = append(failures, pageFailures...)
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"testing"
+
+	"chromiumos/scanning/utils"
+	"chromiumos/scanning/utils/testserver"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// adfCapabilitiesXML advertises an ADF and both blank page capabilities.
+const adfCapabilitiesXML = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<scan:Adf>
+		<scan:AdfSimplexInputCaps>
+			<scan:MinWidth>16</scan:MinWidth>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:SettingProfiles>
+				<scan:SettingProfile>
+					<scan:ColorModes>
+						<scan:ColorMode>Grayscale8</scan:ColorMode>
+					</scan:ColorModes>
+					<scan:DocumentFormats>
+						<pwg:DocumentFormat>image/png</pwg:DocumentFormat>
+						<pwg:DocumentFormat>image/jpeg</pwg:DocumentFormat>
+					</scan:DocumentFormats>
+					<scan:SupportedResolutions>
+						<scan:DiscreteResolutions>
+							<scan:DiscreteResolution>
+								<scan:XResolution>300</scan:XResolution>
+								<scan:YResolution>300</scan:YResolution>
+							</scan:DiscreteResolution>
+							<scan:DiscreteResolution>
+								<scan:XResolution>150</scan:XResolution>
+								<scan:YResolution>150</scan:YResolution>
+							</scan:DiscreteResolution>
+						</scan:DiscreteResolutions>
+					</scan:SupportedResolutions>
+				</scan:SettingProfile>
+			</scan:SettingProfiles>
+		</scan:AdfSimplexInputCaps>
+	</scan:Adf>
+	<scan:BlankPageDetection>true</scan:BlankPageDetection>
+	<scan:BlankPageDetectionAndRemoval>true</scan:BlankPageDetectionAndRemoval>
+</scan:ScannerCapabilities>`
+
+// TestParsePageStack tests that ParsePageStack functions correctly.
+func TestParsePageStack(t *testing.T) {
+	tests := []struct {
+		stack string
+		blank []bool
+		valid bool
+	}{
+		{stack: "pbpb", blank: []bool{false, true, false, true}, valid: true},
+		{stack: "PB", blank: []bool{false, true}, valid: true},
+		{stack: "", valid: false},
+		{stack: "pxb", valid: false},
+	}
+
+	for _, tc := range tests {
+		blank, err := ParsePageStack(tc.stack)
+
+		if tc.valid != (err == nil) {
+			t.Errorf("Stack %q: expected valid %v, got error %v", tc.stack, tc.valid, err)
+			continue
+		}
+		if tc.valid && !cmp.Equal(blank, tc.blank) {
+			t.Errorf("Stack %q: expected %v, got %v", tc.stack, tc.blank, blank)
+		}
+	}
+}
+
+// TestClassifyBlankPageResults tests that classifyBlankPageResults functions
+// correctly.
+func TestClassifyBlankPageResults(t *testing.T) {
+	stack := []bool{false, true, false}
+
+	tests := []struct {
+		pages    []utils.ScannedPage
+		removal  bool
+		failures []utils.FailureType
+	}{
+		{
+			// Should pass: the blank page was detected.
+			pages:    []utils.ScannedPage{{}, {BlankPageDetected: true}, {}},
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should fail: the blank page wasn't detected.
+			pages:    []utils.ScannedPage{{}, {}, {}},
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should fail: a printed page was detected as blank.
+			pages:    []utils.ScannedPage{{BlankPageDetected: true}, {BlankPageDetected: true}, {}},
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should fail: a page was dropped without removal.
+			pages:    []utils.ScannedPage{{}, {}},
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should pass: the blank page was removed.
+			pages:    []utils.ScannedPage{{}, {}},
+			removal:  true,
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should fail: the blank page wasn't removed.
+			pages:    []utils.ScannedPage{{}, {BlankPageDetected: true}, {}},
+			removal:  true,
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+	}
+
+	for i, tc := range tests {
+		failures := classifyBlankPageResults(stack, tc.pages, tc.removal)
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Test %d: number of failures: expected %d, got %d", i, len(tc.failures), len(failures))
+			continue
+		}
+		for j, failure := range failures {
+			if failure.Type != tc.failures[j] {
+				t.Errorf("Test %d: FailureType: expected %d, got %d", i, tc.failures[j], failure.Type)
+			}
+		}
+	}
+}
+
+// TestBlankPageTest runs BlankPageTest against a simulated scanner.
+func TestBlankPageTest(t *testing.T) {
+	stack := []bool{false, true, false, true}
+	s := testserver.New(testserver.Config{
+		CapabilitiesXML: adfCapabilitiesXML,
+		Pages:           [][]byte{grayPNG(t, 8, 8), grayPNG(t, 8, 8), grayPNG(t, 8, 8), grayPNG(t, 8, 8)},
+		Blank:           stack,
+	})
+	defer s.Close()
+
+	info := utils.LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}
+	caps, err := utils.GetScannerCapabilities(context.Background(), info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loads := 0
+	loadStack := func() error {
+		loads++
+		return nil
+	}
+	for _, removal := range []bool{false, true} {
+		result, failures, err := BlankPageTest(context.Background(), info, caps, stack, removal, loadStack)()
+		if result != utils.Passed || err != nil {
+			t.Errorf("Removal %v: expected to pass, got result %d with failures %v and error %v", removal, result, failures, err)
+		}
+	}
+	if loads != 2 {
+		t.Errorf("Stack loads: expected 2, got %d", loads)
+	}
+
+	// Scanners which don't advertise the capabilities are skipped.
+	caps.BlankPageDetection = false
+	caps.BlankPageDetectionAndRemoval = false
+	for _, removal := range []bool{false, true} {
+		if result, _, _ := BlankPageTest(context.Background(), info, caps, stack, removal, loadStack)(); result != utils.Skipped {
+			t.Errorf("Removal %v without capability: expected skipped, got %d", removal, result)
+		}
+	}
+}
+
+// TestBlankPageScanSettings tests that the lowest resolution and JPEG are
+// preferred.
+func TestBlankPageScanSettings(t *testing.T) {
+	adfCaps := utils.SourceCapabilities{
+		MaxWidth: 2550,
+		SettingProfile: utils.SettingProfile{
+			ColorModes:      []string{"RGB24", "Grayscale8"},
+			DocumentFormats: []string{"image/png", "image/jpeg"},
+			SupportedResolutions: utils.SupportedResolutions{
+				DiscreteResolutions: []utils.DiscreteResolution{{XResolution: 300, YResolution: 300}, {XResolution: 150, YResolution: 150}},
+			},
+		},
+	}
+
+	got, err := blankPageScanSettings(adfCaps, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := utils.ScanSettings{
+		InputSource:                  "Feeder",
+		ColorMode:                    "RGB24",
+		Resolution:                   150,
+		DocumentFormat:               "image/jpeg",
+		BlankPageDetectionAndRemoval: true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected settings (-want +got):\n%s", diff)
+	}
+
+	if _, err := blankPageScanSettings(utils.SourceCapabilities{}, false); err == nil {
+		t.Error("Expected error for an ADF without settings")
+	}
+}
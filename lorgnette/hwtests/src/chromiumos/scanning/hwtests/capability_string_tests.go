@@ -0,0 +1,139 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"chromiumos/scanning/utils"
+)
+
+// Maximum lengths in bytes of the capability strings, from the text(127) and
+// uri(1023) syntaxes of the PWG semantic model.
+const (
+	maxCapabilityTextLength = 127
+	maxCapabilityURILength  = 1023
+)
+
+// capabilityString is a string from the scanner's capabilities shown to users.
+type capabilityString struct {
+	name     string
+	value    string
+	required bool
+	isURI    bool
+}
+
+// checkCapabilityText returns the failures of the string `s`, named `name`.
+// Strings which aren't valid UTF-8 or hold control characters break the
+// scanning UI and are critical failures; other issues need an audit.
+func checkCapabilityText(name string, s string, required bool, maxLength int) (failures []utils.TestFailure) {
+	if s == "" {
+		if required {
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s is empty.", name)})
+		}
+		return
+	}
+
+	// Invalid bytes were replaced by utf8.RuneError before parsing.
+	if strings.ContainsRune(s, utf8.RuneError) {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s %q is not valid UTF-8.", name, s)})
+	}
+	if strings.IndexFunc(s, unicode.IsControl) != -1 {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s %q contains control characters.", name, s)})
+	}
+	if len(s) > maxLength {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s is %d bytes long, more than %d.", name, len(s), maxLength)})
+	}
+	if strings.TrimSpace(s) != s {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s %q has leading or trailing whitespace.", name, s)})
+	}
+	return
+}
+
+// checkCapabilityURI returns the failures of the URI `uri`, named `name`, which
+// must be an absolute HTTP or HTTPS URI which `client` can fetch.
+func checkCapabilityURI(ctx context.Context, client *http.Client, name string, uri string) (failures []utils.TestFailure) {
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s %q is not an absolute HTTP or HTTPS URI.", name, uri)})
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s %q can't be requested: %v", name, uri, err)})
+		return
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s %q is unreachable: %v", name, uri, err)})
+		return
+	}
+	resp.Body.Close()
+	// Pages requiring authentication are still reachable.
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode >= 500 {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s %q returned %s.", name, uri, resp.Status)})
+	}
+	return
+}
+
+// CapabilityStringsTest checks the strings of `rawCaps`, the
+// ScannerCapabilities document exactly as reported by the scanner, which the
+// scanning UI shows: MakeAndModel and Manufacturer must be non-empty, and
+// these along with SerialNumber, AdminURI and IconURI must be valid UTF-8
+// without control characters, which are critical failures, and within the PWG
+// length limits. AdminURI and IconURI, if present, must be absolute HTTP or
+// HTTPS URIs which `client` can fetch. Issues other than invalid characters
+// are "needs audit" failures. `rawCaps` should be the output from a call to
+// utils.GetRawScannerCapabilities().
+func CapabilityStringsTest(ctx context.Context, rawCaps []byte, client *http.Client) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		// XML parsing fails on invalid UTF-8, which is reported per string
+		// instead.
+		caps, err := utils.ParseScannerCapabilities(bytesToValidUTF8(rawCaps))
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		strs := []capabilityString{
+			{name: "MakeAndModel", value: caps.MakeAndModel, required: true},
+			{name: "Manufacturer", value: caps.Manufacturer, required: true},
+			{name: "SerialNumber", value: caps.SerialNumber},
+			{name: "AdminURI", value: caps.AdminURI, isURI: true},
+			{name: "IconURI", value: caps.IconURI, isURI: true},
+		}
+		for _, str := range strs {
+			maxLength := maxCapabilityTextLength
+			if str.isURI {
+				maxLength = maxCapabilityURILength
+			}
+			strFailures := checkCapabilityText(str.name, str.value, str.required, maxLength)
+			failures = append(failures, strFailures...)
+			if str.isURI && str.value != "" && len(strFailures) == 0 {
+				failures = append(failures, checkCapabilityURI(ctx, client, str.name, str.value)...)
+			}
+		}
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
+
+// bytesToValidUTF8 returns `b` with each run of invalid UTF-8 bytes replaced
+// by utf8.RuneError.
+func bytesToValidUTF8(b []byte) []byte {
+	return []byte(strings.ToValidUTF8(string(b), string(utf8.RuneError)))
+}
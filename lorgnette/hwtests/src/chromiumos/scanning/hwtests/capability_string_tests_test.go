@@ -0,0 +1,109 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"chromiumos/scanning/utils"
+)
+
+// capabilityStringsXML returns a ScannerCapabilities document holding
+// `elements`.
+func capabilityStringsXML(elements string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+` + elements + `
+</scan:ScannerCapabilities>`
+}
+
+// TestCapabilityStringsTest tests that CapabilityStringsTest functions
+// correctly.
+func TestCapabilityStringsTest(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, "OK")
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		rawCaps  string
+		result   utils.TestResult
+		failures []utils.FailureType
+	}{
+		{
+			rawCaps: capabilityStringsXML(`	<pwg:MakeAndModel>Test Scanner</pwg:MakeAndModel>
+	<pwg:SerialNumber>1234</pwg:SerialNumber>
+	<scan:Manufacturer>Test</scan:Manufacturer>
+	<scan:AdminURI>` + ts.URL + `/admin</scan:AdminURI>
+	<scan:IconURI>` + ts.URL + `/icon.png</scan:IconURI>`),
+			result:   utils.Passed,
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should fail: MakeAndModel and Manufacturer are missing.
+			rawCaps:  capabilityStringsXML(""),
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.NeedsAudit, utils.NeedsAudit},
+		},
+		{
+			// Should fail: MakeAndModel holds invalid UTF-8.
+			rawCaps: capabilityStringsXML("	<pwg:MakeAndModel>Test \xff Scanner</pwg:MakeAndModel>\n" +
+				"	<scan:Manufacturer>Test</scan:Manufacturer>"),
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should fail: Manufacturer holds a tab, and MakeAndModel is
+			// too long and has trailing whitespace.
+			rawCaps: capabilityStringsXML(`	<pwg:MakeAndModel>` + strings.Repeat("a", 128) + ` </pwg:MakeAndModel>
+	<scan:Manufacturer>Te	st</scan:Manufacturer>`),
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.NeedsAudit, utils.NeedsAudit, utils.CriticalFailure},
+		},
+		{
+			// Should fail: AdminURI is relative and IconURI can't be
+			// found.
+			rawCaps: capabilityStringsXML(`	<pwg:MakeAndModel>Test Scanner</pwg:MakeAndModel>
+	<scan:Manufacturer>Test</scan:Manufacturer>
+	<scan:AdminURI>/admin</scan:AdminURI>
+	<scan:IconURI>` + ts.URL + `/missing</scan:IconURI>`),
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.NeedsAudit, utils.NeedsAudit},
+		},
+		{
+			rawCaps:  `<scan:ScannerCapabilities`,
+			result:   utils.Error,
+			failures: []utils.FailureType{},
+		},
+	}
+
+	for _, tc := range tests {
+		result, failures, _ := CapabilityStringsTest(context.Background(), []byte(tc.rawCaps), ts.Client())()
+
+		if result != tc.result {
+			t.Errorf("Result: expected %d, got %d", tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Number of failures: expected %d, got %d", len(tc.failures), len(failures))
+			continue
+		}
+		for i, failure := range failures {
+			if failure.Type != tc.failures[i] {
+				t.Errorf("FailureType: expected %d, got %d", tc.failures[i], failure.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"chromiumos/scanning/utils"
+)
+
+// Operations performed by each client of ConcurrentClientsTest.
+const (
+	capsOperation = "get_json_caps"
+	scanOperation = "scan"
+)
+
+// clientResult is the outcome of one operation of one concurrent client.
+type clientResult struct {
+	Client    int
+	Operation string
+	Err       error
+}
+
+// classifyClientResults turns the results of concurrent clients into test
+// failures. Busy errors are the expected way for lorgnette to reject
+// concurrent jobs; any other error needs to be audited. lorgnette crashing
+// or restarting, as detected by its PID changing from `pidBefore` to
+// `pidAfter`, is a critical failure.
+func classifyClientResults(results []clientResult, pidBefore int, pidAfter int, pidAfterErr error) (failures []utils.TestFailure) {
+	if pidAfterErr != nil {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("lorgnette is not running after concurrent requests: %v", pidAfterErr)})
+	} else if pidAfter != pidBefore {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("lorgnette restarted during concurrent requests: PID %d became %d", pidBefore, pidAfter)})
+	}
+
+	scans := 0
+	for _, r := range results {
+		switch {
+		case r.Err == nil:
+			if r.Operation == scanOperation {
+				scans++
+			}
+		case utils.IsBusyError(r.Err):
+			log.Printf("INFO: Client %d %s was rejected as busy: %v", r.Client, r.Operation, r.Err)
+		default:
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("Client %d %s failed with a non-busy error: %v", r.Client, r.Operation, r.Err)})
+		}
+	}
+
+	if scans == 0 {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: "No concurrent scan succeeded."})
+	}
+
+	return
+}
+
+// ConcurrentClientsTest starts `numClients` lorgnette_cli clients at once,
+// each fetching the capabilities of `scannerName` then performing a small,
+// low-resolution scan from `source`. lorgnette is expected to either serialize
+// the jobs or reject them with busy errors, without crashing. See
+// classifyClientResults for how failures are reported. Scanned images are
+// output to `outputDir`, and if the test fails, the lorgnette lines of
+// `logPath` written during the test are saved to
+// `outputDir`/lorgnette_contention.log. Requests are aborted once `ctx`
+// expires.
+func ConcurrentClientsTest(ctx context.Context, scannerName string, source utils.LorgnetteSource, sourceName string, numClients int, outputDir string, logPath string) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		if !source.IsPopulated() || len(source.Resolutions) == 0 {
+			result = utils.Skipped
+			return
+		}
+
+		lowestResolution := source.Resolutions[0]
+		for _, resolution := range source.Resolutions {
+			if resolution < lowestResolution {
+				lowestResolution = resolution
+			}
+		}
+
+		// Make sure lorgnette is running before recording its PID.
+		if _, err = utils.LorgnetteCLIGetJSONCaps(ctx, scannerName); err != nil {
+			result = utils.Error
+			return
+		}
+		pidBefore, err := utils.FindProcess("/proc", "lorgnette")
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		capture, captureErr := utils.StartLogCapture(logPath)
+		if captureErr != nil {
+			log.Print("WARNING: Unable to capture lorgnette logs: ", captureErr)
+		}
+
+		var mu sync.Mutex
+		var results []clientResult
+		var wg sync.WaitGroup
+		for i := 1; i <= numClients; i++ {
+			wg.Add(1)
+			go func(client int) {
+				defer wg.Done()
+
+				_, capsErr := utils.LorgnetteCLIGetJSONCaps(ctx, scannerName)
+				outputPattern := filepath.Join(outputDir, fmt.Sprintf("contention-client%d_page%%n.png", client))
+				_, scanErr := utils.LorgnetteCLIScan(ctx, scannerName, sourceName, utils.BusinessCardSize, lowestResolution, "Grayscale", outputPattern)
+
+				mu.Lock()
+				defer mu.Unlock()
+				results = append(results,
+					clientResult{Client: client, Operation: capsOperation, Err: capsErr},
+					clientResult{Client: client, Operation: scanOperation, Err: scanErr})
+			}(i)
+		}
+		wg.Wait()
+
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			result = utils.Error
+			return
+		}
+
+		pidAfter, pidAfterErr := utils.FindProcess("/proc", "lorgnette")
+		failures = classifyClientResults(results, pidBefore, pidAfter, pidAfterErr)
+		if len(failures) == 0 {
+			result = utils.Passed
+			return
+		}
+		result = utils.Failed
+
+		if captureErr == nil {
+			lines, logErr := capture.Lines("lorgnette")
+			logFile := filepath.Join(outputDir, "lorgnette_contention.log")
+			if logErr == nil {
+				logErr = ioutil.WriteFile(logFile, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+			}
+			if logErr != nil {
+				log.Print("WARNING: Unable to save lorgnette logs: ", logErr)
+			} else {
+				log.Print("INFO: Saved lorgnette logs to: ", logFile)
+			}
+		}
+
+		return
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"fmt"
+	"testing"
+
+	"chromiumos/scanning/utils"
+)
+
+// TestClassifyClientResults tests that classifyClientResults functions
+// correctly.
+func TestClassifyClientResults(t *testing.T) {
+	busyErr := fmt.Errorf("Device busy")
+	ioErr := fmt.Errorf("I/O error")
+
+	tests := []struct {
+		results     []clientResult
+		pidAfter    int
+		pidAfterErr error
+		failures    []utils.FailureType
+	}{
+		{
+			// Should pass: jobs were serialized.
+			results: []clientResult{
+				{Client: 1, Operation: capsOperation},
+				{Client: 1, Operation: scanOperation},
+				{Client: 2, Operation: capsOperation},
+				{Client: 2, Operation: scanOperation},
+			},
+			pidAfter: 100,
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should pass: the second scan was rejected as busy.
+			results: []clientResult{
+				{Client: 1, Operation: scanOperation},
+				{Client: 2, Operation: scanOperation, Err: busyErr},
+			},
+			pidAfter: 100,
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should fail: non-busy error.
+			results: []clientResult{
+				{Client: 1, Operation: scanOperation},
+				{Client: 2, Operation: capsOperation, Err: ioErr},
+			},
+			pidAfter: 100,
+			failures: []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Should fail: no scan succeeded.
+			results: []clientResult{
+				{Client: 1, Operation: scanOperation, Err: busyErr},
+				{Client: 2, Operation: scanOperation, Err: busyErr},
+			},
+			pidAfter: 100,
+			failures: []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Should fail: lorgnette restarted.
+			results: []clientResult{
+				{Client: 1, Operation: scanOperation},
+			},
+			pidAfter: 200,
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should fail: lorgnette is gone.
+			results: []clientResult{
+				{Client: 1, Operation: scanOperation},
+			},
+			pidAfterErr: fmt.Errorf("No process found with name: lorgnette"),
+			failures:    []utils.FailureType{utils.CriticalFailure},
+		},
+	}
+
+	for _, tc := range tests {
+		failures := classifyClientResults(tc.results, 100, tc.pidAfter, tc.pidAfterErr)
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Number of failures: expected %d, got %d", len(tc.failures), len(failures))
+			continue
+		}
+		for i, failure := range failures {
+			if failure.Type != tc.failures[i] {
+				t.Errorf("FailureType: expected %d, got %d", tc.failures[i], failure.Type)
+			}
+		}
+	}
+}
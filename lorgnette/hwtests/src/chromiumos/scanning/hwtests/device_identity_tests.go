@@ -0,0 +1,40 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"fmt"
+	"strings"
+
+	"chromiumos/scanning/utils"
+)
+
+// DeviceIdentityConsistencyTest checks that the sources merged into `device`
+// identify the same scanner. A UUID differing between sources is a critical
+// failure, as it is how clients recognize the scanner across discovery and
+// eSCL. A differing serial number needs auditing. The test is skipped if only
+// one source could be read.
+func DeviceIdentityConsistencyTest(device utils.DeviceInfo) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		if len(device.Sources) < 2 {
+			result = utils.Skipped
+			return
+		}
+
+		if !device.UUID.Consistent(utils.NormalizeUUID) {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("UUID differs between sources: %s", device.UUID)})
+		}
+		if !device.SerialNumber.Consistent(strings.TrimSpace) {
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("Serial number differs between sources: %s", device.SerialNumber)})
+		}
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
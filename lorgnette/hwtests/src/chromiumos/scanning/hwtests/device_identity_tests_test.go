@@ -0,0 +1,76 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"testing"
+
+	"chromiumos/scanning/utils"
+)
+
+// TestDeviceIdentityConsistencyTest tests that DeviceIdentityConsistencyTest
+// functions correctly.
+func TestDeviceIdentityConsistencyTest(t *testing.T) {
+	tests := []struct {
+		reports  []utils.DeviceReport
+		result   utils.TestResult
+		failures []utils.FailureType
+	}{
+		{
+			// UUIDs only differing in case and prefix match.
+			reports: []utils.DeviceReport{
+				{Source: utils.ESCLSource, UUID: "4509a320-00a0-008f-00b6-002507510eca", SerialNumber: "SN123"},
+				{Source: utils.MDNSSource, UUID: "urn:uuid:4509A320-00A0-008F-00B6-002507510ECA"},
+			},
+			result:   utils.Passed,
+			failures: []utils.FailureType{},
+		},
+		{
+			reports: []utils.DeviceReport{
+				{Source: utils.ESCLSource, UUID: "4509a320-00a0-008f-00b6-002507510eca"},
+				{Source: utils.MDNSSource, UUID: "4509a320-00a0-008f-00b6-002507510ecb"},
+			},
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			reports: []utils.DeviceReport{
+				{Source: utils.ESCLSource, SerialNumber: "SN123"},
+				{Source: utils.USBSource, SerialNumber: "SN124"},
+			},
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			reports: []utils.DeviceReport{
+				{Source: utils.ESCLSource, UUID: "4509a320-00a0-008f-00b6-002507510eca"},
+			},
+			result:   utils.Skipped,
+			failures: []utils.FailureType{},
+		},
+	}
+
+	for _, tc := range tests {
+		result, failures, err := DeviceIdentityConsistencyTest(utils.MergeDeviceReports(tc.reports))()
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if result != tc.result {
+			t.Errorf("Result: expected %d, got %d", tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Number of failures: expected %d, got %d", len(tc.failures), len(failures))
+			continue
+		}
+		for i, failure := range failures {
+			if failure.Type != tc.failures[i] {
+				t.Errorf("FailureType: expected %d, got %d", tc.failures[i], failure.Type)
+			}
+		}
+	}
+}
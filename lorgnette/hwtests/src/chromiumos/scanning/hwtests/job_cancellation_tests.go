@@ -0,0 +1,179 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"chromiumos/scanning/utils"
+)
+
+// cancellationPollInterval is the delay between ScannerStatus requests while
+// waiting for a cancelled job to settle.
+const cancellationPollInterval = time.Second
+
+// classifyCancellationResponses checks the HTTP status `deleteStatus` of the
+// DELETE request cancelling a job, and `nextStatus` of a NextDocument request
+// sent for the job afterwards. A rejected cancellation or a page returned
+// after it are critical failures; a NextDocument status other than 404 or 410
+// needs an audit.
+func classifyCancellationResponses(deleteStatus int, nextStatus int) (failures []utils.TestFailure) {
+	if deleteStatus != http.StatusOK && deleteStatus != http.StatusNoContent {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Cancelling the job returned HTTP status %d.", deleteStatus)})
+	}
+
+	switch nextStatus {
+	case http.StatusNotFound, http.StatusGone:
+	case http.StatusOK:
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: "Scanner returned a page after the job was cancelled."})
+	default:
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("NextDocument after cancellation returned HTTP status %d.", nextStatus)})
+	}
+	return
+}
+
+// cancellationSettled returns whether `status` no longer reports the scanner
+// or the job at `jobPath` as processing.
+func cancellationSettled(status utils.ESCLScannerStatus, jobPath string) bool {
+	if status.State == "Processing" || status.AdfState == "ScannerAdfProcessing" {
+		return false
+	}
+	state, _ := status.JobState(jobPath)
+	return state != "Processing" && state != "Pending"
+}
+
+// classifyCancelledStatus checks the ScannerStatus `status` reported once the
+// cancellation of the job at `jobPath` settled, or timed out. A scanner still
+// processing, or a job in any state but Canceled or Aborted, is a critical
+// failure; Aborted or a job missing from the status needs an audit.
+func classifyCancelledStatus(status utils.ESCLScannerStatus, jobPath string) (failures []utils.TestFailure) {
+	if status.State == "Processing" {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: "Scanner is still processing after the job was cancelled."})
+	}
+	if status.AdfState == "ScannerAdfProcessing" {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: "ADF is still feeding after the job was cancelled."})
+	}
+
+	state, found := status.JobState(jobPath)
+	switch {
+	case !found:
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: "Cancelled job is not listed in the scanner status."})
+	case state == "Canceled":
+	case state == "Aborted":
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: "Cancelled job is reported as Aborted instead of Canceled."})
+	default:
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Cancelled job is reported as %s instead of Canceled.", state)})
+	}
+	return
+}
+
+// waitForCancellation polls the status of the scanner represented by `info`
+// until the cancellation of the job at `jobPath` settled, or `settleTimeout`
+// expires. It returns the last status received.
+func waitForCancellation(ctx context.Context, info utils.LorgnetteScannerInfo, jobPath string, settleTimeout time.Duration) (status utils.ESCLScannerStatus, err error) {
+	settleCtx, cancel := context.WithTimeout(ctx, settleTimeout)
+	defer cancel()
+
+	for {
+		status, err = utils.GetESCLScannerStatus(ctx, info)
+		if err != nil || cancellationSettled(status, jobPath) {
+			return
+		}
+
+		select {
+		case <-time.After(cancellationPollInterval):
+		case <-settleCtx.Done():
+			err = ctx.Err()
+			return
+		}
+	}
+}
+
+// JobCancellationTest starts a scan job from the ADF of the scanner represented
+// by `info`, and cancels it once the first page was transferred. It verifies
+// that no further page is returned, that the scanner stops feeding and reports
+// the job as Canceled within `settleTimeout`, and that a subsequent job
+// completes. It is skipped unless `caps` advertises an ADF. `loadPages` is
+// called before each job, so that at least two pages can be loaded into the
+// ADF. Requests are aborted once `ctx` expires.
+func JobCancellationTest(ctx context.Context, info utils.LorgnetteScannerInfo, caps utils.ScannerCapabilities, settleTimeout time.Duration, loadPages func() error) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		adfCaps := caps.AdfCapabilities.AdfSimplexInputCaps
+		if !adfCaps.IsPopulated() {
+			result = utils.Skipped
+			return
+		}
+
+		settings, err := adfScanSettings(adfCaps)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		if err = loadPages(); err != nil {
+			result = utils.Error
+			return
+		}
+
+		jobPath, err := utils.StartESCLScanJob(ctx, info, settings)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		_, done, err := utils.NextESCLDocument(ctx, info, jobPath)
+		if err == nil && done {
+			err = fmt.Errorf("Job %s completed without returning a page", jobPath)
+		}
+		if err != nil {
+			utils.DeleteESCLScanJob(ctx, info, jobPath)
+			result = utils.Error
+			return
+		}
+
+		deleteStatus, err := utils.DeleteESCLScanJob(ctx, info, jobPath)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		nextStatus, _, err := utils.RequestESCLDocument(ctx, info, jobPath)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		failures = append(failures, classifyCancellationResponses(deleteStatus, nextStatus)...)
+
+		status, err := waitForCancellation(ctx, info, jobPath, settleTimeout)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		log.Printf("INFO: Status after cancelling %s: scanner %s, ADF %s", jobPath, status.State, status.AdfState)
+		failures = append(failures, classifyCancelledStatus(status, jobPath)...)
+
+		if err = loadPages(); err != nil {
+			result = utils.Error
+			return
+		}
+
+		pages, jobErr := utils.RunESCLScanJob(ctx, info, settings)
+		if jobErr != nil {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Job after cancellation failed: %v", jobErr)})
+		} else if len(pages) == 0 {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: "Job after cancellation returned no pages."})
+		}
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
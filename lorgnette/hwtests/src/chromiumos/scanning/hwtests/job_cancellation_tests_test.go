@@ -0,0 +1,170 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"chromiumos/scanning/utils"
+	"chromiumos/scanning/utils/testserver"
+)
+
+// TestClassifyCancellationResponses tests that classifyCancellationResponses
+// functions correctly.
+func TestClassifyCancellationResponses(t *testing.T) {
+	tests := []struct {
+		deleteStatus int
+		nextStatus   int
+		failures     []utils.FailureType
+	}{
+		{
+			// Should pass: the job is gone.
+			deleteStatus: http.StatusOK,
+			nextStatus:   http.StatusNotFound,
+			failures:     []utils.FailureType{},
+		},
+		{
+			// Should pass: 204 and 410 are fine too.
+			deleteStatus: http.StatusNoContent,
+			nextStatus:   http.StatusGone,
+			failures:     []utils.FailureType{},
+		},
+		{
+			// Should fail: the cancellation was rejected and a page
+			// returned.
+			deleteStatus: http.StatusMethodNotAllowed,
+			nextStatus:   http.StatusOK,
+			failures:     []utils.FailureType{utils.CriticalFailure, utils.CriticalFailure},
+		},
+		{
+			// Needs audit: unexpected NextDocument status.
+			deleteStatus: http.StatusOK,
+			nextStatus:   http.StatusServiceUnavailable,
+			failures:     []utils.FailureType{utils.NeedsAudit},
+		},
+	}
+
+	for i, tc := range tests {
+		failures := classifyCancellationResponses(tc.deleteStatus, tc.nextStatus)
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Test %d: number of failures: expected %d, got %d", i, len(tc.failures), len(failures))
+			continue
+		}
+		for j, failure := range failures {
+			if failure.Type != tc.failures[j] {
+				t.Errorf("Test %d: FailureType: expected %d, got %d", i, tc.failures[j], failure.Type)
+			}
+		}
+	}
+}
+
+// TestClassifyCancelledStatus tests that classifyCancelledStatus functions
+// correctly.
+func TestClassifyCancelledStatus(t *testing.T) {
+	const jobPath = "/eSCL/ScanJobs/1"
+	job := func(state string) []utils.ESCLJobInfo {
+		return []utils.ESCLJobInfo{{JobURI: "http://scanner/eSCL/ScanJobs/1", JobState: state}}
+	}
+
+	tests := []struct {
+		status   utils.ESCLScannerStatus
+		failures []utils.FailureType
+	}{
+		{
+			// Should pass: idle and canceled.
+			status:   utils.ESCLScannerStatus{State: "Idle", Jobs: job("Canceled")},
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should fail: still scanning and feeding.
+			status:   utils.ESCLScannerStatus{State: "Processing", AdfState: "ScannerAdfProcessing", Jobs: job("Processing")},
+			failures: []utils.FailureType{utils.CriticalFailure, utils.CriticalFailure, utils.CriticalFailure},
+		},
+		{
+			// Should fail: the job completed instead.
+			status:   utils.ESCLScannerStatus{State: "Idle", Jobs: job("Completed")},
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Needs audit: aborted rather than canceled.
+			status:   utils.ESCLScannerStatus{State: "Idle", Jobs: job("Aborted")},
+			failures: []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Needs audit: the job isn't listed.
+			status:   utils.ESCLScannerStatus{State: "Idle"},
+			failures: []utils.FailureType{utils.NeedsAudit},
+		},
+	}
+
+	for i, tc := range tests {
+		failures := classifyCancelledStatus(tc.status, jobPath)
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Test %d: number of failures: expected %d, got %d", i, len(tc.failures), len(failures))
+			continue
+		}
+		for j, failure := range failures {
+			if failure.Type != tc.failures[j] {
+				t.Errorf("Test %d: FailureType: expected %d, got %d", i, tc.failures[j], failure.Type)
+			}
+		}
+	}
+}
+
+// TestJobCancellationTest runs JobCancellationTest against simulated scanners
+// honoring and ignoring cancellation.
+func TestJobCancellationTest(t *testing.T) {
+	for _, ignoreCancel := range []bool{false, true} {
+		s := testserver.New(testserver.Config{
+			CapabilitiesXML: adfCapabilitiesXML,
+			Pages:           [][]byte{[]byte("1"), []byte("2"), []byte("3")},
+			IgnoreCancel:    ignoreCancel,
+		})
+
+		info := utils.LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}
+		caps, err := utils.GetScannerCapabilities(context.Background(), info)
+		if err != nil {
+			s.Close()
+			t.Fatal(err)
+		}
+
+		loads := 0
+		loadPages := func() error {
+			loads++
+			return nil
+		}
+		result, failures, err := JobCancellationTest(context.Background(), info, caps, 10*time.Millisecond, loadPages)()
+		s.Close()
+
+		want := utils.Passed
+		if ignoreCancel {
+			want = utils.Failed
+		}
+		if result != want || err != nil {
+			t.Errorf("Ignore cancel %v: expected result %d, got %d with failures %v and error %v", ignoreCancel, want, result, failures, err)
+		}
+		if loads != 2 {
+			t.Errorf("Ignore cancel %v: page loads: expected 2, got %d", ignoreCancel, loads)
+		}
+	}
+}
+
+// TestJobCancellationTestSkipped tests that scanners without an ADF are
+// skipped.
+func TestJobCancellationTestSkipped(t *testing.T) {
+	loadPages := func() error {
+		t.Error("Unexpected request to load pages")
+		return nil
+	}
+	result, _, _ := JobCancellationTest(context.Background(), utils.LorgnetteScannerInfo{}, utils.ScannerCapabilities{}, time.Second, loadPages)()
+	if result != utils.Skipped {
+		t.Errorf("Expected skipped, got %d", result)
+	}
+}
@@ -36,3 +36,27 @@ func MatchesLorgnetteCapabilitiesTest(scannerCaps utils.ScannerCapabilities, raw
 		return
 	}
 }
+
+// LorgnetteCapabilitiesSchemaTest checks that `rawLorgnetteCaps` has no field
+// unknown to the schema of lorgnette's output, which would be ignored by the
+// other tests. One "needs audit" failure will be returned if it does, as
+// lorgnette's output may have grown and the schema need updating.
+// `rawLorgnetteCaps` should be the output from a call to
+// utils.LorgnetteCLIGetJSONCaps().
+func LorgnetteCapabilitiesSchemaTest(rawLorgnetteCaps string) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		if _, err = utils.ParseLorgnetteCapabilities(rawLorgnetteCaps); err != nil {
+			result = utils.Error
+			return
+		}
+
+		if _, strictErr := utils.ParseLorgnetteCapabilitiesStrict(rawLorgnetteCaps); strictErr != nil {
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("Lorgnette capabilities %v", strictErr)})
+			result = utils.Failed
+			return
+		}
+
+		result = utils.Passed
+		return
+	}
+}
@@ -165,3 +165,48 @@ func TestMatchesLorgnetteCapabilitiesTestInvalidJSON(t *testing.T) {
 		t.Error("Expected error for invalid JSON data.")
 	}
 }
+
+// TestLorgnetteCapabilitiesSchemaTest tests that
+// LorgnetteCapabilitiesSchemaTest functions correctly.
+func TestLorgnetteCapabilitiesSchemaTest(t *testing.T) {
+	tests := []struct {
+		rawLorgnetteCaps string
+		result           utils.TestResult
+		failures         []utils.FailureType
+	}{
+		{
+			rawLorgnetteCaps: rawLorgnetteCaps,
+			result:           utils.Passed,
+			failures:         []utils.FailureType{},
+		},
+		{
+			// Should fail: unknown field.
+			rawLorgnetteCaps: `{"SOURCE_PLATEN":{"Name":"Flatbed","Duplex":false}}`,
+			result:           utils.Failed,
+			failures:         []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			rawLorgnetteCaps: invalidJSONString,
+			result:           utils.Error,
+			failures:         []utils.FailureType{},
+		},
+	}
+
+	for _, tc := range tests {
+		result, failures, _ := LorgnetteCapabilitiesSchemaTest(tc.rawLorgnetteCaps)()
+
+		if result != tc.result {
+			t.Errorf("Result: expected %d, got %d", tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Number of failures: expected %d, got %d", len(tc.failures), len(failures))
+			continue
+		}
+		for i, failure := range failures {
+			if failure.Type != tc.failures[i] {
+				t.Errorf("FailureType: expected %d, got %d", tc.failures[i], failure.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"fmt"
+
+	"chromiumos/scanning/utils"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// capabilitiesPersistStateName names the state of
+// CapabilitiesPersistAcrossPowerCycleTest in the progress file.
+const capabilitiesPersistStateName = "CapabilitiesPersistAcrossPowerCycle"
+
+// CapabilitiesPersistAcrossPowerCycleTest checks that the scanner reports the
+// same capabilities after being power-cycled. The first run saves `caps` in
+// `progress` and is interrupted for the scanner to be power-cycled. The run
+// resumed afterwards compares `caps` to them: any difference is a critical
+// failure, as clients cache the capabilities of known scanners.
+func CapabilitiesPersistAcrossPowerCycleTest(progress *utils.Progress, caps utils.ScannerCapabilities) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		var before utils.ScannerCapabilities
+		found, err := progress.LoadState(capabilitiesPersistStateName, &before)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		if !found {
+			if err = progress.SaveState(capabilitiesPersistStateName, caps); err != nil {
+				result = utils.Error
+				return
+			}
+			result = utils.Interrupted
+			err = fmt.Errorf("power-cycle the scanner, then run again with -resume")
+			return
+		}
+
+		// Empty lists don't survive the progress file as such.
+		if diff := cmp.Diff(before, caps, cmpopts.EquateEmpty()); diff != "" {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Capabilities changed after a power cycle (-before +after):\n%s", diff)})
+			result = utils.Failed
+		} else {
+			result = utils.Passed
+		}
+		return
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"path/filepath"
+	"testing"
+
+	"chromiumos/scanning/utils"
+)
+
+// TestCapabilitiesPersistAcrossPowerCycleTest tests that
+// CapabilitiesPersistAcrossPowerCycleTest is interrupted on its first run, then
+// compares the capabilities once resumed.
+func TestCapabilitiesPersistAcrossPowerCycleTest(t *testing.T) {
+	const scanner = "airscan:escl:Test Scanner:http://localhost/eSCL/"
+	caps := utils.ScannerCapabilities{
+		MakeAndModel: "Test Scanner",
+		PlatenInputCaps: utils.SourceCapabilities{
+			MaxWidth: 2550,
+			SettingProfile: utils.SettingProfile{
+				ColorModes: []string{"RGB24", "Grayscale8"},
+			},
+		},
+	}
+	changedCaps := caps
+	changedCaps.PlatenInputCaps.SettingProfile.ColorModes = []string{"RGB24"}
+
+	tests := []struct {
+		after    utils.ScannerCapabilities
+		result   utils.TestResult
+		failures []utils.FailureType
+	}{
+		{
+			after:    caps,
+			result:   utils.Passed,
+			failures: []utils.FailureType{},
+		},
+		{
+			after:    changedCaps,
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(t.TempDir(), "progress.json")
+		progress, err := utils.NewProgress(path, scanner, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, _, err := CapabilitiesPersistAcrossPowerCycleTest(progress, caps)()
+		if result != utils.Interrupted || err == nil {
+			t.Fatalf("First run: got (%d, %v), want (%d, an error)", result, err, utils.Interrupted)
+		}
+
+		resumed, err := utils.NewProgress(path, scanner, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, failures, err := CapabilitiesPersistAcrossPowerCycleTest(resumed, tc.after)()
+
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+
+		if result != tc.result {
+			t.Errorf("Result: expected %d, got %d", tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Number of failures: expected %d, got %d", len(tc.failures), len(failures))
+		}
+		for i, failure := range failures {
+			if failure.Type != tc.failures[i] {
+				t.Errorf("FailureType: expected %d, got %d", tc.failures[i], failure.Type)
+			}
+		}
+	}
+}
@@ -7,6 +7,9 @@ package hwtests
 import (
 	"fmt"
 	"math"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
 
 	"chromiumos/scanning/utils"
 )
@@ -174,3 +177,246 @@ func LowestResolutionIsSupportedTest(platenCaps utils.SourceCapabilities, adfSim
 		return
 	}
 }
+
+// Resolutions, in dpi, lorgnette exposes under the WWCB resolution policy.
+// Supported resolutions outside of them are hidden, unless the scanner is
+// flagged as allowed to expose them.
+const (
+	minPolicyResolution = 100
+	maxPolicyResolution = 600
+)
+
+// isPolicyResolution returns whether the WWCB resolution policy lets lorgnette
+// expose `res`.
+func isPolicyResolution(res int) bool {
+	return res >= minPolicyResolution && res <= maxPolicyResolution
+}
+
+// sortedResolutions returns the resolutions of `set` in increasing order.
+func sortedResolutions(set map[int]bool) []int {
+	var resolutions []int
+	for res := range set {
+		resolutions = append(resolutions, res)
+	}
+	sort.Ints(resolutions)
+	return resolutions
+}
+
+// checkResolutionFiltering compares the resolutions lorgnette exposes for a
+// source with the ones it should expose according to `sourceCaps` and the WWCB
+// resolution policy: the advertised resolutions supported by ChromeOS, from
+// minPolicyResolution to maxPolicyResolution unless `allowAllResolutions`. One
+// critical failure is returned for each other resolution exposed by lorgnette,
+// and one "needs audit" failure for each expected resolution lorgnette hides.
+// Resolutions advertised several times, e.g. both as discrete resolutions and
+// in a range, are reported once.
+func checkResolutionFiltering(sourceName string, sourceCaps utils.SourceCapabilities, lorgnetteSource utils.LorgnetteSource, allowAllResolutions bool) (failures []utils.TestFailure) {
+	supported := make(map[int]bool)
+	expected := make(map[int]bool)
+	for _, res := range sourceCaps.SettingProfile.SupportedResolutions.ToLorgnetteResolutions() {
+		supported[res] = true
+		if allowAllResolutions || isPolicyResolution(res) {
+			expected[res] = true
+		}
+	}
+	exposed := make(map[int]bool)
+	for _, res := range lorgnetteSource.Resolutions {
+		exposed[res] = true
+	}
+
+	for _, res := range sortedResolutions(exposed) {
+		switch {
+		case expected[res]:
+		case supported[res]:
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s source: lorgnette exposes resolution %d, outside of the policy range [%d, %d] for scanners which aren't flagged.", sourceName, res, minPolicyResolution, maxPolicyResolution)})
+		default:
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s source: lorgnette exposes resolution %d which is unadvertised or unsupported: %v", sourceName, res, sourceCaps.SettingProfile.SupportedResolutions)})
+		}
+	}
+	for _, res := range sortedResolutions(expected) {
+		if !exposed[res] {
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s source: lorgnette hides advertised resolution %d", sourceName, res)})
+		}
+	}
+
+	return
+}
+
+// ResolutionFilteringPolicyTest checks that the resolutions lorgnette exposes
+// for each document source follow the WWCB resolution policy: they must be
+// exactly the resolutions advertised by `scannerCaps` which are supported by
+// ChromeOS, from minPolicyResolution to maxPolicyResolution dpi unless
+// `allowAllResolutions` flags the scanner as allowed to expose the others. One
+// critical failure will be returned for each resolution lorgnette exposes
+// without it being advertised, supported and allowed, and one "needs audit"
+// failure for each of those lorgnette hides. Unlike
+// MatchesLorgnetteCapabilitiesTest, which checks that lorgnette exposes all of
+// the supported resolutions, this test applies the policy. `rawLorgnetteCaps`
+// should be the output from a call to utils.LorgnetteCLIGetJSONCaps() for the
+// same scanner.
+func ResolutionFilteringPolicyTest(scannerCaps utils.ScannerCapabilities, rawLorgnetteCaps string, allowAllResolutions bool) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		lorgnetteCaps, err := utils.ParseLorgnetteCapabilities(rawLorgnetteCaps)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		platenCaps := scannerCaps.PlatenInputCaps
+		adfSimplexCaps := scannerCaps.AdfCapabilities.AdfSimplexInputCaps
+		adfDuplexCaps := scannerCaps.AdfCapabilities.AdfDuplexInputCaps
+		if !platenCaps.IsPopulated() && !adfSimplexCaps.IsPopulated() && !adfDuplexCaps.IsPopulated() {
+			result = utils.Skipped
+			return
+		}
+
+		failures = append(failures, checkResolutionFiltering("Platen", platenCaps, lorgnetteCaps.PlatenCaps, allowAllResolutions)...)
+		failures = append(failures, checkResolutionFiltering("ADF simplex", adfSimplexCaps, lorgnetteCaps.AdfSimplexCaps, allowAllResolutions)...)
+		failures = append(failures, checkResolutionFiltering("ADF duplex", adfDuplexCaps, lorgnetteCaps.AdfDuplexCaps, allowAllResolutions)...)
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+
+		return
+	}
+}
+
+// checkResolutionRange returns the failures of `r`, the range of the `axis`
+// resolutions of `profile`: its bounds must be positive and ordered, which are
+// critical failures, and its step must divide it and Normal must be one of its
+// values, which are "needs audit" failures. Unused ranges aren't checked.
+func checkResolutionRange(profile string, axis string, r utils.ResolutionRange) (failures []utils.TestFailure) {
+	if r == (utils.ResolutionRange{}) {
+		return
+	}
+
+	if r.Min <= 0 || r.Max < r.Min {
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s: %s resolution range [%d, %d] is invalid.", profile, axis, r.Min, r.Max)})
+		return
+	}
+	if r.Step <= 0 {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s: %s resolution range has step %d.", profile, axis, r.Step)})
+		return
+	}
+	if (r.Max-r.Min)%r.Step != 0 {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s: %s resolution step %d doesn't divide range [%d, %d].", profile, axis, r.Step, r.Min, r.Max)})
+	}
+	if r.Normal < r.Min || r.Normal > r.Max || (r.Normal-r.Min)%r.Step != 0 {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s: %s resolution Normal %d isn't in range [%d, %d] with step %d.", profile, axis, r.Normal, r.Min, r.Max, r.Step)})
+	}
+	return
+}
+
+// checkResolutions returns the failures of `resolutions`, those of the
+// SettingProfile named by `profile`, on their own. Non-positive discrete
+// resolutions are critical failures.
+func checkResolutions(profile string, resolutions utils.SupportedResolutions) (failures []utils.TestFailure) {
+	for _, res := range resolutions.DiscreteResolutions {
+		if res.XResolution <= 0 || res.YResolution <= 0 {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s: discrete resolution %dx%d is invalid.", profile, res.XResolution, res.YResolution)})
+		}
+	}
+	failures = append(failures, checkResolutionRange(profile, "X", resolutions.XResolutionRange)...)
+	failures = append(failures, checkResolutionRange(profile, "Y", resolutions.YResolutionRange)...)
+	return
+}
+
+// checkOpticalResolutions returns a "needs audit" failure for each of
+// `resolutions`, those of the SettingProfile named by `profile`, higher than
+// the optical maxima of `source`, which are ignored if missing.
+func checkOpticalResolutions(sourceName string, profile string, source utils.SourceCapabilities, resolutions utils.SupportedResolutions) (failures []utils.TestFailure) {
+	maxX, maxY := source.MaxOpticalXResolution, source.MaxOpticalYResolution
+	exceeds := func(x int, y int) bool {
+		return (maxX > 0 && x > maxX) || (maxY > 0 && y > maxY)
+	}
+
+	for _, res := range resolutions.DiscreteResolutions {
+		if exceeds(res.XResolution, res.YResolution) {
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s: %s resolution %dx%d exceeds the optical maxima %dx%d.", sourceName, profile, res.XResolution, res.YResolution, maxX, maxY)})
+		}
+	}
+	if exceeds(resolutions.XResolutionRange.Max, resolutions.YResolutionRange.Max) {
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s: %s resolution range maxima %dx%d exceed the optical maxima %dx%d.", sourceName, profile, resolutions.XResolutionRange.Max, resolutions.YResolutionRange.Max, maxX, maxY)})
+	}
+	return
+}
+
+// ResolutionConsistencyTest checks that the resolutions of every SettingProfile
+// of `rawCaps`, the ScannerCapabilities document exactly as reported by the
+// scanner, are consistent: discrete resolutions and range bounds must be
+// positive and ranges ordered, which are critical failures, and range steps
+// must divide their ranges, Normal values must be in their ranges and no
+// resolution may exceed the optical maxima of a source using it, which are
+// "needs audit" failures. Shared profiles are checked once, and against each
+// source referencing them. Shared profiles sharing a name must have the same
+// resolutions, or a "needs audit" failure is returned. `rawCaps` should be the
+// output from a call to utils.GetRawScannerCapabilities().
+func ResolutionConsistencyTest(rawCaps []byte) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		caps, err := utils.ParseScannerCapabilities(rawCaps)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		sources := []struct {
+			name string
+			caps utils.SourceCapabilities
+		}{
+			{"Platen", caps.PlatenInputCaps},
+			{"ADF simplex", caps.AdfCapabilities.AdfSimplexInputCaps},
+			{"ADF duplex", caps.AdfCapabilities.AdfDuplexInputCaps},
+		}
+		populated := false
+		for _, source := range sources {
+			populated = populated || source.caps.IsPopulated()
+		}
+		if !populated {
+			result = utils.Skipped
+			return
+		}
+
+		shared := make(map[string]utils.SupportedResolutions)
+		for _, profile := range caps.SettingProfiles {
+			name := fmt.Sprintf("Shared SettingProfile %q", profile.Name)
+			if previous, ok := shared[profile.Name]; ok {
+				if !cmp.Equal(previous, profile.SupportedResolutions) {
+					failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("%s is defined more than once with different resolutions.", name)})
+				}
+				continue
+			}
+			shared[profile.Name] = profile.SupportedResolutions
+			failures = append(failures, checkResolutions(name, profile.SupportedResolutions)...)
+		}
+
+		for _, source := range sources {
+			if !source.caps.IsPopulated() {
+				continue
+			}
+
+			profile := source.caps.SettingProfile
+			if profile.Ref != "" {
+				// Dangling references are reported by
+				// SettingProfileReferencesTest.
+				resolutions, ok := shared[profile.Ref]
+				if ok {
+					failures = append(failures, checkOpticalResolutions(source.name, fmt.Sprintf("shared SettingProfile %q", profile.Ref), source.caps, resolutions)...)
+				}
+				continue
+			}
+
+			failures = append(failures, checkResolutions(source.name+" SettingProfile", profile.SupportedResolutions)...)
+			failures = append(failures, checkOpticalResolutions(source.name, "SettingProfile", source.caps, profile.SupportedResolutions)...)
+		}
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
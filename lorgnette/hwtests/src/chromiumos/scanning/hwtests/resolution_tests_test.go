@@ -5,6 +5,7 @@
 package hwtests
 
 import (
+	"fmt"
 	"testing"
 
 	"chromiumos/scanning/utils"
@@ -410,3 +411,270 @@ func TestLowestResolutionIsSupportedTest(t *testing.T) {
 		}
 	}
 }
+
+// TestResolutionFilteringPolicyTest tests that ResolutionFilteringPolicyTest
+// functions correctly.
+func TestResolutionFilteringPolicyTest(t *testing.T) {
+	// Advertises 50, 75, 100, 300 and 1200 dpi, of which only 75, 100 and 300
+	// dpi are supported, and 100 and 300 dpi both as discrete resolutions and
+	// in a range.
+	platenCaps := utils.SourceCapabilities{
+		MaxWidth:  2550,
+		MaxHeight: 3300,
+		SettingProfile: utils.SettingProfile{
+			ColorModes: []string{"RGB24"},
+			SupportedResolutions: utils.SupportedResolutions{
+				DiscreteResolutions: []utils.DiscreteResolution{
+					utils.DiscreteResolution{
+						XResolution: 50,
+						YResolution: 50},
+					utils.DiscreteResolution{
+						XResolution: 75,
+						YResolution: 75},
+					utils.DiscreteResolution{
+						XResolution: 100,
+						YResolution: 100},
+					utils.DiscreteResolution{
+						XResolution: 300,
+						YResolution: 300},
+					utils.DiscreteResolution{
+						XResolution: 1200,
+						YResolution: 1200}},
+				XResolutionRange: utils.ResolutionRange{Min: 100, Max: 300, Normal: 100, Step: 200},
+				YResolutionRange: utils.ResolutionRange{Min: 100, Max: 300, Normal: 100, Step: 200}}}}
+
+	tests := []struct {
+		scannerCaps         utils.ScannerCapabilities
+		rawLorgnetteCaps    string
+		allowAllResolutions bool
+		result              utils.TestResult
+		failures            []utils.FailureType
+	}{
+		{
+			// Should pass: lorgnette exposes exactly the supported
+			// resolutions within the policy range.
+			scannerCaps:      utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps: `{"SOURCE_PLATEN":{"Resolutions":[100,300]}}`,
+			result:           utils.Passed,
+			failures:         []utils.FailureType{},
+		},
+		{
+			// Should fail: lorgnette exposes the unsupported 50 and 1200
+			// dpi, and the unadvertised 600 dpi.
+			scannerCaps:      utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps: `{"SOURCE_PLATEN":{"Resolutions":[50,100,300,600,1200]}}`,
+			result:           utils.Failed,
+			failures:         []utils.FailureType{utils.CriticalFailure, utils.CriticalFailure, utils.CriticalFailure},
+		},
+		{
+			// Should fail once: lorgnette hides the supported 300 dpi,
+			// advertised twice.
+			scannerCaps:      utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps: `{"SOURCE_PLATEN":{"Resolutions":[100]}}`,
+			result:           utils.Failed,
+			failures:         []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Should fail: lorgnette exposes the supported 75 dpi, below the
+			// policy range, for a scanner which isn't flagged.
+			scannerCaps:      utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps: `{"SOURCE_PLATEN":{"Resolutions":[75,100,300]}}`,
+			result:           utils.Failed,
+			failures:         []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should pass: the scanner is flagged, so 75 dpi is exposed.
+			scannerCaps:         utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps:    `{"SOURCE_PLATEN":{"Resolutions":[75,100,300]}}`,
+			allowAllResolutions: true,
+			result:              utils.Passed,
+			failures:            []utils.FailureType{},
+		},
+		{
+			// Should fail: the scanner is flagged, but lorgnette hides 75
+			// dpi.
+			scannerCaps:         utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps:    `{"SOURCE_PLATEN":{"Resolutions":[100,300]}}`,
+			allowAllResolutions: true,
+			result:              utils.Failed,
+			failures:            []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Should fail: lorgnette exposes resolutions for an ADF
+			// source the scanner doesn't advertise.
+			scannerCaps:      utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps: `{"SOURCE_PLATEN":{"Resolutions":[100,300]},"SOURCE_ADF_SIMPLEX":{"Resolutions":[300]}}`,
+			result:           utils.Failed,
+			failures:         []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			scannerCaps:      utils.ScannerCapabilities{},
+			rawLorgnetteCaps: `{}`,
+			result:           utils.Skipped,
+			failures:         []utils.FailureType{},
+		},
+		{
+			scannerCaps:      utils.ScannerCapabilities{PlatenInputCaps: platenCaps},
+			rawLorgnetteCaps: `{Not valid JSON!`,
+			result:           utils.Error,
+			failures:         []utils.FailureType{},
+		},
+	}
+
+	for i, tc := range tests {
+		result, failures, _ := ResolutionFilteringPolicyTest(tc.scannerCaps, tc.rawLorgnetteCaps, tc.allowAllResolutions)()
+
+		if result != tc.result {
+			t.Errorf("Test %d: result: expected %d, got %d", i, tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Test %d: number of failures: expected %d, got %v", i, len(tc.failures), failures)
+			continue
+		}
+		for j, failure := range failures {
+			if failure.Type != tc.failures[j] {
+				t.Errorf("Test %d: FailureType: expected %d, got %d", i, tc.failures[j], failure.Type)
+			}
+		}
+	}
+}
+
+// resolutionCapsXML is a ScannerCapabilities document with the shared
+// SettingProfiles, the platen optical maximum and SettingProfile, and the ADF
+// optical maximum and SettingProfile given as arguments.
+const resolutionCapsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<scan:SettingProfiles>%s</scan:SettingProfiles>
+	<scan:Platen>
+		<scan:PlatenInputCaps>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:MaxOpticalXResolution>%[2]d</scan:MaxOpticalXResolution>
+			<scan:MaxOpticalYResolution>%[2]d</scan:MaxOpticalYResolution>
+			<scan:SettingProfiles>%[3]s</scan:SettingProfiles>
+		</scan:PlatenInputCaps>
+	</scan:Platen>
+	<scan:Adf>
+		<scan:AdfSimplexInputCaps>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:MaxOpticalXResolution>%[4]d</scan:MaxOpticalXResolution>
+			<scan:MaxOpticalYResolution>%[4]d</scan:MaxOpticalYResolution>
+			<scan:SettingProfiles>%[5]s</scan:SettingProfiles>
+		</scan:AdfSimplexInputCaps>
+	</scan:Adf>
+</scan:ScannerCapabilities>`
+
+// resolutionProfile returns a SettingProfile element named `name` with the
+// SupportedResolutions `resolutions`.
+func resolutionProfile(name string, resolutions string) string {
+	return fmt.Sprintf(`<scan:SettingProfile name="%s">
+	<scan:ColorModes><scan:ColorMode>RGB24</scan:ColorMode></scan:ColorModes>
+	<scan:SupportedResolutions>%s</scan:SupportedResolutions>
+</scan:SettingProfile>`, name, resolutions)
+}
+
+// discreteResolution returns a DiscreteResolutions element listing `x`x`y`.
+func discreteResolution(x int, y int) string {
+	return fmt.Sprintf(`<scan:DiscreteResolutions><scan:DiscreteResolution>
+	<scan:XResolution>%d</scan:XResolution>
+	<scan:YResolution>%d</scan:YResolution>
+</scan:DiscreteResolution></scan:DiscreteResolutions>`, x, y)
+}
+
+// resolutionRange returns a ResolutionRange element with the same X and Y
+// ranges.
+func resolutionRange(min int, max int, normal int, step int) string {
+	axis := fmt.Sprintf(`<scan:Min>%d</scan:Min><scan:Max>%d</scan:Max><scan:Normal>%d</scan:Normal><scan:Step>%d</scan:Step>`, min, max, normal, step)
+	return fmt.Sprintf(`<scan:ResolutionRange>
+	<scan:XResolutionRange>%s</scan:XResolutionRange>
+	<scan:YResolutionRange>%s</scan:YResolutionRange>
+</scan:ResolutionRange>`, axis, axis)
+}
+
+// TestResolutionConsistencyTest tests that ResolutionConsistencyTest functions
+// correctly.
+func TestResolutionConsistencyTest(t *testing.T) {
+	tests := []struct {
+		shared        string
+		platenOptical int
+		platen        string
+		adfOptical    int
+		adf           string
+		result        utils.TestResult
+		failures      []utils.FailureType
+	}{
+		{
+			shared:        resolutionProfile("shared", resolutionRange(75, 600, 300, 75)),
+			platenOptical: 600,
+			platen:        `<scan:SettingProfile ref="shared"/>`,
+			adfOptical:    600,
+			adf:           resolutionProfile("", discreteResolution(300, 300)),
+			result:        utils.Passed,
+			failures:      []utils.FailureType{},
+		},
+		{
+			// Should fail: invalid discrete resolution and range.
+			platenOptical: 600,
+			platen:        resolutionProfile("", discreteResolution(300, 0)),
+			adfOptical:    600,
+			adf:           resolutionProfile("", resolutionRange(600, 300, 300, 100)),
+			result:        utils.Failed,
+			failures:      []utils.FailureType{utils.CriticalFailure, utils.CriticalFailure, utils.CriticalFailure},
+		},
+		{
+			// Should fail: step doesn't divide the range, and Normal
+			// is out of it.
+			platenOptical: 600,
+			platen:        resolutionProfile("", resolutionRange(100, 600, 700, 200)),
+			result:        utils.Failed,
+			failures:      []utils.FailureType{utils.NeedsAudit, utils.NeedsAudit, utils.NeedsAudit, utils.NeedsAudit},
+		},
+		{
+			// Should fail: the shared profile exceeds the ADF's optical
+			// maxima only.
+			shared:        resolutionProfile("shared", discreteResolution(1200, 1200)),
+			platenOptical: 1200,
+			platen:        `<scan:SettingProfile ref="shared"/>`,
+			adfOptical:    600,
+			adf:           `<scan:SettingProfile ref="shared"/>`,
+			result:        utils.Failed,
+			failures:      []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Should fail: shared profile defined twice differently.
+			shared:        resolutionProfile("shared", discreteResolution(300, 300)) + resolutionProfile("shared", discreteResolution(600, 600)),
+			platenOptical: 600,
+			platen:        `<scan:SettingProfile ref="shared"/>`,
+			result:        utils.Failed,
+			failures:      []utils.FailureType{utils.NeedsAudit},
+		},
+	}
+
+	for _, tc := range tests {
+		rawCaps := fmt.Sprintf(resolutionCapsXML, tc.shared, tc.platenOptical, tc.platen, tc.adfOptical, tc.adf)
+		result, failures, err := ResolutionConsistencyTest([]byte(rawCaps))()
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.result {
+			t.Errorf("Result: expected %d, got %d", tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Number of failures: expected %d, got %d: %v", len(tc.failures), len(failures), failures)
+			continue
+		}
+		for i, failure := range failures {
+			if failure.Type != tc.failures[i] {
+				t.Errorf("FailureType: expected %d, got %d", tc.failures[i], failure.Type)
+			}
+		}
+	}
+
+	result, _, _ := ResolutionConsistencyTest([]byte(`<scan:ScannerCapabilities`))()
+	if result != utils.Error {
+		t.Errorf("Result: expected %d, got %d", utils.Error, result)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"chromiumos/scanning/utils"
+)
+
+// splitScanRegions splits the largest area advertised by `sourceCaps` into `n`
+// horizontal strips of equal height, from top to bottom.
+func splitScanRegions(sourceCaps utils.SourceCapabilities, n int) ([]utils.ScanRegion, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("Invalid number of scan regions: %d", n)
+	}
+	height := sourceCaps.MaxHeight / n
+	if height == 0 || height < sourceCaps.MinHeight {
+		return nil, fmt.Errorf("%d scan regions of height %d are below the minimum height %d", n, height, sourceCaps.MinHeight)
+	}
+
+	var regions []utils.ScanRegion
+	for i := 0; i < n; i++ {
+		regions = append(regions, utils.ScanRegion{
+			YOffset: i * height,
+			Width:   sourceCaps.MaxWidth,
+			Height:  height,
+		})
+	}
+	return regions, nil
+}
+
+// isScanRegionsRejection returns whether `status` is a response to a job
+// creation which the eSCL specification allows for unsupported scan regions.
+func isScanRegionsRejection(status int) bool {
+	return status == http.StatusBadRequest || status == http.StatusConflict
+}
+
+// classifyScanRegionsResults checks the response to a job requesting `regions`
+// scan regions from a source advertising `maxRegions`. A created job must
+// return one page per region. Rejecting a single region within the advertised
+// area is a critical failure, while rejecting several regions needs an audit,
+// as the scanner advertised them. Requests exceeding `maxRegions` may only be
+// rejected, and accepting them needs an audit. Any other response is a
+// critical failure.
+func classifyScanRegionsResults(status, regions, maxRegions, pages int) (failures []utils.TestFailure) {
+	if regions > maxRegions {
+		if status == http.StatusCreated {
+			failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("Accepted %d scan regions, although MaxScanRegions is %d.", regions, maxRegions)})
+		} else if !isScanRegionsRejection(status) {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Unexpected HTTP status %d for %d scan regions, expected 400 or 409.", status, regions)})
+		}
+		return
+	}
+
+	switch {
+	case status == http.StatusCreated:
+		if pages != regions {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Expected %d pages, one per scan region, got %d.", regions, pages)})
+		}
+	case isScanRegionsRejection(status) && regions == 1:
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Rejected a scan region within the advertised area with HTTP status %d.", status)})
+	case isScanRegionsRejection(status):
+		failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("Rejected %d scan regions with HTTP status %d, although MaxScanRegions is %d.", regions, status, maxRegions)})
+	default:
+		failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Unexpected HTTP status %d for %d scan regions.", status, regions)})
+	}
+	return
+}
+
+// maxScanRegions returns the number of scan regions advertised by
+// `sourceCaps`. Sources which don't advertise it support a single region.
+func maxScanRegions(sourceCaps utils.SourceCapabilities) int {
+	if sourceCaps.MaxScanRegions < 1 {
+		return 1
+	}
+	return sourceCaps.MaxScanRegions
+}
+
+// runScanRegionsJob creates a job with `settings` on the scanner represented
+// by `info`, and returns the status of the response along with the pages of
+// the job, if it was created. The job is cancelled if reading its pages fails.
+func runScanRegionsJob(ctx context.Context, info utils.LorgnetteScannerInfo, settings utils.ScanSettings) (status int, pages []utils.ScannedPage, err error) {
+	status, jobPath, err := utils.PostESCLScanJob(ctx, info, settings)
+	if err != nil || status != http.StatusCreated {
+		return
+	}
+
+	for {
+		var page utils.ScannedPage
+		var done bool
+		page, done, err = utils.NextESCLDocument(ctx, info, jobPath)
+		if err != nil {
+			utils.DeleteESCLScanJob(ctx, info, jobPath)
+			return
+		}
+		if done {
+			return
+		}
+		pages = append(pages, page)
+	}
+}
+
+// MaxSizeScanRegionTest scans a single region covering the largest area
+// advertised by the platen of the scanner represented by `info`, and verifies
+// that it is accepted and returned as a single page of its size, as checked by
+// verifyScannedPages. It is skipped unless `caps` advertises a platen. Requests are aborted once `ctx` expires.
+func MaxSizeScanRegionTest(ctx context.Context, info utils.LorgnetteScannerInfo, caps utils.ScannerCapabilities) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		platenCaps := caps.PlatenInputCaps
+		if !platenCaps.IsPopulated() {
+			result = utils.Skipped
+			return
+		}
+
+		settings, err := sourceScanSettings(platenCaps, "Platen")
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		settings.Regions, err = splitScanRegions(platenCaps, 1)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		status, pages, err := runScanRegionsJob(ctx, info, settings)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		log.Printf("INFO: Maximum size scan region: HTTP status %d, %d pages", status, len(pages))
+
+		failures = classifyScanRegionsResults(status, 1, maxScanRegions(platenCaps), len(pages))
+		var pageFailures []utils.TestFailure
+		pageFailures, err = verifyScannedPages(pages, settings)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		failures = append(failures, pageFailures...)
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
+
+// MultipleScanRegionsTest splits the largest area advertised by the platen of
+// the scanner represented by `info` into MaxScanRegions regions, and verifies
+// that the scanner either returns a page of the size of each region, as checked
+// by verifyScannedPages, or rejects the job as allowed by the eSCL
+// specification. It then requests one region more than
+// advertised, which the scanner should reject. It is skipped unless `caps`
+// advertises a platen supporting several scan regions. See
+// classifyScanRegionsResults for how failures are reported. Requests are
+// aborted once `ctx` expires.
+func MultipleScanRegionsTest(ctx context.Context, info utils.LorgnetteScannerInfo, caps utils.ScannerCapabilities) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		platenCaps := caps.PlatenInputCaps
+		maxRegions := maxScanRegions(platenCaps)
+		if !platenCaps.IsPopulated() || maxRegions < 2 {
+			result = utils.Skipped
+			return
+		}
+
+		settings, err := sourceScanSettings(platenCaps, "Platen")
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		settings.Regions, err = splitScanRegions(platenCaps, maxRegions)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		status, pages, err := runScanRegionsJob(ctx, info, settings)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		log.Printf("INFO: %d scan regions: HTTP status %d, %d pages", maxRegions, status, len(pages))
+		failures = classifyScanRegionsResults(status, maxRegions, maxRegions, len(pages))
+		var pageFailures []utils.TestFailure
+		pageFailures, err = verifyScannedPages(pages, settings)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+		failures = append(failures, pageFailures...)
+
+		// Regions too small for the source can't tell whether the scanner
+		// checks their number.
+		if settings.Regions, err = splitScanRegions(platenCaps, maxRegions+1); err != nil {
+			log.Printf("INFO: Not requesting more scan regions than advertised: %v", err)
+			err = nil
+		} else {
+			var jobPath string
+			status, jobPath, err = utils.PostESCLScanJob(ctx, info, settings)
+			if err != nil {
+				result = utils.Error
+				return
+			}
+			if status == http.StatusCreated {
+				utils.DeleteESCLScanJob(ctx, info, jobPath)
+			}
+			log.Printf("INFO: %d scan regions: HTTP status %d", maxRegions+1, status)
+			failures = append(failures, classifyScanRegionsResults(status, maxRegions+1, maxRegions, 0)...)
+		}
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
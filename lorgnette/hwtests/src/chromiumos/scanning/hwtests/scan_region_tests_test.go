@@ -0,0 +1,197 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"chromiumos/scanning/utils"
+	"chromiumos/scanning/utils/testserver"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestSplitScanRegions tests that splitScanRegions splits the area of a source
+// into strips, and refuses strips below its minimum height.
+func TestSplitScanRegions(t *testing.T) {
+	sourceCaps := utils.SourceCapabilities{MinWidth: 16, MaxWidth: 2550, MinHeight: 16, MaxHeight: 3300}
+
+	got, err := splitScanRegions(sourceCaps, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []utils.ScanRegion{
+		{YOffset: 0, Width: 2550, Height: 1100},
+		{YOffset: 1100, Width: 2550, Height: 1100},
+		{YOffset: 2200, Width: 2550, Height: 1100},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected regions (-want +got):\n%s", diff)
+	}
+
+	for _, n := range []int{0, 300} {
+		if _, err := splitScanRegions(sourceCaps, n); err == nil {
+			t.Errorf("%d regions: expected error", n)
+		}
+	}
+}
+
+// TestClassifyScanRegionsResults tests that classifyScanRegionsResults
+// functions correctly.
+func TestClassifyScanRegionsResults(t *testing.T) {
+	tests := []struct {
+		status     int
+		regions    int
+		maxRegions int
+		pages      int
+		failures   []utils.FailureType
+	}{
+		{
+			// Should pass: a page per region.
+			status:     http.StatusCreated,
+			regions:    2,
+			maxRegions: 2,
+			pages:      2,
+			failures:   []utils.FailureType{},
+		},
+		{
+			// Should fail: the regions were merged into one page.
+			status:     http.StatusCreated,
+			regions:    2,
+			maxRegions: 2,
+			pages:      1,
+			failures:   []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should fail: a single region within the area was rejected.
+			status:     http.StatusConflict,
+			regions:    1,
+			maxRegions: 1,
+			failures:   []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Needs audit: advertised regions were rejected.
+			status:     http.StatusBadRequest,
+			regions:    3,
+			maxRegions: 3,
+			failures:   []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Should fail: the scanner errored out.
+			status:     http.StatusInternalServerError,
+			regions:    2,
+			maxRegions: 2,
+			failures:   []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should pass: too many regions were rejected.
+			status:     http.StatusConflict,
+			regions:    3,
+			maxRegions: 2,
+			failures:   []utils.FailureType{},
+		},
+		{
+			// Needs audit: too many regions were accepted.
+			status:     http.StatusCreated,
+			regions:    3,
+			maxRegions: 2,
+			failures:   []utils.FailureType{utils.NeedsAudit},
+		},
+		{
+			// Should fail: too many regions errored out.
+			status:     http.StatusInternalServerError,
+			regions:    3,
+			maxRegions: 2,
+			failures:   []utils.FailureType{utils.CriticalFailure},
+		},
+	}
+
+	for i, tc := range tests {
+		failures := classifyScanRegionsResults(tc.status, tc.regions, tc.maxRegions, tc.pages)
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Test %d: number of failures: expected %d, got %d", i, len(tc.failures), len(failures))
+			continue
+		}
+		for j, failure := range failures {
+			if failure.Type != tc.failures[j] {
+				t.Errorf("Test %d: failure type: expected %d, got %d", i, tc.failures[j], failure.Type)
+			}
+		}
+	}
+}
+
+// TestScanRegionTests runs the scan region tests against simulated scanners
+// advertising one and two scan regions, and supporting `supported` of them.
+// The simulator returns all of its pages, whatever the regions, which are
+// 637x825 pixels for the whole platen at 75 dpi, and 637x412 for half of it.
+func TestScanRegionTests(t *testing.T) {
+	twoRegionsXML := strings.Replace(testserver.DefaultCapabilitiesXML, "<scan:MaxScanRegions>1<", "<scan:MaxScanRegions>2<", 1)
+
+	tests := []struct {
+		capabilitiesXML string
+		supported       int
+		pages           [][]byte
+		maxSize         utils.TestResult
+		multiple        utils.TestResult
+	}{
+		{
+			capabilitiesXML: testserver.DefaultCapabilitiesXML,
+			supported:       1,
+			pages:           [][]byte{grayPNG(t, 637, 825)},
+			maxSize:         utils.Passed,
+			multiple:        utils.Skipped,
+		},
+		{
+			// A single region returns two pages.
+			capabilitiesXML: twoRegionsXML,
+			supported:       2,
+			pages:           [][]byte{grayPNG(t, 637, 412), grayPNG(t, 637, 412)},
+			maxSize:         utils.Failed,
+			multiple:        utils.Passed,
+		},
+		{
+			// The advertised regions are rejected.
+			capabilitiesXML: twoRegionsXML,
+			supported:       1,
+			pages:           [][]byte{grayPNG(t, 637, 825)},
+			maxSize:         utils.Passed,
+			multiple:        utils.Failed,
+		},
+		{
+			// The page doesn't have the size of the region.
+			capabilitiesXML: testserver.DefaultCapabilitiesXML,
+			supported:       1,
+			pages:           [][]byte{grayPNG(t, 8, 8)},
+			maxSize:         utils.Failed,
+			multiple:        utils.Skipped,
+		},
+	}
+
+	for i, tc := range tests {
+		s := testserver.New(testserver.Config{
+			CapabilitiesXML: tc.capabilitiesXML,
+			Pages:           tc.pages,
+			MaxScanRegions:  tc.supported,
+		})
+		info := utils.LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}
+		caps, err := utils.GetScannerCapabilities(context.Background(), info)
+		if err != nil {
+			s.Close()
+			t.Fatal(err)
+		}
+
+		if result, failures, err := MaxSizeScanRegionTest(context.Background(), info, caps)(); result != tc.maxSize || err != nil {
+			t.Errorf("Test %d: MaxSizeScanRegionTest: expected result %d, got %d with failures %v and error %v", i, tc.maxSize, result, failures, err)
+		}
+		if result, failures, err := MultipleScanRegionsTest(context.Background(), info, caps)(); result != tc.multiple || err != nil {
+			t.Errorf("Test %d: MultipleScanRegionsTest: expected result %d, got %d with failures %v and error %v", i, tc.multiple, result, failures, err)
+		}
+		s.Close()
+	}
+}
@@ -5,17 +5,17 @@
 package hwtests
 
 import (
+	"bytes"
 	"chromiumos/scanning/utils"
+	"context"
 	"fmt"
-	"os/exec"
-	"regexp"
+	"log"
 	"strconv"
 	"strings"
 )
 
-// identifyOutputRegex parses out the width, height and colorspace from the
-// output of `identify someImage`.
-var identifyOutputRegex = regexp.MustCompile(`^.+ PNG (?P<width>[0-9]+)x(?P<height>[0-9]+).+ 8-bit (?P<colorspace>sRGB|Grayscale Gray 256c|Grayscale Gray 2c)`)
+// eSCLRegionUnits is the number of units of a utils.ScanRegion per inch.
+const eSCLRegionUnits = 300
 
 // toInputColorMode converts from the color mode output by `lorgnette_cli
 // get_json_caps --scanner=someScanner` to the color mode accepted by
@@ -33,83 +33,117 @@ func toInputColorMode(lorgnetteColorMode string) (string, error) {
 	}
 }
 
-// toIdentifyColorspace converts from the color mode output by `lorgnette_cli
-// get_json_caps --scanner=someScanner` to the colorspace output by `identify
-// someImage`.
-func toIdentifyColorspace(lorgnetteColorMode string) (string, error) {
+// toImageFormat converts from the color mode output by `lorgnette_cli
+// get_json_caps --scanner=someScanner` to the number of channels and the bit
+// depth of the PNG images lorgnette writes in that color mode.
+func toImageFormat(lorgnetteColorMode string) (channels int, bitDepth int, err error) {
 	switch lorgnetteColorMode {
 	case "MODE_LINEART":
-		return "Grayscale Gray 2c", nil
+		return 1, 1, nil
 	case "MODE_GRAYSCALE":
-		return "Grayscale Gray 256c", nil
+		return 1, 8, nil
 	case "MODE_COLOR":
-		return "sRGB", nil
+		return 3, 8, nil
 	default:
-		return "", fmt.Errorf("Unable to convert lorgnette color mode: %s to identify colorspace", lorgnetteColorMode)
+		return 0, 0, fmt.Errorf("Unable to convert lorgnette color mode: %s to image format", lorgnetteColorMode)
 	}
 }
 
-// verifyScannedImage checks that `identifyOutput` is the expected size for the
-// given `resolution`, and that `identifyOutput` matches the given `colorMode`.
-// If the verification fails, the returned string will contain the details of
-// the failures.
-func verifyScannedImage(identifyOutput string, paperSize utils.PaperSize, resolution int, colorMode string) (bool, string, error) {
-	match := identifyOutputRegex.FindStringSubmatch(identifyOutput)
-	if match == nil || len(match) < 4 {
-		return false, "", fmt.Errorf("Unable to parse identify output: %s", identifyOutput)
+// verifyScannedImage checks that `info`, read from a scanned PNG image, is the
+// expected size for `paperSize` at the given `resolution`, and that it matches
+// the given `colorMode`. If the verification fails, the returned string will
+// contain the details of the failures.
+func verifyScannedImage(info utils.ImageInfo, paperSize utils.PaperSize, resolution int, colorMode string) (bool, string, error) {
+	channels, bitDepth, err := toImageFormat(colorMode)
+	if err != nil {
+		return false, "", err
 	}
 
-	for i, name := range identifyOutputRegex.SubexpNames() {
-		if name == "width" {
-			width, err := strconv.Atoi(match[i])
+	if info.Format != utils.PNGFormat {
+		return false, fmt.Sprintf("Format: got %s, expected %s", info.Format, utils.PNGFormat), nil
+	}
+	if info.Channels != channels {
+		return false, fmt.Sprintf("Channels: got %d, expected %d", info.Channels, channels), nil
+	}
+	passed, failureMessage := utils.VerifyImageInfo(info, paperSize, resolution, bitDepth)
+	return passed, failureMessage, nil
+}
 
-			if err != nil {
-				return false, "", err
-			}
+// esclBitDepth returns the number of bits per channel of the pages scanned in
+// the eSCL `colorMode` as `contentType`. JPEG images always have 8 bits per
+// channel.
+func esclBitDepth(colorMode string, contentType string) (int, error) {
+	if contentType == "image/jpeg" {
+		return 8, nil
+	}
+	switch colorMode {
+	case "BlackAndWhite1":
+		return 1, nil
+	case "Grayscale8", "RGB24":
+		return 8, nil
+	case "Grayscale16", "RGB48":
+		return 16, nil
+	default:
+		return 0, fmt.Errorf("Unknown eSCL color mode: %s", colorMode)
+	}
+}
 
-			expectedWidth := paperSize.PixelWidthForResolution(resolution)
-			if expectedWidth != width {
-				return false, fmt.Sprintf("Width: got %d, expected %d", width, expectedWidth), nil
-			}
+// verifyScannedPages checks that each of the `pages` returned by a scan job
+// created with `settings` is a valid image with the requested color mode and
+// resolution. When the job returned a page per scan region of `settings`, each
+// page must also have the size of its region, give or take a pixel of
+// rounding. Pages of a type utils.ReadImageInfo can't read, e.g. PDF, aren't
+// checked. One critical failure is returned for each page failing the
+// verification.
+func verifyScannedPages(pages []utils.ScannedPage, settings utils.ScanSettings) (failures []utils.TestFailure, err error) {
+	for i, page := range pages {
+		if !utils.CanReadImageInfo(page.ContentType) {
+			log.Printf("INFO: Not verifying page %d of type %s", i+1, page.ContentType)
+			continue
 		}
 
-		if name == "height" {
-			height, err := strconv.Atoi(match[i])
-
-			if err != nil {
-				return false, "", err
-			}
+		info, readErr := utils.ReadImageInfo(bytes.NewReader(page.Data), page.ContentType)
+		if readErr != nil {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Page %d is not a valid %s image: %v", i+1, page.ContentType, readErr)})
+			continue
+		}
 
-			expectedHeight := paperSize.PixelHeightForResolution(resolution)
-			if expectedHeight != height {
-				return false, fmt.Sprintf("Height: got %d, expected %d", height, expectedHeight), nil
+		message := ""
+		if len(settings.Regions) == len(pages) {
+			region := settings.Regions[i]
+			width := region.Width * settings.Resolution / eSCLRegionUnits
+			height := region.Height * settings.Resolution / eSCLRegionUnits
+			if info.Width < width || info.Width > width+1 {
+				message = fmt.Sprintf("Width: got %d, expected %d", info.Width, width)
+			} else if info.Height < height || info.Height > height+1 {
+				message = fmt.Sprintf("Height: got %d, expected %d", info.Height, height)
 			}
 		}
-
-		if name == "colorspace" {
-			colorSpace, err := toIdentifyColorspace(colorMode)
+		if message == "" {
+			var bitDepth int
+			bitDepth, err = esclBitDepth(settings.ColorMode, page.ContentType)
 			if err != nil {
-				return false, "", err
-			}
-
-			if colorSpace != match[i] {
-				return false, fmt.Sprintf("Colorspace: got %s, expected %s", match[i], colorSpace), nil
+				return
 			}
+			_, message = utils.VerifyImageFormat(info, settings.Resolution, bitDepth)
+		}
+		if message != "" {
+			failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("Image verification failed for page %d: %s", i+1, message)})
 		}
 	}
-
-	return true, "", nil
+	return
 }
 
 // AllScanCombinationsTest checks that lorgnette CLI produces a scanned image
 // for each combination of resolution and color mode advertised by `source`.
 // Basic verification is performed on the scanned image to make sure that it is
-// the correct size and color space. One critical failure will be returned for
+// the correct size, bit depth and number of channels. One critical failure will be returned for
 // each combination that either produces no scanned image or produces a scanned
 // image which fails the verification. Scanned images will be output to
 // `outputDir`/scan-sourceName-${mode}-${res}_page%n.png` for each color mode
 // `mode` and resolution `res`. `outputDir` should not contain the pattern "%n".
-func AllScanCombinationsTest(source utils.LorgnetteSource, sourceName string, scannerName string, outputDir string) utils.TestFunction {
+// Each scan is aborted once `ctx` expires.
+func AllScanCombinationsTest(ctx context.Context, source utils.LorgnetteSource, sourceName string, scannerName string, outputDir string) utils.TestFunction {
 	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
 		if !source.IsPopulated() {
 			result = utils.Skipped
@@ -144,7 +178,7 @@ func AllScanCombinationsTest(source utils.LorgnetteSource, sourceName string, sc
 				}
 
 				outputPattern := fmt.Sprintf("%s/scan-%s-%s-%d_page%%n.png", outputDir, sourceName, colorMode, resolution)
-				_, err = utils.LorgnetteCLIScan(scannerName, sourceName, utils.LetterSize, resolution, inputColorMode, outputPattern)
+				_, err = utils.LorgnetteCLIScan(ctx, scannerName, sourceName, utils.LetterSize, resolution, inputColorMode, outputPattern)
 
 				if err != nil {
 					result = utils.Error
@@ -152,9 +186,10 @@ func AllScanCombinationsTest(source utils.LorgnetteSource, sourceName string, sc
 				}
 
 				for i := 1; i <= numPages; i++ {
-					cmd := exec.Command("identify", strings.Replace(outputPattern, "%n", strconv.Itoa(i), 1))
-					var identifyBytes []byte
-					identifyBytes, err = cmd.Output()
+					// Streamed, so that high-resolution scans don't have to fit
+					// in memory.
+					var info utils.ImageInfo
+					info, err = utils.ReadImageFileInfo(strings.Replace(outputPattern, "%n", strconv.Itoa(i), 1))
 
 					if err != nil {
 						result = utils.Error
@@ -163,7 +198,7 @@ func AllScanCombinationsTest(source utils.LorgnetteSource, sourceName string, sc
 
 					var passed bool
 					var failureMessage string
-					passed, failureMessage, err = verifyScannedImage(string(identifyBytes), utils.LetterSize, resolution, colorMode)
+					passed, failureMessage, err = verifyScannedImage(info, utils.LetterSize, resolution, colorMode)
 
 					if err != nil {
 						result = utils.Error
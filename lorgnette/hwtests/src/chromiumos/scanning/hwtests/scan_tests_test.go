@@ -5,22 +5,26 @@
 package hwtests
 
 import (
+	"bytes"
 	"chromiumos/scanning/utils"
+	"image"
+	"image/png"
 	"testing"
 )
 
-// Output from `identify` run on a black and white image.
-const identifyOutputLineart = `lorgnette/test_images/bw.png PNG 1700x2200 1700x2200+0+0 8-bit Grayscale Gray 2c 147B 0.000u 0:00.000`
-
-// Output from `identify` run on a grayscale image.
-const identifyOutputGrayscale = `/tmp/scan-airscan_escl_Canon_MF741C_743C__9e_31_7f___4___9e_31_7f___17___9e_31_7f___8__http___200_100_90_40_30_eSCL__page1.png PNG 850x1100 850x1100+0+0 8-bit Grayscale Gray 256c 899008B 0.000u 0:00.000`
-
-// Output from `identify` run on a color image.
-const identifyOutputColor = `/tmp/scan-airscan_escl_Canon_MF741C_743C__9e_31_7f___4___9e_31_7f___17___9e_31_7f___8__http___200_100_90_40_30_eSCL__page1.png PNG 2550x3300 2550x3300+0+0 8-bit sRGB 1.74592MiB 0.000u 0:00.000`
-
-// String input that does not match any of the regexes used in scan_tests.go.
+// String input that does not match any of the color modes used in
+// scan_tests.go.
 const unrecognizedInput = `Unrecognized input.`
 
+// grayPNG returns a blank 8-bit grayscale PNG of the given size.
+func grayPNG(t *testing.T, width int, height int) []byte {
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
 // TestToInputColorMode tests that toInputColorMode functions correctly.
 func TestToInputColorMode(t *testing.T) {
 	tests := []struct {
@@ -63,43 +67,47 @@ func TestToInputColorModeUnrecognizedInput(t *testing.T) {
 	}
 }
 
-// TestToIdentifyColorspace tests that toIdentifyColorspace functions correctly.
-func TestToIdentifyColorspace(t *testing.T) {
+// TestToImageFormat tests that toImageFormat functions correctly.
+func TestToImageFormat(t *testing.T) {
 	tests := []struct {
 		lorgnetteColorMode string
-		identifyColorspace string
+		channels           int
+		bitDepth           int
 	}{
 		{
 			lorgnetteColorMode: "MODE_LINEART",
-			identifyColorspace: "Grayscale Gray 2c",
+			channels:           1,
+			bitDepth:           1,
 		},
 		{
 			lorgnetteColorMode: "MODE_GRAYSCALE",
-			identifyColorspace: "Grayscale Gray 256c",
+			channels:           1,
+			bitDepth:           8,
 		},
 		{
 			lorgnetteColorMode: "MODE_COLOR",
-			identifyColorspace: "sRGB",
+			channels:           3,
+			bitDepth:           8,
 		},
 	}
 
 	for _, tc := range tests {
-		identifyColorspace, err := toIdentifyColorspace(tc.lorgnetteColorMode)
+		channels, bitDepth, err := toImageFormat(tc.lorgnetteColorMode)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
 		}
 
-		if identifyColorspace != tc.identifyColorspace {
-			t.Errorf("identifyColorspace: expected %s, got %s", tc.identifyColorspace, identifyColorspace)
+		if channels != tc.channels || bitDepth != tc.bitDepth {
+			t.Errorf("%s: expected %d channels of %d bits, got %d of %d", tc.lorgnetteColorMode, tc.channels, tc.bitDepth, channels, bitDepth)
 		}
 	}
 }
 
-// TestToIdentifyColorspaceUnrecognizedInput tests that toIdentifyColorspace
-// returns an error when it encounters an unrecognized input.
-func TestToIdentifyColorspaceUnrecognizedInput(t *testing.T) {
-	_, err := toIdentifyColorspace(unrecognizedInput)
+// TestToImageFormatUnrecognizedInput tests that toImageFormat returns an error
+// when it encounters an unrecognized input.
+func TestToImageFormatUnrecognizedInput(t *testing.T) {
+	_, _, err := toImageFormat(unrecognizedInput)
 	if err == nil {
 		t.Error("Expected error from unrecognized input.")
 	}
@@ -107,52 +115,72 @@ func TestToIdentifyColorspaceUnrecognizedInput(t *testing.T) {
 
 // TestVerifyScannedImage tests that verifyScannedImage functions correctly.
 func TestVerifyScannedImage(t *testing.T) {
+	lineart := utils.ImageInfo{Format: utils.PNGFormat, Width: 1700, Height: 2200, BitDepth: 1, Channels: 1}
+	grayscale := utils.ImageInfo{Format: utils.PNGFormat, Width: 850, Height: 1100, BitDepth: 8, Channels: 1}
+	color := utils.ImageInfo{Format: utils.PNGFormat, Width: 2550, Height: 3300, BitDepth: 8, Channels: 3}
+	jpeg := color
+	jpeg.Format = utils.JPEGFormat
+
 	tests := []struct {
-		identifyOutput string
+		info           utils.ImageInfo
 		resolution     int
 		colorMode      string
 		passed         bool
 		failureMessage string
 	}{
 		{
-			identifyOutput: identifyOutputLineart,
+			info:           lineart,
 			resolution:     200,
 			colorMode:      "MODE_LINEART",
 			passed:         true,
 			failureMessage: "",
 		},
 		{
-			identifyOutput: identifyOutputGrayscale,
+			info:           grayscale,
 			resolution:     100,
 			colorMode:      "MODE_GRAYSCALE",
 			passed:         true,
 			failureMessage: "",
 		},
 		{
-			identifyOutput: identifyOutputColor,
+			info:           color,
 			resolution:     300,
 			colorMode:      "MODE_COLOR",
 			passed:         true,
 			failureMessage: "",
 		},
 		{
-			identifyOutput: identifyOutputGrayscale,
+			info:           grayscale,
 			resolution:     100,
 			colorMode:      "MODE_LINEART",
 			passed:         false,
-			failureMessage: "Colorspace: got Grayscale Gray 256c, expected Grayscale Gray 2c",
+			failureMessage: "Bit depth: got 8, expected 1",
 		},
 		{
-			identifyOutput: identifyOutputColor,
+			info:           grayscale,
+			resolution:     100,
+			colorMode:      "MODE_COLOR",
+			passed:         false,
+			failureMessage: "Channels: got 1, expected 3",
+		},
+		{
+			info:           color,
 			resolution:     200,
 			colorMode:      "MODE_COLOR",
 			passed:         false,
 			failureMessage: "Width: got 2550, expected 1700",
 		},
+		{
+			info:           jpeg,
+			resolution:     300,
+			colorMode:      "MODE_COLOR",
+			passed:         false,
+			failureMessage: "Format: got jpeg, expected png",
+		},
 	}
 
 	for _, tc := range tests {
-		passed, failureMessage, err := verifyScannedImage(tc.identifyOutput, utils.LetterSize, tc.resolution, tc.colorMode)
+		passed, failureMessage, err := verifyScannedImage(tc.info, utils.LetterSize, tc.resolution, tc.colorMode)
 
 		if err != nil {
 			t.Errorf("Unexpected error: %v", err)
@@ -169,10 +197,69 @@ func TestVerifyScannedImage(t *testing.T) {
 }
 
 // TestVerifyScannedImageUnrecognizedInput tests that verifyScannedImage returns
-// an error when it encounters an unrecognized input.
+// an error when it encounters an unrecognized color mode.
 func TestVerifyScannedImageUnrecognizedInput(t *testing.T) {
-	_, _, err := verifyScannedImage(unrecognizedInput, utils.LetterSize, 300, "MODE_COLOR")
+	_, _, err := verifyScannedImage(utils.ImageInfo{}, utils.LetterSize, 300, unrecognizedInput)
 	if err == nil {
 		t.Error("Expected error from unrecognized input.")
 	}
 }
+
+// TestVerifyScannedPages tests that verifyScannedPages checks the format of
+// every page it can read, and the size of the pages of scan regions.
+func TestVerifyScannedPages(t *testing.T) {
+	settings := utils.ScanSettings{ColorMode: "Grayscale8", Resolution: 75}
+	regions := []utils.ScanRegion{{Width: 2550, Height: 1650}, {YOffset: 1650, Width: 2550, Height: 1650}}
+
+	tests := []struct {
+		pages    []utils.ScannedPage
+		regions  []utils.ScanRegion
+		failures int
+	}{
+		{
+			// Without regions, the size isn't checked.
+			pages: []utils.ScannedPage{{ContentType: "image/png", Data: grayPNG(t, 8, 8)}},
+		},
+		{
+			// 2550x1650 at 75 dpi is 637.5x412.5 pixels, rounded either way.
+			pages:   []utils.ScannedPage{{ContentType: "image/png", Data: grayPNG(t, 637, 412)}, {ContentType: "image/png", Data: grayPNG(t, 638, 413)}},
+			regions: regions,
+		},
+		{
+			pages:    []utils.ScannedPage{{ContentType: "image/png", Data: grayPNG(t, 637, 412)}, {ContentType: "image/png", Data: grayPNG(t, 637, 825)}},
+			regions:  regions,
+			failures: 1,
+		},
+		{
+			// A page per region isn't checked against the regions otherwise.
+			pages:   []utils.ScannedPage{{ContentType: "image/png", Data: grayPNG(t, 637, 825)}},
+			regions: regions,
+		},
+		{
+			pages:    []utils.ScannedPage{{ContentType: "image/png", Data: []byte("1")}, {ContentType: "application/pdf", Data: []byte("%PDF-1.4")}},
+			failures: 1,
+		},
+	}
+
+	for i, tc := range tests {
+		settings.Regions = tc.regions
+		failures, err := verifyScannedPages(tc.pages, settings)
+		if err != nil {
+			t.Errorf("Test %d: unexpected error: %v", i, err)
+		}
+		if len(failures) != tc.failures {
+			t.Errorf("Test %d: expected %d failures, got %v", i, tc.failures, failures)
+		}
+	}
+
+	// 8-bit pages aren't expected in 1-bit color modes, unless they are JPEG.
+	settings.ColorMode = "BlackAndWhite1"
+	settings.Regions = nil
+	if failures, err := verifyScannedPages([]utils.ScannedPage{{ContentType: "image/png", Data: grayPNG(t, 8, 8)}}, settings); err != nil || len(failures) != 1 {
+		t.Errorf("BlackAndWhite1 PNG: expected a failure, got %v and error %v", failures, err)
+	}
+	settings.ColorMode = "Unknown"
+	if _, err := verifyScannedPages([]utils.ScannedPage{{ContentType: "image/png", Data: grayPNG(t, 8, 8)}}, settings); err == nil {
+		t.Error("Unknown color mode: expected error")
+	}
+}
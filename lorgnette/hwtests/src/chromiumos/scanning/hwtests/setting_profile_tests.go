@@ -0,0 +1,89 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"fmt"
+
+	"chromiumos/scanning/utils"
+)
+
+// hasResolutions returns true iff `resolutions` lists discrete resolutions or
+// X and Y resolution ranges.
+func hasResolutions(resolutions utils.SupportedResolutions) bool {
+	return len(resolutions.DiscreteResolutions) != 0 || (resolutions.XResolutionRange.Max > 0 && resolutions.YResolutionRange.Max > 0)
+}
+
+// SettingProfileReferencesTest checks the SettingProfiles of `rawCaps`, the
+// ScannerCapabilities document exactly as reported by the scanner. Each
+// populated source must resolve to a SettingProfile, either its own or a shared
+// one it references, containing color modes and resolutions; dangling
+// references and incomplete profiles are critical failures. Shared profiles
+// which no source references are "needs audit" failures. `rawCaps` should be
+// the output from a call to utils.GetRawScannerCapabilities().
+func SettingProfileReferencesTest(rawCaps []byte) utils.TestFunction {
+	return func() (result utils.TestResult, failures []utils.TestFailure, err error) {
+		caps, err := utils.ParseScannerCapabilities(rawCaps)
+		if err != nil {
+			result = utils.Error
+			return
+		}
+
+		shared := make(map[string]utils.SettingProfile)
+		for _, profile := range caps.SettingProfiles {
+			if profile.Name == "" {
+				failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: "Shared SettingProfile has no name, so it can't be referenced."})
+				continue
+			}
+			shared[profile.Name] = profile
+		}
+
+		sources := []struct {
+			name string
+			caps utils.SourceCapabilities
+		}{
+			{"Platen", caps.PlatenInputCaps},
+			{"ADF simplex", caps.AdfCapabilities.AdfSimplexInputCaps},
+			{"ADF duplex", caps.AdfCapabilities.AdfDuplexInputCaps},
+			{"Camera", caps.CameraInputCaps},
+		}
+		referenced := make(map[string]bool)
+		for _, source := range sources {
+			if !source.caps.IsPopulated() {
+				continue
+			}
+
+			profile := source.caps.SettingProfile
+			if profile.Ref != "" {
+				referenced[profile.Ref] = true
+				var ok bool
+				if profile, ok = shared[profile.Ref]; !ok {
+					failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s: SettingProfile references %q, which doesn't exist.", source.name, source.caps.SettingProfile.Ref)})
+					continue
+				}
+			}
+
+			if len(profile.ColorModes) == 0 {
+				failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s: SettingProfile has no color modes.", source.name)})
+			}
+			if !hasResolutions(profile.SupportedResolutions) {
+				failures = append(failures, utils.TestFailure{Type: utils.CriticalFailure, Message: fmt.Sprintf("%s: SettingProfile has no resolutions.", source.name)})
+			}
+		}
+
+		for _, profile := range caps.SettingProfiles {
+			if profile.Name != "" && !referenced[profile.Name] {
+				failures = append(failures, utils.TestFailure{Type: utils.NeedsAudit, Message: fmt.Sprintf("Shared SettingProfile %q isn't referenced by any source.", profile.Name)})
+			}
+		}
+
+		if len(failures) == 0 {
+			result = utils.Passed
+		} else {
+			result = utils.Failed
+		}
+		return
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hwtests
+
+import (
+	"fmt"
+	"testing"
+
+	"chromiumos/scanning/utils"
+)
+
+// settingProfileCapsXML is a ScannerCapabilities document with the shared
+// SettingProfiles and platen and ADF SettingProfiles given as arguments.
+const settingProfileCapsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<scan:SettingProfiles>%s</scan:SettingProfiles>
+	<scan:Platen>
+		<scan:PlatenInputCaps>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:SettingProfiles>%s</scan:SettingProfiles>
+		</scan:PlatenInputCaps>
+	</scan:Platen>
+	<scan:Adf>
+		<scan:AdfSimplexInputCaps>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:SettingProfiles>%s</scan:SettingProfiles>
+		</scan:AdfSimplexInputCaps>
+	</scan:Adf>
+</scan:ScannerCapabilities>`
+
+// completeProfile returns a SettingProfile element named `name` with color
+// modes and resolutions.
+func completeProfile(name string) string {
+	return fmt.Sprintf(`<scan:SettingProfile name="%s">
+	<scan:ColorModes><scan:ColorMode>RGB24</scan:ColorMode></scan:ColorModes>
+	<scan:SupportedResolutions>
+		<scan:DiscreteResolutions>
+			<scan:DiscreteResolution>
+				<scan:XResolution>300</scan:XResolution>
+				<scan:YResolution>300</scan:YResolution>
+			</scan:DiscreteResolution>
+		</scan:DiscreteResolutions>
+	</scan:SupportedResolutions>
+</scan:SettingProfile>`, name)
+}
+
+// TestSettingProfileReferencesTest tests that SettingProfileReferencesTest
+// functions correctly.
+func TestSettingProfileReferencesTest(t *testing.T) {
+	tests := []struct {
+		shared   string
+		platen   string
+		adf      string
+		result   utils.TestResult
+		failures []utils.FailureType
+	}{
+		{
+			// Should pass: both sources reference a complete profile.
+			shared:   completeProfile("p1"),
+			platen:   `<scan:SettingProfile ref="p1"/>`,
+			adf:      `<scan:SettingProfile ref="p1"/>`,
+			result:   utils.Passed,
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should pass: inline profiles don't need shared ones.
+			platen:   completeProfile(""),
+			adf:      completeProfile(""),
+			result:   utils.Passed,
+			failures: []utils.FailureType{},
+		},
+		{
+			// Should fail: the ADF references a missing profile.
+			shared:   completeProfile("p1"),
+			platen:   `<scan:SettingProfile ref="p1"/>`,
+			adf:      `<scan:SettingProfile ref="p2"/>`,
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.CriticalFailure},
+		},
+		{
+			// Should fail: the referenced profile has no color modes
+			// or resolutions.
+			shared:   `<scan:SettingProfile name="p1"/>`,
+			platen:   `<scan:SettingProfile ref="p1"/>`,
+			adf:      completeProfile(""),
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.CriticalFailure, utils.CriticalFailure},
+		},
+		{
+			// Should fail: p2 is never referenced.
+			shared:   completeProfile("p1") + completeProfile("p2"),
+			platen:   `<scan:SettingProfile ref="p1"/>`,
+			adf:      `<scan:SettingProfile ref="p1"/>`,
+			result:   utils.Failed,
+			failures: []utils.FailureType{utils.NeedsAudit},
+		},
+	}
+
+	for i, tc := range tests {
+		rawCaps := fmt.Sprintf(settingProfileCapsXML, tc.shared, tc.platen, tc.adf)
+		result, failures, err := SettingProfileReferencesTest([]byte(rawCaps))()
+
+		if err != nil {
+			t.Errorf("Test %d: unexpected error: %v", i, err)
+		}
+		if result != tc.result {
+			t.Errorf("Test %d: Result: expected %d, got %d", i, tc.result, result)
+		}
+
+		if len(failures) != len(tc.failures) {
+			t.Errorf("Test %d: Number of failures: expected %d, got %d", i, len(tc.failures), len(failures))
+			continue
+		}
+		for j, failure := range failures {
+			if failure.Type != tc.failures[j] {
+				t.Errorf("Test %d: FailureType: expected %d, got %d", i, tc.failures[j], failure.Type)
+			}
+		}
+	}
+}
+
+// TestSettingProfileReferencesTestMalformed tests that malformed capabilities
+// are reported as an error.
+func TestSettingProfileReferencesTestMalformed(t *testing.T) {
+	if result, _, err := SettingProfileReferencesTest([]byte(`<scan:ScannerCapabilities`))(); result != utils.Error || err == nil {
+		t.Errorf("Expected error result, got %d with error %v", result, err)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"time"
+
+	"chromiumos/scanning/utils"
+)
+
+// pickScanSource returns the name and capabilities of the first scan source
+// advertised by `caps`, preferring the platen.
+func pickScanSource(caps utils.LorgnetteCapabilities) (string, utils.LorgnetteSource, error) {
+	if caps.PlatenCaps.IsPopulated() {
+		return "Platen", caps.PlatenCaps, nil
+	}
+	if caps.AdfSimplexCaps.IsPopulated() {
+		return "ADF Simplex", caps.AdfSimplexCaps, nil
+	}
+	return "", utils.LorgnetteSource{}, fmt.Errorf("No platen or ADF simplex source advertised")
+}
+
+// runIteration fetches the scanner's capabilities through lorgnette, then
+// performs a small, low-resolution scan. The scanned image is written to
+// `outputPattern`. Each request is aborted once `ctx` expires.
+func runIteration(ctx context.Context, identifier string, outputPattern string) (iteration utils.StressIteration) {
+	iteration.Time = time.Now()
+	defer func() {
+		// Sample lorgnette's memory after the work so that leaks show up.
+		rss, err := utils.ProcessRSSKiB("/proc", "lorgnette")
+		if err != nil {
+			log.Print("WARNING: Unable to read lorgnette RSS: ", err)
+		}
+		iteration.LorgnetteRSSKiB = rss
+	}()
+
+	listOutput, err := utils.LorgnetteCLIList(ctx)
+	if err != nil {
+		log.Print("ERROR: lorgnette_cli list failed: ", err)
+		return
+	}
+
+	scannerInfo, err := utils.GetLorgnetteScannerInfo(listOutput, identifier)
+	if err != nil {
+		log.Print("ERROR: Scanner not found: ", err)
+		return
+	}
+	iteration.ScannerFound = true
+	scannerName := scannerInfo.ToLorgnetteScannerName()
+
+	rawLorgnetteCaps, err := utils.LorgnetteCLIGetJSONCaps(ctx, scannerName)
+	if err != nil {
+		iteration.CapsError = err.Error()
+		log.Print("ERROR: Fetching capabilities failed: ", err)
+		return
+	}
+
+	lorgnetteCaps, err := utils.ParseLorgnetteCapabilities(rawLorgnetteCaps)
+	if err != nil {
+		iteration.CapsError = err.Error()
+		log.Print("ERROR: Parsing capabilities failed: ", err)
+		return
+	}
+
+	sourceName, source, err := pickScanSource(lorgnetteCaps)
+	if err != nil || len(source.Resolutions) == 0 || len(source.ColorModes) == 0 {
+		iteration.CapsError = fmt.Sprintf("No usable scan source in capabilities: %v", err)
+		log.Print("ERROR: ", iteration.CapsError)
+		return
+	}
+
+	lowestResolution := source.Resolutions[0]
+	for _, resolution := range source.Resolutions {
+		if resolution < lowestResolution {
+			lowestResolution = resolution
+		}
+	}
+
+	if _, err := utils.LorgnetteCLIScan(ctx, scannerName, sourceName, utils.BusinessCardSize, lowestResolution, "Grayscale", outputPattern); err != nil {
+		iteration.ScanError = err.Error()
+		log.Print("ERROR: Scan failed: ", err)
+	}
+
+	return
+}
+
+// Repeatedly fetches a scanner's capabilities and performs a small scan for a
+// given duration, recording failures, scanner reconnections and lorgnette's
+// memory usage over time. A time series is written next to the log file and a
+// summary is printed at the end.
+func main() {
+	identifierFlag := flag.String("identifier", "", "Substring of the identifier printed by lorgnette_cli of the scanner to test.")
+	durationFlag := flag.Duration("duration", time.Hour, "How long to run the stress test for.")
+	intervalFlag := flag.Duration("interval", 30*time.Second, "Minimum time between the start of two iterations.")
+	windowsFlag := flag.Int("trend_windows", 10, "Number of windows the failure rate trend is split into.")
+	deadlineFlags := utils.AddDeadlineFlags(flag.CommandLine)
+	politenessFlags := utils.AddPolitenessFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctx, cancel := deadlineFlags.SuiteContext()
+	defer cancel()
+	ctx = utils.WithPoliteness(ctx, *politenessFlags)
+
+	logFile, err := utils.CreateLogFile("stress_test")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.SetOutput(logFile)
+	fmt.Printf("Created log file at: %s\n", logFile.Name())
+
+	outputDir := path.Dir(logFile.Name())
+	outputPattern := path.Join(outputDir, "scan_page%n.png")
+
+	var report utils.StressReport
+	deadline := time.Now().Add(*durationFlag)
+	for i := 1; time.Now().Before(deadline) && ctx.Err() == nil; i++ {
+		log.Printf("===== ITERATION %d =====", i)
+		iteration := runIteration(ctx, *identifierFlag, outputPattern)
+		if ctx.Err() != nil {
+			// Iterations interrupted by the deadline aren't scanner failures.
+			log.Print("INFO: Deadline exceeded, stopping early.")
+			break
+		}
+		report.Add(iteration)
+		if iteration.Failed() {
+			fmt.Printf("Iteration %d failed.\n", i)
+		}
+
+		if wait := time.Until(iteration.Time.Add(*intervalFlag)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	seriesFile, err := os.Create(path.Join(outputDir, "time_series.csv"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer seriesFile.Close()
+
+	if err := report.WriteTimeSeries(seriesFile); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote time series to: %s\n", seriesFile.Name())
+
+	summary := report.Summary(*windowsFlag)
+	log.Print(summary)
+	fmt.Print(summary)
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"chromiumos/scanning/hwtests"
+	"chromiumos/scanning/utils"
+)
+
+// Scans a known stack of printed and blank pages from the ADF to verify that
+// the scanner's blank page detection and removal behave as advertised in its
+// capabilities. The operator is asked to load the stack before each scan.
+func main() {
+	identifierFlag := flag.String("identifier", "", "Substring of the identifier printed by lorgnette_cli of the scanner to test.")
+	stackFlag := flag.String("stack", "pbpb", "Pages loaded in the ADF, in feeding order: 'p' for a printed page and 'b' for a blank one.")
+	deadlineFlags := utils.AddDeadlineFlags(flag.CommandLine)
+	politenessFlags := utils.AddPolitenessFlags(flag.CommandLine)
+	flag.Parse()
+
+	stack, err := hwtests.ParsePageStack(*stackFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := deadlineFlags.SuiteContext()
+	defer cancel()
+	ctx = utils.WithPoliteness(ctx, *politenessFlags)
+
+	logFile, err := utils.CreateLogFile("test_blank_pages")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.SetOutput(logFile)
+	fmt.Printf("Created log file at: %s\n", logFile.Name())
+
+	listOutput, err := utils.LorgnetteCLIList(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scannerInfo, err := utils.GetLorgnetteScannerInfo(listOutput, *identifierFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Some scanners redirect their eSCL resources or serve them on other
+	// ports. Failing that, the reachability checks report what's wrong.
+	if scannerInfo, err = utils.ResolveScannerEndpoint(ctx, scannerInfo); err != nil {
+		log.Print("WARNING: ", err)
+	}
+
+	log.Print("INFO: Testing scanner: ", scannerInfo.ToLorgnetteScannerName())
+
+	// Tells network problems apart from capability errors.
+	if _, err := utils.CheckScannerReachability(ctx, scannerInfo, 3, 2*time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	caps, err := utils.GetScannerCapabilities(ctx, scannerInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	loadStack := func() error {
+		fmt.Printf("Load the page stack %q into the ADF, then press Enter.\n", *stackFlag)
+		_, err := stdin.ReadString('\n')
+		return err
+	}
+
+	// Run in a fixed order, so that the operator knows which scan is next.
+	names := []string{"BlankPageDetection", "BlankPageDetectionAndRemoval"}
+	tests := map[string]utils.TestFunction{
+		"BlankPageDetection":           hwtests.BlankPageTest(ctx, scannerInfo, caps, stack, false, loadStack),
+		"BlankPageDetectionAndRemoval": hwtests.BlankPageTest(ctx, scannerInfo, caps, stack, true, loadStack)}
+	failed := []string{}
+	skipped := []string{}
+	errors := []string{}
+	notRun := []string{}
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			log.Printf("NOT RUN %s: deadline exceeded", name)
+			notRun = append(notRun, name)
+			continue
+		}
+
+		testResult := utils.RunTest(name, tests[name])
+		if testResult == utils.Failed {
+			failed = append(failed, name)
+		} else if testResult == utils.Skipped {
+			skipped = append(skipped, name)
+		} else if testResult == utils.Error {
+			errors = append(errors, name)
+		}
+	}
+
+	fmt.Printf("Ran %d tests.\n", len(tests)-len(notRun))
+	if len(failed) != 0 {
+		fmt.Printf("%d tests failed:\n", len(failed))
+		for _, failedTest := range failed {
+			fmt.Println(failedTest)
+		}
+	}
+	if len(skipped) != 0 {
+		fmt.Printf("%d tests skipped:\n", len(skipped))
+		for _, skippedTest := range skipped {
+			fmt.Println(skippedTest)
+		}
+	}
+	if len(errors) != 0 {
+		fmt.Printf("%d tests had errors:\n", len(errors))
+		for _, errorTest := range errors {
+			fmt.Println(errorTest)
+		}
+	}
+	if len(notRun) != 0 {
+		fmt.Printf("%d tests were not run before the deadline:\n", len(notRun))
+		for _, notRunTest := range notRun {
+			fmt.Println(notRunTest)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path"
+
+	"chromiumos/scanning/hwtests"
+	"chromiumos/scanning/utils"
+)
+
+// Sends overlapping capability and scan requests to lorgnette from several
+// clients, to make sure that it serializes or rejects concurrent jobs
+// gracefully.
+func main() {
+	identifierFlag := flag.String("identifier", "", "Substring of the identifier printed by lorgnette_cli of the scanner to test.")
+	clientsFlag := flag.Int("clients", 4, "Number of concurrent lorgnette_cli clients.")
+	logPathFlag := flag.String("syslog", "/var/log/messages", "Path of the system log containing lorgnette's messages.")
+	deadlineFlags := utils.AddDeadlineFlags(flag.CommandLine)
+	politenessFlags := utils.AddPolitenessFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctx, cancel := deadlineFlags.SuiteContext()
+	defer cancel()
+	ctx = utils.WithPoliteness(ctx, *politenessFlags)
+
+	logFile, err := utils.CreateLogFile("test_concurrent_clients")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.SetOutput(logFile)
+	fmt.Printf("Created log file at: %s\n", logFile.Name())
+
+	listOutput, err := utils.LorgnetteCLIList(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scannerInfo, err := utils.GetLorgnetteScannerInfo(listOutput, *identifierFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Print("INFO: Testing scanner: ", scannerInfo.ToLorgnetteScannerName())
+
+	rawLorgnetteCaps, err := utils.LorgnetteCLIGetJSONCaps(ctx, scannerInfo.ToLorgnetteScannerName())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lorgnetteCaps, err := utils.ParseLorgnetteCapabilities(rawLorgnetteCaps)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	source, sourceName := lorgnetteCaps.PlatenCaps, "Platen"
+	if !source.IsPopulated() {
+		source, sourceName = lorgnetteCaps.AdfSimplexCaps, "ADF Simplex"
+	}
+
+	outputDir := path.Dir(logFile.Name())
+	name := "ConcurrentClients"
+	switch utils.RunTest(name, hwtests.ConcurrentClientsTest(ctx, scannerInfo.ToLorgnetteScannerName(), source, sourceName, *clientsFlag, outputDir, *logPathFlag)) {
+	case utils.Passed:
+		fmt.Printf("%s passed.\n", name)
+	case utils.Failed:
+		fmt.Printf("%s failed.\n", name)
+	case utils.Skipped:
+		fmt.Printf("%s skipped.\n", name)
+	case utils.Error:
+		fmt.Printf("%s had errors.\n", name)
+	}
+}
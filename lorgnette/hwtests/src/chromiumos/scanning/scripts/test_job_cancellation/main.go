@@ -0,0 +1,92 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"chromiumos/scanning/hwtests"
+	"chromiumos/scanning/utils"
+)
+
+// Cancels an ADF scan job after its first page to verify that the scanner
+// stops feeding, reports the job as cancelled and accepts a subsequent job.
+// The operator is asked to load a few pages before each job.
+func main() {
+	identifierFlag := flag.String("identifier", "", "Substring of the identifier printed by lorgnette_cli of the scanner to test.")
+	settleTimeoutFlag := flag.Duration("settle_timeout", 30*time.Second, "Time allowed for the scanner to stop processing a cancelled job.")
+	deadlineFlags := utils.AddDeadlineFlags(flag.CommandLine)
+	politenessFlags := utils.AddPolitenessFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctx, cancel := deadlineFlags.SuiteContext()
+	defer cancel()
+	ctx = utils.WithPoliteness(ctx, *politenessFlags)
+
+	logFile, err := utils.CreateLogFile("test_job_cancellation")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.SetOutput(logFile)
+	fmt.Printf("Created log file at: %s\n", logFile.Name())
+
+	listOutput, err := utils.LorgnetteCLIList(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scannerInfo, err := utils.GetLorgnetteScannerInfo(listOutput, *identifierFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Some scanners redirect their eSCL resources or serve them on other
+	// ports. Failing that, the reachability checks report what's wrong.
+	if scannerInfo, err = utils.ResolveScannerEndpoint(ctx, scannerInfo); err != nil {
+		log.Print("WARNING: ", err)
+	}
+
+	log.Print("INFO: Testing scanner: ", scannerInfo.ToLorgnetteScannerName())
+
+	// Tells network problems apart from capability errors.
+	if _, err := utils.CheckScannerReachability(ctx, scannerInfo, 3, 2*time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	caps, err := utils.GetScannerCapabilities(ctx, scannerInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	loadPages := func() error {
+		fmt.Println("Load at least three pages into the ADF, then press Enter.")
+		_, err := stdin.ReadString('\n')
+		return err
+	}
+
+	name := "JobCancellation"
+	if ctx.Err() != nil {
+		fmt.Printf("%s was not run before the deadline.\n", name)
+		return
+	}
+
+	switch utils.RunTest(name, hwtests.JobCancellationTest(ctx, scannerInfo, caps, *settleTimeoutFlag, loadPages)) {
+	case utils.Passed:
+		fmt.Printf("%s passed.\n", name)
+	case utils.Failed:
+		fmt.Printf("%s failed.\n", name)
+	case utils.Skipped:
+		fmt.Printf("%s skipped: the scanner has no ADF.\n", name)
+	case utils.Error:
+		fmt.Printf("%s had errors.\n", name)
+	}
+}
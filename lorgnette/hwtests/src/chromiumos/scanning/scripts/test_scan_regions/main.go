@@ -0,0 +1,119 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"chromiumos/scanning/hwtests"
+	"chromiumos/scanning/utils"
+)
+
+// Scans the platen with a region covering its whole advertised area, and with
+// as many regions as advertised by MaxScanRegions, to verify that the scanner
+// returns a page per region or rejects the regions as allowed by eSCL.
+func main() {
+	identifierFlag := flag.String("identifier", "", "Substring of the identifier printed by lorgnette_cli of the scanner to test.")
+	deadlineFlags := utils.AddDeadlineFlags(flag.CommandLine)
+	politenessFlags := utils.AddPolitenessFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctx, cancel := deadlineFlags.SuiteContext()
+	defer cancel()
+	ctx = utils.WithPoliteness(ctx, *politenessFlags)
+
+	logFile, err := utils.CreateLogFile("test_scan_regions")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.SetOutput(logFile)
+	fmt.Printf("Created log file at: %s\n", logFile.Name())
+
+	listOutput, err := utils.LorgnetteCLIList(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scannerInfo, err := utils.GetLorgnetteScannerInfo(listOutput, *identifierFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Some scanners redirect their eSCL resources or serve them on other
+	// ports. Failing that, the reachability checks report what's wrong.
+	if scannerInfo, err = utils.ResolveScannerEndpoint(ctx, scannerInfo); err != nil {
+		log.Print("WARNING: ", err)
+	}
+
+	log.Print("INFO: Testing scanner: ", scannerInfo.ToLorgnetteScannerName())
+
+	// Tells network problems apart from capability errors.
+	if _, err := utils.CheckScannerReachability(ctx, scannerInfo, 3, 2*time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	caps, err := utils.GetScannerCapabilities(ctx, scannerInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Run in a fixed order, so that a failing single region is reported
+	// before any multi-region job.
+	names := []string{"MaxSizeScanRegion", "MultipleScanRegions"}
+	tests := map[string]utils.TestFunction{
+		"MaxSizeScanRegion":   hwtests.MaxSizeScanRegionTest(ctx, scannerInfo, caps),
+		"MultipleScanRegions": hwtests.MultipleScanRegionsTest(ctx, scannerInfo, caps)}
+	failed := []string{}
+	skipped := []string{}
+	errors := []string{}
+	notRun := []string{}
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			log.Printf("NOT RUN %s: deadline exceeded", name)
+			notRun = append(notRun, name)
+			continue
+		}
+
+		testResult := utils.RunTest(name, tests[name])
+		if testResult == utils.Failed {
+			failed = append(failed, name)
+		} else if testResult == utils.Skipped {
+			skipped = append(skipped, name)
+		} else if testResult == utils.Error {
+			errors = append(errors, name)
+		}
+	}
+
+	fmt.Printf("Ran %d tests.\n", len(tests)-len(notRun))
+	if len(failed) != 0 {
+		fmt.Printf("%d tests failed:\n", len(failed))
+		for _, failedTest := range failed {
+			fmt.Println(failedTest)
+		}
+	}
+	if len(skipped) != 0 {
+		fmt.Printf("%d tests skipped:\n", len(skipped))
+		for _, skippedTest := range skipped {
+			fmt.Println(skippedTest)
+		}
+	}
+	if len(errors) != 0 {
+		fmt.Printf("%d tests had errors:\n", len(errors))
+		for _, errorTest := range errors {
+			fmt.Println(errorTest)
+		}
+	}
+	if len(notRun) != 0 {
+		fmt.Printf("%d tests were not run before the deadline:\n", len(notRun))
+		for _, notRunTest := range notRun {
+			fmt.Println(notRunTest)
+		}
+	}
+}
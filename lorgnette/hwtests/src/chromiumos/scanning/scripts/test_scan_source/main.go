@@ -19,8 +19,14 @@ import (
 // letter-sized.
 func main() {
 	identifierFlag := flag.String("identifier", "", "Substring of the identifier printed by lorgnette_cli of the scanner to test.")
+	deadlineFlags := utils.AddDeadlineFlags(flag.CommandLine)
+	politenessFlags := utils.AddPolitenessFlags(flag.CommandLine)
 	flag.Parse()
 
+	ctx, cancel := deadlineFlags.SuiteContext()
+	defer cancel()
+	ctx = utils.WithPoliteness(ctx, *politenessFlags)
+
 	logFile, err := utils.CreateLogFile("test_scan_source")
 	if err != nil {
 		log.Fatal(err)
@@ -29,7 +35,7 @@ func main() {
 	log.SetOutput(logFile)
 	fmt.Printf("Created log file at: %s\n", logFile.Name())
 
-	listOutput, err := utils.LorgnetteCLIList()
+	listOutput, err := utils.LorgnetteCLIList(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -41,7 +47,7 @@ func main() {
 
 	log.Print("INFO: Testing scanner: ", scannerInfo.ToLorgnetteScannerName())
 
-	rawLorgnetteCaps, err := utils.LorgnetteCLIGetJSONCaps(scannerInfo.ToLorgnetteScannerName())
+	rawLorgnetteCaps, err := utils.LorgnetteCLIGetJSONCaps(ctx, scannerInfo.ToLorgnetteScannerName())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -53,14 +59,21 @@ func main() {
 
 	outputDir := path.Dir(logFile.Name())
 	tests := map[string]utils.TestFunction{
-		"PlatenScanSource":     hwtests.AllScanCombinationsTest(lorgnetteCaps.PlatenCaps, "Platen", scannerInfo.ToLorgnetteScannerName(), outputDir),
-		"AdfSimplexScanSource": hwtests.AllScanCombinationsTest(lorgnetteCaps.AdfSimplexCaps, "ADF Simplex", scannerInfo.ToLorgnetteScannerName(), outputDir),
-		"AdfDuplexScanSource":  hwtests.AllScanCombinationsTest(lorgnetteCaps.AdfDuplexCaps, "ADF Duplex", scannerInfo.ToLorgnetteScannerName(), outputDir)}
+		"PlatenScanSource":     hwtests.AllScanCombinationsTest(ctx, lorgnetteCaps.PlatenCaps, "Platen", scannerInfo.ToLorgnetteScannerName(), outputDir),
+		"AdfSimplexScanSource": hwtests.AllScanCombinationsTest(ctx, lorgnetteCaps.AdfSimplexCaps, "ADF Simplex", scannerInfo.ToLorgnetteScannerName(), outputDir),
+		"AdfDuplexScanSource":  hwtests.AllScanCombinationsTest(ctx, lorgnetteCaps.AdfDuplexCaps, "ADF Duplex", scannerInfo.ToLorgnetteScannerName(), outputDir)}
 	failed := []string{}
 	skipped := []string{}
 	errors := []string{}
+	notRun := []string{}
 
 	for name, test := range tests {
+		if ctx.Err() != nil {
+			log.Printf("NOT RUN %s: deadline exceeded", name)
+			notRun = append(notRun, name)
+			continue
+		}
+
 		testResult := utils.RunTest(name, test)
 		if testResult == utils.Failed {
 			failed = append(failed, name)
@@ -71,7 +84,7 @@ func main() {
 		}
 	}
 
-	fmt.Printf("Ran %d tests.\n", len(tests))
+	fmt.Printf("Ran %d tests.\n", len(tests)-len(notRun))
 	if len(failed) != 0 {
 		fmt.Printf("%d tests failed:\n", len(failed))
 		for _, failedTest := range failed {
@@ -90,4 +103,10 @@ func main() {
 			fmt.Println(errorTest)
 		}
 	}
+	if len(notRun) != 0 {
+		fmt.Printf("%d tests were not run before the deadline:\n", len(notRun))
+		for _, notRunTest := range notRun {
+			fmt.Println(notRunTest)
+		}
+	}
 }
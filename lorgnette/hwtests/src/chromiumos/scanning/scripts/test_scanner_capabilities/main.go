@@ -5,9 +5,12 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"time"
 
 	"chromiumos/scanning/hwtests"
 	"chromiumos/scanning/utils"
@@ -17,8 +20,22 @@ import (
 // the WWCB specification.
 func main() {
 	identifierFlag := flag.String("identifier", "", "Substring of the identifier printed by lorgnette_cli of the scanner to test.")
+	eSCLSchemaFlag := flag.String("escl_schema", "/usr/local/opt/wwcb_mfp/usr/share/wwcb_mfp/eSCLApproximation.xsd", "Path of the ChromeOS approximation of the eSCL XSD used to validate the scanner's capabilities.")
+	allowAllResolutionsFlag := flag.Bool("allow_all_resolutions", false, "Whether the scanner is flagged as allowed to expose its supported resolutions below 100 dpi and above 600 dpi.")
+	progressFlag := flag.String("progress_file", "", "Path of the file saving the progress of the tests, which enables the tests spanning a power cycle of the scanner.")
+	resumeFlag := flag.Bool("resume", false, "Continue from the progress saved in -progress_file, e.g. after power-cycling the scanner.")
+	deadlineFlags := utils.AddDeadlineFlags(flag.CommandLine)
+	politenessFlags := utils.AddPolitenessFlags(flag.CommandLine)
 	flag.Parse()
 
+	if *resumeFlag && *progressFlag == "" {
+		log.Fatal("-resume requires -progress_file")
+	}
+
+	ctx, cancel := deadlineFlags.SuiteContext()
+	defer cancel()
+	ctx = utils.WithPoliteness(ctx, *politenessFlags)
+
 	logFile, err := utils.CreateLogFile("test_scanner_capabilities")
 	if err != nil {
 		log.Fatal(err)
@@ -27,7 +44,7 @@ func main() {
 	log.SetOutput(logFile)
 	fmt.Printf("Created log file at: %s\n", logFile.Name())
 
-	listOutput, err := utils.LorgnetteCLIList()
+	listOutput, err := utils.LorgnetteCLIList(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -37,19 +54,64 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Some scanners redirect their eSCL resources or serve them on other
+	// ports. Failing that, the reachability checks report what's wrong.
+	if scannerInfo, err = utils.ResolveScannerEndpoint(ctx, scannerInfo); err != nil {
+		log.Print("WARNING: ", err)
+	}
+
 	log.Print("INFO: Testing scanner: ", scannerInfo.ToLorgnetteScannerName())
 
-	caps, err := utils.GetScannerCapabilities(scannerInfo)
+	progress, err := utils.NewProgress(*progressFlag, scannerInfo.ToLorgnetteScannerName(), *resumeFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Tells network problems apart from capability errors.
+	if _, err := utils.CheckScannerReachability(ctx, scannerInfo, 3, 2*time.Second); err != nil {
+		log.Fatal(err)
+	}
+
+	caps, err := utils.GetScannerCapabilities(ctx, scannerInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rawCaps, err := utils.GetRawScannerCapabilities(ctx, scannerInfo)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	device, err := utils.ProbeDevice(ctx, scannerInfo)
 	if err != nil {
 		log.Fatal(err)
 	}
+	log.Printf("INFO: Make and model: %s", device.MakeAndModel)
+	log.Printf("INFO: Manufacturer: %s", device.Manufacturer)
+	log.Printf("INFO: Serial number: %s", device.SerialNumber)
+	log.Printf("INFO: UUID: %s", device.UUID)
+	log.Printf("INFO: Admin URI: %s", device.AdminURI)
 
-	rawLorgnetteCaps, err := utils.LorgnetteCLIGetJSONCaps(scannerInfo.ToLorgnetteScannerName())
+	rawLorgnetteCaps, err := utils.LorgnetteCLIGetJSONCaps(ctx, scannerInfo.ToLorgnetteScannerName())
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Fetches the URIs advertised by the scanner, which normally have
+	// self-signed certificates.
+	uriClient := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				MinVersion:         tls.VersionTLS12,
+				InsecureSkipVerify: true,
+			},
+		},
+	}
+
 	tests := map[string]utils.TestFunction{
+		"CapabilityStrings":            hwtests.CapabilityStringsTest(ctx, rawCaps, uriClient),
+		"DeviceIdentityConsistency":    hwtests.DeviceIdentityConsistencyTest(device),
 		"HasSupportedDocumentSource":   hwtests.HasSupportedDocumentSourceTest(caps.PlatenInputCaps, caps.AdfCapabilities.AdfSimplexInputCaps, caps.AdfCapabilities.AdfDuplexInputCaps),
 		"NoCameraSource":               hwtests.NoCameraSourceTest(caps.CameraInputCaps),
 		"NoStoredJobSupport":           hwtests.NoStoredJobSupportTest(caps.StoredJobRequestSupport),
@@ -58,23 +120,46 @@ func main() {
 		"LowestResolutionIsSupported":  hwtests.LowestResolutionIsSupportedTest(caps.PlatenInputCaps, caps.AdfCapabilities.AdfSimplexInputCaps, caps.AdfCapabilities.AdfDuplexInputCaps),
 		"HasSupportedColorMode":        hwtests.HasSupportedColorModeTest(caps.PlatenInputCaps, caps.AdfCapabilities.AdfSimplexInputCaps, caps.AdfCapabilities.AdfDuplexInputCaps),
 		"NoUnsupportedColorMode":       hwtests.NoUnsupportedColorModeTest(caps.PlatenInputCaps, caps.AdfCapabilities.AdfSimplexInputCaps, caps.AdfCapabilities.AdfDuplexInputCaps),
-		"MatchesLorgnetteCapabilities": hwtests.MatchesLorgnetteCapabilitiesTest(caps, rawLorgnetteCaps)}
+		"LorgnetteCapabilitiesSchema":  hwtests.LorgnetteCapabilitiesSchemaTest(rawLorgnetteCaps),
+		"MatchesLorgnetteCapabilities": hwtests.MatchesLorgnetteCapabilitiesTest(caps, rawLorgnetteCaps),
+		"ResolutionConsistency":        hwtests.ResolutionConsistencyTest(rawCaps),
+		"ResolutionFilteringPolicy":    hwtests.ResolutionFilteringPolicyTest(caps, rawLorgnetteCaps, *allowAllResolutionsFlag),
+		"ApproximateSchemaConformance": hwtests.ApproximateSchemaConformanceTest(ctx, rawCaps, *eSCLSchemaFlag),
+		"SettingProfileReferences":     hwtests.SettingProfileReferencesTest(rawCaps)}
+	// Only runs which can be resumed can wait for the scanner to be
+	// power-cycled.
+	if *progressFlag != "" {
+		tests["CapabilitiesPersistAcrossPowerCycle"] = hwtests.CapabilitiesPersistAcrossPowerCycleTest(progress, caps)
+	}
 	failed := []string{}
 	skipped := []string{}
 	errors := []string{}
+	interrupted := []string{}
+	notRun := []string{}
 
 	for name, test := range tests {
-		testResult := utils.RunTest(name, test)
+		if ctx.Err() != nil {
+			log.Printf("NOT RUN %s: deadline exceeded", name)
+			notRun = append(notRun, name)
+			continue
+		}
+
+		testResult, err := progress.RunTest(name, test)
+		if err != nil {
+			log.Fatal(err)
+		}
 		if testResult == utils.Failed {
 			failed = append(failed, name)
 		} else if testResult == utils.Skipped {
 			skipped = append(skipped, name)
 		} else if testResult == utils.Error {
 			errors = append(errors, name)
+		} else if testResult == utils.Interrupted {
+			interrupted = append(interrupted, name)
 		}
 	}
 
-	fmt.Printf("Ran %d tests.\n", len(tests))
+	fmt.Printf("Ran %d tests.\n", len(tests)-len(notRun))
 	if len(failed) != 0 {
 		fmt.Printf("%d tests failed:\n", len(failed))
 		for _, failedTest := range failed {
@@ -93,4 +178,16 @@ func main() {
 			fmt.Println(errorTest)
 		}
 	}
+	if len(interrupted) != 0 {
+		fmt.Printf("%d tests were interrupted, see the log for what to do before running again with -resume:\n", len(interrupted))
+		for _, interruptedTest := range interrupted {
+			fmt.Println(interruptedTest)
+		}
+	}
+	if len(notRun) != 0 {
+		fmt.Printf("%d tests were not run before the deadline:\n", len(notRun))
+		for _, notRunTest := range notRun {
+			fmt.Println(notRunTest)
+		}
+	}
 }
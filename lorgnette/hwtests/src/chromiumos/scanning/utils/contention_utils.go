@@ -0,0 +1,79 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for exercising lorgnette with several concurrent clients.
+
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// IsBusyError returns true iff `err`, as returned by one of the LorgnetteCLI
+// functions, reports that the scanner or lorgnette is busy with another
+// request. Such errors are the expected way to reject concurrent jobs.
+func IsBusyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	message := err.Error()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		message += " " + string(exitErr.Stderr)
+	}
+	return strings.Contains(strings.ToLower(message), "busy")
+}
+
+// LogCapture collects the lines appended to a log file after its creation.
+type LogCapture struct {
+	path   string
+	offset int64
+}
+
+// StartLogCapture starts capturing the lines appended to the log file at
+// `path`, e.g. "/var/log/messages".
+func StartLogCapture(path string) (LogCapture, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return LogCapture{}, err
+	}
+	return LogCapture{path: path, offset: info.Size()}, nil
+}
+
+// Lines returns the lines appended to the log file since `capture` was started
+// which contain `match`. If the log was rotated in the meantime, lines are
+// returned from the start of the new file.
+func (capture LogCapture) Lines(match string) (lines []string, err error) {
+	f, err := os.Open(capture.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := capture.offset
+	if info.Size() < offset {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.Contains(scanner.Text(), match) {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines, scanner.Err()
+}
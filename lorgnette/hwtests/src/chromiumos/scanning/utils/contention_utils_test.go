@@ -0,0 +1,70 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for contention_utils.go.
+
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestIsBusyError tests that IsBusyError recognizes busy errors, including
+// ones only reported on lorgnette_cli's stderr.
+func TestIsBusyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		busy bool
+	}{
+		{err: nil, busy: false},
+		{err: fmt.Errorf("Device busy"), busy: true},
+		{err: fmt.Errorf("Invalid argument"), busy: false},
+		{err: &exec.ExitError{Stderr: []byte("Scan failed: SANE_STATUS_DEVICE_BUSY")}, busy: true},
+		{err: &exec.ExitError{Stderr: []byte("Scan failed: SANE_STATUS_IO_ERROR")}, busy: false},
+	}
+
+	for _, tc := range tests {
+		if got := IsBusyError(tc.err); got != tc.busy {
+			t.Errorf("IsBusyError(%v): expected %t, got %t", tc.err, tc.busy, got)
+		}
+	}
+}
+
+// TestLogCapture tests that LogCapture only returns matching lines appended
+// after the capture started.
+func TestLogCapture(t *testing.T) {
+	f, err := ioutil.TempFile("", "messages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := f.WriteString("old lorgnette line\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	capture, err := StartLogCapture(f.Name())
+	if err != nil {
+		t.Fatalf("StartLogCapture failed: %v", err)
+	}
+
+	if _, err := f.WriteString("new lorgnette line\nnew other line\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := capture.Lines("lorgnette")
+	if err != nil {
+		t.Fatalf("Lines failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"new lorgnette line"}, got); diff != "" {
+		t.Errorf("Captured lines (-want +got):\n%s", diff)
+	}
+}
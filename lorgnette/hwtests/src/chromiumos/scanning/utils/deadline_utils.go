@@ -0,0 +1,61 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for bounding how long requests and whole test suites can take, so
+// that unattended runs always terminate.
+
+package utils
+
+import (
+	"context"
+	"flag"
+	"time"
+)
+
+// requestTimeoutKey is the context key under which the per-request timeout is
+// stored.
+type requestTimeoutKey struct{}
+
+// DeadlineFlags holds the values of the flags registered by
+// AddDeadlineFlags.
+type DeadlineFlags struct {
+	Deadline       time.Duration // Time allowed for the whole suite.
+	RequestTimeout time.Duration // Time allowed for each request.
+}
+
+// AddDeadlineFlags registers the -deadline and -request_timeout flags on
+// `flags`. Values of 0 mean no limit.
+func AddDeadlineFlags(flags *flag.FlagSet) *DeadlineFlags {
+	var deadlines DeadlineFlags
+	flags.DurationVar(&deadlines.Deadline, "deadline", 0, "Maximum time for the whole run. Tests which haven't started by then are reported as not run. 0 means no limit.")
+	flags.DurationVar(&deadlines.RequestTimeout, "request_timeout", 2*time.Minute, "Maximum time for each request to the scanner or lorgnette. 0 means no limit.")
+	return &deadlines
+}
+
+// SuiteContext returns a context which expires once the suite deadline in
+// `deadlines` elapses, and which applies the per-request timeout in
+// `deadlines` to each request made with it.
+func (deadlines *DeadlineFlags) SuiteContext() (context.Context, context.CancelFunc) {
+	ctx := WithRequestTimeout(context.Background(), deadlines.RequestTimeout)
+	if deadlines.Deadline <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, deadlines.Deadline)
+}
+
+// WithRequestTimeout returns a copy of `ctx` under which each request made by
+// this package is cancelled after `timeout`. A `timeout` of 0 means no limit.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+// requestContext returns the context for a single request made under `ctx`,
+// bounded by the timeout set with WithRequestTimeout, if any.
+func requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout, _ := ctx.Value(requestTimeoutKey{}).(time.Duration)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for deadline_utils.go.
+
+package utils
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAddDeadlineFlags tests that the deadline flags are parsed correctly.
+func TestAddDeadlineFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	deadlines := AddDeadlineFlags(flags)
+	if err := flags.Parse([]string{"-deadline=1h", "-request_timeout=5s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if deadlines.Deadline != time.Hour {
+		t.Errorf("Deadline: expected %v, got %v", time.Hour, deadlines.Deadline)
+	}
+	if deadlines.RequestTimeout != 5*time.Second {
+		t.Errorf("RequestTimeout: expected %v, got %v", 5*time.Second, deadlines.RequestTimeout)
+	}
+}
+
+// TestSuiteContext tests that SuiteContext only sets a deadline when one was
+// requested.
+func TestSuiteContext(t *testing.T) {
+	ctx, cancel := (&DeadlineFlags{}).SuiteContext()
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Unexpected deadline for a suite without limit")
+	}
+
+	ctx, cancel = (&DeadlineFlags{Deadline: time.Hour}).SuiteContext()
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("Missing deadline for a suite with a limit")
+	}
+}
+
+// TestRequestContext tests that requestContext applies the timeout set with
+// WithRequestTimeout.
+func TestRequestContext(t *testing.T) {
+	ctx, cancel := requestContext(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("Unexpected deadline without a request timeout")
+	}
+
+	before := time.Now()
+	ctx, cancel = requestContext(WithRequestTimeout(context.Background(), time.Minute))
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("Missing deadline with a request timeout")
+	}
+	if deadline.Before(before.Add(time.Minute)) {
+		t.Errorf("Deadline: expected after %v, got %v", before.Add(time.Minute), deadline)
+	}
+}
+
+// TestGetScannerCapabilitiesRequestTimeout tests that a scanner which doesn't
+// respond in time causes an error instead of a hang.
+func TestGetScannerCapabilitiesRequestTimeout(t *testing.T) {
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer ts.Close()
+	defer close(unblock)
+
+	ctx := WithRequestTimeout(context.Background(), 10*time.Millisecond)
+	_, err := GetScannerCapabilities(ctx, LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
+	if err == nil {
+		t.Error("Expected timeout error")
+	}
+}
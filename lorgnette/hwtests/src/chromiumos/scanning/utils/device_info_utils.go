@@ -0,0 +1,387 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for identifying a scanner from every source which describes it:
+// its eSCL capabilities, the TXT record of its mDNS service and its USB
+// descriptors.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Name of the avahi-browse executable, used to read the TXT records of
+// network scanners.
+const avahiBrowse = "avahi-browse"
+
+// Directory holding the sysfs entries of USB devices.
+const usbDevicesDir = "/sys/bus/usb/devices"
+
+// DeviceInfoSource is a source of identification data of a scanner.
+type DeviceInfoSource int
+
+// Enumeration of the DeviceInfoSources, in decreasing order of precedence when
+// they disagree.
+const (
+	// ESCLSource is the scanner's ScannerCapabilities document.
+	ESCLSource DeviceInfoSource = iota
+	// MDNSSource is the TXT record of the scanner's _uscan._tcp or
+	// _uscans._tcp mDNS service.
+	MDNSSource
+	// USBSource is the descriptor of IPP over USB scanners.
+	USBSource
+)
+
+// String returns the name of `source` used in reports.
+func (source DeviceInfoSource) String() string {
+	switch source {
+	case ESCLSource:
+		return "eSCL"
+	case MDNSSource:
+		return "mDNS TXT"
+	case USBSource:
+		return "USB"
+	}
+	return fmt.Sprintf("DeviceInfoSource(%d)", int(source))
+}
+
+// DeviceReport is the identification data of a scanner read from a single
+// source. Fields which the source doesn't provide are empty.
+type DeviceReport struct {
+	Source       DeviceInfoSource
+	MakeAndModel string
+	Manufacturer string
+	SerialNumber string
+	UUID         string
+	AdminURI     string
+}
+
+// DeviceField is a field of DeviceInfo, with the source its value comes from.
+type DeviceField struct {
+	Value string
+	// Source of `Value`. Meaningless if `Value` is empty.
+	Source DeviceInfoSource
+	// Every non-empty value reported for the field, by source.
+	Reported map[DeviceInfoSource]string
+}
+
+// DeviceInfo merges the identification data of a scanner from all of its
+// sources.
+type DeviceInfo struct {
+	MakeAndModel DeviceField
+	Manufacturer DeviceField
+	SerialNumber DeviceField
+	UUID         DeviceField
+	AdminURI     DeviceField
+	// Sources which could be read, in the order they were merged.
+	Sources []DeviceInfoSource
+}
+
+// add records `value` reported by `source`. The first non-empty value becomes
+// the value of `field`.
+func (field *DeviceField) add(source DeviceInfoSource, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	if field.Reported == nil {
+		field.Reported = map[DeviceInfoSource]string{}
+		field.Value = value
+		field.Source = source
+	}
+	field.Reported[source] = value
+}
+
+// Consistent returns whether all the sources reporting `field` agree on its
+// value, after applying `normalize` to each value.
+func (field DeviceField) Consistent(normalize func(string) string) bool {
+	for _, value := range field.Reported {
+		if normalize(value) != normalize(field.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the value of `field` followed by its source, and the values
+// of the sources disagreeing with it.
+func (field DeviceField) String() string {
+	if field.Value == "" {
+		return "<unknown>"
+	}
+	description := fmt.Sprintf("%s (%s)", field.Value, field.Source)
+	for _, source := range []DeviceInfoSource{ESCLSource, MDNSSource, USBSource} {
+		if value, ok := field.Reported[source]; ok && value != field.Value {
+			description += fmt.Sprintf(", %s (%s)", value, source)
+		}
+	}
+	return description
+}
+
+// NormalizeUUID returns `uuid` in the form used by eSCL, so that UUIDs can be
+// compared across sources: lowercase, without the "urn:uuid:" prefix.
+func NormalizeUUID(uuid string) string {
+	uuid = strings.ToLower(strings.TrimSpace(uuid))
+	return strings.TrimPrefix(uuid, "urn:uuid:")
+}
+
+// MergeDeviceReports merges `reports` into a DeviceInfo. When sources disagree,
+// the value of the first report providing a field is used.
+func MergeDeviceReports(reports []DeviceReport) (info DeviceInfo) {
+	for _, report := range reports {
+		info.MakeAndModel.add(report.Source, report.MakeAndModel)
+		info.Manufacturer.add(report.Source, report.Manufacturer)
+		info.SerialNumber.add(report.Source, report.SerialNumber)
+		info.UUID.add(report.Source, report.UUID)
+		info.AdminURI.add(report.Source, report.AdminURI)
+		info.Sources = append(info.Sources, report.Source)
+	}
+	return
+}
+
+// ProbeDevice reads the identification data of the scanner represented by
+// `info` from every source available for it, and merges them. Sources which
+// can't be read are logged and skipped; an error is returned only if none
+// could be read.
+func ProbeDevice(ctx context.Context, info LorgnetteScannerInfo) (device DeviceInfo, err error) {
+	var reports []DeviceReport
+
+	caps, capsErr := GetScannerCapabilities(ctx, info)
+	if capsErr == nil {
+		reports = append(reports, DeviceReport{
+			Source:       ESCLSource,
+			MakeAndModel: caps.MakeAndModel,
+			Manufacturer: caps.Manufacturer,
+			SerialNumber: caps.SerialNumber,
+			UUID:         caps.UUID,
+			AdminURI:     caps.AdminURI})
+	} else {
+		log.Printf("WARNING: Failed to read %s identification: %v", ESCLSource, capsErr)
+	}
+
+	if info.Protocol == "ippusb" {
+		report, usbErr := readUSBDeviceReport(usbDevicesDir, info.Address)
+		if usbErr == nil {
+			reports = append(reports, report)
+		} else {
+			log.Printf("WARNING: Failed to read %s identification: %v", USBSource, usbErr)
+		}
+	} else {
+		report, mdnsErr := browseMDNSDeviceReport(ctx, info.Address)
+		if mdnsErr == nil {
+			reports = append(reports, report)
+		} else {
+			log.Printf("WARNING: Failed to read %s identification: %v", MDNSSource, mdnsErr)
+		}
+	}
+
+	if len(reports) == 0 {
+		err = fmt.Errorf("No identification source could be read for scanner: %s", info.Address)
+		return
+	}
+	device = MergeDeviceReports(reports)
+	return
+}
+
+// MDNSService is a resolved mDNS service, as listed by avahi-browse.
+type MDNSService struct {
+	Name     string
+	Type     string
+	HostName string
+	Address  string
+	Port     string
+	TXT      map[string]string
+}
+
+// ParseAvahiBrowseOutput parses the resolved services in `output`, the output
+// of `avahi-browse --resolve --parsable`.
+func ParseAvahiBrowseOutput(output string) (services []MDNSService) {
+	for _, line := range strings.Split(output, "\n") {
+		// =;interface;protocol;name;type;domain;host name;address;port;txt
+		fields := strings.SplitN(line, ";", 10)
+		if len(fields) != 10 || fields[0] != "=" {
+			continue
+		}
+		services = append(services, MDNSService{
+			Name:     unescapeAvahiString(fields[3]),
+			Type:     fields[4],
+			HostName: fields[6],
+			Address:  fields[7],
+			Port:     fields[8],
+			TXT:      parseAvahiTXT(fields[9])})
+	}
+	return
+}
+
+// parseAvahiTXT parses `txt`, the quoted key=value strings of a TXT record
+// as printed by avahi-browse.
+func parseAvahiTXT(txt string) map[string]string {
+	record := map[string]string{}
+	for _, entry := range strings.Split(txt, `" "`) {
+		entry = strings.Trim(entry, `"`)
+		keyValue := strings.SplitN(entry, "=", 2)
+		if keyValue[0] == "" {
+			continue
+		}
+		if len(keyValue) == 2 {
+			record[keyValue[0]] = keyValue[1]
+		} else {
+			record[keyValue[0]] = ""
+		}
+	}
+	return record
+}
+
+// unescapeAvahiString replaces the \DDD decimal escapes used by avahi-browse
+// for special characters in service names.
+func unescapeAvahiString(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if code, err := strconv.Atoi(s[i+1 : i+4]); err == nil && code < 256 {
+				b.WriteByte(byte(code))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// DeviceReport returns the identification data in the TXT record of
+// `service`, following the keys defined by Mopria for eSCL services.
+func (service MDNSService) DeviceReport() DeviceReport {
+	return DeviceReport{
+		Source:       MDNSSource,
+		MakeAndModel: service.TXT["ty"],
+		UUID:         service.TXT["UUID"],
+		AdminURI:     service.TXT["adminurl"]}
+}
+
+// browseMDNSDeviceReport finds the mDNS service of the network scanner at
+// `address`, the URL reported by lorgnette, and returns the identification
+// data of its TXT record.
+func browseMDNSDeviceReport(ctx context.Context, address string) (report DeviceReport, err error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return
+	}
+	serviceType := "_uscan._tcp"
+	if u.Scheme == "https" {
+		serviceType = "_uscans._tcp"
+	}
+
+	services, err := browseMDNSServices(ctx, serviceType)
+	if err != nil {
+		return
+	}
+
+	service, err := FindMDNSService(services, u)
+	if err != nil {
+		return
+	}
+	report = service.DeviceReport()
+	return
+}
+
+// BrowseESCLServices returns the resolved eSCL services advertised through
+// mDNS, over https and http.
+func BrowseESCLServices(ctx context.Context) (services []MDNSService, err error) {
+	for _, serviceType := range []string{"_uscans._tcp", "_uscan._tcp"} {
+		var found []MDNSService
+		found, err = browseMDNSServices(ctx, serviceType)
+		if err != nil {
+			return
+		}
+		services = append(services, found...)
+	}
+	return
+}
+
+// browseMDNSServices returns the resolved mDNS services of `serviceType`,
+// e.g. _uscan._tcp.
+func browseMDNSServices(ctx context.Context, serviceType string) ([]MDNSService, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, avahiBrowse, "--resolve", "--parsable", "--terminate", "--no-db-lookup", serviceType)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return ParseAvahiBrowseOutput(string(output)), nil
+}
+
+// FindMDNSService returns the service among `services` which serves the
+// scanner at `scannerURL`, matched by address or host name, and port.
+func FindMDNSService(services []MDNSService, scannerURL *url.URL) (service MDNSService, err error) {
+	host := scannerURL.Hostname()
+	port := addressPort(scannerURL)
+
+	for _, service = range services {
+		if service.Port == port && service.servesHost(host) {
+			return
+		}
+	}
+	err = fmt.Errorf("No mDNS service found for scanner: %s", scannerURL)
+	return
+}
+
+// servesHost returns whether `service` is served by `host`, an address or a
+// host name.
+func (service MDNSService) servesHost(host string) bool {
+	return service.Address == host || strings.TrimSuffix(service.HostName, ".") == strings.TrimSuffix(host, ".")
+}
+
+// readUSBDeviceReport returns the identification data in the descriptor of
+// the USB device under `devicesDir` whose vendor and product IDs match
+// `address`, the IPP over USB address reported by lorgnette, e.g. 04a9_1823.
+func readUSBDeviceReport(devicesDir string, address string) (report DeviceReport, err error) {
+	ids := strings.Split(address, "_")
+	if len(ids) != 2 {
+		err = fmt.Errorf("Malformed IPP over USB address: %s", address)
+		return
+	}
+	vendorID, productID := ids[0], ids[1]
+
+	entries, err := ioutil.ReadDir(devicesDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		dir := filepath.Join(devicesDir, entry.Name())
+		if readSysfsAttribute(dir, "idVendor") != vendorID || readSysfsAttribute(dir, "idProduct") != productID {
+			continue
+		}
+
+		report = DeviceReport{
+			Source:       USBSource,
+			MakeAndModel: readSysfsAttribute(dir, "product"),
+			Manufacturer: readSysfsAttribute(dir, "manufacturer"),
+			SerialNumber: readSysfsAttribute(dir, "serial")}
+		return
+	}
+
+	err = fmt.Errorf("No USB device found for address: %s", address)
+	return
+}
+
+// readSysfsAttribute returns the value of the sysfs attribute `name` of the
+// device at `dir`, or the empty string if it doesn't exist.
+func readSysfsAttribute(dir string, name string) string {
+	value, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(value))
+}
@@ -0,0 +1,184 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for device_info_utils.go.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Sample output from `avahi-browse --resolve --parsable --terminate
+// _uscan._tcp`, with a service being removed and one being resolved.
+const avahiBrowseOutput = `+;eth0;IPv4;Canon\032MF741C\047743C\032\0408\041;_uscan._tcp;local
+-;eth0;IPv4;Old\032Scanner;_uscan._tcp;local
+=;eth0;IPv4;Canon\032MF741C\047743C\032\0408\041;_uscan._tcp;local;canon-mf741c.local;192.168.1.20;80;"txtvers=1" "ty=Canon MF741C/743C" "UUID=4509a320-00a0-008f-00b6-002507510eca" "adminurl=http://canon-mf741c.local/" "duplex"`
+
+// TestMergeDeviceReports tests that the first source reporting a field
+// provides its value, and that every reported value is kept.
+func TestMergeDeviceReports(t *testing.T) {
+	got := MergeDeviceReports([]DeviceReport{
+		{Source: ESCLSource, MakeAndModel: "MF741C/743C", UUID: "4509a320-00a0-008f-00b6-002507510eca"},
+		{Source: MDNSSource, MakeAndModel: "Canon MF741C/743C", UUID: "4509A320-00A0-008F-00B6-002507510ECA", AdminURI: "http://canon-mf741c.local/"},
+	})
+
+	want := DeviceInfo{
+		MakeAndModel: DeviceField{
+			Value:    "MF741C/743C",
+			Source:   ESCLSource,
+			Reported: map[DeviceInfoSource]string{ESCLSource: "MF741C/743C", MDNSSource: "Canon MF741C/743C"}},
+		UUID: DeviceField{
+			Value:    "4509a320-00a0-008f-00b6-002507510eca",
+			Source:   ESCLSource,
+			Reported: map[DeviceInfoSource]string{ESCLSource: "4509a320-00a0-008f-00b6-002507510eca", MDNSSource: "4509A320-00A0-008F-00B6-002507510ECA"}},
+		AdminURI: DeviceField{
+			Value:    "http://canon-mf741c.local/",
+			Source:   MDNSSource,
+			Reported: map[DeviceInfoSource]string{MDNSSource: "http://canon-mf741c.local/"}},
+		Sources: []DeviceInfoSource{ESCLSource, MDNSSource},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected DeviceInfo (-want +got):\n%s", diff)
+	}
+	if !got.UUID.Consistent(NormalizeUUID) {
+		t.Errorf("UUID: expected consistent, got %s", got.UUID)
+	}
+	if got.MakeAndModel.Consistent(NormalizeUUID) {
+		t.Errorf("MakeAndModel: expected inconsistent, got %s", got.MakeAndModel)
+	}
+}
+
+// TestParseAvahiBrowseOutput tests that only resolved services are parsed,
+// with their names unescaped.
+func TestParseAvahiBrowseOutput(t *testing.T) {
+	got := ParseAvahiBrowseOutput(avahiBrowseOutput)
+
+	want := []MDNSService{
+		{
+			Name:     "Canon MF741C/743C (8)",
+			Type:     "_uscan._tcp",
+			HostName: "canon-mf741c.local",
+			Address:  "192.168.1.20",
+			Port:     "80",
+			TXT: map[string]string{
+				"txtvers":  "1",
+				"ty":       "Canon MF741C/743C",
+				"UUID":     "4509a320-00a0-008f-00b6-002507510eca",
+				"adminurl": "http://canon-mf741c.local/",
+				"duplex":   ""},
+		},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Unexpected services (-want +got):\n%s", diff)
+	}
+}
+
+// TestFindMDNSService tests that the service of a scanner is found by address
+// or host name, and port.
+func TestFindMDNSService(t *testing.T) {
+	services := ParseAvahiBrowseOutput(avahiBrowseOutput)
+
+	tests := []struct {
+		address string
+		found   bool
+	}{
+		{address: "http://192.168.1.20/eSCL", found: true},
+		{address: "http://192.168.1.20:80", found: true},
+		{address: "http://canon-mf741c.local", found: true},
+		{address: "http://192.168.1.20:8080", found: false},
+		{address: "https://192.168.1.20", found: false},
+		{address: "http://192.168.1.21", found: false},
+	}
+
+	for _, tc := range tests {
+		u, err := url.Parse(tc.address)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		service, err := FindMDNSService(services, u)
+		if tc.found && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.address, err)
+		} else if !tc.found && err == nil {
+			t.Errorf("%s: expected no service, got %+v", tc.address, service)
+		}
+	}
+}
+
+// TestReadUSBDeviceReport tests that the descriptor of the USB device matching
+// an IPP over USB address is read from sysfs.
+func TestReadUSBDeviceReport(t *testing.T) {
+	devicesDir, err := ioutil.TempDir("", "usb_devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(devicesDir)
+
+	devices := map[string]map[string]string{
+		"1-1": {"idVendor": "046d", "idProduct": "c52b", "product": "USB Receiver"},
+		"1-2": {"idVendor": "04a9", "idProduct": "1823", "manufacturer": "Canon", "product": "TR8500 series", "serial": "SN123\n"},
+	}
+	for name, attributes := range devices {
+		dir := filepath.Join(devicesDir, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for attribute, value := range attributes {
+			if err := ioutil.WriteFile(filepath.Join(dir, attribute), []byte(value), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	got, err := readUSBDeviceReport(devicesDir, "04a9_1823")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DeviceReport{Source: USBSource, MakeAndModel: "TR8500 series", Manufacturer: "Canon", SerialNumber: "SN123"}
+	if got != want {
+		t.Errorf("DeviceReport: expected %+v, got %+v", want, got)
+	}
+
+	if _, err := readUSBDeviceReport(devicesDir, "04a9_0001"); err == nil {
+		t.Error("Expected an error for a missing device")
+	}
+}
+
+// TestProbeDevice tests that ProbeDevice reads the eSCL identification of a
+// network scanner, and fails when no source can be read.
+func TestProbeDevice(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, XMLTestData)
+	}))
+
+	got, err := ProbeDevice(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
+	ts.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.UUID.Value != "TestUuid" || got.UUID.Source != ESCLSource {
+		t.Errorf("UUID: expected TestUuid (eSCL), got %s", got.UUID)
+	}
+	if got.SerialNumber.Value != "TestSerialNumber" || got.SerialNumber.Source != ESCLSource {
+		t.Errorf("SerialNumber: expected TestSerialNumber (eSCL), got %s", got.SerialNumber)
+	}
+
+	if _, err := ProbeDevice(context.Background(), LorgnetteScannerInfo{Protocol: "ippusb", Address: "ffff_ffff", SocketDir: "/nonexistent"}); err == nil {
+		t.Error("Expected an error for an unreachable scanner")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for reaching the eSCL resources of network scanners which redirect
+// them, serve them on another port than the one reported by lorgnette, or only
+// over one of https and http.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Path of the eSCL resources, as used by the requests of this package. The
+// rs= key of the TXT record of a scanner's mDNS service may advertise another
+// one.
+const eSCLRoot = "/eSCL"
+
+// Maximum number of redirects followed by a single request.
+const maxRedirects = 5
+
+// Time after which ResolveScannerEndpoint gives up on a candidate endpoint,
+// e.g. on a port dropping connections.
+const endpointProbeTimeout = 10 * time.Second
+
+// ScannerEndpoint is where a network scanner serves its eSCL resources.
+type ScannerEndpoint struct {
+	BaseURL string // Scheme, host and port, e.g. "https://192.168.0.2:8443".
+	Root    string // Path of the eSCL resources, e.g. "/eSCL".
+}
+
+// String returns the URL of the root of `endpoint`.
+func (endpoint ScannerEndpoint) String() string {
+	return endpoint.BaseURL + endpoint.Root
+}
+
+// path maps `path`, under /eSCL as in the requests of this package, to the
+// root of `endpoint`. Other paths, e.g. the job paths returned by the scanner,
+// are left alone. A nil endpoint serves /eSCL.
+func (endpoint *ScannerEndpoint) path(path string) string {
+	if endpoint == nil || endpoint.Root == eSCLRoot {
+		return path
+	}
+	if path == eSCLRoot || strings.HasPrefix(path, eSCLRoot+"/") {
+		return endpoint.Root + strings.TrimPrefix(path, eSCLRoot)
+	}
+	return path
+}
+
+// checkSameHostRedirect is the redirect policy of the clients of this package:
+// redirects are only followed within the host of the original request, and
+// not when they would turn a request into a GET, dropping its body.
+func checkSameHostRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("Stopped after %d redirects", len(via))
+	}
+	from := via[0]
+	if !strings.EqualFold(req.URL.Hostname(), from.URL.Hostname()) {
+		return fmt.Errorf("Refusing redirect of %s to another host: %s", from.URL, req.URL)
+	}
+	if req.Method != from.Method {
+		return fmt.Errorf("Refusing redirect of %s %s to %s, which would turn it into a %s", from.Method, from.URL, req.URL, req.Method)
+	}
+	return nil
+}
+
+// addressPort returns the port of `u`, or the default port of its scheme.
+func addressPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// EndpointCandidates returns the endpoints where the network scanner
+// represented by `info` may serve its eSCL resources, in the order
+// ResolveScannerEndpoint tries them: https before http, and for each scheme,
+// the ports of the mDNS `services` of the scanner's host, the port of their
+// adminurl, the port reported by lorgnette and the default port. The rs= key
+// of the services, if any, replaces the /eSCL root.
+func EndpointCandidates(info LorgnetteScannerInfo, services []MDNSService) ([]ScannerEndpoint, error) {
+	address, err := url.Parse(info.Address)
+	if err != nil {
+		return nil, err
+	}
+	host := address.Hostname()
+
+	var hostServices []MDNSService
+	root := eSCLRoot
+	for _, service := range services {
+		if !service.servesHost(host) {
+			continue
+		}
+		hostServices = append(hostServices, service)
+		if rs := strings.Trim(service.TXT["rs"], "/"); rs != "" && root == eSCLRoot {
+			root = "/" + rs
+		}
+	}
+
+	var candidates []ScannerEndpoint
+	seen := map[ScannerEndpoint]bool{}
+	add := func(scheme string, port string) {
+		if port == "" {
+			return
+		}
+		endpoint := ScannerEndpoint{BaseURL: scheme + "://" + net.JoinHostPort(host, port), Root: root}
+		if !seen[endpoint] {
+			seen[endpoint] = true
+			candidates = append(candidates, endpoint)
+		}
+	}
+	for _, scheme := range []string{"https", "http"} {
+		serviceType := "_uscan._tcp"
+		if scheme == "https" {
+			serviceType = "_uscans._tcp"
+		}
+		for _, service := range hostServices {
+			if service.Type == serviceType {
+				add(scheme, service.Port)
+			}
+		}
+		for _, service := range hostServices {
+			if adminURL, err := url.Parse(service.TXT["adminurl"]); err == nil && adminURL.Scheme == scheme {
+				add(scheme, adminURL.Port())
+			}
+		}
+		if address.Scheme == scheme {
+			add(scheme, addressPort(address))
+		}
+		add(scheme, addressPort(&url.URL{Scheme: scheme}))
+	}
+	return candidates, nil
+}
+
+// ResolveScannerEndpoint finds where the scanner represented by `info` serves
+// its eSCL resources, among the EndpointCandidates for the eSCL services
+// advertised through mDNS, and returns a copy of `info` sending its requests
+// there. The endpoint is the one reached after following the redirects of the
+// scanner, so that later requests, e.g. creating jobs, aren't redirected. IPP
+// over USB scanners are returned as is. If no candidate serves the scanner's
+// capabilities, `info` is returned as is, along with the failure of each
+// candidate.
+func ResolveScannerEndpoint(ctx context.Context, info LorgnetteScannerInfo) (LorgnetteScannerInfo, error) {
+	if info.Protocol == "ippusb" {
+		return info, nil
+	}
+	services, err := BrowseESCLServices(ctx)
+	if err != nil {
+		log.Printf("INFO: Failed to browse mDNS services, only trying the usual ports: %v", err)
+	}
+	return resolveScannerEndpoint(ctx, info, services)
+}
+
+// resolveScannerEndpoint implements ResolveScannerEndpoint for the mDNS
+// `services`.
+func resolveScannerEndpoint(ctx context.Context, info LorgnetteScannerInfo, services []MDNSService) (LorgnetteScannerInfo, error) {
+	candidates, err := EndpointCandidates(info, services)
+	if err != nil {
+		return info, err
+	}
+
+	var failures []string
+	for _, candidate := range candidates {
+		endpoint, err := probeEndpoint(ctx, info, candidate)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", candidate, err))
+			continue
+		}
+		log.Printf("INFO: Scanner %s serves its eSCL resources at %s", info.Address, endpoint)
+		info.Endpoint = &endpoint
+		return info, nil
+	}
+	return info, fmt.Errorf("No eSCL endpoint found for scanner %s:\n%s", info.Address, strings.Join(failures, "\n"))
+}
+
+// probeEndpoint requests the capabilities of the scanner represented by
+// `info` from `candidate`, and returns the endpoint they were served from
+// once the redirects are followed.
+func probeEndpoint(ctx context.Context, info LorgnetteScannerInfo, candidate ScannerEndpoint) (endpoint ScannerEndpoint, err error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+	ctx, cancelProbe := context.WithTimeout(ctx, endpointProbeTimeout)
+	defer cancelProbe()
+
+	info.Endpoint = &candidate
+	resp, err := info.HTTPGet(ctx, eSCLRoot+"/ScannerCapabilities")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("Unexpected HTTP response status: %s", resp.Status)
+		return
+	}
+	final := resp.Request.URL
+	root := strings.TrimSuffix(final.Path, "/ScannerCapabilities")
+	if root == final.Path {
+		err = fmt.Errorf("Redirected to %s, which isn't a ScannerCapabilities resource", final)
+		return
+	}
+	endpoint = ScannerEndpoint{BaseURL: final.Scheme + "://" + final.Host, Root: root}
+	return
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestEndpointCandidates tests that EndpointCandidates tries https before
+// http, and the advertised ports before the reported and default ones.
+func TestEndpointCandidates(t *testing.T) {
+	services := []MDNSService{
+		{
+			Type:     "_uscans._tcp",
+			HostName: "printer.local.",
+			Address:  "192.168.0.2",
+			Port:     "8443",
+			TXT:      map[string]string{"rs": "scan/", "adminurl": "https://printer.local:9443/admin"},
+		},
+		{
+			Type:     "_uscan._tcp",
+			HostName: "printer.local.",
+			Address:  "192.168.0.2",
+			Port:     "8080",
+			TXT:      map[string]string{"rs": "scan"},
+		},
+		{
+			Type:    "_uscan._tcp",
+			Address: "192.168.0.3",
+			Port:    "8081",
+		},
+	}
+
+	tests := []struct {
+		address    string
+		services   []MDNSService
+		candidates []ScannerEndpoint
+	}{
+		{
+			address:  "http://192.168.0.2:8080",
+			services: services,
+			candidates: []ScannerEndpoint{
+				{BaseURL: "https://192.168.0.2:8443", Root: "/scan"},
+				{BaseURL: "https://192.168.0.2:9443", Root: "/scan"},
+				{BaseURL: "https://192.168.0.2:443", Root: "/scan"},
+				{BaseURL: "http://192.168.0.2:8080", Root: "/scan"},
+				{BaseURL: "http://192.168.0.2:80", Root: "/scan"},
+			},
+		},
+		{
+			address: "https://printer.local",
+			candidates: []ScannerEndpoint{
+				{BaseURL: "https://printer.local:443", Root: "/eSCL"},
+				{BaseURL: "http://printer.local:80", Root: "/eSCL"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		candidates, err := EndpointCandidates(LorgnetteScannerInfo{Protocol: "airscan", Address: tc.address}, tc.services)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.address, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.candidates, candidates); diff != "" {
+			t.Errorf("%s: unexpected candidates (-want +got):\n%s", tc.address, diff)
+		}
+	}
+}
+
+// TestScannerEndpointPath tests that only the paths under /eSCL are moved
+// under the root of an endpoint.
+func TestScannerEndpointPath(t *testing.T) {
+	endpoint := &ScannerEndpoint{BaseURL: "http://printer.local:80", Root: "/scan"}
+	tests := []struct {
+		endpoint *ScannerEndpoint
+		path     string
+		want     string
+	}{
+		{nil, "/eSCL/ScannerCapabilities", "/eSCL/ScannerCapabilities"},
+		{endpoint, "/eSCL/ScannerCapabilities", "/scan/ScannerCapabilities"},
+		{endpoint, "/eSCLScanJobs", "/eSCLScanJobs"},
+		{endpoint, "/scan/ScanJobs/1", "/scan/ScanJobs/1"},
+	}
+
+	for _, tc := range tests {
+		if got := tc.endpoint.path(tc.path); got != tc.want {
+			t.Errorf("%v: path(%s): expected %s, got %s", tc.endpoint, tc.path, tc.want, got)
+		}
+	}
+}
+
+// TestHTTPRedirects tests that requests follow redirects within the host of
+// the scanner only, and not when they would turn into a GET.
+func TestHTTPRedirects(t *testing.T) {
+	var s *httptest.Server
+	s = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same", "/post":
+			http.Redirect(w, r, "/target", http.StatusFound)
+		case "/other":
+			u, _ := url.Parse(s.URL)
+			http.Redirect(w, r, "http://localhost:"+u.Port()+"/target", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/target":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+	info := LorgnetteScannerInfo{Protocol: "airscan", Address: strings.Replace(s.URL, "localhost", "127.0.0.1", 1)}
+
+	resp, err := info.HTTPGet(context.Background(), "/same")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.Request.URL.Path != "/target" {
+		t.Errorf("/same: expected to be redirected to /target, got %s", resp.Request.URL)
+	}
+
+	for path, errText := range map[string]string{
+		"/other": "another host",
+		"/loop":  "Stopped after",
+	} {
+		if _, err := info.HTTPGet(context.Background(), path); err == nil || !strings.Contains(err.Error(), errText) {
+			t.Errorf("%s: expected error containing %q, got %v", path, errText, err)
+		}
+	}
+	if _, err := info.HTTPPost(context.Background(), "/post", "text/xml", []byte("<xml/>")); err == nil || !strings.Contains(err.Error(), "turn it into a GET") {
+		t.Errorf("/post: expected error refusing the redirect, got %v", err)
+	}
+}
+
+// TestResolveScannerEndpoint tests that resolveScannerEndpoint prefers the
+// advertised https endpoint, follows its redirects, and that later requests
+// are sent to where they led.
+func TestResolveScannerEndpoint(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/eSCL/ScannerCapabilities", r.URL.Path == "/eSCL/ScanJobs":
+			http.Redirect(w, r, "/scan/"+strings.TrimPrefix(r.URL.Path, "/eSCL/"), http.StatusMovedPermanently)
+		case r.URL.Path == "/scan/ScannerCapabilities" && r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/scan/ScanJobs" && r.Method == http.MethodPost:
+			w.Header().Set("Location", "/scan/ScanJobs/1")
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	httpsServer := httptest.NewTLSServer(handler)
+	defer httpsServer.Close()
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	httpsURL, err := url.Parse(httpsServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := []MDNSService{{
+		Type:    "_uscans._tcp",
+		Address: httpsURL.Hostname(),
+		Port:    httpsURL.Port(),
+	}}
+
+	info, err := resolveScannerEndpoint(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: httpServer.URL}, services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ScannerEndpoint{BaseURL: httpsServer.URL, Root: "/scan"}
+	if info.Endpoint == nil || *info.Endpoint != want {
+		t.Fatalf("Endpoint: expected %v, got %v", want, info.Endpoint)
+	}
+
+	status, jobPath, err := postESCLScanJob(context.Background(), info, []byte("<xml/>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusCreated || jobPath != "/scan/ScanJobs/1" {
+		t.Errorf("Scan job: expected 201 at /scan/ScanJobs/1, got %d at %s", status, jobPath)
+	}
+}
@@ -0,0 +1,401 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities to run eSCL scan jobs directly against a scanner, for settings
+// which lorgnette_cli doesn't expose.
+
+package utils
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// eSCL namespaces used in ScanSettings documents.
+const (
+	pwgNamespace  = "http://www.pwg.org/schemas/2010/12/sm"
+	scanNamespace = "http://schemas.hp.com/imaging/escl/2011/05/03"
+)
+
+// How long to wait before asking again for a page which isn't ready yet.
+const nextDocumentRetryDelay = time.Second
+
+// ScanSettings represents the settings of an eSCL scan job.
+type ScanSettings struct {
+	// eSCL input source: "Platen" or "Feeder".
+	InputSource string
+	// eSCL color mode, e.g. "Grayscale8".
+	ColorMode string
+	// Resolution in both dimensions, in dpi.
+	Resolution int
+	// MIME type of the scanned pages, e.g. "image/jpeg".
+	DocumentFormat string
+	// Whether both sides of each page are scanned from the feeder.
+	Duplex bool
+	// Whether the scanner reports which pages are blank.
+	BlankPageDetection bool
+	// Whether the scanner drops blank pages from the job.
+	BlankPageDetectionAndRemoval bool
+	// Regions of the source to scan, each returned as its own page. The
+	// whole source is scanned if empty.
+	Regions []ScanRegion
+}
+
+// ScanRegion represents a region of the scanned source, in three-hundredths
+// of an inch, as in ScannerCapabilities.
+type ScanRegion struct {
+	XOffset int
+	YOffset int
+	Width   int
+	Height  int
+}
+
+// scanRegionXML is the XML representation of ScanRegion.
+type scanRegionXML struct {
+	Height             int    `xml:"pwg:Height"`
+	ContentRegionUnits string `xml:"pwg:ContentRegionUnits"`
+	Width              int    `xml:"pwg:Width"`
+	XOffset            int    `xml:"pwg:XOffset"`
+	YOffset            int    `xml:"pwg:YOffset"`
+}
+
+// scanRegionsXML is the XML representation of ScanSettings.Regions.
+type scanRegionsXML struct {
+	MustHonor string          `xml:"pwg:MustHonor,attr"`
+	Regions   []scanRegionXML `xml:"pwg:ScanRegion"`
+}
+
+// scanSettingsXML is the XML representation of ScanSettings.
+type scanSettingsXML struct {
+	XMLName                      xml.Name        `xml:"scan:ScanSettings"`
+	PWGNamespace                 string          `xml:"xmlns:pwg,attr"`
+	ScanNamespace                string          `xml:"xmlns:scan,attr"`
+	Version                      string          `xml:"pwg:Version"`
+	ScanRegions                  *scanRegionsXML `xml:"pwg:ScanRegions,omitempty"`
+	InputSource                  string          `xml:"pwg:InputSource"`
+	DocumentFormatExt            string          `xml:"scan:DocumentFormatExt"`
+	ColorMode                    string          `xml:"scan:ColorMode"`
+	XResolution                  int             `xml:"scan:XResolution"`
+	YResolution                  int             `xml:"scan:YResolution"`
+	Duplex                       bool            `xml:"scan:Duplex,omitempty"`
+	BlankPageDetection           bool            `xml:"scan:BlankPageDetection,omitempty"`
+	BlankPageDetectionAndRemoval bool            `xml:"scan:BlankPageDetectionAndRemoval,omitempty"`
+}
+
+// ToXML returns the ScanSettings XML document posted to create a scan job
+// with `settings`.
+func (settings ScanSettings) ToXML() ([]byte, error) {
+	out, err := xml.MarshalIndent(scanSettingsXML{
+		PWGNamespace:                 pwgNamespace,
+		ScanNamespace:                scanNamespace,
+		Version:                      "2.63",
+		ScanRegions:                  settings.regionsXML(),
+		InputSource:                  settings.InputSource,
+		DocumentFormatExt:            settings.DocumentFormat,
+		ColorMode:                    settings.ColorMode,
+		XResolution:                  settings.Resolution,
+		YResolution:                  settings.Resolution,
+		Duplex:                       settings.Duplex,
+		BlankPageDetection:           settings.BlankPageDetection,
+		BlankPageDetectionAndRemoval: settings.BlankPageDetectionAndRemoval,
+	}, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// regionsXML returns the ScanRegions element of `settings`, or nil if it
+// requests no region. The scanner must honor the regions rather than silently
+// scan the whole source, so that tests see how it handles them.
+func (settings ScanSettings) regionsXML() *scanRegionsXML {
+	if len(settings.Regions) == 0 {
+		return nil
+	}
+	regions := &scanRegionsXML{MustHonor: "true"}
+	for _, region := range settings.Regions {
+		regions.Regions = append(regions.Regions, scanRegionXML{
+			Height:             region.Height,
+			ContentRegionUnits: "escl:ThreeHundredthsOfInches",
+			Width:              region.Width,
+			XOffset:            region.XOffset,
+			YOffset:            region.YOffset,
+		})
+	}
+	return regions
+}
+
+// ScannedPage represents one page returned by a scan job.
+type ScannedPage struct {
+	ContentType string
+	Data        []byte
+	// Whether the scanner reported the page as blank in its ScanImageInfo.
+	BlankPageDetected bool
+}
+
+// scanImageInfo represents the parts of an eSCL ScanImageInfo document used
+// by this package.
+type scanImageInfo struct {
+	BlankPageDetected bool `xml:"BlankPageDetected"`
+}
+
+// RunESCLScanJob creates a scan job with `settings` on the scanner represented
+// by `info`, and returns all of its pages. Each request is bounded by `ctx` and
+// the request timeout it carries, if any. The job is cancelled if it fails
+// before all pages were returned.
+func RunESCLScanJob(ctx context.Context, info LorgnetteScannerInfo, settings ScanSettings) (pages []ScannedPage, err error) {
+	jobPath, err := StartESCLScanJob(ctx, info, settings)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			cancelESCLScanJob(ctx, info, jobPath)
+		}
+	}()
+
+	for {
+		var page ScannedPage
+		var done bool
+		page, done, err = NextESCLDocument(ctx, info, jobPath)
+		if err != nil || done {
+			return
+		}
+		page.BlankPageDetected, err = getESCLBlankPageDetected(ctx, info, jobPath)
+		if err != nil {
+			return
+		}
+		pages = append(pages, page)
+	}
+}
+
+// StartESCLScanJob creates a scan job with `settings` on the scanner represented
+// by `info`, and returns the path of the job. The caller is responsible for
+// reading all of its pages or cancelling it.
+func StartESCLScanJob(ctx context.Context, info LorgnetteScannerInfo, settings ScanSettings) (string, error) {
+	body, err := settings.ToXML()
+	if err != nil {
+		return "", err
+	}
+	return createESCLScanJob(ctx, info, body)
+}
+
+// PostESCLScanJob sends a single request creating a scan job with `settings`
+// on the scanner represented by `info`, and returns the HTTP status of the
+// response. `jobPath` is only valid if the status is 201; other statuses
+// aren't errors, so that tests can check how the scanner rejects settings.
+func PostESCLScanJob(ctx context.Context, info LorgnetteScannerInfo, settings ScanSettings) (status int, jobPath string, err error) {
+	body, err := settings.ToXML()
+	if err != nil {
+		return
+	}
+	return postESCLScanJob(ctx, info, body)
+}
+
+// createESCLScanJob posts the ScanSettings document `body` and returns the
+// path of the created job.
+func createESCLScanJob(ctx context.Context, info LorgnetteScannerInfo, body []byte) (string, error) {
+	status, jobPath, err := postESCLScanJob(ctx, info, body)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusCreated {
+		return "", fmt.Errorf("Unexpected HTTP response status creating scan job: %d %s", status, http.StatusText(status))
+	}
+	return jobPath, nil
+}
+
+// postESCLScanJob posts the ScanSettings document `body`, and returns the
+// HTTP status of the response along with the path of the job it created, if
+// any.
+func postESCLScanJob(ctx context.Context, info LorgnetteScannerInfo, body []byte) (status int, jobPath string, err error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	resp, err := info.HTTPPost(ctx, "/eSCL/ScanJobs", "text/xml", body)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	status = resp.StatusCode
+	if status != http.StatusCreated {
+		return
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || location.Path == "" {
+		err = fmt.Errorf("Invalid scan job location %q: %v", resp.Header.Get("Location"), err)
+		return
+	}
+	jobPath = location.Path
+	return
+}
+
+// NextESCLDocument returns the next page of the job at `jobPath`, waiting for
+// it while the scanner isn't ready. `done` is true once all pages were
+// returned.
+func NextESCLDocument(ctx context.Context, info LorgnetteScannerInfo, jobPath string) (page ScannedPage, done bool, err error) {
+	for {
+		var status int
+		status, page, err = RequestESCLDocument(ctx, info, jobPath)
+		if err != nil {
+			return
+		}
+
+		switch status {
+		case http.StatusOK:
+			return
+		case http.StatusNotFound:
+			done = true
+			return
+		case http.StatusServiceUnavailable:
+			select {
+			case <-time.After(nextDocumentRetryDelay):
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			}
+		default:
+			err = fmt.Errorf("Unexpected HTTP response status getting next document: %d", status)
+			return
+		}
+	}
+}
+
+// RequestESCLDocument sends a single NextDocument request for the job at
+// `jobPath`. `page` is only valid if the returned status is 200.
+func RequestESCLDocument(ctx context.Context, info LorgnetteScannerInfo, jobPath string) (status int, page ScannedPage, err error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	resp, err := info.HTTPGet(ctx, jobPath+"/NextDocument")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	status = resp.StatusCode
+	if status != http.StatusOK {
+		return
+	}
+	page.ContentType = resp.Header.Get("Content-Type")
+	page.Data, err = ioutil.ReadAll(resp.Body)
+	return
+}
+
+// getESCLBlankPageDetected returns whether the scanner reported the last page
+// returned by the job at `jobPath` as blank. Scanners which don't serve
+// ScanImageInfo report no blank pages.
+func getESCLBlankPageDetected(ctx context.Context, info LorgnetteScannerInfo, jobPath string) (bool, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	resp, err := info.HTTPGet(ctx, jobPath+"/ScanImageInfo")
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("Unexpected HTTP response status getting scan image info: %s", resp.Status)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	var imageInfo scanImageInfo
+	if err := xml.Unmarshal(respBytes, &imageInfo); err != nil {
+		return false, err
+	}
+	return imageInfo.BlankPageDetected, nil
+}
+
+// cancelESCLScanJob deletes the job at `jobPath`, ignoring errors as the job
+// may already be gone.
+func cancelESCLScanJob(ctx context.Context, info LorgnetteScannerInfo, jobPath string) {
+	DeleteESCLScanJob(ctx, info, jobPath)
+}
+
+// DeleteESCLScanJob sends a DELETE request cancelling the job at `jobPath`, and
+// returns the HTTP status of the response.
+func DeleteESCLScanJob(ctx context.Context, info LorgnetteScannerInfo, jobPath string) (int, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	resp, err := info.HTTPDelete(ctx, jobPath)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// ESCLJobInfo represents a JobInfo element of an eSCL ScannerStatus document.
+type ESCLJobInfo struct {
+	// URI of the job, either a path or an absolute URL.
+	JobURI string `xml:"JobUri"`
+	// eSCL job state, e.g. "Processing" or "Canceled".
+	JobState string `xml:"JobState"`
+}
+
+// ESCLScannerStatus represents the parts of an eSCL ScannerStatus document used
+// by hwtests.
+type ESCLScannerStatus struct {
+	// eSCL scanner state, e.g. "Idle" or "Processing".
+	State string `xml:"State"`
+	// eSCL ADF state, e.g. "ScannerAdfProcessing", if the scanner has an
+	// ADF.
+	AdfState string        `xml:"AdfState"`
+	Jobs     []ESCLJobInfo `xml:"Jobs>JobInfo"`
+}
+
+// JobState returns the state which `status` reports for the job at `jobPath`,
+// and whether the job is listed at all.
+func (status ESCLScannerStatus) JobState(jobPath string) (string, bool) {
+	for _, job := range status.Jobs {
+		uri, err := url.Parse(job.JobURI)
+		if err != nil {
+			continue
+		}
+		if strings.TrimSuffix(uri.Path, "/") == strings.TrimSuffix(jobPath, "/") {
+			return job.JobState, true
+		}
+	}
+	return "", false
+}
+
+// GetESCLScannerStatus returns the ScannerStatus of the scanner represented by
+// `info`.
+func GetESCLScannerStatus(ctx context.Context, info LorgnetteScannerInfo) (status ESCLScannerStatus, err error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	resp, err := info.HTTPGet(ctx, "/eSCL/ScannerStatus")
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("Unexpected HTTP response status getting scanner status: %s", resp.Status)
+		return
+	}
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	err = xml.Unmarshal(respBytes, &status)
+	return
+}
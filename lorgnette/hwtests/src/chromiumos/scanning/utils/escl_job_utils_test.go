@@ -0,0 +1,228 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package utils
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"testing"
+
+	"chromiumos/scanning/utils/testserver"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestScanSettingsToXML tests that ScanSettings are serialized with the eSCL
+// element names, omitting disabled options.
+func TestScanSettingsToXML(t *testing.T) {
+	settings := ScanSettings{
+		InputSource:                  "Feeder",
+		ColorMode:                    "Grayscale8",
+		Resolution:                   300,
+		DocumentFormat:               "image/jpeg",
+		BlankPageDetectionAndRemoval: true,
+	}
+	out, err := settings.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := xml.Header + `<scan:ScanSettings xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<pwg:InputSource>Feeder</pwg:InputSource>
+	<scan:DocumentFormatExt>image/jpeg</scan:DocumentFormatExt>
+	<scan:ColorMode>Grayscale8</scan:ColorMode>
+	<scan:XResolution>300</scan:XResolution>
+	<scan:YResolution>300</scan:YResolution>
+	<scan:BlankPageDetectionAndRemoval>true</scan:BlankPageDetectionAndRemoval>
+</scan:ScanSettings>`
+	if diff := cmp.Diff(want, string(out)); diff != "" {
+		t.Errorf("Unexpected XML (-want +got):\n%s", diff)
+	}
+}
+
+// TestScanSettingsToXMLRegions tests that the regions of ScanSettings are
+// serialized as ScanRegions the scanner must honor.
+func TestScanSettingsToXMLRegions(t *testing.T) {
+	settings := ScanSettings{
+		InputSource:    "Platen",
+		ColorMode:      "RGB24",
+		Resolution:     75,
+		DocumentFormat: "image/png",
+		Regions: []ScanRegion{
+			{Width: 2550, Height: 1650},
+			{YOffset: 1650, Width: 2550, Height: 1650},
+		},
+	}
+	out, err := settings.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := xml.Header + `<scan:ScanSettings xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<pwg:ScanRegions pwg:MustHonor="true">
+		<pwg:ScanRegion>
+			<pwg:Height>1650</pwg:Height>
+			<pwg:ContentRegionUnits>escl:ThreeHundredthsOfInches</pwg:ContentRegionUnits>
+			<pwg:Width>2550</pwg:Width>
+			<pwg:XOffset>0</pwg:XOffset>
+			<pwg:YOffset>0</pwg:YOffset>
+		</pwg:ScanRegion>
+		<pwg:ScanRegion>
+			<pwg:Height>1650</pwg:Height>
+			<pwg:ContentRegionUnits>escl:ThreeHundredthsOfInches</pwg:ContentRegionUnits>
+			<pwg:Width>2550</pwg:Width>
+			<pwg:XOffset>0</pwg:XOffset>
+			<pwg:YOffset>1650</pwg:YOffset>
+		</pwg:ScanRegion>
+	</pwg:ScanRegions>
+	<pwg:InputSource>Platen</pwg:InputSource>
+	<scan:DocumentFormatExt>image/png</scan:DocumentFormatExt>
+	<scan:ColorMode>RGB24</scan:ColorMode>
+	<scan:XResolution>75</scan:XResolution>
+	<scan:YResolution>75</scan:YResolution>
+</scan:ScanSettings>`
+	if diff := cmp.Diff(want, string(out)); diff != "" {
+		t.Errorf("Unexpected XML (-want +got):\n%s", diff)
+	}
+}
+
+// TestRunESCLScanJob tests that RunESCLScanJob returns every page of a job
+// along with the blank pages reported by the scanner.
+func TestRunESCLScanJob(t *testing.T) {
+	pages := [][]byte{[]byte("printed1"), []byte("blank"), []byte("printed2")}
+	blank := []bool{false, true, false}
+
+	tests := []struct {
+		settings ScanSettings
+		pages    []ScannedPage
+	}{
+		{
+			settings: ScanSettings{},
+			pages: []ScannedPage{
+				{ContentType: "image/jpeg", Data: pages[0]},
+				{ContentType: "image/jpeg", Data: pages[1]},
+				{ContentType: "image/jpeg", Data: pages[2]},
+			},
+		},
+		{
+			settings: ScanSettings{BlankPageDetection: true},
+			pages: []ScannedPage{
+				{ContentType: "image/jpeg", Data: pages[0]},
+				{ContentType: "image/jpeg", Data: pages[1], BlankPageDetected: true},
+				{ContentType: "image/jpeg", Data: pages[2]},
+			},
+		},
+		{
+			settings: ScanSettings{BlankPageDetectionAndRemoval: true},
+			pages: []ScannedPage{
+				{ContentType: "image/jpeg", Data: pages[0]},
+				{ContentType: "image/jpeg", Data: pages[2]},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		s := testserver.New(testserver.Config{Pages: pages, PageContentType: "image/jpeg", Blank: blank})
+		got, err := RunESCLScanJob(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}, tc.settings)
+		s.Close()
+
+		if err != nil {
+			t.Errorf("Settings %+v: unexpected error: %v", tc.settings, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.pages, got); diff != "" {
+			t.Errorf("Settings %+v: unexpected pages (-want +got):\n%s", tc.settings, diff)
+		}
+	}
+}
+
+// TestRunESCLScanJobFailure tests that a failed job is cancelled.
+func TestRunESCLScanJobFailure(t *testing.T) {
+	s := testserver.New(testserver.Config{})
+	defer s.Close()
+	s.SetFault(testserver.EndpointNextDocument, testserver.Fault{StatusCode: http.StatusInternalServerError})
+
+	if _, err := RunESCLScanJob(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}, ScanSettings{}); err == nil {
+		t.Error("Expected error from failed NextDocument request")
+	}
+
+	want := []string{
+		"POST /eSCL/ScanJobs",
+		"GET /eSCL/ScanJobs/1/NextDocument",
+		"DELETE /eSCL/ScanJobs/1",
+	}
+	if diff := cmp.Diff(want, s.Requests()); diff != "" {
+		t.Errorf("Unexpected requests (-want +got):\n%s", diff)
+	}
+}
+
+// TestDeleteESCLScanJob tests that a cancelled job is reported as Canceled and
+// stops returning pages.
+func TestDeleteESCLScanJob(t *testing.T) {
+	s := testserver.New(testserver.Config{Pages: [][]byte{[]byte("1"), []byte("2")}})
+	defer s.Close()
+	info := LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}
+	ctx := context.Background()
+
+	jobPath, err := StartESCLScanJob(ctx, info, ScanSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, done, err := NextESCLDocument(ctx, info, jobPath); done || err != nil {
+		t.Fatalf("Expected a first page, got done %v and error %v", done, err)
+	}
+
+	status, err := GetESCLScannerStatus(ctx, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := status.JobState(jobPath); status.State != "Processing" || state != "Processing" {
+		t.Errorf("Before cancellation: expected scanner and job processing, got %q and %q", status.State, state)
+	}
+
+	if code, err := DeleteESCLScanJob(ctx, info, jobPath); code != http.StatusOK || err != nil {
+		t.Errorf("Expected status 200, got %d and error %v", code, err)
+	}
+	if code, _, err := RequestESCLDocument(ctx, info, jobPath); code != http.StatusNotFound || err != nil {
+		t.Errorf("NextDocument after cancellation: expected status 404, got %d and error %v", code, err)
+	}
+
+	status, err = GetESCLScannerStatus(ctx, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state, found := status.JobState(jobPath); status.State != "Idle" || !found || state != "Canceled" {
+		t.Errorf("After cancellation: expected scanner Idle and job Canceled, got %q and %q (found %v)", status.State, state, found)
+	}
+	if _, found := status.JobState("/eSCL/ScanJobs/2"); found {
+		t.Error("Unexpected state for a job which doesn't exist")
+	}
+}
+
+// TestPostESCLScanJob tests that PostESCLScanJob returns the path of created
+// jobs, and the status of rejected ones without an error.
+func TestPostESCLScanJob(t *testing.T) {
+	s := testserver.New(testserver.Config{})
+	defer s.Close()
+	info := LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}
+	ctx := context.Background()
+
+	status, jobPath, err := PostESCLScanJob(ctx, info, ScanSettings{})
+	if status != http.StatusCreated || jobPath != "/eSCL/ScanJobs/1" || err != nil {
+		t.Errorf("Expected status 201 and job /eSCL/ScanJobs/1, got %d, %q and error %v", status, jobPath, err)
+	}
+
+	s.SetFault(testserver.EndpointScanJobs, testserver.Fault{StatusCode: http.StatusConflict})
+	status, jobPath, err = PostESCLScanJob(ctx, info, ScanSettings{})
+	if status != http.StatusConflict || jobPath != "" || err != nil {
+		t.Errorf("Expected status 409 and no job, got %d, %q and error %v", status, jobPath, err)
+	}
+	if _, err := StartESCLScanJob(ctx, info, ScanSettings{}); err == nil {
+		t.Error("StartESCLScanJob: expected error from rejected job")
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for handling differences between eSCL schema versions.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ESCLVersion represents an eSCL schema version. Minor versions are stored with
+// two digits, so that "2.6" and "2.60" compare equal and both sort before
+// "2.63".
+type ESCLVersion struct {
+	Major int
+	Minor int
+}
+
+// renamedElement describes an element which was renamed in a later version of
+// the eSCL schema.
+type renamedElement struct {
+	// First version which uses `NewName`.
+	RenamedIn ESCLVersion
+	OldName   string
+	NewName   string
+}
+
+// eSCLRenamedElements lists all known element renames between eSCL schema
+// versions. Sections which were added in newer versions, such as
+// BrightnessSupport (2.6) or FeedDirections (2.9), don't need an entry here:
+// they are parsed directly into ScannerCapabilities and left at their zero
+// values for scanners which don't report them.
+var eSCLRenamedElements = []renamedElement{
+	{RenamedIn: ESCLVersion{Major: 2, Minor: 60}, OldName: "SupportedIntent", NewName: "Intent"},
+}
+
+// ParseESCLVersion parses a version string as reported in the Version element
+// of a scanner's capabilities, e.g. "2.63".
+func ParseESCLVersion(version string) (ESCLVersion, error) {
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	if len(parts) != 2 || len(parts[1]) == 0 || len(parts[1]) > 2 {
+		return ESCLVersion{}, fmt.Errorf("Malformed eSCL version: %q", version)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil || major < 0 {
+		return ESCLVersion{}, fmt.Errorf("Malformed eSCL major version: %q", version)
+	}
+
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor < 0 {
+		return ESCLVersion{}, fmt.Errorf("Malformed eSCL minor version: %q", version)
+	}
+	if len(parts[1]) == 1 {
+		minor *= 10
+	}
+
+	return ESCLVersion{Major: major, Minor: minor}, nil
+}
+
+// Less returns true iff `version` is older than `other`.
+func (version ESCLVersion) Less(other ESCLVersion) bool {
+	if version.Major != other.Major {
+		return version.Major < other.Major
+	}
+	return version.Minor < other.Minor
+}
+
+// String returns `version` in the shortest form it can be reported in, e.g.
+// "2.6" rather than "2.60".
+func (version ESCLVersion) String() string {
+	if version.Minor%10 == 0 {
+		return fmt.Sprintf("%d.%d", version.Major, version.Minor/10)
+	}
+	return fmt.Sprintf("%d.%02d", version.Major, version.Minor)
+}
+
+// renamesForESCLVersion returns a map from old to new element names for every
+// element which `version` still reports under its old name.
+func renamesForESCLVersion(version ESCLVersion) map[string]string {
+	renames := make(map[string]string)
+	for _, element := range eSCLRenamedElements {
+		if version.Less(element.RenamedIn) {
+			renames[element.OldName] = element.NewName
+		}
+	}
+	return renames
+}
+
+// normalizeESCLSchema rewrites the ScannerCapabilities XML document `raw` so
+// that it follows the latest eSCL schema, based on the Version it reports.
+// Documents which report the latest schema, or a version which can't be
+// parsed, are returned unchanged.
+func normalizeESCLSchema(raw []byte) ([]byte, error) {
+	var header struct {
+		Version string `xml:"Version"`
+	}
+	if err := xml.Unmarshal(raw, &header); err != nil {
+		return nil, err
+	}
+
+	version, err := ParseESCLVersion(header.Version)
+	if err != nil {
+		return raw, nil
+	}
+
+	renames := renamesForESCLVersion(version)
+	if len(renames) == 0 {
+		return raw, nil
+	}
+
+	// Element matching in ScannerCapabilities only uses local names, so
+	// namespaces are dropped rather than carried over to the rewritten
+	// document.
+	var normalized bytes.Buffer
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	encoder := xml.NewEncoder(&normalized)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			t.Name = normalizeESCLName(t.Name, renames)
+			var attrs []xml.Attr
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				attrs = append(attrs, xml.Attr{Name: xml.Name{Local: attr.Name.Local}, Value: attr.Value})
+			}
+			t.Attr = attrs
+			token = t
+		case xml.EndElement:
+			t.Name = normalizeESCLName(t.Name, renames)
+			token = t
+		}
+
+		if err := encoder.EncodeToken(token); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return normalized.Bytes(), nil
+}
+
+// normalizeESCLName strips the namespace from `name` and applies any rename
+// from `renames` to it.
+func normalizeESCLName(name xml.Name, renames map[string]string) xml.Name {
+	if newName, ok := renames[name.Local]; ok {
+		return xml.Name{Local: newName}
+	}
+	return xml.Name{Local: name.Local}
+}
@@ -0,0 +1,331 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for escl_schema_utils.go.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// eSCL 2.0 capabilities, which list intents as SupportedIntent elements and
+// don't report any adjustable settings.
+const eSCL20XMLTestData = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.0</pwg:Version>
+	<pwg:MakeAndModel>OldScanner 100</pwg:MakeAndModel>
+	<scan:Manufacturer>TestManufacturer</scan:Manufacturer>
+	<scan:Platen>
+		<scan:PlatenInputCaps>
+			<scan:MinWidth>16</scan:MinWidth>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:MinHeight>16</scan:MinHeight>
+			<scan:MaxHeight>3508</scan:MaxHeight>
+			<scan:SettingProfiles>
+				<scan:SettingProfile>
+					<scan:ColorModes>
+						<scan:ColorMode>Grayscale8</scan:ColorMode>
+						<scan:ColorMode>RGB24</scan:ColorMode>
+					</scan:ColorModes>
+					<scan:SupportedResolutions>
+						<scan:DiscreteResolutions>
+							<scan:DiscreteResolution>
+								<scan:XResolution>300</scan:XResolution>
+								<scan:YResolution>300</scan:YResolution>
+							</scan:DiscreteResolution>
+						</scan:DiscreteResolutions>
+					</scan:SupportedResolutions>
+				</scan:SettingProfile>
+			</scan:SettingProfiles>
+			<scan:SupportedIntents>
+				<scan:SupportedIntent>Document</scan:SupportedIntent>
+				<scan:SupportedIntent>Photo</scan:SupportedIntent>
+			</scan:SupportedIntents>
+		</scan:PlatenInputCaps>
+	</scan:Platen>
+</scan:ScannerCapabilities>`
+
+// eSCL 2.6 capabilities, which add adjustable settings and edge detection.
+const eSCL26XMLTestData = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.6</pwg:Version>
+	<pwg:MakeAndModel>MidScanner 200</pwg:MakeAndModel>
+	<scan:Manufacturer>TestManufacturer</scan:Manufacturer>
+	<scan:Platen>
+		<scan:PlatenInputCaps>
+			<scan:MinWidth>16</scan:MinWidth>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:MinHeight>16</scan:MinHeight>
+			<scan:MaxHeight>3508</scan:MaxHeight>
+			<scan:SettingProfiles>
+				<scan:SettingProfile>
+					<scan:ColorModes>
+						<scan:ColorMode>RGB24</scan:ColorMode>
+					</scan:ColorModes>
+				</scan:SettingProfile>
+			</scan:SettingProfiles>
+			<scan:SupportedIntents>
+				<scan:Intent>Document</scan:Intent>
+			</scan:SupportedIntents>
+			<scan:EdgeAutoDetection>
+				<scan:SupportedEdge>TopEdge</scan:SupportedEdge>
+			</scan:EdgeAutoDetection>
+		</scan:PlatenInputCaps>
+	</scan:Platen>
+	<scan:BrightnessSupport>
+		<scan:Min>0</scan:Min>
+		<scan:Max>100</scan:Max>
+		<scan:Normal>50</scan:Normal>
+		<scan:Step>1</scan:Step>
+	</scan:BrightnessSupport>
+	<scan:ContrastSupport>
+		<scan:Min>0</scan:Min>
+		<scan:Max>100</scan:Max>
+		<scan:Normal>50</scan:Normal>
+		<scan:Step>5</scan:Step>
+	</scan:ContrastSupport>
+</scan:ScannerCapabilities>`
+
+// eSCL 2.9 capabilities, which add feed directions and blank page detection.
+const eSCL29XMLTestData = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.9</pwg:Version>
+	<pwg:MakeAndModel>NewScanner 300</pwg:MakeAndModel>
+	<scan:Manufacturer>TestManufacturer</scan:Manufacturer>
+	<scan:Adf>
+		<scan:AdfSimplexInputCaps>
+			<scan:MinWidth>32</scan:MinWidth>
+			<scan:MaxWidth>2551</scan:MaxWidth>
+			<scan:MinHeight>32</scan:MinHeight>
+			<scan:MaxHeight>4200</scan:MaxHeight>
+			<scan:SettingProfiles>
+				<scan:SettingProfile>
+					<scan:ColorModes>
+						<scan:ColorMode>Grayscale8</scan:ColorMode>
+					</scan:ColorModes>
+				</scan:SettingProfile>
+			</scan:SettingProfiles>
+			<scan:SupportedIntents>
+				<scan:Intent>TextAndGraphic</scan:Intent>
+			</scan:SupportedIntents>
+			<scan:FeedDirections>
+				<scan:FeedDirection>ShortEdgeFeed</scan:FeedDirection>
+				<scan:FeedDirection>LongEdgeFeed</scan:FeedDirection>
+			</scan:FeedDirections>
+		</scan:AdfSimplexInputCaps>
+	</scan:Adf>
+	<scan:SharpenSupport>
+		<scan:Min>0</scan:Min>
+		<scan:Max>10</scan:Max>
+		<scan:Normal>5</scan:Normal>
+		<scan:Step>1</scan:Step>
+	</scan:SharpenSupport>
+	<scan:BlankPageDetection>true</scan:BlankPageDetection>
+	<scan:BlankPageDetectionAndRemoval>false</scan:BlankPageDetectionAndRemoval>
+</scan:ScannerCapabilities>`
+
+// TestParseESCLVersion tests that eSCL versions are parsed and normalized.
+func TestParseESCLVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    ESCLVersion
+		str     string
+	}{
+		{
+			version: "2.0",
+			want:    ESCLVersion{Major: 2, Minor: 0},
+			str:     "2.0",
+		},
+		{
+			version: "2.6",
+			want:    ESCLVersion{Major: 2, Minor: 60},
+			str:     "2.6",
+		},
+		{
+			version: "2.60",
+			want:    ESCLVersion{Major: 2, Minor: 60},
+			str:     "2.6",
+		},
+		{
+			version: "2.63",
+			want:    ESCLVersion{Major: 2, Minor: 63},
+			str:     "2.63",
+		},
+		{
+			version: " 2.9\n",
+			want:    ESCLVersion{Major: 2, Minor: 90},
+			str:     "2.9",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseESCLVersion(tc.version)
+		if err != nil {
+			t.Errorf("Version %q: unexpected error: %v", tc.version, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Version %q: expected %v, got %v", tc.version, tc.want, got)
+		}
+		if got.String() != tc.str {
+			t.Errorf("Version %q: expected string %s, got %s", tc.version, tc.str, got.String())
+		}
+	}
+}
+
+// TestParseESCLVersionMalformed tests that malformed eSCL versions are
+// rejected.
+func TestParseESCLVersionMalformed(t *testing.T) {
+	for _, version := range []string{"", "2", "2.", ".6", "2.6.3", "2.633", "a.b", "-1.0"} {
+		if _, err := ParseESCLVersion(version); err == nil {
+			t.Errorf("Version %q: expected error", version)
+		}
+	}
+}
+
+// TestESCLVersionLess tests that eSCL versions are ordered correctly.
+func TestESCLVersionLess(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{a: "2.0", b: "2.6", want: true},
+		{a: "2.6", b: "2.60", want: false},
+		{a: "2.6", b: "2.63", want: true},
+		{a: "2.63", b: "2.9", want: true},
+		{a: "2.9", b: "2.63", want: false},
+		{a: "2.9", b: "3.0", want: true},
+	}
+
+	for _, tc := range tests {
+		a, _ := ParseESCLVersion(tc.a)
+		b, _ := ParseESCLVersion(tc.b)
+		if got := a.Less(b); got != tc.want {
+			t.Errorf("%s < %s: expected %t, got %t", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+// getTestScannerCapabilities serves `xmlData` from a test server and parses it
+// with GetScannerCapabilities.
+func getTestScannerCapabilities(t *testing.T, xmlData string) ScannerCapabilities {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, xmlData)
+	}))
+	defer ts.Close()
+
+	caps, err := GetScannerCapabilities(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return caps
+}
+
+// TestGetScannerCapabilitiesESCL20 tests that renamed elements in eSCL 2.0
+// capabilities are normalized to the latest schema.
+func TestGetScannerCapabilitiesESCL20(t *testing.T) {
+	got := getTestScannerCapabilities(t, eSCL20XMLTestData)
+
+	if got.Version != "2.0" {
+		t.Errorf("Version: expected 2.0, got %s", got.Version)
+	}
+	if got.MakeAndModel != "OldScanner 100" {
+		t.Errorf("MakeAndModel: expected OldScanner 100, got %s", got.MakeAndModel)
+	}
+
+	wantIntents := []string{"Document", "Photo"}
+	if !cmp.Equal(got.PlatenInputCaps.SupportedIntents, wantIntents) {
+		t.Errorf("SupportedIntents: expected %v, got %v", wantIntents, got.PlatenInputCaps.SupportedIntents)
+	}
+
+	wantColorModes := []string{"Grayscale8", "RGB24"}
+	if !cmp.Equal(got.PlatenInputCaps.SettingProfile.ColorModes, wantColorModes) {
+		t.Errorf("ColorModes: expected %v, got %v", wantColorModes, got.PlatenInputCaps.SettingProfile.ColorModes)
+	}
+
+	wantResolutions := []DiscreteResolution{{XResolution: 300, YResolution: 300}}
+	if !cmp.Equal(got.PlatenInputCaps.SettingProfile.SupportedResolutions.DiscreteResolutions, wantResolutions) {
+		t.Errorf("DiscreteResolutions: expected %v, got %v", wantResolutions, got.PlatenInputCaps.SettingProfile.SupportedResolutions.DiscreteResolutions)
+	}
+
+	if got.BrightnessSupport != (SettingRange{}) {
+		t.Errorf("BrightnessSupport: expected none, got %v", got.BrightnessSupport)
+	}
+}
+
+// TestGetScannerCapabilitiesESCL26 tests that sections added in eSCL 2.6 are
+// parsed.
+func TestGetScannerCapabilitiesESCL26(t *testing.T) {
+	got := getTestScannerCapabilities(t, eSCL26XMLTestData)
+
+	wantIntents := []string{"Document"}
+	if !cmp.Equal(got.PlatenInputCaps.SupportedIntents, wantIntents) {
+		t.Errorf("SupportedIntents: expected %v, got %v", wantIntents, got.PlatenInputCaps.SupportedIntents)
+	}
+
+	wantEdges := []string{"TopEdge"}
+	if !cmp.Equal(got.PlatenInputCaps.EdgeAutoDetection, wantEdges) {
+		t.Errorf("EdgeAutoDetection: expected %v, got %v", wantEdges, got.PlatenInputCaps.EdgeAutoDetection)
+	}
+
+	wantBrightness := SettingRange{Min: 0, Max: 100, Normal: 50, Step: 1}
+	if got.BrightnessSupport != wantBrightness {
+		t.Errorf("BrightnessSupport: expected %v, got %v", wantBrightness, got.BrightnessSupport)
+	}
+
+	wantContrast := SettingRange{Min: 0, Max: 100, Normal: 50, Step: 5}
+	if got.ContrastSupport != wantContrast {
+		t.Errorf("ContrastSupport: expected %v, got %v", wantContrast, got.ContrastSupport)
+	}
+}
+
+// TestGetScannerCapabilitiesESCL29 tests that sections added in eSCL 2.9 are
+// parsed.
+func TestGetScannerCapabilitiesESCL29(t *testing.T) {
+	got := getTestScannerCapabilities(t, eSCL29XMLTestData)
+
+	simplexCaps := got.AdfCapabilities.AdfSimplexInputCaps
+	wantIntents := []string{"TextAndGraphic"}
+	if !cmp.Equal(simplexCaps.SupportedIntents, wantIntents) {
+		t.Errorf("SupportedIntents: expected %v, got %v", wantIntents, simplexCaps.SupportedIntents)
+	}
+
+	wantFeedDirections := []string{"ShortEdgeFeed", "LongEdgeFeed"}
+	if !cmp.Equal(simplexCaps.FeedDirections, wantFeedDirections) {
+		t.Errorf("FeedDirections: expected %v, got %v", wantFeedDirections, simplexCaps.FeedDirections)
+	}
+
+	wantSharpen := SettingRange{Min: 0, Max: 10, Normal: 5, Step: 1}
+	if got.SharpenSupport != wantSharpen {
+		t.Errorf("SharpenSupport: expected %v, got %v", wantSharpen, got.SharpenSupport)
+	}
+
+	if !got.BlankPageDetection {
+		t.Error("BlankPageDetection: expected true, got false")
+	}
+	if got.BlankPageDetectionAndRemoval {
+		t.Error("BlankPageDetectionAndRemoval: expected false, got true")
+	}
+}
+
+// TestNormalizeESCLSchemaLatestUnchanged tests that documents which already
+// follow the latest schema are not rewritten.
+func TestNormalizeESCLSchemaLatestUnchanged(t *testing.T) {
+	got, err := normalizeESCLSchema([]byte(eSCL29XMLTestData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != eSCL29XMLTestData {
+		t.Errorf("Expected unchanged document, got: %s", got)
+	}
+}
@@ -0,0 +1,528 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for verifying scanned images. Images are read as streams and
+// their pixel data is never held in memory, so that high-resolution scans can
+// be checked with bounded memory usage.
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+)
+
+// Formats reported in ImageInfo.Format.
+const (
+	PNGFormat  = "png"
+	JPEGFormat = "jpeg"
+	RawFormat  = "raw"
+)
+
+// pngSignature is the magic header of every PNG file.
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// jpegSOI is the magic header of every JPEG file.
+var jpegSOI = []byte{0xff, 0xd8}
+
+// JPEG markers which need special handling.
+const (
+	jpegTEM  = 0x01
+	jpegRST0 = 0xd0
+	jpegRST7 = 0xd7
+	jpegEOI  = 0xd9
+	jpegSOS  = 0xda
+	jpegAPP0 = 0xe0
+	jpegAPP1 = 0xe1
+)
+
+// maxPNGChunkLength is the largest chunk length allowed by the PNG spec.
+const maxPNGChunkLength = 1<<31 - 1
+
+// ImageInfo describes a scanned image, as read from its headers.
+type ImageInfo struct {
+	// One of PNGFormat, JPEGFormat or RawFormat.
+	Format string
+	// Dimensions of the image in pixels.
+	Width  int
+	Height int
+	// Number of bits per channel.
+	BitDepth int
+	// Number of channels per pixel, e.g. 1 for grayscale or 3 for RGB.
+	Channels int
+	// Resolution recorded in the image, or 0 if the image doesn't record one.
+	XDPI int
+	YDPI int
+}
+
+// ReadImageFileInfo reads the ImageInfo of the PNG or JPEG image at `path`.
+// The whole file is read and validated, but only its headers are kept in
+// memory.
+func ReadImageFileInfo(path string) (ImageInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ImageInfo{}, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	magic, err := r.Peek(len(pngSignature))
+	if err != nil && len(magic) < len(jpegSOI) {
+		return ImageInfo{}, fmt.Errorf("Unable to read header of %s: %v", path, err)
+	}
+
+	var info ImageInfo
+	switch {
+	case bytes.HasPrefix(magic, pngSignature):
+		info, err = ReadPNGInfo(r)
+	case bytes.HasPrefix(magic, jpegSOI):
+		info, err = ReadJPEGInfo(r)
+	default:
+		return ImageInfo{}, fmt.Errorf("Unrecognized image format for %s", path)
+	}
+
+	if err != nil {
+		return ImageInfo{}, fmt.Errorf("Invalid image %s: %v", path, err)
+	}
+	return info, nil
+}
+
+// imageInfoReaders maps the MIME types of the images whose ImageInfo can be
+// read to their reader.
+var imageInfoReaders = map[string]func(io.Reader) (ImageInfo, error){
+	"image/png":  ReadPNGInfo,
+	"image/jpeg": ReadJPEGInfo,
+}
+
+// CanReadImageInfo returns whether ReadImageInfo supports images of the MIME
+// type `contentType`.
+func CanReadImageInfo(contentType string) bool {
+	_, ok := imageInfoReaders[contentType]
+	return ok
+}
+
+// ReadImageInfo reads the ImageInfo of the image in `r`, of the MIME type
+// `contentType`, e.g. a page returned by a scan job. Only PNG and JPEG images
+// are supported.
+func ReadImageInfo(r io.Reader, contentType string) (ImageInfo, error) {
+	read, ok := imageInfoReaders[contentType]
+	if !ok {
+		return ImageInfo{}, fmt.Errorf("Unsupported image type: %s", contentType)
+	}
+	return read(r)
+}
+
+// ReadPNGInfo reads the ImageInfo of the PNG image in `r`. The CRC of every
+// chunk is checked, and `r` is consumed up to and including the IEND chunk.
+func ReadPNGInfo(r io.Reader) (ImageInfo, error) {
+	info := ImageInfo{Format: PNGFormat}
+
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil {
+		return info, fmt.Errorf("Unable to read PNG signature: %v", err)
+	}
+	if !bytes.Equal(signature, pngSignature) {
+		return info, fmt.Errorf("Invalid PNG signature: %x", signature)
+	}
+
+	sawHeader := false
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return info, fmt.Errorf("Unable to read PNG chunk header: %v", err)
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		chunkType := string(header[4:])
+		if length > maxPNGChunkLength {
+			return info, fmt.Errorf("Invalid length %d for PNG chunk %s", length, chunkType)
+		}
+
+		crc := crc32.NewIEEE()
+		crc.Write(header[4:])
+
+		// Only the small chunks we care about are kept in memory; all other
+		// chunks, including the image data, are streamed through the CRC.
+		var data []byte
+		if chunkType == "IHDR" || chunkType == "pHYs" {
+			data = make([]byte, length)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return info, fmt.Errorf("Unable to read PNG chunk %s: %v", chunkType, err)
+			}
+			crc.Write(data)
+		} else if _, err := io.CopyN(crc, r, int64(length)); err != nil {
+			return info, fmt.Errorf("Unable to read PNG chunk %s: %v", chunkType, err)
+		}
+
+		var footer [4]byte
+		if _, err := io.ReadFull(r, footer[:]); err != nil {
+			return info, fmt.Errorf("Unable to read CRC of PNG chunk %s: %v", chunkType, err)
+		}
+		if binary.BigEndian.Uint32(footer[:]) != crc.Sum32() {
+			return info, fmt.Errorf("CRC mismatch for PNG chunk %s", chunkType)
+		}
+
+		if !sawHeader && chunkType != "IHDR" {
+			return info, fmt.Errorf("First PNG chunk is %s, expected IHDR", chunkType)
+		}
+
+		switch chunkType {
+		case "IHDR":
+			if len(data) != 13 {
+				return info, fmt.Errorf("Invalid IHDR length: %d", len(data))
+			}
+			info.Width = int(binary.BigEndian.Uint32(data[0:4]))
+			info.Height = int(binary.BigEndian.Uint32(data[4:8]))
+			info.BitDepth = int(data[8])
+			channels, err := pngChannels(data[9])
+			if err != nil {
+				return info, err
+			}
+			info.Channels = channels
+			sawHeader = true
+		case "pHYs":
+			// Only a unit of 1 (meters) allows the resolution to be known.
+			if len(data) == 9 && data[8] == 1 {
+				info.XDPI = dotsPerMeterToDPI(binary.BigEndian.Uint32(data[0:4]))
+				info.YDPI = dotsPerMeterToDPI(binary.BigEndian.Uint32(data[4:8]))
+			}
+		case "IEND":
+			return info, nil
+		}
+	}
+}
+
+// pngChannels returns the number of channels for the PNG `colorType`.
+func pngChannels(colorType byte) (int, error) {
+	switch colorType {
+	case 0, 3:
+		// Grayscale and palette.
+		return 1, nil
+	case 2:
+		return 3, nil
+	case 4:
+		return 2, nil
+	case 6:
+		return 4, nil
+	default:
+		return 0, fmt.Errorf("Invalid PNG color type: %d", colorType)
+	}
+}
+
+// dotsPerMeterToDPI converts a resolution in dots per meter to dots per inch.
+func dotsPerMeterToDPI(dotsPerMeter uint32) int {
+	return int(math.Round(float64(dotsPerMeter) * 0.0254))
+}
+
+// ReadJPEGInfo reads the ImageInfo of the JPEG image in `r`. The resolution is
+// taken from the JFIF header if it records one, and from the EXIF header
+// otherwise. `r` is consumed up to and including the EOI marker.
+func ReadJPEGInfo(r io.Reader) (ImageInfo, error) {
+	info := ImageInfo{Format: JPEGFormat}
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	var soi [2]byte
+	for i := range soi {
+		b, err := br.ReadByte()
+		if err != nil {
+			return info, fmt.Errorf("Unable to read JPEG SOI marker: %v", err)
+		}
+		soi[i] = b
+	}
+	if !bytes.Equal(soi[:], jpegSOI) {
+		return info, fmt.Errorf("Invalid JPEG SOI marker: %x", soi)
+	}
+
+	sawFrame, sawScan, sawJFIFDensity := false, false, false
+	marker, err := readJPEGMarker(br)
+	for err == nil && marker != jpegEOI {
+		if marker == jpegTEM || (marker >= jpegRST0 && marker <= jpegRST7) {
+			// Standalone markers have no segment.
+			marker, err = readJPEGMarker(br)
+			continue
+		}
+
+		var segment []byte
+		segment, err = readJPEGSegment(br)
+		if err != nil {
+			break
+		}
+
+		switch {
+		case isJPEGSOF(marker):
+			if len(segment) < 6 {
+				return info, fmt.Errorf("Invalid JPEG SOF length: %d", len(segment))
+			}
+			info.BitDepth = int(segment[0])
+			info.Height = int(binary.BigEndian.Uint16(segment[1:3]))
+			info.Width = int(binary.BigEndian.Uint16(segment[3:5]))
+			info.Channels = int(segment[5])
+			sawFrame = true
+		case marker == jpegAPP0:
+			if xdpi, ydpi, ok := parseJFIFDensity(segment); ok {
+				info.XDPI, info.YDPI = xdpi, ydpi
+				sawJFIFDensity = true
+			}
+		case marker == jpegAPP1:
+			if sawJFIFDensity {
+				break
+			}
+			if xdpi, ydpi, ok := parseEXIFResolution(segment); ok {
+				info.XDPI, info.YDPI = xdpi, ydpi
+			}
+		case marker == jpegSOS:
+			if !sawFrame {
+				return info, fmt.Errorf("JPEG scan found before frame header")
+			}
+			sawScan = true
+			// The entropy-coded data ends at the next marker, which is
+			// returned by skipJPEGScanData.
+			marker, err = skipJPEGScanData(br)
+			continue
+		}
+
+		marker, err = readJPEGMarker(br)
+	}
+
+	if err != nil {
+		return info, err
+	}
+	if !sawScan {
+		return info, fmt.Errorf("No JPEG scan found before EOI")
+	}
+	return info, nil
+}
+
+// isJPEGSOF returns true iff `marker` is a start of frame marker.
+func isJPEGSOF(marker byte) bool {
+	// 0xc4, 0xc8 and 0xcc are DHT, JPG and DAC, which share the range.
+	return marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc
+}
+
+// readJPEGMarker reads the next marker from `br`, skipping fill bytes.
+func readJPEGMarker(br io.ByteReader) (byte, error) {
+	b, err := br.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("Unable to read JPEG marker: %v", err)
+	}
+	if b != 0xff {
+		return 0, fmt.Errorf("Expected JPEG marker, got byte %#x", b)
+	}
+	for b == 0xff {
+		if b, err = br.ReadByte(); err != nil {
+			return 0, fmt.Errorf("Unable to read JPEG marker: %v", err)
+		}
+	}
+	return b, nil
+}
+
+// readJPEGSegment reads the payload of the segment following a marker. JPEG
+// segments are at most 64KiB long.
+func readJPEGSegment(br io.ByteReader) ([]byte, error) {
+	var length [2]byte
+	for i := range length {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read JPEG segment length: %v", err)
+		}
+		length[i] = b
+	}
+	n := int(binary.BigEndian.Uint16(length[:]))
+	if n < 2 {
+		return nil, fmt.Errorf("Invalid JPEG segment length: %d", n)
+	}
+
+	segment := make([]byte, n-2)
+	for i := range segment {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read JPEG segment: %v", err)
+		}
+		segment[i] = b
+	}
+	return segment, nil
+}
+
+// skipJPEGScanData skips over the entropy-coded data following a SOS segment
+// and returns the marker which ends it.
+func skipJPEGScanData(br io.ByteReader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, fmt.Errorf("Unable to read JPEG scan data: %v", err)
+		}
+		if b != 0xff {
+			continue
+		}
+
+		for b == 0xff {
+			if b, err = br.ReadByte(); err != nil {
+				return 0, fmt.Errorf("Unable to read JPEG scan data: %v", err)
+			}
+		}
+		// Stuffed zero bytes and restart markers are part of the scan data.
+		if b != 0 && (b < jpegRST0 || b > jpegRST7) {
+			return b, nil
+		}
+	}
+}
+
+// parseJFIFDensity returns the resolution recorded in the APP0 `segment`, if
+// it is a JFIF header with a density unit.
+func parseJFIFDensity(segment []byte) (int, int, bool) {
+	if len(segment) < 12 || !bytes.HasPrefix(segment, []byte("JFIF\x00")) {
+		return 0, 0, false
+	}
+
+	x := float64(binary.BigEndian.Uint16(segment[8:10]))
+	y := float64(binary.BigEndian.Uint16(segment[10:12]))
+	switch segment[7] {
+	case 1:
+		// Dots per inch.
+		return int(x), int(y), true
+	case 2:
+		// Dots per centimeter.
+		return int(math.Round(x * 2.54)), int(math.Round(y * 2.54)), true
+	default:
+		return 0, 0, false
+	}
+}
+
+// parseEXIFResolution returns the resolution recorded in IFD0 of the APP1
+// `segment`, if it is an EXIF header.
+func parseEXIFResolution(segment []byte) (int, int, bool) {
+	const (
+		xResolutionTag    = 0x011a
+		yResolutionTag    = 0x011b
+		resolutionUnitTag = 0x0128
+	)
+
+	if !bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+		return 0, 0, false
+	}
+	tiff := segment[6:]
+	if len(tiff) < 8 {
+		return 0, 0, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, 0, false
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0, 0, false
+	}
+	numEntries := int(order.Uint16(tiff[ifd:]))
+
+	rational := func(offset int) float64 {
+		if offset+8 > len(tiff) {
+			return 0
+		}
+		den := order.Uint32(tiff[offset+4:])
+		if den == 0 {
+			return 0
+		}
+		return float64(order.Uint32(tiff[offset:])) / float64(den)
+	}
+
+	var x, y float64
+	// The default EXIF resolution unit is inches.
+	unit := uint16(2)
+	for i := 0; i < numEntries; i++ {
+		entry := ifd + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return 0, 0, false
+		}
+		switch order.Uint16(tiff[entry:]) {
+		case xResolutionTag:
+			x = rational(int(order.Uint32(tiff[entry+8:])))
+		case yResolutionTag:
+			y = rational(int(order.Uint32(tiff[entry+8:])))
+		case resolutionUnitTag:
+			unit = order.Uint16(tiff[entry+8:])
+		}
+	}
+
+	if x == 0 || y == 0 {
+		return 0, 0, false
+	}
+	switch unit {
+	case 2:
+		return int(math.Round(x)), int(math.Round(y)), true
+	case 3:
+		return int(math.Round(x * 2.54)), int(math.Round(y * 2.54)), true
+	default:
+		return 0, 0, false
+	}
+}
+
+// ReadRawInfo reads the ImageInfo of the headerless raw image in `r`, which
+// must contain rows of `width` pixels with `channels` channels of `bitDepth`
+// bits each, with every row padded to a whole byte. The height is computed
+// from the length of `r`, which is consumed entirely.
+func ReadRawInfo(r io.Reader, width int, bitDepth int, channels int) (ImageInfo, error) {
+	info := ImageInfo{Format: RawFormat, Width: width, BitDepth: bitDepth, Channels: channels}
+
+	bytesPerRow := int64((width*bitDepth*channels + 7) / 8)
+	if bytesPerRow <= 0 {
+		return info, fmt.Errorf("Invalid raw image geometry: width %d, bit depth %d, channels %d", width, bitDepth, channels)
+	}
+
+	n, err := io.Copy(ioutil.Discard, r)
+	if err != nil {
+		return info, fmt.Errorf("Unable to read raw image: %v", err)
+	}
+	if n == 0 || n%bytesPerRow != 0 {
+		return info, fmt.Errorf("Raw image length %d is not a multiple of row length %d", n, bytesPerRow)
+	}
+
+	info.Height = int(n / bytesPerRow)
+	return info, nil
+}
+
+// VerifyImageInfo checks that `info` has the size expected for `paperSize` at
+// `resolution`, and passes VerifyImageFormat. If the verification fails, the
+// returned string will contain the details of the first failure.
+func VerifyImageInfo(info ImageInfo, paperSize PaperSize, resolution int, bitDepth int) (bool, string) {
+	if expected := paperSize.PixelWidthForResolution(resolution); info.Width != expected {
+		return false, fmt.Sprintf("Width: got %d, expected %d", info.Width, expected)
+	}
+	if expected := paperSize.PixelHeightForResolution(resolution); info.Height != expected {
+		return false, fmt.Sprintf("Height: got %d, expected %d", info.Height, expected)
+	}
+	return VerifyImageFormat(info, resolution, bitDepth)
+}
+
+// VerifyImageFormat checks that `info` has the given `bitDepth`, and that the
+// resolution recorded in the image, if any, matches `resolution`, for images
+// whose size isn't known in advance. If the verification fails, the returned
+// string will contain the details of the first failure.
+func VerifyImageFormat(info ImageInfo, resolution int, bitDepth int) (bool, string) {
+	if info.BitDepth != bitDepth {
+		return false, fmt.Sprintf("Bit depth: got %d, expected %d", info.BitDepth, bitDepth)
+	}
+	if info.XDPI != 0 && info.XDPI != resolution {
+		return false, fmt.Sprintf("Horizontal resolution: got %d, expected %d", info.XDPI, resolution)
+	}
+	if info.YDPI != 0 && info.YDPI != resolution {
+		return false, fmt.Sprintf("Vertical resolution: got %d, expected %d", info.YDPI, resolution)
+	}
+	return true, ""
+}
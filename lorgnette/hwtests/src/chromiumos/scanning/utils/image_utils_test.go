@@ -0,0 +1,374 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// encodePNG returns a grayscale PNG of the given size. If `dotsPerMeter` is
+// non-zero, a pHYs chunk recording it is inserted after IHDR.
+func encodePNG(t *testing.T, width int, height int, dotsPerMeter uint32) []byte {
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	encoded := b.Bytes()
+	if dotsPerMeter == 0 {
+		return encoded
+	}
+
+	data := make([]byte, 9)
+	binary.BigEndian.PutUint32(data[0:4], dotsPerMeter)
+	binary.BigEndian.PutUint32(data[4:8], dotsPerMeter)
+	data[8] = 1
+	chunk := make([]byte, 4, 21)
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	chunk = append(chunk, "pHYs"...)
+	chunk = append(chunk, data...)
+	chunk = append(chunk, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(chunk[17:], crc32.ChecksumIEEE(chunk[4:17]))
+
+	// The signature and IHDR chunk take 8 + 25 bytes.
+	var out []byte
+	out = append(out, encoded[:33]...)
+	out = append(out, chunk...)
+	return append(out, encoded[33:]...)
+}
+
+// encodeJPEG returns a grayscale JPEG of the given size, with `segment`
+// inserted after the SOI marker.
+func encodeJPEG(t *testing.T, width int, height int, segment []byte) []byte {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, image.NewGray(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	encoded := b.Bytes()
+
+	var out []byte
+	out = append(out, encoded[:2]...)
+	out = append(out, segment...)
+	return append(out, encoded[2:]...)
+}
+
+// jfifSegment returns an APP0 JFIF segment with the given density.
+func jfifSegment(units byte, density uint16) []byte {
+	segment := []byte{0xff, 0xe0, 0, 16, 'J', 'F', 'I', 'F', 0, 1, 2, units, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(segment[12:], density)
+	binary.BigEndian.PutUint16(segment[14:], density)
+	return segment
+}
+
+// exifSegment returns a little-endian APP1 EXIF segment recording `dpi` in
+// IFD0.
+func exifSegment(dpi uint32) []byte {
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0, 3, 0}
+	entry := func(tag uint16, typ uint16, value uint32) {
+		e := make([]byte, 12)
+		binary.LittleEndian.PutUint16(e[0:], tag)
+		binary.LittleEndian.PutUint16(e[2:], typ)
+		binary.LittleEndian.PutUint32(e[4:], 1)
+		binary.LittleEndian.PutUint32(e[8:], value)
+		tiff = append(tiff, e...)
+	}
+	// Rationals are stored after the IFD, which is 2 + 3*12 + 4 bytes long.
+	rationalOffset := uint32(8 + 2 + 3*12 + 4)
+	entry(0x011a, 5, rationalOffset)
+	entry(0x011b, 5, rationalOffset)
+	entry(0x0128, 3, 2)
+	tiff = append(tiff, 0, 0, 0, 0)
+	rational := make([]byte, 8)
+	binary.LittleEndian.PutUint32(rational[0:], dpi)
+	binary.LittleEndian.PutUint32(rational[4:], 1)
+	tiff = append(tiff, rational...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// TestReadPNGInfo tests that ReadPNGInfo parses valid PNGs and rejects
+// corrupted ones.
+func TestReadPNGInfo(t *testing.T) {
+	valid := encodePNG(t, 30, 20, 11811)
+	corrupted := append([]byte(nil), valid...)
+	corrupted[len(corrupted)-20] ^= 0xff
+
+	tests := []struct {
+		data  []byte
+		info  ImageInfo
+		valid bool
+	}{
+		{
+			data:  valid,
+			info:  ImageInfo{Format: PNGFormat, Width: 30, Height: 20, BitDepth: 8, Channels: 1, XDPI: 300, YDPI: 300},
+			valid: true,
+		},
+		{
+			data:  encodePNG(t, 5, 7, 0),
+			info:  ImageInfo{Format: PNGFormat, Width: 5, Height: 7, BitDepth: 8, Channels: 1},
+			valid: true,
+		},
+		{
+			// Truncated before IEND.
+			data:  valid[:len(valid)-12],
+			valid: false,
+		},
+		{
+			// Bad CRC.
+			data:  corrupted,
+			valid: false,
+		},
+		{
+			data:  []byte("not a png"),
+			valid: false,
+		},
+	}
+
+	for i, tc := range tests {
+		info, err := ReadPNGInfo(bytes.NewReader(tc.data))
+
+		if tc.valid && err != nil {
+			t.Errorf("Test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got %v", i, info)
+			}
+			continue
+		}
+		if diff := cmp.Diff(tc.info, info); diff != "" {
+			t.Errorf("Test %d: unexpected info (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+// TestReadJPEGInfo tests that ReadJPEGInfo parses the frame header and
+// resolution of valid JPEGs and rejects truncated ones.
+func TestReadJPEGInfo(t *testing.T) {
+	valid := encodeJPEG(t, 40, 24, jfifSegment(1, 600))
+
+	tests := []struct {
+		data  []byte
+		info  ImageInfo
+		valid bool
+	}{
+		{
+			data:  valid,
+			info:  ImageInfo{Format: JPEGFormat, Width: 40, Height: 24, BitDepth: 8, Channels: 1, XDPI: 600, YDPI: 600},
+			valid: true,
+		},
+		{
+			// Dots per centimeter.
+			data:  encodeJPEG(t, 8, 8, jfifSegment(2, 118)),
+			info:  ImageInfo{Format: JPEGFormat, Width: 8, Height: 8, BitDepth: 8, Channels: 1, XDPI: 300, YDPI: 300},
+			valid: true,
+		},
+		{
+			// JFIF without units falls back to EXIF.
+			data:  encodeJPEG(t, 8, 8, append(jfifSegment(0, 1), exifSegment(150)...)),
+			info:  ImageInfo{Format: JPEGFormat, Width: 8, Height: 8, BitDepth: 8, Channels: 1, XDPI: 150, YDPI: 150},
+			valid: true,
+		},
+		{
+			data:  encodeJPEG(t, 8, 8, nil),
+			info:  ImageInfo{Format: JPEGFormat, Width: 8, Height: 8, BitDepth: 8, Channels: 1},
+			valid: true,
+		},
+		{
+			// Truncated before EOI.
+			data:  valid[:len(valid)-2],
+			valid: false,
+		},
+		{
+			data:  []byte("not a jpeg"),
+			valid: false,
+		},
+	}
+
+	for i, tc := range tests {
+		info, err := ReadJPEGInfo(bytes.NewReader(tc.data))
+
+		if tc.valid && err != nil {
+			t.Errorf("Test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got %v", i, info)
+			}
+			continue
+		}
+		if diff := cmp.Diff(tc.info, info); diff != "" {
+			t.Errorf("Test %d: unexpected info (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+// TestReadRawInfo tests that ReadRawInfo computes the height of raw images.
+func TestReadRawInfo(t *testing.T) {
+	tests := []struct {
+		length   int
+		width    int
+		bitDepth int
+		channels int
+		height   int
+		valid    bool
+	}{
+		{length: 300, width: 10, bitDepth: 8, channels: 3, height: 10, valid: true},
+		{length: 80, width: 10, bitDepth: 16, channels: 1, height: 4, valid: true},
+		// Lineart rows are padded to a whole byte.
+		{length: 20, width: 10, bitDepth: 1, channels: 1, height: 10, valid: true},
+		{length: 301, width: 10, bitDepth: 8, channels: 3, valid: false},
+		{length: 0, width: 10, bitDepth: 8, channels: 3, valid: false},
+		{length: 10, width: 0, bitDepth: 8, channels: 3, valid: false},
+	}
+
+	for _, tc := range tests {
+		info, err := ReadRawInfo(bytes.NewReader(make([]byte, tc.length)), tc.width, tc.bitDepth, tc.channels)
+
+		if tc.valid != (err == nil) {
+			t.Errorf("Raw image of length %d, width %d: expected valid %v, got error %v", tc.length, tc.width, tc.valid, err)
+			continue
+		}
+		if tc.valid && info.Height != tc.height {
+			t.Errorf("Height: expected %d, got %d", tc.height, info.Height)
+		}
+	}
+}
+
+// TestReadImageFileInfo tests that ReadImageFileInfo detects the image format.
+func TestReadImageFileInfo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "image_utils_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{name: "page.png", data: encodePNG(t, 4, 4, 0), format: PNGFormat},
+		{name: "page.jpg", data: encodeJPEG(t, 4, 4, nil), format: JPEGFormat},
+		{name: "page.txt", data: []byte("plain text"), format: ""},
+	}
+
+	for _, tc := range tests {
+		path := filepath.Join(dir, tc.name)
+		if err := ioutil.WriteFile(path, tc.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		info, err := ReadImageFileInfo(path)
+		if tc.format == "" {
+			if err == nil {
+				t.Errorf("%s: expected error, got %v", tc.name, info)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if info.Format != tc.format {
+			t.Errorf("%s: expected format %s, got %s", tc.name, tc.format, info.Format)
+		}
+	}
+}
+
+// TestVerifyImageInfo tests that VerifyImageInfo functions correctly.
+func TestVerifyImageInfo(t *testing.T) {
+	tests := []struct {
+		info   ImageInfo
+		passed bool
+	}{
+		{info: ImageInfo{Width: 1050, Height: 600, BitDepth: 8}, passed: true},
+		{info: ImageInfo{Width: 1050, Height: 600, BitDepth: 8, XDPI: 300, YDPI: 300}, passed: true},
+		{info: ImageInfo{Width: 1049, Height: 600, BitDepth: 8}, passed: false},
+		{info: ImageInfo{Width: 1050, Height: 601, BitDepth: 8}, passed: false},
+		{info: ImageInfo{Width: 1050, Height: 600, BitDepth: 16}, passed: false},
+		{info: ImageInfo{Width: 1050, Height: 600, BitDepth: 8, XDPI: 72, YDPI: 72}, passed: false},
+	}
+
+	for _, tc := range tests {
+		passed, message := VerifyImageInfo(tc.info, BusinessCardSize, 300, 8)
+
+		if passed != tc.passed {
+			t.Errorf("Verifying %v: expected %v, got %v with message %q", tc.info, tc.passed, passed, message)
+		}
+	}
+}
+
+// TestReadImageInfo tests that ReadImageInfo reads images by MIME type, and
+// rejects the types it doesn't support.
+func TestReadImageInfo(t *testing.T) {
+	tests := []struct {
+		contentType string
+		data        []byte
+		format      string
+	}{
+		{contentType: "image/png", data: encodePNG(t, 4, 4, 0), format: PNGFormat},
+		{contentType: "image/jpeg", data: encodeJPEG(t, 4, 4, nil), format: JPEGFormat},
+		{contentType: "image/jpeg", data: encodePNG(t, 4, 4, 0), format: ""},
+		{contentType: "application/pdf", data: []byte("%PDF-1.4"), format: ""},
+	}
+
+	for _, tc := range tests {
+		if supported := tc.contentType != "application/pdf"; CanReadImageInfo(tc.contentType) != supported {
+			t.Errorf("CanReadImageInfo(%s): expected %v", tc.contentType, supported)
+		}
+		info, err := ReadImageInfo(bytes.NewReader(tc.data), tc.contentType)
+		if tc.format == "" {
+			if err == nil {
+				t.Errorf("%s: expected error, got %v", tc.contentType, info)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.contentType, err)
+			continue
+		}
+		if info.Format != tc.format || info.Width != 4 || info.Height != 4 {
+			t.Errorf("%s: expected a 4x4 %s image, got %v", tc.contentType, tc.format, info)
+		}
+	}
+}
+
+// TestVerifyImageFormat tests that VerifyImageFormat ignores the size of the
+// image.
+func TestVerifyImageFormat(t *testing.T) {
+	tests := []struct {
+		info   ImageInfo
+		passed bool
+	}{
+		{info: ImageInfo{Width: 1, Height: 1, BitDepth: 8}, passed: true},
+		{info: ImageInfo{Width: 1050, Height: 600, BitDepth: 8, XDPI: 300, YDPI: 300}, passed: true},
+		{info: ImageInfo{Width: 1050, Height: 600, BitDepth: 1}, passed: false},
+		{info: ImageInfo{Width: 1050, Height: 600, BitDepth: 8, XDPI: 300, YDPI: 150}, passed: false},
+	}
+
+	for _, tc := range tests {
+		passed, message := VerifyImageFormat(tc.info, 300, 8)
+
+		if passed != tc.passed {
+			t.Errorf("Verifying %v: expected %v, got %v with message %q", tc.info, tc.passed, passed, message)
+		}
+	}
+}
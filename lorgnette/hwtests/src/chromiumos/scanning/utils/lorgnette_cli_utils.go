@@ -5,9 +5,13 @@
 package utils
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"net"
 	"net/http"
 	"os/exec"
@@ -29,20 +33,28 @@ type LorgnetteScannerInfo struct {
 	Name      string
 	Address   string
 	SocketDir string
+	// Endpoint is where a network scanner serves its eSCL resources, if
+	// ResolveScannerEndpoint found it. Requests are otherwise sent to
+	// Address.
+	Endpoint *ScannerEndpoint
 }
 
 // LorgnetteCLIList runs the command `lorgnette_cli list` and returns its
 // stdout.
-func LorgnetteCLIList() (string, error) {
-	cmd := exec.Command(lorgnetteCLI, "list")
+func LorgnetteCLIList(ctx context.Context) (string, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, lorgnetteCLI, "list")
 	outputBytes, err := cmd.Output()
 	return string(outputBytes), err
 }
 
 // LorgnetteCLIGetJSONCaps runs the command
 // `lorgnette_cli get_json_caps --scanner=`scanner`` and returns its stdout.
-func LorgnetteCLIGetJSONCaps(scanner string) (string, error) {
-	cmd := exec.Command(lorgnetteCLI, "get_json_caps", "--scanner="+scanner)
+func LorgnetteCLIGetJSONCaps(ctx context.Context, scanner string) (string, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, lorgnetteCLI, "get_json_caps", "--scanner="+scanner)
 	outputBytes, err := cmd.Output()
 	return string(outputBytes), err
 }
@@ -51,8 +63,10 @@ func LorgnetteCLIGetJSONCaps(scanner string) (string, error) {
 // scanner, source, resolution and color mode. The command's stdout is returned.
 // The scanned image will be the same size as `paperSize`. Scanned images will
 // be output to `output`.
-func LorgnetteCLIScan(scanner string, source string, paperSize PaperSize, resolution int, colorMode string, output string) (string, error) {
-	cmd := exec.Command(lorgnetteCLI, "scan", "--scanner="+scanner, "--top_left_x=0.0", "--top_left_y=0.0", "--bottom_right_x="+fmt.Sprintf("%f", paperSize.BottomRightX()), "--bottom_right_y="+fmt.Sprintf("%f", paperSize.BottomRightY()), "--scan_resolution="+strconv.Itoa(resolution), "--color_mode="+colorMode, "--scan_source="+source, "--output="+output)
+func LorgnetteCLIScan(ctx context.Context, scanner string, source string, paperSize PaperSize, resolution int, colorMode string, output string) (string, error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, lorgnetteCLI, "scan", "--scanner="+scanner, "--top_left_x=0.0", "--top_left_y=0.0", "--bottom_right_x="+fmt.Sprintf("%f", paperSize.BottomRightX()), "--bottom_right_y="+fmt.Sprintf("%f", paperSize.BottomRightY()), "--scan_resolution="+strconv.Itoa(resolution), "--color_mode="+colorMode, "--scan_source="+source, "--output="+output)
 	outputBytes, err := cmd.Output()
 	return string(outputBytes), err
 }
@@ -110,23 +124,26 @@ func (info LorgnetteScannerInfo) GetIPPUSBSocket() (socket string, err error) {
 	return
 }
 
-// HTTPGet sends an HTTP GET method to the scanner represented by `info`.
-func (info LorgnetteScannerInfo) HTTPGet(url string) (*http.Response, error) {
+// httpClient returns the client used to send requests to the scanner
+// represented by `info`, and the URL prefix of the scanner's resources. The
+// client follows the redirects allowed by checkSameHostRedirect.
+func (info LorgnetteScannerInfo) httpClient() (*http.Client, string, error) {
 	if info.Protocol == "ippusb" {
 		socket, err := info.GetIPPUSBSocket()
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
-		client := http.Client{
+		client := &http.Client{
 			Transport: &http.Transport{
 				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
 					return net.Dial("unix", socket)
 				},
 			},
+			CheckRedirect: checkSameHostRedirect,
 		}
 
-		return client.Get("http://localhost" + url)
+		return client, "http://localhost", nil
 	}
 
 	// Deliberately ignore certificate errors because printers normally
@@ -138,9 +155,83 @@ func (info LorgnetteScannerInfo) HTTPGet(url string) (*http.Response, error) {
 				InsecureSkipVerify: true,
 			},
 		},
+		CheckRedirect: checkSameHostRedirect,
+	}
+
+	if info.Endpoint != nil {
+		return client, info.Endpoint.BaseURL, nil
 	}
+	return client, info.Address, nil
+}
+
+// HTTPGet sends an HTTP GET method to the scanner represented by `info`. The
+// request is aborted, including reading the response body, once `ctx` expires.
+func (info LorgnetteScannerInfo) HTTPGet(ctx context.Context, url string) (*http.Response, error) {
+	return info.httpDo(ctx, http.MethodGet, url, "", nil)
+}
+
+// HTTPPost sends an HTTP POST method with `body` of type `contentType` to the
+// scanner represented by `info`. The request is aborted, including reading the
+// response body, once `ctx` expires.
+func (info LorgnetteScannerInfo) HTTPPost(ctx context.Context, url string, contentType string, body []byte) (*http.Response, error) {
+	return info.httpDo(ctx, http.MethodPost, url, contentType, body)
+}
 
-	return client.Get(info.Address + url)
+// HTTPDelete sends an HTTP DELETE method to the scanner represented by `info`.
+// The request is aborted once `ctx` expires.
+func (info LorgnetteScannerInfo) HTTPDelete(ctx context.Context, url string) (*http.Response, error) {
+	return info.httpDo(ctx, http.MethodDelete, url, "", nil)
+}
+
+// httpDo sends an HTTP `method` to `url` on the scanner represented by `info`,
+// bounded by `ctx`. `body` is only sent if non-nil. The request follows the
+// politeness set with WithPoliteness, if any: it is paced and retried while
+// the scanner answers 503, and counts as in flight until its response body is
+// closed. Paths under /eSCL are sent under the root of the resolved endpoint,
+// if any.
+func (info LorgnetteScannerInfo) httpDo(ctx context.Context, method string, url string, contentType string, body []byte) (*http.Response, error) {
+	client, prefix, err := info.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	politeness := politenessFromContext(ctx)
+
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, prefix+info.Endpoint.path(url), reader)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		release, err := politeness.acquire(ctx, info.ToLorgnetteScannerName())
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		delay, retry := politeness.retryDelay(resp, attempt)
+		if !retry {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		release()
+		log.Printf("INFO: %s %s answered %s, retrying in %v", method, url, resp.Status, delay)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
 }
 
 // ToLorgnetteScannerName constructs the scanner name used by Lorgnette for
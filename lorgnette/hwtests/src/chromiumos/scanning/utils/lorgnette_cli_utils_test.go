@@ -7,6 +7,7 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -191,7 +192,7 @@ func TestHTTPGetNetwork(t *testing.T) {
 	ts.Start()
 	defer ts.Close()
 
-	resp, err := LorgnetteScannerInfo{Protocol: "airscan", Address: "http://" + listener.Addr().String()}.HTTPGet("/TestUrl")
+	resp, err := LorgnetteScannerInfo{Protocol: "airscan", Address: "http://" + listener.Addr().String()}.HTTPGet(context.Background(), "/TestUrl")
 	if err != nil {
 		t.Error(err)
 	}
@@ -239,7 +240,7 @@ func TestHTTPGetIPPUSB(t *testing.T) {
 	ts.Start()
 	defer ts.Close()
 
-	resp, err := info.HTTPGet("/TestUrl")
+	resp, err := info.HTTPGet(context.Background(), "/TestUrl")
 	if err != nil {
 		t.Error(err)
 	}
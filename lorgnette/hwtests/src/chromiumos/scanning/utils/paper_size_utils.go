@@ -10,6 +10,10 @@ const inchesToMillimeters = 25.4
 // LetterSize represents letter-sized paper.
 var LetterSize = PaperSize{heightInches: 11, widthInches: 8.5}
 
+// BusinessCardSize represents business card-sized paper. It is used for quick
+// scans where the content doesn't matter.
+var BusinessCardSize = PaperSize{heightInches: 2, widthInches: 3.5}
+
 // PaperSize represents a particular size of paper.
 type PaperSize struct {
 	// Height of this paper size in inches.
@@ -0,0 +1,155 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for pacing the requests sent to each scanner, as some firmwares
+// crash under rapid or concurrent requests, and for backing off when a
+// scanner reports being busy.
+
+package utils
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// politenessKey is the context key under which the politeness state is
+// stored.
+type politenessKey struct{}
+
+// Politeness holds the limits on the requests sent to each scanner.
+type Politeness struct {
+	RequestInterval       time.Duration // Minimum time between the starts of two requests.
+	MaxConcurrentRequests int           // Maximum number of requests in flight, 0 for no limit.
+	MaxRetries            int           // Maximum number of retries of a request answered with 503.
+	InitialBackoff        time.Duration // Delay before the first retry, doubled for each other.
+}
+
+// AddPolitenessFlags registers the -request_interval,
+// -max_concurrent_requests, -max_retries and -initial_backoff flags on
+// `flags`.
+func AddPolitenessFlags(flags *flag.FlagSet) *Politeness {
+	var politeness Politeness
+	flags.DurationVar(&politeness.RequestInterval, "request_interval", 0, "Minimum time between the starts of two requests to the scanner.")
+	flags.IntVar(&politeness.MaxConcurrentRequests, "max_concurrent_requests", 0, "Maximum number of requests in flight to the scanner. 0 means no limit.")
+	flags.IntVar(&politeness.MaxRetries, "max_retries", 3, "Maximum number of retries of a request the scanner answers with 503 Service Unavailable.")
+	flags.DurationVar(&politeness.InitialBackoff, "initial_backoff", time.Second, "Delay before retrying a request answered with 503 without Retry-After, doubled for each further retry.")
+	return &politeness
+}
+
+// scannerPacer tracks the requests sent to a single scanner.
+type scannerPacer struct {
+	next  time.Time     // Earliest start of the next request.
+	slots chan struct{} // Holds a value per request in flight, nil for no limit.
+}
+
+// politenessState applies Politeness to the requests sent to each scanner.
+type politenessState struct {
+	politeness Politeness
+	mu         sync.Mutex
+	pacers     map[string]*scannerPacer
+}
+
+// WithPoliteness returns a copy of `ctx` under which the requests sent by
+// this package to each scanner follow `politeness`.
+func WithPoliteness(ctx context.Context, politeness Politeness) context.Context {
+	return context.WithValue(ctx, politenessKey{}, &politenessState{
+		politeness: politeness,
+		pacers:     map[string]*scannerPacer{},
+	})
+}
+
+// politenessFromContext returns the politeness state set by WithPoliteness, or
+// nil if there is none.
+func politenessFromContext(ctx context.Context) *politenessState {
+	state, _ := ctx.Value(politenessKey{}).(*politenessState)
+	return state
+}
+
+// sleep waits for `d`, unless `ctx` expires first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acquire waits until a request can be sent to `scanner`, and returns the
+// function to call once the request completes.
+func (state *politenessState) acquire(ctx context.Context, scanner string) (func(), error) {
+	if state == nil {
+		return func() {}, nil
+	}
+
+	state.mu.Lock()
+	pacer, ok := state.pacers[scanner]
+	if !ok {
+		pacer = &scannerPacer{}
+		if state.politeness.MaxConcurrentRequests > 0 {
+			pacer.slots = make(chan struct{}, state.politeness.MaxConcurrentRequests)
+		}
+		state.pacers[scanner] = pacer
+	}
+	state.mu.Unlock()
+
+	release := func() {}
+	if pacer.slots != nil {
+		select {
+		case pacer.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		var once sync.Once
+		release = func() { once.Do(func() { <-pacer.slots }) }
+	}
+
+	state.mu.Lock()
+	start := time.Now()
+	if pacer.next.After(start) {
+		start = pacer.next
+	}
+	pacer.next = start.Add(state.politeness.RequestInterval)
+	state.mu.Unlock()
+
+	if err := sleep(ctx, time.Until(start)); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// retryDelay returns how long to wait before retrying a request answered with
+// 503 for the `attempt`th time, or false if it shouldn't be retried.
+func (state *politenessState) retryDelay(resp *http.Response, attempt int) (time.Duration, bool) {
+	if state == nil || resp.StatusCode != http.StatusServiceUnavailable || attempt >= state.politeness.MaxRetries {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	return state.politeness.InitialBackoff << uint(attempt), true
+}
+
+// releasingBody is a response body which releases its request when closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (body *releasingBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.release()
+	return err
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for politeness_utils.go.
+
+package utils
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAddPolitenessFlags tests that the politeness flags are parsed correctly.
+func TestAddPolitenessFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	politeness := AddPolitenessFlags(flags)
+	if err := flags.Parse([]string{"-request_interval=500ms", "-max_concurrent_requests=1", "-max_retries=5", "-initial_backoff=2s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Politeness{
+		RequestInterval:       500 * time.Millisecond,
+		MaxConcurrentRequests: 1,
+		MaxRetries:            5,
+		InitialBackoff:        2 * time.Second,
+	}
+	if *politeness != want {
+		t.Errorf("Politeness: expected %+v, got %+v", want, *politeness)
+	}
+}
+
+// TestPolitenessRequestInterval tests that requests to a scanner are spaced by
+// the request interval.
+func TestPolitenessRequestInterval(t *testing.T) {
+	var starts []time.Time
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		starts = append(starts, time.Now())
+	}))
+	defer ts.Close()
+
+	const interval = 50 * time.Millisecond
+	ctx := WithPoliteness(context.Background(), Politeness{RequestInterval: interval})
+	info := LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL}
+	for i := 0; i < 3; i++ {
+		resp, err := info.HTTPGet(ctx, "/eSCL/ScannerStatus")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	for i := 1; i < len(starts); i++ {
+		// The server sees the requests slightly after they are sent.
+		if gap := starts[i].Sub(starts[i-1]); gap < interval-10*time.Millisecond {
+			t.Errorf("Gap between requests %d and %d: expected at least %v, got %v", i-1, i, interval, gap)
+		}
+	}
+}
+
+// TestPolitenessMaxConcurrentRequests tests that no more requests than allowed
+// are in flight at once, until their response bodies are closed.
+func TestPolitenessMaxConcurrentRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+	}))
+	defer ts.Close()
+
+	ctx := WithPoliteness(context.Background(), Politeness{MaxConcurrentRequests: 2})
+	info := LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL}
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := info.HTTPGet(ctx, "/eSCL/ScannerStatus")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight > 2 {
+		t.Errorf("Requests in flight: expected at most 2, got %d", maxInFlight)
+	}
+}
+
+// TestPolitenessRetries tests that requests answered with 503 are retried up
+// to the maximum number of retries, honoring Retry-After.
+func TestPolitenessRetries(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	tests := []struct {
+		unavailable int    // Number of 503 answers before a 200.
+		retryAfter  string // Retry-After header of the 503 answers.
+		status      int
+		requests    int
+	}{
+		{unavailable: 0, status: http.StatusOK, requests: 1},
+		{unavailable: 2, status: http.StatusOK, requests: 3},
+		{unavailable: 2, retryAfter: "0", status: http.StatusOK, requests: 3},
+		{unavailable: 5, status: http.StatusServiceUnavailable, requests: 4},
+	}
+
+	for _, tc := range tests {
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests <= tc.unavailable {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+
+		ctx := WithPoliteness(context.Background(), Politeness{MaxRetries: 3, InitialBackoff: time.Millisecond})
+		resp, err := LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL}.HTTPPost(ctx, "/eSCL/ScanJobs", "text/xml", []byte("<ScanSettings/>"))
+		ts.Close()
+		if err != nil {
+			t.Errorf("%d unavailable answers: unexpected error: %v", tc.unavailable, err)
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status {
+			t.Errorf("%d unavailable answers: expected status %d, got %d", tc.unavailable, tc.status, resp.StatusCode)
+		}
+		if requests != tc.requests {
+			t.Errorf("%d unavailable answers: expected %d requests, got %d", tc.unavailable, tc.requests, requests)
+		}
+	}
+}
+
+// TestNoPoliteness tests that requests aren't retried without politeness.
+func TestNoPoliteness(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	resp, err := LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL}.HTTPGet(context.Background(), "/eSCL/ScannerStatus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if requests != 1 {
+		t.Errorf("Requests: expected 1, got %d", requests)
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for checking that a scanner can be reached before testing it, and
+// reporting which network layer fails if it can't.
+
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PreflightLayer is a layer of the path to the scanner checked by
+// CheckScannerReachability.
+type PreflightLayer int
+
+const (
+	// ResolveLayer is the DNS or mDNS resolution of the scanner's host name.
+	ResolveLayer PreflightLayer = iota
+	// ConnectLayer is the TCP connection to the scanner, or the connection to
+	// its IPP over USB socket.
+	ConnectLayer
+	// TLSLayer is the TLS handshake with HTTPS scanners.
+	TLSLayer
+	// HTTPLayer is an HTTP HEAD request of the scanner's capabilities.
+	HTTPLayer
+)
+
+// String returns the name of `layer` used in reports.
+func (layer PreflightLayer) String() string {
+	switch layer {
+	case ResolveLayer:
+		return "name resolution"
+	case ConnectLayer:
+		return "connection"
+	case TLSLayer:
+		return "TLS handshake"
+	case HTTPLayer:
+		return "HTTP request"
+	}
+	return fmt.Sprintf("PreflightLayer(%d)", int(layer))
+}
+
+// PreflightStep reports the check of a single layer.
+type PreflightStep struct {
+	Layer    PreflightLayer
+	Attempts int
+	// Detail describes the outcome of the last attempt, e.g. the addresses
+	// the host name resolved to.
+	Detail string
+	// Err is the error of the last attempt, or nil if the layer was reached.
+	Err error
+}
+
+// PreflightError reports the layer at which the scanner couldn't be reached.
+type PreflightError struct {
+	Step PreflightStep
+}
+
+// Error names the layer which failed and its error.
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("Scanner unreachable: %s failed after %d attempt(s): %v", e.Step.Layer, e.Step.Attempts, e.Step.Err)
+}
+
+// Unwrap returns the error of the failed layer.
+func (e *PreflightError) Unwrap() error {
+	return e.Step.Err
+}
+
+// preflightCheck checks a single layer, returning a description of what it
+// found.
+type preflightCheck struct {
+	layer PreflightLayer
+	run   func(ctx context.Context) (string, error)
+}
+
+// CheckScannerReachability checks, one layer at a time, that the scanner
+// represented by `info` can be reached: name resolution, connection, TLS
+// handshake if the scanner uses HTTPS, and finally an HTTP request. Each layer
+// is attempted up to `attempts` times, `retryDelay` apart, before giving up.
+// The steps checked are logged and returned; if a layer can't be reached, the
+// returned error is a *PreflightError naming it, and no further layers are
+// checked. Each attempt is bounded by the request timeout of `ctx`, if any.
+func CheckScannerReachability(ctx context.Context, info LorgnetteScannerInfo, attempts int, retryDelay time.Duration) (steps []PreflightStep, err error) {
+	checks, err := preflightChecks(info)
+	if err != nil {
+		return
+	}
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for _, check := range checks {
+		step := PreflightStep{Layer: check.layer}
+		for step.Attempts < attempts {
+			if step.Attempts > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(retryDelay):
+				}
+				if ctx.Err() != nil {
+					break
+				}
+			}
+
+			step.Attempts++
+			reqCtx, cancel := requestContext(ctx)
+			step.Detail, step.Err = check.run(reqCtx)
+			cancel()
+			if step.Err == nil {
+				break
+			}
+			log.Printf("Preflight %s attempt %d failed: %v", step.Layer, step.Attempts, step.Err)
+		}
+
+		steps = append(steps, step)
+		if step.Err != nil {
+			err = &PreflightError{Step: step}
+			return
+		}
+		log.Printf("INFO: Preflight %s: %s", step.Layer, step.Detail)
+	}
+	return
+}
+
+// preflightChecks returns the checks of each layer between the DUT and the
+// scanner represented by `info`, in order.
+func preflightChecks(info LorgnetteScannerInfo) ([]preflightCheck, error) {
+	if info.Protocol == "ippusb" {
+		socket, err := info.GetIPPUSBSocket()
+		if err != nil {
+			return nil, err
+		}
+		connect := preflightCheck{ConnectLayer, func(ctx context.Context) (string, error) {
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "unix", socket)
+			if err != nil {
+				return "", err
+			}
+			conn.Close()
+			return "connected to " + socket, nil
+		}}
+		return []preflightCheck{connect, httpPreflightCheck(info)}, nil
+	}
+
+	u, err := url.Parse(info.Address)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Hostname()
+	port := addressPort(u)
+
+	// Set by the resolution and used by the later layers.
+	var addrs []string
+	checks := []preflightCheck{
+		{ResolveLayer, func(ctx context.Context) (string, error) {
+			if net.ParseIP(host) != nil {
+				addrs = []string{host}
+				return host + " is an IP address", nil
+			}
+			// .local names are resolved through mDNS by the system
+			// resolver.
+			resolved, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return "", err
+			}
+			addrs = resolved
+			return fmt.Sprintf("%s resolved to %s", host, strings.Join(resolved, ", ")), nil
+		}},
+		{ConnectLayer, func(ctx context.Context) (string, error) {
+			conn, err := dialAny(ctx, addrs, port)
+			if err != nil {
+				return "", err
+			}
+			conn.Close()
+			return "connected to " + conn.RemoteAddr().String(), nil
+		}},
+	}
+
+	if u.Scheme == "https" {
+		checks = append(checks, preflightCheck{TLSLayer, func(ctx context.Context) (string, error) {
+			conn, err := dialAny(ctx, addrs, port)
+			if err != nil {
+				return "", fmt.Errorf("Failed to reconnect: %v", err)
+			}
+			defer conn.Close()
+
+			// Certificates aren't verified, like in httpClient.
+			tlsConn := tls.Client(conn, &tls.Config{
+				MinVersion:         tls.VersionTLS12,
+				InsecureSkipVerify: true,
+				ServerName:         host,
+			})
+			if err := tlsConn.HandshakeContext(ctx); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("negotiated %s with %s", tlsVersionName(tlsConn.ConnectionState().Version), conn.RemoteAddr()), nil
+		}})
+	}
+
+	return append(checks, httpPreflightCheck(info)), nil
+}
+
+// httpPreflightCheck returns the check of the HTTP layer of the scanner
+// represented by `info`. Scanners which don't implement HEAD requests pass, as
+// they responded.
+func httpPreflightCheck(info LorgnetteScannerInfo) preflightCheck {
+	return preflightCheck{HTTPLayer, func(ctx context.Context) (string, error) {
+		const path = "/eSCL/ScannerCapabilities"
+		resp, err := info.httpDo(ctx, http.MethodHead, path, "", nil)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+
+		detail := fmt.Sprintf("HEAD %s returned %s", path, resp.Status)
+		if (resp.StatusCode < 200 || resp.StatusCode > 299) && resp.StatusCode != http.StatusMethodNotAllowed {
+			return "", fmt.Errorf("Unexpected response: %s", detail)
+		}
+		return detail, nil
+	}}
+}
+
+// dialAny returns a TCP connection to `port` of the first of `addrs` which
+// accepts one, or the error of the last attempt.
+func dialAny(ctx context.Context, addrs []string, port string) (conn net.Conn, err error) {
+	err = fmt.Errorf("No address to connect to")
+	var dialer net.Dialer
+	for _, addr := range addrs {
+		conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr, port))
+		if err == nil {
+			return
+		}
+	}
+	return
+}
+
+// tlsVersionName returns the name of TLS `version`, as found in
+// tls.ConnectionState.
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("TLS version %#04x", version)
+}
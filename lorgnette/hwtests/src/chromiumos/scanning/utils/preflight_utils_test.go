@@ -0,0 +1,94 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"chromiumos/scanning/utils/testserver"
+)
+
+// TestCheckScannerReachability tests that CheckScannerReachability checks the
+// expected layers of reachable scanners.
+func TestCheckScannerReachability(t *testing.T) {
+	tests := []struct {
+		tls    bool
+		layers []PreflightLayer
+	}{
+		{
+			tls:    false,
+			layers: []PreflightLayer{ResolveLayer, ConnectLayer, HTTPLayer},
+		},
+		{
+			tls:    true,
+			layers: []PreflightLayer{ResolveLayer, ConnectLayer, TLSLayer, HTTPLayer},
+		},
+	}
+
+	for _, tc := range tests {
+		s := testserver.New(testserver.Config{TLS: tc.tls})
+		steps, err := CheckScannerReachability(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}, 3, 0)
+		s.Close()
+
+		if err != nil {
+			t.Errorf("TLS %v: unexpected error: %v", tc.tls, err)
+			continue
+		}
+		if len(steps) != len(tc.layers) {
+			t.Errorf("TLS %v: number of steps: expected %d, got %d", tc.tls, len(tc.layers), len(steps))
+			continue
+		}
+		for i, step := range steps {
+			if step.Layer != tc.layers[i] || step.Attempts != 1 || step.Err != nil {
+				t.Errorf("TLS %v: step %d: expected %s passing on the first attempt, got %+v", tc.tls, i, tc.layers[i], step)
+			}
+		}
+	}
+}
+
+// TestCheckScannerReachabilityFailure tests that CheckScannerReachability
+// reports the layer which failed, after retrying it.
+func TestCheckScannerReachabilityFailure(t *testing.T) {
+	s := testserver.New(testserver.Config{})
+	s.SetFault(testserver.EndpointCapabilities, testserver.Fault{StatusCode: http.StatusNotFound})
+	_, err := CheckScannerReachability(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}, 2, 0)
+	var preflightErr *PreflightError
+	if !errors.As(err, &preflightErr) {
+		t.Fatalf("HTTP failure: expected PreflightError, got %v", err)
+	}
+	if preflightErr.Step.Layer != HTTPLayer || preflightErr.Step.Attempts != 2 {
+		t.Errorf("HTTP failure: expected %s failing after 2 attempts, got %+v", HTTPLayer, preflightErr.Step)
+	}
+	if got := len(s.Requests()); got != 2 {
+		t.Errorf("HTTP failure: number of requests: expected 2, got %d", got)
+	}
+
+	// Nothing listens on the address of a closed server.
+	s.Close()
+	steps, err := CheckScannerReachability(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}, 2, 0)
+	if !errors.As(err, &preflightErr) {
+		t.Fatalf("Connection failure: expected PreflightError, got %v", err)
+	}
+	if preflightErr.Step.Layer != ConnectLayer || preflightErr.Step.Attempts != 2 {
+		t.Errorf("Connection failure: expected %s failing after 2 attempts, got %+v", ConnectLayer, preflightErr.Step)
+	}
+	if len(steps) != 2 || steps[0].Layer != ResolveLayer || steps[0].Err != nil {
+		t.Errorf("Connection failure: expected %s to pass before the failure, got %+v", ResolveLayer, steps)
+	}
+}
+
+// TestCheckScannerReachabilityIPPUSB tests that the connection to a missing
+// IPP over USB socket fails.
+func TestCheckScannerReachabilityIPPUSB(t *testing.T) {
+	info := LorgnetteScannerInfo{Protocol: "ippusb", Address: "missing_scanner", SocketDir: t.TempDir()}
+	_, err := CheckScannerReachability(context.Background(), info, 1, 0)
+	var preflightErr *PreflightError
+	if !errors.As(err, &preflightErr) || preflightErr.Step.Layer != ConnectLayer {
+		t.Errorf("Expected %s PreflightError, got %v", ConnectLayer, err)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities to persist the progress of a test run, so that tests spanning a
+// reboot of the DUT or a power cycle of the scanner can continue where they
+// left off.
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+)
+
+// progressFile is the content of the progress file.
+type progressFile struct {
+	// Scanner the progress was made on, as named by lorgnette_cli.
+	Scanner string `json:"scanner"`
+	// Results of the tests which completed, by test name.
+	Results map[string]TestResult `json:"results"`
+	// State kept by tests between runs, by test name.
+	State map[string]json.RawMessage `json:"state"`
+}
+
+// Progress records the results of the tests run so far, and the state kept
+// by the tests which were interrupted, in a file a later run can resume from.
+// With no file, the progress is only kept in memory.
+type Progress struct {
+	path string
+	file progressFile
+}
+
+// NewProgress returns the progress of a test run on `scanner`, saved to the
+// file at `path` if not empty. If `resume` is true, it continues from the
+// progress saved there by a previous run on the same scanner. Otherwise, the
+// progress starts over.
+func NewProgress(path string, scanner string, resume bool) (*Progress, error) {
+	p := &Progress{
+		path: path,
+		file: progressFile{
+			Scanner: scanner,
+			Results: map[string]TestResult{},
+			State:   map[string]json.RawMessage{},
+		},
+	}
+	if !resume {
+		return p, nil
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no progress file to resume from")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress file: %v", err)
+	}
+	var file progressFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse progress file %s: %v", path, err)
+	}
+	if file.Scanner != scanner {
+		return nil, fmt.Errorf("progress file %s is for scanner %s, not %s", path, file.Scanner, scanner)
+	}
+	if file.Results != nil {
+		p.file.Results = file.Results
+	}
+	if file.State != nil {
+		p.file.State = file.State
+	}
+	return p, nil
+}
+
+// save writes the progress to its file, if any. The file is replaced
+// atomically, so that the progress survives the DUT rebooting meanwhile.
+func (p *Progress) save() error {
+	if p.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(p.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := p.path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to save progress: %v", err)
+	}
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save progress: %v", err)
+	}
+	return os.Rename(tmpPath, p.path)
+}
+
+// RunTest runs `testFunction` with the RunTest wrapper and records its result,
+// unless a previous run already completed it, in which case its result is
+// returned again. Interrupted tests are not recorded, so they run again.
+func (p *Progress) RunTest(testName string, testFunction TestFunction) (TestResult, error) {
+	if result, ok := p.file.Results[testName]; ok {
+		log.Printf("===== RESUMED %s: completed by a previous run =====", testName)
+		return result, nil
+	}
+
+	result := RunTest(testName, testFunction)
+	if result != Interrupted {
+		p.file.Results[testName] = result
+	}
+	return result, p.save()
+}
+
+// SaveState saves `state` for the test `testName`, as JSON, so that it can
+// load it back after being interrupted.
+func (p *Progress) SaveState(testName string, state interface{}) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode state of %s: %v", testName, err)
+	}
+	p.file.State[testName] = b
+	return p.save()
+}
+
+// LoadState loads the state saved by the test `testName` into `state`, and
+// reports whether there was any.
+func (p *Progress) LoadState(testName string, state interface{}) (bool, error) {
+	b, ok := p.file.State[testName]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(b, state); err != nil {
+		return false, fmt.Errorf("failed to decode state of %s: %v", testName, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for progress_utils.go.
+
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const progressScanner = "airscan:escl:Test Scanner:http://localhost/eSCL/"
+
+// TestProgressResume tests that a resumed run returns the results of the
+// tests completed by the previous run without running them again, runs the
+// interrupted ones again, and gets back their state.
+func TestProgressResume(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	path := filepath.Join(t.TempDir(), "progress.json")
+	progress, err := NewProgress(path, progressScanner, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result, err := progress.RunTest("Completed", integerTest(3)); result != Failed || err != nil {
+		t.Errorf("RunTest(Completed): got (%d, %v), want (%d, nil)", result, err, Failed)
+	}
+	if err := progress.SaveState("Interrupted", []int{300, 600}); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := progress.RunTest("Interrupted", integerTest(6)); result != Interrupted || err != nil {
+		t.Errorf("RunTest(Interrupted): got (%d, %v), want (%d, nil)", result, err, Interrupted)
+	}
+
+	resumed, err := NewProgress(path, progressScanner, true)
+	if err != nil {
+		t.Fatalf("NewProgress with resume: %v", err)
+	}
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	ran := false
+	result, err := resumed.RunTest("Completed", func() (TestResult, []TestFailure, error) {
+		ran = true
+		return Passed, nil, nil
+	})
+	if ran {
+		t.Error("Test completed by the previous run ran again")
+	}
+	if result != Failed || err != nil {
+		t.Errorf("RunTest(Completed) after resume: got (%d, %v), want (%d, nil)", result, err, Failed)
+	}
+	if !bytes.Contains(logBuf.Bytes(), []byte("RESUMED Completed")) {
+		t.Errorf("Log of a resumed test: got %q, want a RESUMED line", logBuf.String())
+	}
+
+	var state []int
+	found, err := resumed.LoadState("Interrupted", &state)
+	if err != nil || !found {
+		t.Fatalf("LoadState(Interrupted): got (%t, %v), want (true, nil)", found, err)
+	}
+	if len(state) != 2 || state[0] != 300 || state[1] != 600 {
+		t.Errorf("LoadState(Interrupted): got %v, want [300 600]", state)
+	}
+	if result, err := resumed.RunTest("Interrupted", integerTest(4)); result != Passed || err != nil {
+		t.Errorf("RunTest(Interrupted) after resume: got (%d, %v), want (%d, nil)", result, err, Passed)
+	}
+
+	if found, err := resumed.LoadState("Unknown", &state); found || err != nil {
+		t.Errorf("LoadState(Unknown): got (%t, %v), want (false, nil)", found, err)
+	}
+}
+
+// TestNewProgressErrors tests that progress can't be resumed without a file,
+// or from the progress of another scanner.
+func TestNewProgressErrors(t *testing.T) {
+	dir := t.TempDir()
+	otherScannerPath := filepath.Join(dir, "other.json")
+	if err := ioutil.WriteFile(otherScannerPath, []byte(`{"scanner": "ippusb:escl:Other:1234_5678/eSCL/"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "no path", path: ""},
+		{name: "missing file", path: filepath.Join(dir, "missing.json")},
+		{name: "other scanner", path: otherScannerPath},
+	}
+
+	for _, tc := range tests {
+		if _, err := NewProgress(tc.path, progressScanner, true); err == nil {
+			t.Errorf("NewProgress(%s): got nil error, want an error", tc.name)
+		}
+	}
+
+	// Without resuming, a missing file is fine.
+	if _, err := NewProgress(filepath.Join(dir, "missing.json"), progressScanner, false); err != nil {
+		t.Errorf("NewProgress without resume: %v", err)
+	}
+}
@@ -7,10 +7,14 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -85,6 +89,9 @@ type SourceCapabilities struct {
 	MaxOpticalYResolution int            `xml:"MaxOpticalYResolution"`
 	MaxPhysicalWidth      int            `xml:"MaxPhysicalWidth"`
 	MaxPhysicalHeight     int            `xml:"MaxPhysicalHeight"`
+	SupportedIntents      []string       `xml:"SupportedIntents>Intent"`
+	EdgeAutoDetection     []string       `xml:"EdgeAutoDetection>SupportedEdge"`
+	FeedDirections        []string       `xml:"FeedDirections>FeedDirection"`
 }
 
 // AdfCapabilities represents all of a scanner's ADF capabilities.
@@ -94,6 +101,15 @@ type AdfCapabilities struct {
 	AdfOptions          []string           `xml:"AdfOptions>AdfOption"`
 }
 
+// SettingRange represents the range of values a scanner supports for an
+// adjustable scan setting, such as brightness or contrast.
+type SettingRange struct {
+	Min    int `xml:"Min"`
+	Max    int `xml:"Max"`
+	Normal int `xml:"Normal"`
+	Step   int `xml:"Step"`
+}
+
 // StoredJobRequestSupport represents a scanner's support for stored job
 // requests.
 type StoredJobRequestSupport struct {
@@ -105,14 +121,25 @@ type StoredJobRequestSupport struct {
 
 // ScannerCapabilities represents all of a scanner's capabilities.
 type ScannerCapabilities struct {
-	Version                 string                  `xml:"Version"`
-	MakeAndModel            string                  `xml:"MakeAndModel"`
-	Manufacturer            string                  `xml:"Manufacturer"`
-	SettingProfiles         []SettingProfile        `xml:"SettingProfiles>SettingProfile"`
-	PlatenInputCaps         SourceCapabilities      `xml:"Platen>PlatenInputCaps"`
-	AdfCapabilities         AdfCapabilities         `xml:"Adf"`
-	CameraInputCaps         SourceCapabilities      `xml:"Camera>CameraInputCaps"`
-	StoredJobRequestSupport StoredJobRequestSupport `xml:"StoredJobRequestSupport"`
+	Version                      string                  `xml:"Version"`
+	MakeAndModel                 string                  `xml:"MakeAndModel"`
+	Manufacturer                 string                  `xml:"Manufacturer"`
+	SerialNumber                 string                  `xml:"SerialNumber"`
+	UUID                         string                  `xml:"UUID"`
+	AdminURI                     string                  `xml:"AdminURI"`
+	IconURI                      string                  `xml:"IconURI"`
+	SettingProfiles              []SettingProfile        `xml:"SettingProfiles>SettingProfile"`
+	PlatenInputCaps              SourceCapabilities      `xml:"Platen>PlatenInputCaps"`
+	AdfCapabilities              AdfCapabilities         `xml:"Adf"`
+	CameraInputCaps              SourceCapabilities      `xml:"Camera>CameraInputCaps"`
+	StoredJobRequestSupport      StoredJobRequestSupport `xml:"StoredJobRequestSupport"`
+	BrightnessSupport            SettingRange            `xml:"BrightnessSupport"`
+	ContrastSupport              SettingRange            `xml:"ContrastSupport"`
+	SharpenSupport               SettingRange            `xml:"SharpenSupport"`
+	ThresholdSupport             SettingRange            `xml:"ThresholdSupport"`
+	CompressionFactorSupport     SettingRange            `xml:"CompressionFactorSupport"`
+	BlankPageDetection           bool                    `xml:"BlankPageDetection"`
+	BlankPageDetectionAndRemoval bool                    `xml:"BlankPageDetectionAndRemoval"`
 }
 
 // constructScannableAreaFromESCL constructs a ScannableArea object from eSCL
@@ -159,13 +186,14 @@ func setReferencedProfileIfNecessary(
 	return fmt.Errorf("No profile found for reference: %s", outProfile.Ref)
 }
 
-// GetScannerCapabilities uses the HTTP address of the scanner to get its
-// capabilities. `addr` should have a trailing slash. The returned
-// ScannerCapabilities object is invalid when the returned error is non-nil. Any
-// fields in ScannerCapabilities which were missing from the scanner's response
-// will be left at their zero values.
-func GetScannerCapabilities(info LorgnetteScannerInfo) (caps ScannerCapabilities, err error) {
-	resp, err := info.HTTPGet("/eSCL/ScannerCapabilities")
+// GetRawScannerCapabilities uses the HTTP address of the scanner to get its
+// ScannerCapabilities XML document, exactly as the scanner reported it. The
+// request is bounded by `ctx` and the request timeout it carries, if any.
+func GetRawScannerCapabilities(ctx context.Context, info LorgnetteScannerInfo) (raw []byte, err error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	resp, err := info.HTTPGet(ctx, "/eSCL/ScannerCapabilities")
 	if err != nil {
 		return
 	}
@@ -176,35 +204,55 @@ func GetScannerCapabilities(info LorgnetteScannerInfo) (caps ScannerCapabilities
 		return
 	}
 
-	respbytes, err := ioutil.ReadAll(resp.Body)
+	return ioutil.ReadAll(resp.Body)
+}
+
+// GetScannerCapabilities uses the HTTP address of the scanner to get its
+// capabilities. `addr` should have a trailing slash. The response is normalized
+// to the latest eSCL schema before being parsed, so older scanners populate the
+// same fields as newer ones. The returned ScannerCapabilities object is invalid
+// when the returned error is non-nil. Any fields in ScannerCapabilities which
+// were missing from the scanner's response will be left at their zero values.
+// References to SettingProfiles which don't exist are left unresolved, with
+// only their Ref set, for SettingProfileReferencesTest to report.
+func GetScannerCapabilities(ctx context.Context, info LorgnetteScannerInfo) (caps ScannerCapabilities, err error) {
+	respbytes, err := GetRawScannerCapabilities(ctx, info)
 	if err != nil {
 		return
 	}
 
-	err = xml.Unmarshal(respbytes, &caps)
+	caps, err = ParseScannerCapabilities(respbytes)
 	if err != nil {
 		return
 	}
 
 	// Replace any references to SettingProfiles with the referenced
 	// SettingProfile.
-	err = setReferencedProfileIfNecessary(&caps.PlatenInputCaps.SettingProfile, caps.SettingProfiles)
-	if err != nil {
-		return
-	}
-	err = setReferencedProfileIfNecessary(&caps.AdfCapabilities.AdfSimplexInputCaps.SettingProfile, caps.SettingProfiles)
-	if err != nil {
-		return
-	}
-	err = setReferencedProfileIfNecessary(&caps.AdfCapabilities.AdfDuplexInputCaps.SettingProfile, caps.SettingProfiles)
-	if err != nil {
-		return
+	for _, profile := range []*SettingProfile{
+		&caps.PlatenInputCaps.SettingProfile,
+		&caps.AdfCapabilities.AdfSimplexInputCaps.SettingProfile,
+		&caps.AdfCapabilities.AdfDuplexInputCaps.SettingProfile,
+		&caps.CameraInputCaps.SettingProfile,
+	} {
+		if refErr := setReferencedProfileIfNecessary(profile, caps.SettingProfiles); refErr != nil {
+			log.Printf("WARNING: %v", refErr)
+		}
 	}
-	err = setReferencedProfileIfNecessary(&caps.CameraInputCaps.SettingProfile, caps.SettingProfiles)
+
+	return
+}
+
+// ParseScannerCapabilities parses `raw`, a ScannerCapabilities document as
+// returned by GetRawScannerCapabilities(), after normalizing it to the latest
+// eSCL schema. Unlike GetScannerCapabilities(), references to SettingProfiles
+// are kept as is rather than resolved.
+func ParseScannerCapabilities(raw []byte) (caps ScannerCapabilities, err error) {
+	raw, err = normalizeESCLSchema(raw)
 	if err != nil {
 		return
 	}
 
+	err = xml.Unmarshal(raw, &caps)
 	return
 }
 
@@ -218,6 +266,66 @@ func ParseLorgnetteCapabilities(rawData string) (caps LorgnetteCapabilities, err
 	return
 }
 
+// LorgnetteCapabilitiesSchemaVersion is the version of the output of
+// `lorgnette_cli get_json_caps` described by lorgnetteJSONCapabilities. Bump
+// it when updating the schema for new fields of lorgnette's output.
+const LorgnetteCapabilitiesSchemaVersion = 1
+
+// lorgnetteJSONSource is the schema of a source in the output of
+// `lorgnette_cli get_json_caps`.
+type lorgnetteJSONSource struct {
+	Name          string        `json:"Name"`
+	ColorModes    []string      `json:"ColorModes"`
+	Resolutions   []int         `json:"Resolutions"`
+	ScannableArea ScannableArea `json:"ScannableArea"`
+}
+
+// lorgnetteJSONCapabilities is the schema of the output of
+// `lorgnette_cli get_json_caps`, which has a source for each lorgnette
+// SourceType the scanner supports.
+type lorgnetteJSONCapabilities struct {
+	UnspecifiedCaps lorgnetteJSONSource `json:"SOURCE_UNSPECIFIED"`
+	PlatenCaps      lorgnetteJSONSource `json:"SOURCE_PLATEN"`
+	AdfSimplexCaps  lorgnetteJSONSource `json:"SOURCE_ADF_SIMPLEX"`
+	AdfDuplexCaps   lorgnetteJSONSource `json:"SOURCE_ADF_DUPLEX"`
+	DefaultCaps     lorgnetteJSONSource `json:"SOURCE_DEFAULT"`
+}
+
+// toLorgnetteSource drops the fields of `source` which LorgnetteSource
+// doesn't hold.
+func (source lorgnetteJSONSource) toLorgnetteSource() LorgnetteSource {
+	return LorgnetteSource{
+		ColorModes:    source.ColorModes,
+		Resolutions:   source.Resolutions,
+		ScannableArea: source.ScannableArea}
+}
+
+// ParseLorgnetteCapabilitiesStrict parses `rawData` like
+// ParseLorgnetteCapabilities(), but fails if `rawData` has any field unknown
+// to version LorgnetteCapabilitiesSchemaVersion of the schema of lorgnette's
+// output, so that additions to it get noticed rather than silently ignored.
+// ParseLorgnetteCapabilities() is the compatible mode, accepting such fields.
+func ParseLorgnetteCapabilitiesStrict(rawData string) (caps LorgnetteCapabilities, err error) {
+	decoder := json.NewDecoder(strings.NewReader(rawData))
+	decoder.DisallowUnknownFields()
+
+	var jsonCaps lorgnetteJSONCapabilities
+	if err = decoder.Decode(&jsonCaps); err != nil {
+		err = fmt.Errorf("does not match schema version %d: %v", LorgnetteCapabilitiesSchemaVersion, err)
+		return
+	}
+	if _, tokenErr := decoder.Token(); tokenErr != io.EOF {
+		err = fmt.Errorf("unexpected data after the capabilities")
+		return
+	}
+
+	caps = LorgnetteCapabilities{
+		PlatenCaps:     jsonCaps.PlatenCaps.toLorgnetteSource(),
+		AdfSimplexCaps: jsonCaps.AdfSimplexCaps.toLorgnetteSource(),
+		AdfDuplexCaps:  jsonCaps.AdfDuplexCaps.toLorgnetteSource()}
+	return
+}
+
 // IsPopulated returns returns true iff `source` is non-empty.
 func (source LorgnetteSource) IsPopulated() bool {
 	return !cmp.Equal(source, LorgnetteSource{})
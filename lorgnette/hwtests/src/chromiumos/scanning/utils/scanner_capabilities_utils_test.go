@@ -7,6 +7,7 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -249,7 +250,7 @@ func TestGetScannerCapabilities(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	got, err := GetScannerCapabilities(LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
+	got, err := GetScannerCapabilities(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
 
 	if err != nil {
 		t.Error(err)
@@ -259,6 +260,10 @@ func TestGetScannerCapabilities(t *testing.T) {
 		Version:      "2.63",
 		MakeAndModel: "MF741C/743C",
 		Manufacturer: "Canon",
+		SerialNumber: "TestSerialNumber",
+		UUID:         "TestUuid",
+		AdminURI:     "TestAdminURI",
+		IconURI:      "TestIconURI",
 		SettingProfiles: []SettingProfile{
 			SettingProfile{
 				Name:               "p1",
@@ -309,7 +314,9 @@ func TestGetScannerCapabilities(t *testing.T) {
 			MaxOpticalXResolution: 800,
 			MaxOpticalYResolution: 1200,
 			MaxPhysicalWidth:      1200,
-			MaxPhysicalHeight:     2800},
+			MaxPhysicalHeight:     2800,
+			SupportedIntents:      []string{"Document", "Photo"},
+			EdgeAutoDetection:     []string{"TopEdge", "BottomEdge"}},
 		AdfCapabilities: AdfCapabilities{
 			AdfSimplexInputCaps: SourceCapabilities{
 				MaxWidth:       2551,
@@ -344,7 +351,8 @@ func TestGetScannerCapabilities(t *testing.T) {
 				MaxOpticalXResolution: 300,
 				MaxOpticalYResolution: 300,
 				MaxPhysicalWidth:      2551,
-				MaxPhysicalHeight:     4200},
+				MaxPhysicalHeight:     4200,
+				SupportedIntents:      []string{"Document"}},
 			AdfDuplexInputCaps: SourceCapabilities{
 				MaxWidth:       2551,
 				MinWidth:       32,
@@ -378,7 +386,8 @@ func TestGetScannerCapabilities(t *testing.T) {
 				MaxOpticalXResolution: 300,
 				MaxOpticalYResolution: 300,
 				MaxPhysicalWidth:      2551,
-				MaxPhysicalHeight:     4200},
+				MaxPhysicalHeight:     4200,
+				SupportedIntents:      []string{"Photo"}},
 			AdfOptions: []string{"DetectPaperLoaded", "Duplex"}},
 		CameraInputCaps: SourceCapabilities{
 			MaxWidth:       0,
@@ -408,7 +417,9 @@ func TestGetScannerCapabilities(t *testing.T) {
 			MaxStoredJobRequests: 10,
 			TimeoutInSeconds:     120,
 			PINLength:            4,
-			MaxJobNameLength:     256}}
+			MaxJobNameLength:     256},
+		BlankPageDetection:           true,
+		BlankPageDetectionAndRemoval: true}
 
 	if !cmp.Equal(want, got) {
 		// For such long structs, it's easier to compare if they're
@@ -417,19 +428,22 @@ func TestGetScannerCapabilities(t *testing.T) {
 	}
 }
 
-// TestGetScannerCapabilitiesNotReferencedProfile tests that an input
-// capabilities object which references a SettingProfile which does exist fails
-// to parse.
+// TestGetScannerCapabilitiesNotReferencedProfile tests that a reference to a
+// SettingProfile which doesn't exist is left unresolved rather than failing the
+// parse, so that it can be reported by a test.
 func TestGetScannerCapabilitiesNotReferencedProfile(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintln(w, noReferencedProfileXMLTestData)
 	}))
 	defer ts.Close()
 
-	_, err := GetScannerCapabilities(LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
+	caps, err := GetScannerCapabilities(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
 
-	if err == nil {
-		t.Error("Expected error from referenced profile not existing")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := (SettingProfile{Ref: "p1"}); !cmp.Equal(caps.PlatenInputCaps.SettingProfile, want) {
+		t.Errorf("Expected unresolved profile %+v, got %+v", want, caps.PlatenInputCaps.SettingProfile)
 	}
 }
 
@@ -442,7 +456,7 @@ func TestGetScannerCapabilitiesBadHttpResponse(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	_, err := GetScannerCapabilities(LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
+	_, err := GetScannerCapabilities(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
 
 	if err == nil {
 		t.Error("Expected error from bad HTTP response status")
@@ -456,7 +470,7 @@ func TestGetScannerCapabilitiesBadXml(t *testing.T) {
 	}))
 	defer ts.Close()
 
-	_, err := GetScannerCapabilities(LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
+	_, err := GetScannerCapabilities(context.Background(), LorgnetteScannerInfo{Protocol: "airscan", Address: ts.URL})
 
 	if err == nil {
 		t.Error("Expected error from bad XML")
@@ -519,6 +533,70 @@ func TestParseLorgnetteCapabilitiesBadJSON(t *testing.T) {
 
 }
 
+// TestParseLorgnetteCapabilitiesStrict tests that JSON data is only parsed
+// strictly if it has no field unknown to the schema.
+func TestParseLorgnetteCapabilitiesStrict(t *testing.T) {
+	tests := []struct {
+		rawData string
+		wantErr bool
+	}{
+		{
+			rawData: lorgnetteCLITestData,
+			wantErr: false,
+		},
+		{
+			rawData: `{"SOURCE_DEFAULT":{"Name":"Default","ColorModes":[],"Resolutions":[]}}`,
+			wantErr: false,
+		},
+		{
+			// Unknown field of a source.
+			rawData: `{"SOURCE_PLATEN":{"Name":"Flatbed","Duplex":false}}`,
+			wantErr: true,
+		},
+		{
+			// Unknown source.
+			rawData: `{"SOURCE_CAMERA":{"Name":"Camera"}}`,
+			wantErr: true,
+		},
+		{
+			// Unknown field of a scannable area.
+			rawData: `{"SOURCE_PLATEN":{"ScannableArea":{"Height":1,"Width":1,"Depth":1}}}`,
+			wantErr: true,
+		},
+		{
+			rawData: `{"SOURCE_PLATEN":{}} {}`,
+			wantErr: true,
+		},
+		{
+			rawData: badJSONlorgnetteCLITestData,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseLorgnetteCapabilitiesStrict(tc.rawData)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("Expected error from %s", tc.rawData)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error from %s: %v", tc.rawData, err)
+			continue
+		}
+
+		// Strict parsing must give the same capabilities as compatible parsing.
+		want, err := ParseLorgnetteCapabilities(tc.rawData)
+		if err != nil {
+			t.Error(err)
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("Expected: %s, got: %s", prettyFormatStruct(want), prettyFormatStruct(got))
+		}
+	}
+}
+
 // TestIsPopulatedLorgnetteSource tests that the IsPopulated function works
 // correctly on lorgnette sources.
 func TestIsPopulatedLorgnetteSource(t *testing.T) {
@@ -0,0 +1,212 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities related to long-running stress testing of a scanner.
+
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StressIteration records the outcome of a single iteration of a stress test.
+type StressIteration struct {
+	// Time at which the iteration started.
+	Time time.Time
+	// Whether the scanner was listed by lorgnette.
+	ScannerFound bool
+	// Error message from fetching capabilities, empty on success.
+	CapsError string
+	// Error message from scanning, empty on success.
+	ScanError string
+	// Resident set size of lorgnette in KiB, or 0 if it couldn't be read.
+	LorgnetteRSSKiB int
+}
+
+// Failed returns true iff any step of `iteration` failed.
+func (iteration StressIteration) Failed() bool {
+	return !iteration.ScannerFound || iteration.CapsError != "" || iteration.ScanError != ""
+}
+
+// StressReport aggregates the iterations of a stress test.
+type StressReport struct {
+	Iterations []StressIteration
+}
+
+// Add appends `iteration` to the report.
+func (report *StressReport) Add(iteration StressIteration) {
+	report.Iterations = append(report.Iterations, iteration)
+}
+
+// failureRate returns the fraction of failed iterations in `iterations`.
+func failureRate(iterations []StressIteration) float64 {
+	if len(iterations) == 0 {
+		return 0
+	}
+
+	failed := 0
+	for _, iteration := range iterations {
+		if iteration.Failed() {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(iterations))
+}
+
+// FailureRate returns the fraction of failed iterations over the whole run.
+func (report StressReport) FailureRate() float64 {
+	return failureRate(report.Iterations)
+}
+
+// FailureRateTrend splits the iterations into `numWindows` consecutive windows
+// of (nearly) equal size and returns the failure rate of each window, so that
+// a degradation over time can be spotted. Fewer windows are returned if there
+// are fewer iterations than `numWindows`.
+func (report StressReport) FailureRateTrend(numWindows int) (trend []float64) {
+	n := len(report.Iterations)
+	if numWindows > n {
+		numWindows = n
+	}
+
+	for i := 0; i < numWindows; i++ {
+		start := i * n / numWindows
+		end := (i + 1) * n / numWindows
+		trend = append(trend, failureRate(report.Iterations[start:end]))
+	}
+	return
+}
+
+// Reconnections returns the number of times the scanner was listed again by
+// lorgnette after having disappeared.
+func (report StressReport) Reconnections() (reconnections int) {
+	disconnected := false
+	for _, iteration := range report.Iterations {
+		if !iteration.ScannerFound {
+			disconnected = true
+		} else if disconnected {
+			disconnected = false
+			reconnections++
+		}
+	}
+	return
+}
+
+// RSSGrowthKiB returns the difference between the last and the first known
+// resident set size of lorgnette.
+func (report StressReport) RSSGrowthKiB() int {
+	first, last := 0, 0
+	for _, iteration := range report.Iterations {
+		if iteration.LorgnetteRSSKiB == 0 {
+			continue
+		}
+		if first == 0 {
+			first = iteration.LorgnetteRSSKiB
+		}
+		last = iteration.LorgnetteRSSKiB
+	}
+	return last - first
+}
+
+// WriteTimeSeries writes one CSV row per iteration of `report` to `w`.
+func (report StressReport) WriteTimeSeries(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"time", "scanner_found", "caps_error", "scan_error", "lorgnette_rss_kib"}); err != nil {
+		return err
+	}
+
+	for _, iteration := range report.Iterations {
+		row := []string{
+			iteration.Time.Format(time.RFC3339),
+			strconv.FormatBool(iteration.ScannerFound),
+			iteration.CapsError,
+			iteration.ScanError,
+			strconv.Itoa(iteration.LorgnetteRSSKiB),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Summary returns a human-readable summary of `report`, with the failure rate
+// trend split into `numWindows` windows.
+func (report StressReport) Summary(numWindows int) string {
+	var trend []string
+	for _, rate := range report.FailureRateTrend(numWindows) {
+		trend = append(trend, fmt.Sprintf("%.1f%%", rate*100))
+	}
+
+	return fmt.Sprintf("Iterations: %d\nFailure rate: %.1f%%\nFailure rate trend: [%s]\nScanner reconnections: %d\nLorgnette RSS growth: %d KiB\n",
+		len(report.Iterations), report.FailureRate()*100, strings.Join(trend, " "), report.Reconnections(), report.RSSGrowthKiB())
+}
+
+// FindProcess returns the PID of the first process named `name` found under
+// `procRoot`, which is normally "/proc".
+func FindProcess(procRoot string, name string) (int, error) {
+	dirs, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(dir.Name())
+		if err != nil {
+			continue
+		}
+
+		comm, err := ioutil.ReadFile(filepath.Join(procRoot, dir.Name(), "comm"))
+		if err != nil || strings.TrimSpace(string(comm)) != name {
+			continue
+		}
+
+		return pid, nil
+	}
+
+	return 0, fmt.Errorf("No process found with name: %s", name)
+}
+
+// ProcessRSSKiB returns the resident set size in KiB of the first process
+// named `name` found under `procRoot`, which is normally "/proc".
+func ProcessRSSKiB(procRoot string, name string) (int, error) {
+	pid, err := FindProcess(procRoot, name)
+	if err != nil {
+		return 0, err
+	}
+
+	return readVMRSSKiB(filepath.Join(procRoot, strconv.Itoa(pid), "status"))
+}
+
+// readVMRSSKiB parses the VmRSS line of the procfs status file at `path`.
+func readVMRSSKiB(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			return strconv.Atoi(fields[1])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("No VmRSS found in: %s", path)
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var passingIteration = StressIteration{ScannerFound: true}
+var scanFailureIteration = StressIteration{ScannerFound: true, ScanError: "scan failed"}
+var disconnectedIteration = StressIteration{ScannerFound: false}
+
+// TestFailureRateTrend tests that FailureRateTrend splits iterations into
+// windows correctly.
+func TestFailureRateTrend(t *testing.T) {
+	tests := []struct {
+		iterations []StressIteration
+		numWindows int
+		trend      []float64
+	}{
+		{
+			iterations: nil,
+			numWindows: 2,
+			trend:      nil,
+		},
+		{
+			iterations: []StressIteration{passingIteration, scanFailureIteration},
+			numWindows: 4,
+			trend:      []float64{0, 1},
+		},
+		{
+			iterations: []StressIteration{passingIteration, passingIteration, scanFailureIteration, disconnectedIteration},
+			numWindows: 2,
+			trend:      []float64{0, 1},
+		},
+		{
+			iterations: []StressIteration{passingIteration, scanFailureIteration, passingIteration, disconnectedIteration},
+			numWindows: 1,
+			trend:      []float64{0.5},
+		},
+	}
+
+	for _, tc := range tests {
+		report := StressReport{Iterations: tc.iterations}
+		got := report.FailureRateTrend(tc.numWindows)
+
+		if diff := cmp.Diff(tc.trend, got); diff != "" {
+			t.Errorf("Unexpected trend for %v (-want +got):\n%s", tc.iterations, diff)
+		}
+	}
+}
+
+// TestReconnections tests that Reconnections counts scanner recoveries.
+func TestReconnections(t *testing.T) {
+	report := StressReport{}
+	for _, iteration := range []StressIteration{
+		disconnectedIteration,
+		passingIteration,
+		disconnectedIteration,
+		disconnectedIteration,
+		scanFailureIteration,
+		disconnectedIteration,
+	} {
+		report.Add(iteration)
+	}
+
+	if got := report.Reconnections(); got != 2 {
+		t.Errorf("Reconnections: got %d, want 2", got)
+	}
+}
+
+// TestRSSGrowthKiB tests that RSSGrowthKiB ignores unknown RSS values.
+func TestRSSGrowthKiB(t *testing.T) {
+	report := StressReport{Iterations: []StressIteration{
+		{LorgnetteRSSKiB: 0},
+		{LorgnetteRSSKiB: 1000},
+		{LorgnetteRSSKiB: 1500},
+		{LorgnetteRSSKiB: 0},
+	}}
+
+	if got := report.RSSGrowthKiB(); got != 500 {
+		t.Errorf("RSSGrowthKiB: got %d, want 500", got)
+	}
+}
+
+// TestWriteTimeSeries tests that WriteTimeSeries writes one row per iteration.
+func TestWriteTimeSeries(t *testing.T) {
+	start := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	report := StressReport{Iterations: []StressIteration{
+		{Time: start, ScannerFound: true, LorgnetteRSSKiB: 1024},
+		{Time: start.Add(time.Minute), ScannerFound: true, CapsError: "timeout"},
+	}}
+
+	var buf bytes.Buffer
+	if err := report.WriteTimeSeries(&buf); err != nil {
+		t.Fatalf("WriteTimeSeries failed: %v", err)
+	}
+
+	want := `time,scanner_found,caps_error,scan_error,lorgnette_rss_kib
+2022-01-02T03:04:05Z,true,,,1024
+2022-01-02T03:05:05Z,true,timeout,,0
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Unexpected time series (-want +got):\n%s", diff)
+	}
+}
+
+// TestProcessRSSKiB tests that FindProcess and ProcessRSSKiB find a process by
+// name in a fake procfs.
+func TestProcessRSSKiB(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(procRoot)
+
+	for pid, contents := range map[string][2]string{
+		"1":   {"init\n", "Name:\tinit\nVmRSS:\t    100 kB\n"},
+		"123": {"lorgnette\n", "Name:\tlorgnette\nVmPeak:\t   9999 kB\nVmRSS:\t   4321 kB\n"},
+	} {
+		if err := os.MkdirAll(filepath.Join(procRoot, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(procRoot, pid, "comm"), []byte(contents[0]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(procRoot, pid, "status"), []byte(contents[1]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pid, err := FindProcess(procRoot, "lorgnette")
+	if err != nil {
+		t.Fatalf("FindProcess failed: %v", err)
+	}
+	if pid != 123 {
+		t.Errorf("PID: got %d, want 123", pid)
+	}
+
+	rss, err := ProcessRSSKiB(procRoot, "lorgnette")
+	if err != nil {
+		t.Fatalf("ProcessRSSKiB failed: %v", err)
+	}
+	if rss != 4321 {
+		t.Errorf("RSS: got %d, want 4321", rss)
+	}
+
+	if _, err := ProcessRSSKiB(procRoot, "missing"); err == nil {
+		t.Error("ProcessRSSKiB succeeded for a missing process")
+	}
+}
@@ -14,7 +14,9 @@ import "log"
 // unsupported resolutions on Chrome OS. If the test is unable to retrieve the
 // capabilities from the scanner, it should return an error. If it retrieves and
 // parses the capabilities successfully, then finds an unsupported resolution,
-// it should return a TestFailure.
+// it should return a TestFailure. Tests which can only continue once the DUT
+// rebooted or the scanner was power-cycled return Interrupted, with an error
+// telling what to do before running them again with -resume.
 type TestFunction func() (TestResult, []TestFailure, error)
 
 // TestResult indicates the result of a TestFunction.
@@ -26,6 +28,7 @@ const (
 	Failed
 	Skipped
 	Error
+	Interrupted
 )
 
 // FailureType differentiates between different failure types.
@@ -95,6 +98,12 @@ func RunTest(testName string, testFunction TestFunction) (testResult TestResult)
 		logFailures(failures)
 
 		log.Printf("ERROR: %v", err)
+	case Interrupted:
+		if err == nil {
+			log.Fatal("Nil error in interrupted test.")
+		}
+
+		log.Printf("INTERRUPTED: %v", err)
 	}
 
 	log.Printf("===== END %s =====", testName)
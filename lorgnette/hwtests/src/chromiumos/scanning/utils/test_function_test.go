@@ -17,6 +17,7 @@ const testName = "testInt"
 const criticalFailureMessage = "Critical failure."
 const needsAuditFailureMessage = "Needs audit failure."
 const errorMessage = "Bad integer: 1"
+const interruptedMessage = "Power-cycle the scanner"
 
 var criticalFailure = TestFailure{Type: CriticalFailure, Message: criticalFailureMessage}
 var needsAuditFailure = TestFailure{Type: NeedsAudit, Message: needsAuditFailureMessage}
@@ -39,6 +40,9 @@ func integerTest(testInt int) TestFunction {
 			result = Passed
 		case 5:
 			result = Skipped
+		case 6:
+			err = fmt.Errorf(interruptedMessage)
+			result = Interrupted
 		}
 		return
 	}
@@ -82,6 +86,12 @@ func TestRunTest(t *testing.T) {
 			failures:   []TestFailure{},
 			errText:    "",
 		},
+		{
+			testInt:    6,
+			testResult: Interrupted,
+			failures:   []TestFailure{},
+			errText:    interruptedMessage,
+		},
 	}
 
 	for _, tc := range tests {
@@ -128,6 +138,8 @@ func TestRunTest(t *testing.T) {
 				expectedLine = "CRITICAL FAILURE: " + criticalFailureMessage
 			} else if len(tc.failures) >= lineNum && tc.failures[lineNum-1] == needsAuditFailure {
 				expectedLine = "NEEDS AUDIT: " + needsAuditFailureMessage
+			} else if tc.testResult == Interrupted {
+				expectedLine = "INTERRUPTED: " + tc.errText
 			} else if tc.testResult == Error {
 				expectedLine = "ERROR: " + tc.errText
 			}
@@ -0,0 +1,426 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package testserver provides a simulated eSCL scanner, so that hwtests and
+// utils changes can be developed and regression-tested without hardware.
+package testserver
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Endpoint identifies one of the eSCL resources served by the simulator.
+type Endpoint string
+
+// eSCL resources which faults can be injected into.
+const (
+	EndpointCapabilities Endpoint = "ScannerCapabilities"
+	EndpointStatus       Endpoint = "ScannerStatus"
+	EndpointScanJobs     Endpoint = "ScanJobs"
+	EndpointNextDocument Endpoint = "NextDocument"
+	EndpointImageInfo    Endpoint = "ScanImageInfo"
+)
+
+// Fault describes how responses from an endpoint should misbehave.
+type Fault struct {
+	// Delay before the response is sent.
+	Delay time.Duration
+	// If non-zero, the HTTP status sent instead of the normal response.
+	StatusCode int
+	// Whether the response body is cut in half, producing malformed XML or
+	// a truncated image.
+	Truncate bool
+}
+
+// Config represents the behavior of a simulated scanner.
+type Config struct {
+	// ScannerCapabilities XML document. DefaultCapabilitiesXML is used if
+	// empty.
+	CapabilitiesXML string
+	// Images returned for each page of a scan job. A single blank PNG page is
+	// used if empty.
+	Pages [][]byte
+	// Content type of `Pages`. "image/png" is used if empty.
+	PageContentType string
+	// Whether each of `Pages` is blank. Blank pages are dropped from jobs
+	// requesting BlankPageDetectionAndRemoval, and reported in ScanImageInfo
+	// for jobs requesting BlankPageDetection. No page is blank if empty.
+	Blank []bool
+	// Whether the scanner is served over HTTPS, with a self-signed
+	// certificate.
+	TLS bool
+	// Whether the scanner acknowledges DELETE requests on jobs without
+	// cancelling them, which keep returning pages.
+	IgnoreCancel bool
+	// If non-zero, jobs requesting more scan regions are rejected with 409
+	// Conflict.
+	MaxScanRegions int
+}
+
+// DefaultCapabilitiesXML advertises a platen supporting color and grayscale
+// scans at 75 to 600 dpi.
+const DefaultCapabilitiesXML = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerCapabilities xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<pwg:MakeAndModel>Simulated Scanner</pwg:MakeAndModel>
+	<scan:Manufacturer>ChromiumOS</scan:Manufacturer>
+	<scan:Platen>
+		<scan:PlatenInputCaps>
+			<scan:MinWidth>16</scan:MinWidth>
+			<scan:MaxWidth>2550</scan:MaxWidth>
+			<scan:MinHeight>16</scan:MinHeight>
+			<scan:MaxHeight>3300</scan:MaxHeight>
+			<scan:MaxScanRegions>1</scan:MaxScanRegions>
+			<scan:SettingProfiles>
+				<scan:SettingProfile>
+					<scan:ColorModes>
+						<scan:ColorMode>Grayscale8</scan:ColorMode>
+						<scan:ColorMode>RGB24</scan:ColorMode>
+					</scan:ColorModes>
+					<scan:DocumentFormats>
+						<pwg:DocumentFormat>image/png</pwg:DocumentFormat>
+					</scan:DocumentFormats>
+					<scan:SupportedResolutions>
+						<scan:DiscreteResolutions>
+							<scan:DiscreteResolution>
+								<scan:XResolution>75</scan:XResolution>
+								<scan:YResolution>75</scan:YResolution>
+							</scan:DiscreteResolution>
+							<scan:DiscreteResolution>
+								<scan:XResolution>300</scan:XResolution>
+								<scan:YResolution>300</scan:YResolution>
+							</scan:DiscreteResolution>
+							<scan:DiscreteResolution>
+								<scan:XResolution>600</scan:XResolution>
+								<scan:YResolution>600</scan:YResolution>
+							</scan:DiscreteResolution>
+						</scan:DiscreteResolutions>
+					</scan:SupportedResolutions>
+				</scan:SettingProfile>
+			</scan:SettingProfiles>
+		</scan:PlatenInputCaps>
+	</scan:Platen>
+</scan:ScannerCapabilities>`
+
+// statusXML is the ScannerStatus document, filled with the state of the
+// scanner and the JobInfo elements of its jobs.
+const statusXML = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScannerStatus xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:Version>2.63</pwg:Version>
+	<pwg:State>%s</pwg:State>
+	<scan:Jobs>%s
+	</scan:Jobs>
+</scan:ScannerStatus>`
+
+// jobInfoXML is the JobInfo element describing a job in ScannerStatus.
+const jobInfoXML = `
+		<scan:JobInfo>
+			<pwg:JobUri>/eSCL/ScanJobs/%d</pwg:JobUri>
+			<pwg:JobState>%s</pwg:JobState>
+		</scan:JobInfo>`
+
+// eSCL job states reported in ScannerStatus.
+const (
+	jobProcessing = "Processing"
+	jobCompleted  = "Completed"
+	jobCanceled   = "Canceled"
+)
+
+// scanImageInfoXML is the ScanImageInfo document served for the last page
+// returned by a job.
+const scanImageInfoXML = `<?xml version="1.0" encoding="UTF-8"?>
+<scan:ScanImageInfo xmlns:pwg="http://www.pwg.org/schemas/2010/12/sm" xmlns:scan="http://schemas.hp.com/imaging/escl/2011/05/03">
+	<pwg:JobUri>/eSCL/ScanJobs/%d</pwg:JobUri>
+	<scan:BlankPageDetected>%t</scan:BlankPageDetected>
+</scan:ScanImageInfo>`
+
+// scanSettings represents the parts of a ScanSettings document used by the
+// simulator.
+type scanSettings struct {
+	BlankPageDetection           bool       `xml:"BlankPageDetection"`
+	BlankPageDetectionAndRemoval bool       `xml:"BlankPageDetectionAndRemoval"`
+	ScanRegions                  []struct{} `xml:"ScanRegions>ScanRegion"`
+}
+
+// job represents the state of a scan job.
+type job struct {
+	settings scanSettings
+	// Index in Config.Pages of the next page to consider.
+	next int
+	// Index in Config.Pages of the last page returned, or -1.
+	last int
+	// eSCL state of the job, e.g. jobProcessing.
+	state string
+}
+
+// Server is a simulated eSCL scanner listening on a local HTTP address.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	config   Config
+	faults   map[Endpoint]Fault
+	jobs     map[int]*job
+	nextJob  int
+	requests []string
+}
+
+// New starts a simulated scanner behaving as described by `config`. The
+// caller must call Close() on the returned server.
+func New(config Config) *Server {
+	if config.CapabilitiesXML == "" {
+		config.CapabilitiesXML = DefaultCapabilitiesXML
+	}
+	if len(config.Pages) == 0 {
+		config.Pages = [][]byte{blankPNG()}
+	}
+	if config.PageContentType == "" {
+		config.PageContentType = "image/png"
+	}
+
+	s := &Server{
+		config:  config,
+		faults:  make(map[Endpoint]Fault),
+		jobs:    make(map[int]*job),
+		nextJob: 1,
+	}
+	if config.TLS {
+		s.Server = httptest.NewTLSServer(http.HandlerFunc(s.handle))
+	} else {
+		s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	}
+	return s
+}
+
+// SetFault makes all further responses from `endpoint` misbehave as described
+// by `fault`. Passing Fault{} restores the normal behavior.
+func (s *Server) SetFault(endpoint Endpoint, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[endpoint] = fault
+}
+
+// Requests returns the method and path of every request received so far, e.g.
+// "GET /eSCL/ScannerCapabilities".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// blankPNG returns a small white PNG image.
+func blankPNG() []byte {
+	img := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var b bytes.Buffer
+	// Encoding an in-memory image can't fail.
+	png.Encode(&b, img)
+	return b.Bytes()
+}
+
+// handle dispatches a request to the eSCL resource it targets.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/eSCL/")
+	switch {
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && path == "ScannerCapabilities":
+		s.respond(w, EndpointCapabilities, "text/xml", []byte(s.config.CapabilitiesXML))
+	case r.Method == http.MethodGet && path == "ScannerStatus":
+		s.respond(w, EndpointStatus, "text/xml", s.status())
+	case r.Method == http.MethodPost && path == "ScanJobs":
+		s.createJob(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "ScanJobs/") && strings.HasSuffix(path, "/NextDocument"):
+		s.nextDocument(w, strings.TrimSuffix(strings.TrimPrefix(path, "ScanJobs/"), "/NextDocument"))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "ScanJobs/") && strings.HasSuffix(path, "/ScanImageInfo"):
+		s.imageInfo(w, strings.TrimSuffix(strings.TrimPrefix(path, "ScanJobs/"), "/ScanImageInfo"))
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "ScanJobs/"):
+		s.deleteJob(w, strings.TrimPrefix(path, "ScanJobs/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// applyFault applies the fault configured for `endpoint`, if any. It returns
+// whether the response was already sent, and the possibly truncated `body`.
+func (s *Server) applyFault(w http.ResponseWriter, endpoint Endpoint, body []byte) (bool, []byte) {
+	s.mu.Lock()
+	fault := s.faults[endpoint]
+	s.mu.Unlock()
+
+	time.Sleep(fault.Delay)
+	if fault.StatusCode != 0 {
+		w.WriteHeader(fault.StatusCode)
+		return true, nil
+	}
+	if fault.Truncate {
+		body = body[:len(body)/2]
+	}
+	return false, body
+}
+
+// respond sends `body` unless a fault injected into `endpoint` prevents it.
+func (s *Server) respond(w http.ResponseWriter, endpoint Endpoint, contentType string, body []byte) {
+	sent, body := s.applyFault(w, endpoint, body)
+	if sent {
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// isBlank returns whether the page at `index` is blank.
+func (s *Server) isBlank(index int) bool {
+	return index < len(s.config.Blank) && s.config.Blank[index]
+}
+
+// createJob starts a new scan job returning the configured pages, as selected
+// by the ScanSettings in the body of `r`. An empty body requests the default
+// settings.
+func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
+	if sent, _ := s.applyFault(w, EndpointScanJobs, nil); sent {
+		return
+	}
+
+	var settings scanSettings
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil && len(body) != 0 {
+		err = xml.Unmarshal(body, &settings)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if s.config.MaxScanRegions != 0 && len(settings.ScanRegions) > s.config.MaxScanRegions {
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	s.mu.Lock()
+	id := s.nextJob
+	s.nextJob++
+	s.jobs[id] = &job{settings: settings, last: -1, state: jobProcessing}
+	s.mu.Unlock()
+
+	w.Header().Set("Location", fmt.Sprintf("%s/eSCL/ScanJobs/%d", s.URL, id))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// nextDocument returns the next page of the job `jobID`, or 404 once all pages
+// were returned.
+func (s *Server) nextDocument(w http.ResponseWriter, jobID string) {
+	id, err := strconv.Atoi(jobID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	page := -1
+	j, ok := s.jobs[id]
+	if ok && j.state != jobCanceled {
+		for j.next < len(s.config.Pages) && j.settings.BlankPageDetectionAndRemoval && s.isBlank(j.next) {
+			j.next++
+		}
+		if j.next < len(s.config.Pages) {
+			page = j.next
+			j.next++
+			j.last = page
+		} else {
+			j.state = jobCompleted
+		}
+	}
+	s.mu.Unlock()
+
+	if page < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.respond(w, EndpointNextDocument, s.config.PageContentType, s.config.Pages[page])
+}
+
+// imageInfo describes the last page returned by the job `jobID`, or returns
+// 404 if no page was returned yet.
+func (s *Server) imageInfo(w http.ResponseWriter, jobID string) {
+	id, err := strconv.Atoi(jobID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	ok = ok && j.state != jobCanceled
+	var last int
+	var blank bool
+	if ok {
+		last = j.last
+		blank = (j.settings.BlankPageDetection || j.settings.BlankPageDetectionAndRemoval) && last >= 0 && s.isBlank(last)
+	}
+	s.mu.Unlock()
+
+	if !ok || last < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.respond(w, EndpointImageInfo, "text/xml", []byte(fmt.Sprintf(scanImageInfoXML, id, blank)))
+}
+
+// deleteJob cancels the job `jobID`, unless the scanner ignores cancellation.
+func (s *Server) deleteJob(w http.ResponseWriter, jobID string) {
+	id, err := strconv.Atoi(jobID)
+
+	s.mu.Lock()
+	j, ok := s.jobs[id]
+	ok = ok && j.state != jobCanceled
+	if ok && !s.config.IgnoreCancel {
+		j.state = jobCanceled
+	}
+	s.mu.Unlock()
+
+	if err != nil || !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// status returns the ScannerStatus document, where the scanner is processing
+// while any of its jobs is.
+func (s *Server) status() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int, 0, len(s.jobs))
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	state := "Idle"
+	var jobs strings.Builder
+	for _, id := range ids {
+		if s.jobs[id].state == jobProcessing {
+			state = "Processing"
+		}
+		fmt.Fprintf(&jobs, jobInfoXML, id, s.jobs[id].state)
+	}
+	return []byte(fmt.Sprintf(statusXML, state, jobs.String()))
+}
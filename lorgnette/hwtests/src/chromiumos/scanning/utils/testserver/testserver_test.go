@@ -0,0 +1,175 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for testserver.go.
+
+package testserver
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"chromiumos/scanning/utils"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// scannerInfo returns the information needed by utils to talk to `s`.
+func scannerInfo(s *Server) utils.LorgnetteScannerInfo {
+	return utils.LorgnetteScannerInfo{Protocol: "airscan", Address: s.URL}
+}
+
+// TestDefaultCapabilities tests that the default capabilities can be parsed.
+func TestDefaultCapabilities(t *testing.T) {
+	s := New(Config{})
+	defer s.Close()
+
+	caps, err := utils.GetScannerCapabilities(context.Background(), scannerInfo(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps.MakeAndModel != "Simulated Scanner" {
+		t.Errorf("MakeAndModel: expected Simulated Scanner, got %s", caps.MakeAndModel)
+	}
+
+	want := []int{75, 300, 600}
+	got := caps.PlatenInputCaps.ToLorgnetteSource().Resolutions
+	if !cmp.Equal(got, want) {
+		t.Errorf("Resolutions: expected %v, got %v", want, got)
+	}
+}
+
+// TestMalformedCapabilities tests that truncated capabilities fail to parse.
+func TestMalformedCapabilities(t *testing.T) {
+	s := New(Config{})
+	defer s.Close()
+	s.SetFault(EndpointCapabilities, Fault{Truncate: true})
+
+	if _, err := utils.GetScannerCapabilities(context.Background(), scannerInfo(s)); err == nil {
+		t.Error("Expected error from malformed XML")
+	}
+}
+
+// TestServerError tests that an injected HTTP status is returned.
+func TestServerError(t *testing.T) {
+	s := New(Config{})
+	defer s.Close()
+	s.SetFault(EndpointCapabilities, Fault{StatusCode: http.StatusServiceUnavailable})
+
+	if _, err := utils.GetScannerCapabilities(context.Background(), scannerInfo(s)); err == nil {
+		t.Error("Expected error from bad HTTP response status")
+	}
+
+	s.SetFault(EndpointCapabilities, Fault{})
+	if _, err := utils.GetScannerCapabilities(context.Background(), scannerInfo(s)); err != nil {
+		t.Errorf("Expected no error after clearing fault, got %v", err)
+	}
+}
+
+// TestSlowResponse tests that an injected delay is applied.
+func TestSlowResponse(t *testing.T) {
+	s := New(Config{})
+	defer s.Close()
+	delay := 50 * time.Millisecond
+	s.SetFault(EndpointStatus, Fault{Delay: delay})
+
+	start := time.Now()
+	resp, err := http.Get(s.URL + "/eSCL/ScannerStatus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Response time: expected at least %v, got %v", delay, elapsed)
+	}
+}
+
+// TestScanJob tests that a scan job returns every configured page, then 404.
+func TestScanJob(t *testing.T) {
+	pages := [][]byte{[]byte("page1"), []byte("page2")}
+	s := New(Config{Pages: pages, PageContentType: "image/jpeg"})
+	defer s.Close()
+
+	resp, err := http.Post(s.URL+"/eSCL/ScanJobs", "text/xml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("ScanJobs status: expected %d, got %d", http.StatusCreated, resp.StatusCode)
+	}
+	job := resp.Header.Get("Location")
+
+	for i, want := range pages {
+		resp, err := http.Get(job + "/NextDocument")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Page %d status: expected %d, got %d", i, http.StatusOK, resp.StatusCode)
+		}
+		if contentType := resp.Header.Get("Content-Type"); contentType != "image/jpeg" {
+			t.Errorf("Page %d content type: expected image/jpeg, got %s", i, contentType)
+		}
+		if string(got) != string(want) {
+			t.Errorf("Page %d: expected %s, got %s", i, want, got)
+		}
+	}
+
+	resp, err = http.Get(job + "/NextDocument")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Last page status: expected %d, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	wantRequests := []string{
+		"POST /eSCL/ScanJobs",
+		"GET /eSCL/ScanJobs/1/NextDocument",
+		"GET /eSCL/ScanJobs/1/NextDocument",
+		"GET /eSCL/ScanJobs/1/NextDocument",
+	}
+	if diff := cmp.Diff(s.Requests(), wantRequests); diff != "" {
+		t.Errorf("Requests diff (-got +want):\n%s", diff)
+	}
+}
+
+// TestDefaultPage tests that the default page is a non-empty PNG image.
+func TestDefaultPage(t *testing.T) {
+	s := New(Config{})
+	defer s.Close()
+
+	resp, err := http.Post(s.URL+"/eSCL/ScanJobs", "text/xml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(resp.Header.Get("Location") + "/NextDocument")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	page, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page) < 8 || string(page[1:4]) != "PNG" {
+		t.Errorf("Expected a PNG image, got %q", page)
+	}
+}
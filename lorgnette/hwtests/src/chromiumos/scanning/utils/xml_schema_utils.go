@@ -0,0 +1,79 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Utilities for validating XML documents against an XSD.
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// xmllint is the command used to validate documents.
+const xmllint = "xmllint"
+
+// xmllintValidationErrorStatus is the exit status of xmllint when the document
+// was parsed, but doesn't conform to the schema.
+const xmllintValidationErrorStatus = 3
+
+// xmllintErrorRegex matches one schema violation reported by xmllint for a
+// document read from stdin, e.g.
+// "-:12: Schemas validity error : Element 'Foo': This element is not expected."
+var xmllintErrorRegex = regexp.MustCompile(`^-:(\d+): .*validity error : (.*)$`)
+
+// SchemaViolation is a single way in which an XML document doesn't conform to
+// its schema.
+type SchemaViolation struct {
+	Line    int    // Line of the document where the violation was found.
+	Message string // Description of the violation, as reported by xmllint.
+}
+
+// ValidateXMLSchema validates the XML document `raw` against the XSD at
+// `xsdPath` using xmllint. A nil error with no violations means that `raw`
+// conforms to the schema. An error is returned if the validation couldn't be
+// completed, e.g. because `raw` isn't well-formed XML, the schema is invalid or
+// `ctx` expired.
+func ValidateXMLSchema(ctx context.Context, raw []byte, xsdPath string) (violations []SchemaViolation, err error) {
+	ctx, cancel := requestContext(ctx)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, xmllint, "--noout", "--schema", xsdPath, "-")
+	cmd.Stdin = bytes.NewReader(raw)
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == xmllintValidationErrorStatus {
+		return parseXmllintErrors(stderr.String()), nil
+	}
+	if err != nil {
+		err = fmt.Errorf("Failed to run %s: %v: %s", xmllint, err, strings.TrimSpace(stderr.String()))
+	}
+	return
+}
+
+// parseXmllintErrors extracts the schema violations from xmllint's stderr
+// `output`. Lines which don't describe a violation are ignored.
+func parseXmllintErrors(output string) (violations []SchemaViolation) {
+	for _, line := range strings.Split(output, "\n") {
+		match := xmllintErrorRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		lineNumber, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		violations = append(violations, SchemaViolation{Line: lineNumber, Message: match[2]})
+	}
+	return
+}
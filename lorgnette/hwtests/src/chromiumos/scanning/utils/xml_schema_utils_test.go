@@ -0,0 +1,38 @@
+// Copyright 2026 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Tests for xml_schema_utils.go.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestParseXmllintErrors tests that parseXmllintErrors correctly extracts
+// schema violations from xmllint's output.
+func TestParseXmllintErrors(t *testing.T) {
+	output := `-:5: Schemas validity error : Element '{http://schemas.hp.com/imaging/escl/2011/05/03}BlankPageDetection': 'yes' is not a valid value of the atomic type 'xs:boolean'.
+-:6: Schemas validity error : Element '{http://schemas.hp.com/imaging/escl/2011/05/03}VendorSpecific': This element is not expected.
+- fails to validate
+`
+	got := parseXmllintErrors(output)
+	want := []SchemaViolation{
+		{Line: 5, Message: "Element '{http://schemas.hp.com/imaging/escl/2011/05/03}BlankPageDetection': 'yes' is not a valid value of the atomic type 'xs:boolean'."},
+		{Line: 6, Message: "Element '{http://schemas.hp.com/imaging/escl/2011/05/03}VendorSpecific': This element is not expected."},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Schema violations (-want +got):\n%s", diff)
+	}
+}
+
+// TestParseXmllintErrorsValid tests that parseXmllintErrors returns no
+// violations for a valid document.
+func TestParseXmllintErrorsValid(t *testing.T) {
+	if got := parseXmllintErrors("- validates\n"); len(got) != 0 {
+		t.Errorf("Expected no violations, got %v", got)
+	}
+}